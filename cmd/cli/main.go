@@ -7,6 +7,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
@@ -14,10 +15,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/peterh/liner"
 
 	"github.com/DistAlchemist/Mongongo/mql"
+	"github.com/DistAlchemist/Mongongo/service"
 )
 
 var (
@@ -28,7 +31,9 @@ var (
 	cc        *rpc.Client
 	historyFn = filepath.Join(os.TempDir(), ".liner_example_history")
 	names     = []string{"get", "GET", "set", "SET", "select", "SELECT",
-		"delete", "DELETE", "explain", "EXPLAIN"}
+		"incr", "INCR", "delete", "DELETE", "explain", "EXPLAIN", "stats", "STATS",
+		"verify", "VERIFY", "scrub", "SCRUB", "snapshot", "SNAPSHOT",
+		"listsnapshots", "LISTSNAPSHOTS", "clearsnapshot", "CLEARSNAPSHOT", "repair", "REPAIR"}
 	line *liner.State
 )
 
@@ -38,7 +43,10 @@ func printBanner() {
 }
 
 func processServerQuery(line string) {
-	mql.ExecuteQuery(cc, line)
+	res := mql.ExecuteQuery(cc, line)
+	if res.ErrorCode != service.ErrorCodeNone {
+		printErrorHint(res.ErrorCode)
+	}
 	// //
 	// args := server.ExecuteArgs{}
 	// reply := server.ExecuteReply{}
@@ -53,15 +61,44 @@ func processServerQuery(line string) {
 	// }
 }
 
+// printError prints the error an rpc failed with
+func printError(err error) {
+	fmt.Printf("error: %v\n", err)
+	printErrorHint(service.GetErrorCode(err))
+}
+
+// printErrorHint tells what to do about the errors
+// that may go away when the request is retried
+func printErrorHint(code int) {
+	switch code {
+	case service.ErrorCodeUnavailable:
+		fmt.Printf("not enough replicas are alive, retry later or at a lower consistency level\n")
+	case service.ErrorCodeTimedOut:
+		fmt.Printf("not enough replicas answered in time, a write may still have been applied by some of them\n")
+	}
+}
+
 func printHelp() {
 	fmt.Printf("Usage: (currently supported)\n")
 	fmt.Printf("\tSET table.superCF['rowKey']['superColumnKey']['columnKey']='value'\n")
 	fmt.Printf("\tSET table.superCF['rowKey']['superColumnKey']={'columnKey'=>'value',...}\n")
 	fmt.Printf("\tSET table.superCF['rowKey'] = {'superColumnKey'=>{columnMapValue},...}\n")
-	fmt.Printf("\tSET table.standardCF['key']['column']='value'\n")
+	fmt.Printf("\tSET table.standardCF['key']['column']='value' [TTL seconds]\n")
 	fmt.Printf("\tSET table.standardCF['key']={'columnKey'=>'value',...}\n")
 	// fmt.Printf("\tSET tableName.columnFamilyName['rowKey']['column']='value'\n")
-	fmt.Printf("keywords(case insensitive): SET, GET, SELECT, DELETE, EXPLAIN\n\n")
+	fmt.Printf("\tGET table.standardCF WHERE column='value' [AND column='value' ...]\n")
+	fmt.Printf("\tGET table.standardCF RANGE 'startKey' TO 'endKey' [LIMIT n]\n")
+	fmt.Printf("\tINCR table.counterCF['key']['column'] [BY n]\n")
+	fmt.Printf("\tINCR table.superCounterCF['key']['superColumnKey']['columnKey'] [BY n]\n")
+	fmt.Printf("\tSTATS [table.columnFamily]\n")
+	fmt.Printf("\tVERIFY table.columnFamily\n")
+	fmt.Printf("\tSCRUB table.columnFamily\n")
+	fmt.Printf("\tSNAPSHOT table[.columnFamily] [name]\n")
+	fmt.Printf("\tLISTSNAPSHOTS\n")
+	fmt.Printf("\tCLEARSNAPSHOT [name]\n")
+	fmt.Printf("\tREPAIR table[.columnFamily] ['startToken' 'endToken'], random partitioner tokens as '0x<hex>'\n")
+	fmt.Printf("keywords(case insensitive): SET, GET, SELECT, WHERE, AND, RANGE, TO, LIMIT, INCR, BY, DELETE, EXPLAIN,\n")
+	fmt.Printf("\tTTL, STATS, VERIFY, SCRUB, SNAPSHOT, LISTSNAPSHOTS, CLEARSNAPSHOT, REPAIR\n\n")
 	fmt.Printf("press Ctrl-C or type exit to quit\n\n")
 	fmt.Printf("\tTry SET table1.standardCF1['row1']['column1']='value' \n")
 	fmt.Printf("\tand GET table1.standardCF1['row1']['column1'] \n\t :)\n\n")
@@ -73,10 +110,194 @@ func processCLISTMT(line string) {
 	} else if strings.HasPrefix(line, "EXIT") {
 		quitCli()
 		os.Exit(0)
+	} else if strings.HasPrefix(line, "STATS") {
+		printStats(strings.TrimSpace(strings.TrimPrefix(line, "STATS")))
+		return
+	} else if strings.HasPrefix(line, "VERIFY") {
+		verify(strings.TrimSpace(strings.TrimPrefix(line, "VERIFY")))
+		return
+	} else if strings.HasPrefix(line, "SCRUB") {
+		scrub(strings.TrimSpace(strings.TrimPrefix(line, "SCRUB")))
+		return
+	} else if strings.HasPrefix(line, "SNAPSHOT") {
+		snapshot(strings.TrimSpace(strings.TrimPrefix(line, "SNAPSHOT")))
+		return
+	} else if strings.HasPrefix(line, "LISTSNAPSHOTS") {
+		listSnapshots()
+		return
+	} else if strings.HasPrefix(line, "CLEARSNAPSHOT") {
+		clearSnapshot(strings.TrimSpace(strings.TrimPrefix(line, "CLEARSNAPSHOT")))
+		return
+	} else if strings.HasPrefix(line, "REPAIR") {
+		repair(strings.TrimSpace(strings.TrimPrefix(line, "REPAIR")))
+		return
 	}
 	log.Println("processing CLI statement")
 }
 
+func printStats(name string) {
+	if name == "" {
+		printReadRepairStats()
+		return
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 {
+		fmt.Printf("usage: STATS [table.columnFamily]\n")
+		return
+	}
+	args := service.ColumnFamilyStatsArgs{Keyspace: parts[0], ColumnFamily: parts[1]}
+	reply := service.ColumnFamilyStatsReply{}
+	err := cc.Call("Mongongo.GetColumnFamilyStats", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	stats := reply.Stats
+	fmt.Printf("Column Family: %v.%v\n", stats.Table, stats.ColumnFamily)
+	fmt.Printf("\tSSTable count: %v\n", stats.SSTableCount)
+	fmt.Printf("\tCompaction strategy: %v\n", stats.CompactionStrategy)
+	fmt.Printf("\tSSTables in each level: %v\n", stats.SSTablesPerLevel)
+	fmt.Printf("\tSpace used (live): %v\n", stats.LiveDiskSpaceUsed)
+	fmt.Printf("\tUncompressed data size: %v\n", stats.UncompressedDataSize)
+	fmt.Printf("\tCompression: %v\n", stats.Compression)
+	fmt.Printf("\tCompression ratio: %.3f\n", stats.CompressionRatio)
+	fmt.Printf("\tMemtable columns count: %v\n", stats.MemtableColumnsCount)
+	fmt.Printf("\tMemtable data size: %v\n", stats.MemtableDataSize)
+	fmt.Printf("\tKey cache capacity (per sstable): %v\n", stats.KeyCacheCapacity)
+	fmt.Printf("\tKey cache size: %v\n", stats.KeyCacheSize)
+	fmt.Printf("\tKey cache hit rate: %.3f (%v/%v)\n", stats.KeyCacheHitRate, stats.KeyCacheHits, stats.KeyCacheRequests)
+	fmt.Printf("\tRow cache capacity: %v\n", stats.RowCacheCapacity)
+	fmt.Printf("\tRow cache size: %v\n", stats.RowCacheSize)
+	fmt.Printf("\tRow cache hit rate: %.3f (%v/%v)\n", stats.RowCacheHitRate, stats.RowCacheHits, stats.RowCacheRequests)
+}
+
+func printReadRepairStats() {
+	args := service.ReadRepairStatsArgs{}
+	reply := service.ReadRepairStatsReply{}
+	err := cc.Call("Mongongo.GetReadRepairStats", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	stats := reply.Stats
+	fmt.Printf("Read repair:\n")
+	fmt.Printf("\tConsistency checks: %v\n", stats.ConsistencyChecks)
+	fmt.Printf("\tDigest mismatches: %v\n", stats.DigestMismatches)
+	fmt.Printf("\tRows repaired: %v\n", stats.Repairs)
+}
+
+func verify(name string) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 {
+		fmt.Printf("usage: VERIFY table.columnFamily\n")
+		return
+	}
+	args := service.VerifyArgs{Keyspace: parts[0], ColumnFamily: parts[1]}
+	reply := service.VerifyReply{}
+	err := cc.Call("Mongongo.Verify", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	corrupt := 0
+	for _, result := range reply.Results {
+		status := "OK"
+		if len(result.Errors) > 0 {
+			status = "CORRUPT"
+			corrupt++
+		}
+		fmt.Printf("%v: %v, %v rows\n", result.File, status, result.Rows)
+		if !result.Checksummed {
+			fmt.Printf("\twritten without checksums, only rows were checked\n")
+		}
+		for _, e := range result.Errors {
+			fmt.Printf("\t%v\n", e)
+		}
+	}
+	fmt.Printf("%v of %v sstables corrupt\n", corrupt, len(reply.Results))
+	if corrupt > 0 {
+		fmt.Printf("run SCRUB %v to rewrite them without the unreadable rows\n", name)
+	}
+}
+
+func scrub(name string) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 {
+		fmt.Printf("usage: SCRUB table.columnFamily\n")
+		return
+	}
+	args := service.ScrubArgs{Keyspace: parts[0], ColumnFamily: parts[1]}
+	reply := service.ScrubReply{}
+	err := cc.Call("Mongongo.Scrub", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	for _, result := range reply.Results {
+		if result.NewFile == "" {
+			fmt.Printf("%v: left in place\n", result.File)
+		} else {
+			fmt.Printf("%v -> %v: %v rows kept\n", result.File, result.NewFile, result.Rows)
+		}
+		for _, e := range result.Errors {
+			fmt.Printf("\tskipped %v\n", e)
+		}
+	}
+}
+
+func snapshot(line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 1 || len(fields) > 2 {
+		fmt.Printf("usage: SNAPSHOT table[.columnFamily] [name]\n")
+		return
+	}
+	parts := strings.SplitN(fields[0], ".", 2)
+	args := service.SnapshotArgs{Keyspace: parts[0]}
+	if len(parts) == 2 {
+		args.ColumnFamily = parts[1]
+	}
+	if len(fields) == 2 {
+		args.Name = fields[1]
+	}
+	reply := service.SnapshotReply{}
+	err := cc.Call("Mongongo.Snapshot", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	fmt.Printf("snapshot %v taken\n", reply.Name)
+}
+
+func listSnapshots() {
+	args := service.ListSnapshotsArgs{}
+	reply := service.ListSnapshotsReply{}
+	err := cc.Call("Mongongo.ListSnapshots", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	for _, snapshot := range reply.Snapshots {
+		fmt.Printf("%v: %v files, %v bytes\n", snapshot.Name, snapshot.Files, snapshot.Size)
+		for table, cfNames := range snapshot.Tables {
+			fmt.Printf("\t%v: %v\n", table, strings.Join(cfNames, ", "))
+		}
+	}
+	fmt.Printf("%v snapshots\n", len(reply.Snapshots))
+}
+
+func clearSnapshot(name string) {
+	args := service.ClearSnapshotArgs{Name: name}
+	reply := service.ClearSnapshotReply{}
+	err := cc.Call("Mongongo.ClearSnapshot", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	for _, name := range reply.Cleared {
+		fmt.Printf("cleared snapshot %v\n", name)
+	}
+}
+
 func processLine(line string) {
 	tokens := strings.Split(line, " ")
 	tokens[0] = strings.ToUpper(tokens[0])
@@ -85,6 +306,7 @@ func processLine(line string) {
 	if strings.HasPrefix(token, "GET") ||
 		strings.HasPrefix(token, "SELECT") ||
 		strings.HasPrefix(token, "SET") ||
+		strings.HasPrefix(token, "INCR") ||
 		strings.HasPrefix(token, "DELETE") ||
 		strings.HasPrefix(token, "EXPLAIN") {
 		processServerQuery(line)
@@ -149,3 +371,66 @@ func main() {
 	quitCli()
 	return
 }
+
+// parseToken reads a token of REPAIR. The tokens of the random
+// partitioner are raw md5 sums, they are given in hex after 0x.
+func parseToken(field string) (string, error) {
+	token := strings.Trim(field, "'")
+	if !strings.HasPrefix(token, "0x") {
+		return token, nil
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(token, "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid hex token %v: %v", token, err)
+	}
+	return string(b), nil
+}
+
+// repair starts a repair on the server and
+// prints its progress until it is done
+func repair(line string) {
+	fields := strings.Fields(line)
+	if len(fields) != 1 && len(fields) != 3 {
+		fmt.Printf("usage: REPAIR table[.columnFamily] ['startToken' 'endToken']\n")
+		return
+	}
+	parts := strings.SplitN(fields[0], ".", 2)
+	args := service.RepairArgs{Keyspace: parts[0]}
+	if len(parts) == 2 {
+		args.ColumnFamily = parts[1]
+	}
+	if len(fields) == 3 {
+		var err error
+		args.StartToken, err = parseToken(fields[1])
+		if err == nil {
+			args.EndToken, err = parseToken(fields[2])
+		}
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+	}
+	reply := service.RepairReply{}
+	err := cc.Call("Mongongo.Repair", &args, &reply)
+	if err != nil {
+		printError(err)
+		return
+	}
+	printed := 0
+	for {
+		status := service.RepairStatusReply{}
+		err = cc.Call("Mongongo.GetRepairStatus", &service.RepairStatusArgs{ID: reply.ID}, &status)
+		if err != nil {
+			printError(err)
+			return
+		}
+		session := status.Session
+		for ; printed < len(session.Progress); printed++ {
+			fmt.Printf("[%v/%v] %v\n", session.RangesDone, session.RangesTotal, session.Progress[printed])
+		}
+		if session.Done {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+var (
+	inputFile   = flag.String("i", "", "file to read the json from instead of stdin")
+	columnType  = flag.String("type", "", "Standard or Super, for column families not in storage-conf.json")
+	compression = flag.String("compression", "", "none, snappy or zstd, for column families not in storage-conf.json")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: json2sstable [options] <data dir>/<table>/<cf>-<index>-Data.db\n")
+	fmt.Fprintf(os.Stderr, "Builds an sstable from json in the format written by sstable2json.\n")
+	fmt.Fprintf(os.Stderr, "Pick an index no sstable of the column family uses yet, the node\n")
+	fmt.Fprintf(os.Stderr, "loads the sstable on its next start.\n")
+	fmt.Fprintf(os.Stderr, "storage-conf.json is read from the directory in $storage-config.\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	dataFile := flag.Arg(0)
+	if !strings.HasSuffix(dataFile, "-Data.db") {
+		log.Fatalf("%v is not a data file name", dataFile)
+	}
+	var in io.Reader = os.Stdin
+	if *inputFile != "" {
+		file, err := os.Open(*inputFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		in = file
+	}
+	config.Init()
+	err := db.DefineColumnFamily(dataFile, *columnType, *compression)
+	if err != nil {
+		log.Fatalf("cannot write %v: %v", dataFile, err)
+	}
+	err = db.ImportSSTable(in, dataFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
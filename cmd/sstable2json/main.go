@@ -0,0 +1,77 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// keyList collects the keys given with -k
+type keyList []string
+
+func (k *keyList) String() string {
+	return strings.Join(*k, ",")
+}
+
+func (k *keyList) Set(key string) error {
+	*k = append(*k, key)
+	return nil
+}
+
+var (
+	keys       keyList
+	outputFile = flag.String("o", "", "file to write the json to instead of stdout")
+	columnType = flag.String("type", "", "Standard or Super, for column families not in storage-conf.json")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: sstable2json [options] <data dir>/<table>/<cf>-<index>-Data.db\n")
+	fmt.Fprintf(os.Stderr, "Dumps the rows of an sstable as json, the node should not be running.\n")
+	fmt.Fprintf(os.Stderr, "storage-conf.json is read from the directory in $storage-config.\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Var(&keys, "k", "key of a row to dump, may be repeated; all rows if not given")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+	dataFile := flag.Arg(0)
+	if !strings.HasSuffix(dataFile, "-Data.db") {
+		log.Fatalf("%v is not a data file", dataFile)
+	}
+	if _, err := os.Stat(dataFile); err != nil {
+		log.Fatal(err)
+	}
+	out := os.Stdout
+	if *outputFile != "" {
+		var err error
+		out, err = os.OpenFile(*outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+	config.Init()
+	err := db.DefineColumnFamily(dataFile, *columnType, "")
+	if err != nil {
+		log.Fatalf("cannot read %v: %v", dataFile, err)
+	}
+	err = db.ExportSSTable(dataFile, keys, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
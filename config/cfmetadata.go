@@ -19,8 +19,49 @@ type CFMetaData struct {
 	NColumnKey       string
 	NColumnValue     string
 	NColumnTimestamp string
+
+	// Compression of the sstable data files, none if empty.
+	// Changing it only affects sstables written afterwards.
+	Compression string
+	// CompactionStrategy picks the sstables to compact,
+	// SizeTiered if empty. It can be changed at any time.
+	CompactionStrategy string
+	// KeyCacheSize is the number of data file positions of keys
+	// cached for each sstable, TouchKeyCacheSize if 0 and no
+	// key cache if -1
+	KeyCacheSize int
+	// RowCacheSize is the number of whole rows cached, there is
+	// no row cache if 0. Writes to a row drop it from the cache.
+	RowCacheSize int
+	// DefaultTTL is the time to live in seconds of the columns
+	// written without one, 0 if they never expire
+	DefaultTTL int
+	// Counter column families hold counters, which are changed
+	// by adding to them instead of by overwriting them
+	Counter bool
+	// IndexedColumns are the columns of a standard column family
+	// whose values the rows can be looked up by. Every one of them
+	// is indexed in a hidden column family, see IndexCFName.
+	IndexedColumns []string
 }
 
+const (
+	// CompressionNone stores sstable data uncompressed
+	CompressionNone = "none"
+	// CompressionSnappy compresses sstable data with snappy
+	CompressionSnappy = "snappy"
+	// CompressionZstd compresses sstable data with zstd
+	CompressionZstd = "zstd"
+)
+
+const (
+	// SizeTieredCompaction compacts sstables of similar size
+	SizeTieredCompaction = "SizeTiered"
+	// LeveledCompaction keeps sstables in levels of non-overlapping
+	// sstables, each level ten times as large as the one before
+	LeveledCompaction = "Leveled"
+)
+
 // Pretty prints and describes the column family
 func (c *CFMetaData) Pretty() string {
 	desc := c.NColumnMap + "(" + c.NColumnKey + "," + c.NColumnValue + "," + c.NColumnTimestamp + ")"
@@ -31,3 +72,46 @@ func (c *CFMetaData) Pretty() string {
 	desc += "Column Family Type: " + c.ColumnType + "\nColumns Sorted by: " + c.IndexProperty + "\n"
 	return desc
 }
+
+// IsIndexed tells whether rows can be looked up by the value of column
+func (c *CFMetaData) IsIndexed(column string) bool {
+	for _, name := range c.IndexedColumns {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexCFName returns the name of the column family holding the index
+// of column in column family cfName. Its rows are keyed by the values
+// of column and hold an empty column named after every row key of
+// cfName with that value. Column family names cannot contain dots, so
+// index column families never collide with the ones users define.
+func IndexCFName(cfName, column string) string {
+	return cfName + "." + column
+}
+
+// getIndexCFMetaData describes the index column family of column
+func (c *CFMetaData) getIndexCFMetaData(column string) CFMetaData {
+	return CFMetaData{
+		TableName:          c.TableName,
+		CFName:             IndexCFName(c.CFName, column),
+		ColumnType:         "Standard",
+		IndexProperty:      "Name",
+		Compression:        c.Compression,
+		CompactionStrategy: c.CompactionStrategy,
+		KeyCacheSize:       c.KeyCacheSize,
+	}
+}
+
+// GetKeyCacheSize returns the number of keys cached for each sstable
+func (c *CFMetaData) GetKeyCacheSize() int {
+	if c.KeyCacheSize == 0 {
+		return TouchKeyCacheSize
+	}
+	if c.KeyCacheSize < 0 {
+		return 0
+	}
+	return c.KeyCacheSize
+}
@@ -6,8 +6,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 )
 
 const (
@@ -39,6 +43,12 @@ var (
 	RPCTimeoutInMillis = 5000
 	// GcGraceInSeconds defaults to 10 days
 	GcGraceInSeconds = 10 * 24 * 3600
+	// MaxHintWindowInMillis is how long hints are kept being
+	// written for a dead replica, 1 hour by default
+	MaxHintWindowInMillis = 3600 * 1000
+	// HintedHandoffThrottleInMillis is the pause between two
+	// hinted rows delivered to a replica that came back
+	HintedHandoffThrottleInMillis = 10
 	// Seeds is a set of nodes to connect to when a new node join the cluster
 	Seeds = map[string]bool{
 		"thumm01": true,
@@ -60,7 +70,9 @@ var (
 
 	// FastSync defaults to false
 	FastSync = false
-	// CommitLogSync can be either periodic or batch
+	// CommitLogSync can be either periodic or batch. Batch acks a
+	// write only after the commit log was synced, periodic syncs
+	// every CommitLogSyncPeriodInMS in the background
 	CommitLogSync = Periodic
 	// CommitLogSyncPeriodInMS defaults to 1000 i.e. 1s
 	CommitLogSyncPeriodInMS = 1000
@@ -72,10 +84,11 @@ var (
 	SysTableName = "system"
 	// HintsCF is the cf name for hinted handoff
 	HintsCF = "HintsColumnFamily"
-	// Tables for list of table name
-	// currently we cannot change the schema online
-	// this will be improved in the future
-	Tables = []string{SysTableName, "table1", "table2"} // TO BE IMPROVED
+	// SchemaCF is the cf name for the persisted schema definitions
+	SchemaCF = "Schema"
+	// Tables for list of table name, the list can be changed
+	// online through AddTable and DropTable
+	Tables = []string{SysTableName, "table1", "table2"}
 
 	// ApplicationColumnFamilies is a set of column family names
 	ApplicationColumnFamilies = map[string]bool{
@@ -98,7 +111,14 @@ var (
 			"column",       // NColumnMap
 			"",             // NColumnKey
 			"",             // NColumnValue
-			""},            // NColumnTimestamp
+			"",             // NColumnTimestamp
+			"",             // Compression
+			"",             // CompactionStrategy
+			0,              // KeyCacheSize
+			0,              // RowCacheSize
+			0,              // DefaultTTL
+			false,          // Counter
+			nil},           // IndexedColumns
 		"HintsColumnFamily": {
 			SysTableName,        // TableName
 			"HintsColumnFamily", // CFName
@@ -110,7 +130,33 @@ var (
 			"column0",           // NColumnMap
 			"",                  // NColumnKey
 			"",                  // NColumnValue
-			""},                 // NColumnTimestamp
+			"",                  // NColumnTimestamp
+			"",                  // Compression
+			"",                  // CompactionStrategy
+			0,                   // KeyCacheSize
+			0,                   // RowCacheSize
+			0,                   // DefaultTTL
+			false,               // Counter
+			nil},                // IndexedColumns
+		"Schema": {
+			SysTableName, // TableName
+			"Schema",     // CFName
+			"Standard",   // ColumnType
+			"Name",       // IndexProperty
+			"row",        // NRowKey
+			"",           // NSuperColumnMap
+			"",           // NSuperColumnKey
+			"column",     // NColumnMap
+			"",           // NColumnKey
+			"",           // NColumnValue
+			"",           // NColumnTimestamp
+			"",           // Compression
+			"",           // CompactionStrategy
+			0,            // KeyCacheSize
+			0,            // RowCacheSize
+			0,            // DefaultTTL
+			false,        // Counter
+			nil},         // IndexedColumns
 	}
 
 	// TableToCFMetaData map table names to column families and corresponding meta data
@@ -142,7 +188,14 @@ var (
 				"column1",     // NColumnMap
 				"",            // NColumnKey
 				"",            // NColumnValue
-				""},           // NColumnTimestamp
+				"",            // NColumnTimestamp
+				"",            // Compression
+				"",            // CompactionStrategy
+				0,             // KeyCacheSize
+				0,             // RowCacheSize
+				0,             // DefaultTTL
+				false,         // Counter
+				nil},          // IndexedColumns
 			"superCF1": {
 				"table1",   // TableName
 				"superCF1", // CFName
@@ -154,13 +207,20 @@ var (
 				"column2",  // NColumnMap
 				"",         // NColumnKey
 				"",         // NColumnValue
-				""},        // NColumnTimestamp
+				"",         // NColumnTimestamp
+				"",         // Compression
+				"",         // CompactionStrategy
+				0,          // KeyCacheSize
+				0,          // RowCacheSize
+				0,          // DefaultTTL
+				false,      // Counter
+				nil},       // IndexedColumns
 		},
 		"table2": {
 			"standardCF2": {"table2", "standardCF2", "Standard", "Name",
-				"row1", "", "", "column2", "", "", ""},
+				"row1", "", "", "column2", "", "", "", "", "", 0, 0, 0, false, nil},
 			"superCF2": {"table2", "superCF2", "Super", "Timestamp",
-				"row2", "superCM", "superCK", "column2", "", "", ""},
+				"row2", "superCM", "superCK", "column2", "", "", "", "", "", 0, 0, 0, false, nil},
 		},
 	}
 
@@ -174,7 +234,15 @@ var (
 	LogRotationThres = int64(128 * 1024 * 1024)
 	// ColumnIndexSizeInKB : indexing will kick in if size of column/supercolumn excedes
 	ColumnIndexSizeInKB = 64 // pre read from file
-	// TouchKeyCacheSize specifies sizeof touch key cache
+	// CompressionChunkLengthInKB is the size of the chunks compressed
+	// sstable data files are split into, before compression
+	CompressionChunkLengthInKB = 64
+	// LeveledSSTableSizeInMB is the size of the sstables written
+	// by compactions of column families using LeveledCompaction
+	LeveledSSTableSizeInMB = 5
+	// TouchKeyCacheSize is the number of keys whose data file
+	// position is cached for each sstable of a column family
+	// that does not set its own KeyCacheSize
 	TouchKeyCacheSize = 1024
 	// MemtableLifetime is the number of hours to keep a memtable in memory
 	MemtableLifetime = 6
@@ -192,26 +260,344 @@ var (
 	DoConsistencyCheck = true
 	// SnapshotBeforeCompaction defaults to false
 	SnapshotBeforeCompaction = false
+	// IncrementalBackups links every sstable written by a flush or a
+	// compaction into the backups directory next to it, defaults to false
+	IncrementalBackups = false
 	// JobTrackerHost is the address where to run the job tracker
 	JobTrackerHost string
 	// ConfigFileName is the path to config file
 	ConfigFileName string
 	// RingRange is the size of consistent hashing ring
 	RingRange = uint64(1 << 32)
+
+	// schemaMu guards Tables, ApplicationColumnFamilies
+	// and TableToCFMetaData against online schema changes
+	schemaMu sync.RWMutex
 )
 
 // DatabaseDescriptor contains meta data for the underlying storage system
 type DatabaseDescriptor struct {
 }
 
-// GetTableMetaData read CFMetaData through map using table name
+// GetTableMetaData read CFMetaData through map using table name,
+// the returned map is a copy and nil if the table does not exist
 func GetTableMetaData(tableName string) map[string]CFMetaData {
-	return TableToCFMetaData[tableName]
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	cfs, ok := TableToCFMetaData[tableName]
+	if !ok {
+		return nil
+	}
+	res := make(map[string]CFMetaData, len(cfs))
+	for cfName, cfMetaData := range cfs {
+		res[cfName] = cfMetaData
+	}
+	return res
+}
+
+// GetCFMetaData returns the meta data of the given column family,
+// which may also be the index column family of an indexed column
+func GetCFMetaData(tableName, cfName string) (CFMetaData, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	return lookupCFMetaData(tableName, cfName)
+}
+
+// lookupCFMetaData is GetCFMetaData for callers holding schemaMu.
+// Index column families are not listed in TableToCFMetaData, their
+// meta data is derived from the column family they index.
+func lookupCFMetaData(tableName, cfName string) (CFMetaData, bool) {
+	cfMetaData, ok := TableToCFMetaData[tableName][cfName]
+	if ok {
+		return cfMetaData, true
+	}
+	dot := strings.Index(cfName, ".")
+	if dot < 0 {
+		return CFMetaData{}, false
+	}
+	base, ok := TableToCFMetaData[tableName][cfName[:dot]]
+	if !ok || !base.IsIndexed(cfName[dot+1:]) {
+		return CFMetaData{}, false
+	}
+	return base.getIndexCFMetaData(cfName[dot+1:]), true
+}
+
+// IsIndexCF tells whether cfName is the index column family of an
+// indexed column, which clients cannot write to directly
+func IsIndexCF(tableName, cfName string) bool {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	_, ok := TableToCFMetaData[tableName][cfName]
+	if ok {
+		return false
+	}
+	_, ok = lookupCFMetaData(tableName, cfName)
+	return ok
+}
+
+// ValidateCFMetaData checks the parts of a column family definition
+// the storage layer relies on
+func ValidateCFMetaData(cfMetaData CFMetaData) error {
+	if cfMetaData.CFName == "" {
+		return fmt.Errorf("column family has no name")
+	}
+	// data files are named <cf>-<index>-Data.db, so a dash
+	// in the name would confuse the file name parser, dots
+	// are left for the names of index column families
+	if strings.ContainsAny(cfMetaData.CFName, "-."+string(os.PathSeparator)) {
+		return fmt.Errorf("column family name %q must not contain '-', '.' or path separators", cfMetaData.CFName)
+	}
+	if cfMetaData.ColumnType != "Standard" && cfMetaData.ColumnType != "Super" {
+		return fmt.Errorf("column family %q: ColumnType must be Standard or Super, got %q",
+			cfMetaData.CFName, cfMetaData.ColumnType)
+	}
+	if cfMetaData.IndexProperty != "Name" && cfMetaData.IndexProperty != "Timestamp" {
+		return fmt.Errorf("column family %q: IndexProperty must be Name or Timestamp, got %q",
+			cfMetaData.CFName, cfMetaData.IndexProperty)
+	}
+	switch cfMetaData.Compression {
+	case "", CompressionNone, CompressionSnappy, CompressionZstd:
+	default:
+		return fmt.Errorf("column family %q: Compression must be %v, %v or %v, got %q",
+			cfMetaData.CFName, CompressionNone, CompressionSnappy, CompressionZstd, cfMetaData.Compression)
+	}
+	switch cfMetaData.CompactionStrategy {
+	case "", SizeTieredCompaction, LeveledCompaction:
+	default:
+		return fmt.Errorf("column family %q: CompactionStrategy must be %v or %v, got %q",
+			cfMetaData.CFName, SizeTieredCompaction, LeveledCompaction, cfMetaData.CompactionStrategy)
+	}
+	if cfMetaData.KeyCacheSize < -1 {
+		return fmt.Errorf("column family %q: KeyCacheSize must be -1 or more, got %v",
+			cfMetaData.CFName, cfMetaData.KeyCacheSize)
+	}
+	if cfMetaData.RowCacheSize < 0 {
+		return fmt.Errorf("column family %q: RowCacheSize must not be negative, got %v",
+			cfMetaData.CFName, cfMetaData.RowCacheSize)
+	}
+	if cfMetaData.DefaultTTL < 0 {
+		return fmt.Errorf("column family %q: DefaultTTL must not be negative, got %v",
+			cfMetaData.CFName, cfMetaData.DefaultTTL)
+	}
+	if cfMetaData.Counter && cfMetaData.DefaultTTL != 0 {
+		return fmt.Errorf("column family %q: counters do not expire, DefaultTTL must be 0", cfMetaData.CFName)
+	}
+	return validateIndexedColumns(cfMetaData)
+}
+
+// validateIndexedColumns checks the indexed columns of a column
+// family, whose names become part of column family names
+func validateIndexedColumns(cfMetaData CFMetaData) error {
+	if len(cfMetaData.IndexedColumns) == 0 {
+		return nil
+	}
+	if cfMetaData.ColumnType != "Standard" || cfMetaData.Counter {
+		return fmt.Errorf("column family %q: only standard column families without counters can have IndexedColumns",
+			cfMetaData.CFName)
+	}
+	seen := make(map[string]bool)
+	for _, column := range cfMetaData.IndexedColumns {
+		if column == "" || strings.ContainsAny(column, "-"+string(os.PathSeparator)) {
+			return fmt.Errorf("column family %q: indexed column %q must not be empty or contain '-' or path separators",
+				cfMetaData.CFName, column)
+		}
+		if seen[column] {
+			return fmt.Errorf("column family %q: column %q is indexed twice", cfMetaData.CFName, column)
+		}
+		seen[column] = true
+	}
+	return nil
+}
+
+// ValidateTableName checks whether name can be used for a new table
+func ValidateTableName(name string) error {
+	if name == "" {
+		return fmt.Errorf("table has no name")
+	}
+	if name == SysTableName {
+		return fmt.Errorf("table name %q is reserved", SysTableName)
+	}
+	if strings.ContainsAny(name, string(os.PathSeparator)+".") {
+		return fmt.Errorf("table name %q must not contain '.' or path separators", name)
+	}
+	return nil
+}
+
+// AddTable registers a new table and its column families
+func AddTable(tableName string, cfMetaDatas []CFMetaData) error {
+	if err := ValidateTableName(tableName); err != nil {
+		return err
+	}
+	cfs := make(map[string]CFMetaData)
+	for _, cfMetaData := range cfMetaDatas {
+		cfMetaData.TableName = tableName
+		if err := ValidateCFMetaData(cfMetaData); err != nil {
+			return err
+		}
+		if _, ok := cfs[cfMetaData.CFName]; ok {
+			return fmt.Errorf("duplicate column family %q", cfMetaData.CFName)
+		}
+		cfs[cfMetaData.CFName] = cfMetaData
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if _, ok := TableToCFMetaData[tableName]; ok {
+		return fmt.Errorf("table %q already exists", tableName)
+	}
+	Tables = append(Tables, tableName)
+	TableToCFMetaData[tableName] = cfs
+	for cfName := range cfs {
+		ApplicationColumnFamilies[cfName] = true
+	}
+	return nil
 }
 
-// Init read the configuration file to retrieve DB related properties
+// DropTable unregisters a table and all its column families
+func DropTable(tableName string) error {
+	if tableName == SysTableName {
+		return fmt.Errorf("table %q cannot be dropped", SysTableName)
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if _, ok := TableToCFMetaData[tableName]; !ok {
+		return fmt.Errorf("table %q does not exist", tableName)
+	}
+	delete(TableToCFMetaData, tableName)
+	tables := make([]string, 0, len(Tables))
+	for _, table := range Tables {
+		if table != tableName {
+			tables = append(tables, table)
+		}
+	}
+	Tables = tables
+	rebuildApplicationColumnFamilies()
+	return nil
+}
+
+// AddColumnFamily registers a new column family in an existing table
+func AddColumnFamily(cfMetaData CFMetaData) error {
+	if cfMetaData.TableName == SysTableName {
+		return fmt.Errorf("table %q cannot be altered", SysTableName)
+	}
+	if err := ValidateCFMetaData(cfMetaData); err != nil {
+		return err
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	cfs, ok := TableToCFMetaData[cfMetaData.TableName]
+	if !ok {
+		return fmt.Errorf("table %q does not exist", cfMetaData.TableName)
+	}
+	if _, ok := cfs[cfMetaData.CFName]; ok {
+		return fmt.Errorf("column family %v.%v already exists", cfMetaData.TableName, cfMetaData.CFName)
+	}
+	cfs[cfMetaData.CFName] = cfMetaData
+	ApplicationColumnFamilies[cfMetaData.CFName] = true
+	return nil
+}
+
+// UpdateColumnFamily replaces the definition of an existing column
+// family. The column type cannot be changed once data was written.
+func UpdateColumnFamily(cfMetaData CFMetaData) error {
+	if cfMetaData.TableName == SysTableName {
+		return fmt.Errorf("table %q cannot be altered", SysTableName)
+	}
+	if err := ValidateCFMetaData(cfMetaData); err != nil {
+		return err
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	old, ok := TableToCFMetaData[cfMetaData.TableName][cfMetaData.CFName]
+	if !ok {
+		return fmt.Errorf("column family %v.%v does not exist", cfMetaData.TableName, cfMetaData.CFName)
+	}
+	if old.ColumnType != cfMetaData.ColumnType {
+		return fmt.Errorf("column family %v.%v: cannot change ColumnType from %v to %v",
+			cfMetaData.TableName, cfMetaData.CFName, old.ColumnType, cfMetaData.ColumnType)
+	}
+	TableToCFMetaData[cfMetaData.TableName][cfMetaData.CFName] = cfMetaData
+	return nil
+}
+
+// DropColumnFamily unregisters a column family
+func DropColumnFamily(tableName, cfName string) error {
+	if tableName == SysTableName {
+		return fmt.Errorf("table %q cannot be altered", SysTableName)
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	cfs, ok := TableToCFMetaData[tableName]
+	if !ok {
+		return fmt.Errorf("table %q does not exist", tableName)
+	}
+	if _, ok := cfs[cfName]; !ok {
+		return fmt.Errorf("column family %v.%v does not exist", tableName, cfName)
+	}
+	delete(cfs, cfName)
+	rebuildApplicationColumnFamilies()
+	return nil
+}
+
+// SetApplicationTables replaces all application tables at once,
+// used when the schema is restored from the system table
+func SetApplicationTables(tableToCFMetaData map[string]map[string]CFMetaData) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	names := make([]string, 0, len(tableToCFMetaData))
+	for table := range tableToCFMetaData {
+		if table != SysTableName {
+			names = append(names, table)
+		}
+	}
+	sort.Strings(names)
+	Tables = append([]string{SysTableName}, names...)
+	TableToCFMetaData = map[string]map[string]CFMetaData{SysTableName: SystemMetadata}
+	for _, table := range names {
+		TableToCFMetaData[table] = tableToCFMetaData[table]
+	}
+	rebuildApplicationColumnFamilies()
+}
+
+// CloneApplicationTables returns a deep copy of the meta data of
+// every table except the system table
+func CloneApplicationTables() map[string]map[string]CFMetaData {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	res := make(map[string]map[string]CFMetaData)
+	for table, cfs := range TableToCFMetaData {
+		if table == SysTableName {
+			continue
+		}
+		res[table] = make(map[string]CFMetaData, len(cfs))
+		for cfName, cfMetaData := range cfs {
+			res[table][cfName] = cfMetaData
+		}
+	}
+	return res
+}
+
+func rebuildApplicationColumnFamilies() {
+	ApplicationColumnFamilies = make(map[string]bool)
+	for table, cfs := range TableToCFMetaData {
+		if table == SysTableName {
+			continue
+		}
+		for cfName := range cfs {
+			ApplicationColumnFamilies[cfName] = true
+		}
+	}
+}
+
+// Init read the configuration file to retrieve DB related properties.
+// The directory holding storage-conf.json is taken from the
+// storage-config environment variable. A missing or invalid file
+// aborts the startup, the built-in defaults are only used if
+// storage-config is not set.
 func Init() map[string]map[string]CFMetaData {
-	ConfigFileName = os.Getenv("storage-config") + string(os.PathSeparator) + "storage-conf.json"
+	ConfigFileName = ""
+	if dir := os.Getenv("storage-config"); dir != "" {
+		ConfigFileName = dir + string(os.PathSeparator) + "storage-conf.json"
+	}
 	return initInternal(ConfigFileName)
 }
 
@@ -241,7 +627,15 @@ func mkdir(path string) {
 }
 
 func initInternal(file string) map[string]map[string]CFMetaData {
-	// I will not use file, however..
+	loaded, err := loadStorageConf(file)
+	if err != nil {
+		log.Fatalf("invalid storage configuration: %v\n", err)
+	}
+	if loaded {
+		log.Printf("loaded storage configuration from %v\n", file)
+	} else {
+		log.Printf("storage-config is not set, using default storage configuration\n")
+	}
 	mkdir(MetadataDir)
 	mkdir(SnapshotDir)
 	// make sure all tables have directory
@@ -258,6 +652,8 @@ func initInternal(file string) map[string]map[string]CFMetaData {
 
 // GetColumnType retrieve column type from cf metadata
 func GetColumnType(cfName string) string {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
 	table := Tables[0]
 	cfMetadata, ok := TableToCFMetaData[table][cfName]
 	if !ok {
@@ -268,7 +664,9 @@ func GetColumnType(cfName string) string {
 
 // GetColumnTypeTableName retrieve column type from cf metadata
 func GetColumnTypeTableName(table string, cfName string) string {
-	cfMetadata, ok := TableToCFMetaData[table][cfName]
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	cfMetadata, ok := lookupCFMetaData(table, cfName)
 	if !ok {
 		return ""
 	}
@@ -319,5 +717,7 @@ func GetColumnIndexSize() int {
 
 // GetTables ...
 func GetTables() []string {
-	return Tables
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	return append([]string{}, Tables...)
 }
@@ -0,0 +1,409 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// storageConf mirrors the layout of storage-conf.json. Every
+// field is optional: anything left out keeps the built-in
+// default declared in databasedescriptor.go.
+type storageConf struct {
+	ClusterName                   *string
+	StoragePort                   *string
+	ControlPort                   *string
+	HTTPPort                      *string
+	ReplicationFactor             *int
+	RPCTimeoutInMillis            *int
+	GcGraceInSeconds              *int
+	MaxHintWindowInMillis         *int
+	HintedHandoffThrottleInMillis *int
+	Seeds                         []string
+	MetadataDir                   *string
+	DataFileDirs                  []string
+	LogFileDir                    *string
+	BootstrapFileDir              *string
+	CommitLogSync                 *string
+	CommitLogSyncPeriodInMS       *int
+	InitialToken                  *string
+	RackAware                     *bool
+	HashingStrategy               *string
+	MinCompactionThres            *int
+	MaxCompactionThres            *int
+	LogRotationThresInMB          *int
+	ColumnIndexSizeInKB           *int
+	CompressionChunkLengthInKB    *int
+	LeveledSSTableSizeInMB        *int
+	TouchKeyCacheSize             *int
+	MemtableLifetime              *int
+	MemtableSize                  *int
+	MemtableObjectCount           *int
+	FlushDataBufferSizeInMB       *int
+	FlushIndexBufferSizeInMB      *int
+	DoConsistencyCheck            *bool
+	SnapshotBeforeCompaction      *bool
+	IncrementalBackups            *bool
+	JobTrackerHost                *string
+	Keyspaces                     []keyspaceConf
+}
+
+// keyspaceConf describes one application table
+type keyspaceConf struct {
+	Name           string
+	ColumnFamilies []columnFamilyConf
+}
+
+// columnFamilyConf describes one column family, the
+// N* names are only used by the MQL front end
+type columnFamilyConf struct {
+	Name               string
+	ColumnType         string
+	IndexProperty      string
+	RowKey             string
+	SuperColumnMap     string
+	SuperColumnKey     string
+	ColumnMap          string
+	ColumnKey          string
+	ColumnValue        string
+	ColumnTimestamp    string
+	Compression        string
+	CompactionStrategy string
+	KeyCacheSize       int
+	RowCacheSize       int
+	DefaultTTL         int
+	Counter            bool
+	IndexedColumns     []string
+}
+
+// loadStorageConf parses and validates the given file and, only
+// if everything checks out, applies it to the package settings.
+// An empty file name keeps the built-in defaults, a file that
+// does not exist is an error.
+func loadStorageConf(file string) (bool, error) {
+	if file == "" {
+		return false, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("%v does not exist, check storage-config", file)
+	}
+	if err != nil {
+		return false, err
+	}
+	conf, err := parseStorageConf(data)
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", file, err)
+	}
+	if err = conf.validate(); err != nil {
+		return false, fmt.Errorf("%v: %v", file, err)
+	}
+	conf.apply()
+	return true, nil
+}
+
+func parseStorageConf(data []byte) (*storageConf, error) {
+	conf := &storageConf{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// a misspelled key would otherwise be silently ignored
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(conf); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the top level object")
+	}
+	return conf, nil
+}
+
+func checkPort(name string, port *string) error {
+	if port == nil {
+		return nil
+	}
+	n, err := strconv.Atoi(*port)
+	if err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("%v must be a port number in [1, 65535], got %q", name, *port)
+	}
+	return nil
+}
+
+func checkPositive(name string, value *int) error {
+	if value != nil && *value <= 0 {
+		return fmt.Errorf("%v must be positive, got %v", name, *value)
+	}
+	return nil
+}
+
+func checkNotEmpty(name string, value *string) error {
+	if value != nil && strings.TrimSpace(*value) == "" {
+		return fmt.Errorf("%v must not be empty", name)
+	}
+	return nil
+}
+
+func (c *storageConf) validate() error {
+	ports := []struct {
+		name  string
+		value *string
+	}{
+		{"StoragePort", c.StoragePort},
+		{"ControlPort", c.ControlPort},
+		{"HTTPPort", c.HTTPPort},
+	}
+	for _, p := range ports {
+		if err := checkPort(p.name, p.value); err != nil {
+			return err
+		}
+	}
+	positives := []struct {
+		name  string
+		value *int
+	}{
+		{"ReplicationFactor", c.ReplicationFactor},
+		{"RPCTimeoutInMillis", c.RPCTimeoutInMillis},
+		{"MaxHintWindowInMillis", c.MaxHintWindowInMillis},
+		{"CommitLogSyncPeriodInMS", c.CommitLogSyncPeriodInMS},
+		{"MinCompactionThres", c.MinCompactionThres},
+		{"MaxCompactionThres", c.MaxCompactionThres},
+		{"LogRotationThresInMB", c.LogRotationThresInMB},
+		{"ColumnIndexSizeInKB", c.ColumnIndexSizeInKB},
+		{"CompressionChunkLengthInKB", c.CompressionChunkLengthInKB},
+		{"LeveledSSTableSizeInMB", c.LeveledSSTableSizeInMB},
+		{"TouchKeyCacheSize", c.TouchKeyCacheSize},
+		{"MemtableLifetime", c.MemtableLifetime},
+		{"MemtableSize", c.MemtableSize},
+		{"MemtableObjectCount", c.MemtableObjectCount},
+		{"FlushDataBufferSizeInMB", c.FlushDataBufferSizeInMB},
+		{"FlushIndexBufferSizeInMB", c.FlushIndexBufferSizeInMB},
+	}
+	for _, p := range positives {
+		if err := checkPositive(p.name, p.value); err != nil {
+			return err
+		}
+	}
+	if c.GcGraceInSeconds != nil && *c.GcGraceInSeconds < 0 {
+		return fmt.Errorf("GcGraceInSeconds must not be negative, got %v", *c.GcGraceInSeconds)
+	}
+	if c.HintedHandoffThrottleInMillis != nil && *c.HintedHandoffThrottleInMillis < 0 {
+		return fmt.Errorf("HintedHandoffThrottleInMillis must not be negative, got %v", *c.HintedHandoffThrottleInMillis)
+	}
+	minThres, maxThres := MinCompactionThres, MaxCompactionThres
+	if c.MinCompactionThres != nil {
+		minThres = *c.MinCompactionThres
+	}
+	if c.MaxCompactionThres != nil {
+		maxThres = *c.MaxCompactionThres
+	}
+	if minThres > maxThres {
+		return fmt.Errorf("MinCompactionThres (%v) must not exceed MaxCompactionThres (%v)", minThres, maxThres)
+	}
+	for _, dir := range []struct {
+		name  string
+		value *string
+	}{
+		{"ClusterName", c.ClusterName},
+		{"MetadataDir", c.MetadataDir},
+		{"LogFileDir", c.LogFileDir},
+		{"BootstrapFileDir", c.BootstrapFileDir},
+	} {
+		if err := checkNotEmpty(dir.name, dir.value); err != nil {
+			return err
+		}
+	}
+	if c.DataFileDirs != nil && len(c.DataFileDirs) == 0 {
+		return fmt.Errorf("DataFileDirs must list at least one directory")
+	}
+	for _, dir := range c.DataFileDirs {
+		if strings.TrimSpace(dir) == "" {
+			return fmt.Errorf("DataFileDirs must not contain empty entries")
+		}
+	}
+	if c.Seeds != nil && len(c.Seeds) == 0 {
+		return fmt.Errorf("Seeds must list at least one host")
+	}
+	for _, seed := range c.Seeds {
+		if strings.TrimSpace(seed) == "" {
+			return fmt.Errorf("Seeds must not contain empty host names")
+		}
+	}
+	if c.CommitLogSync != nil {
+		if _, err := parseCommitLogSync(*c.CommitLogSync); err != nil {
+			return err
+		}
+	}
+	if c.HashingStrategy != nil && *c.HashingStrategy != Random && *c.HashingStrategy != Ophf {
+		return fmt.Errorf("HashingStrategy must be %v or %v, got %q", Random, Ophf, *c.HashingStrategy)
+	}
+	return c.validateKeyspaces()
+}
+
+func parseCommitLogSync(mode string) (int, error) {
+	switch strings.ToLower(mode) {
+	case "batch":
+		return Batch, nil
+	case "periodic":
+		return Periodic, nil
+	}
+	return 0, fmt.Errorf("CommitLogSync must be batch or periodic, got %q", mode)
+}
+
+func (c *storageConf) validateKeyspaces() error {
+	tables := make(map[string]bool)
+	for i, ks := range c.Keyspaces {
+		if ks.Name == "" {
+			return fmt.Errorf("Keyspaces[%v] has no Name", i)
+		}
+		if ks.Name == SysTableName {
+			return fmt.Errorf("keyspace name %q is reserved", SysTableName)
+		}
+		if tables[ks.Name] {
+			return fmt.Errorf("duplicate keyspace %q", ks.Name)
+		}
+		tables[ks.Name] = true
+		if len(ks.ColumnFamilies) == 0 {
+			return fmt.Errorf("keyspace %q defines no column families", ks.Name)
+		}
+		cfs := make(map[string]bool)
+		for j, cf := range ks.ColumnFamilies {
+			if err := cf.validate(); err != nil {
+				return fmt.Errorf("keyspace %q, ColumnFamilies[%v]: %v", ks.Name, j, err)
+			}
+			if cfs[cf.Name] {
+				return fmt.Errorf("keyspace %q: duplicate column family %q", ks.Name, cf.Name)
+			}
+			cfs[cf.Name] = true
+		}
+	}
+	return nil
+}
+
+func (cf *columnFamilyConf) validate() error {
+	if cf.Name == "" {
+		return fmt.Errorf("column family has no Name")
+	}
+	return ValidateCFMetaData(cf.toCFMetaData(""))
+}
+
+func (cf *columnFamilyConf) toCFMetaData(table string) CFMetaData {
+	indexProperty := cf.IndexProperty
+	if indexProperty == "" {
+		indexProperty = "Name"
+	}
+	return CFMetaData{
+		TableName:          table,
+		CFName:             cf.Name,
+		ColumnType:         cf.ColumnType,
+		IndexProperty:      indexProperty,
+		NRowKey:            cf.RowKey,
+		NSuperColumnMap:    cf.SuperColumnMap,
+		NSuperColumnKey:    cf.SuperColumnKey,
+		NColumnMap:         cf.ColumnMap,
+		NColumnKey:         cf.ColumnKey,
+		NColumnValue:       cf.ColumnValue,
+		NColumnTimestamp:   cf.ColumnTimestamp,
+		Compression:        cf.Compression,
+		CompactionStrategy: cf.CompactionStrategy,
+		KeyCacheSize:       cf.KeyCacheSize,
+		RowCacheSize:       cf.RowCacheSize,
+		DefaultTTL:         cf.DefaultTTL,
+		Counter:            cf.Counter,
+		IndexedColumns:     cf.IndexedColumns,
+	}
+}
+
+func setString(dst *string, src *string) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setInt(dst *int, src *int) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// apply copies the parsed settings into the package variables.
+// It must only be called on a validated storageConf.
+func (c *storageConf) apply() {
+	setString(&ClusterName, c.ClusterName)
+	setString(&StoragePort, c.StoragePort)
+	setString(&ControlPort, c.ControlPort)
+	setString(&HTTPPort, c.HTTPPort)
+	setInt(&ReplicationFactor, c.ReplicationFactor)
+	setInt(&RPCTimeoutInMillis, c.RPCTimeoutInMillis)
+	setInt(&GcGraceInSeconds, c.GcGraceInSeconds)
+	setInt(&MaxHintWindowInMillis, c.MaxHintWindowInMillis)
+	setInt(&HintedHandoffThrottleInMillis, c.HintedHandoffThrottleInMillis)
+	if c.Seeds != nil {
+		Seeds = make(map[string]bool)
+		for _, seed := range c.Seeds {
+			Seeds[seed] = true
+		}
+	}
+	if c.MetadataDir != nil {
+		MetadataDir = *c.MetadataDir
+		SnapshotDir = MetadataDir + string(os.PathSeparator) + "snapshot"
+	}
+	if c.DataFileDirs != nil {
+		DataFileDirs = c.DataFileDirs
+	}
+	setString(&LogFileDir, c.LogFileDir)
+	setString(&BootstrapFileDir, c.BootstrapFileDir)
+	if c.CommitLogSync != nil {
+		CommitLogSync, _ = parseCommitLogSync(*c.CommitLogSync)
+	}
+	setInt(&CommitLogSyncPeriodInMS, c.CommitLogSyncPeriodInMS)
+	setString(&InitialToken, c.InitialToken)
+	setBool(&RackAware, c.RackAware)
+	setString(&HashingStrategy, c.HashingStrategy)
+	setInt(&MinCompactionThres, c.MinCompactionThres)
+	setInt(&MaxCompactionThres, c.MaxCompactionThres)
+	if c.LogRotationThresInMB != nil {
+		LogRotationThres = int64(*c.LogRotationThresInMB) * 1024 * 1024
+	}
+	setInt(&ColumnIndexSizeInKB, c.ColumnIndexSizeInKB)
+	setInt(&CompressionChunkLengthInKB, c.CompressionChunkLengthInKB)
+	setInt(&LeveledSSTableSizeInMB, c.LeveledSSTableSizeInMB)
+	setInt(&TouchKeyCacheSize, c.TouchKeyCacheSize)
+	setInt(&MemtableLifetime, c.MemtableLifetime)
+	setInt(&MemtableSize, c.MemtableSize)
+	setInt(&MemtableObjectCount, c.MemtableObjectCount)
+	setInt(&FlushDataBufferSizeInMB, c.FlushDataBufferSizeInMB)
+	setInt(&FlushIndexBufferSizeInMB, c.FlushIndexBufferSizeInMB)
+	setBool(&DoConsistencyCheck, c.DoConsistencyCheck)
+	setBool(&SnapshotBeforeCompaction, c.SnapshotBeforeCompaction)
+	setBool(&IncrementalBackups, c.IncrementalBackups)
+	setString(&JobTrackerHost, c.JobTrackerHost)
+	if c.Keyspaces != nil {
+		Tables = []string{SysTableName}
+		ApplicationColumnFamilies = make(map[string]bool)
+		TableToCFMetaData = map[string]map[string]CFMetaData{
+			SysTableName: SystemMetadata,
+		}
+		for _, ks := range c.Keyspaces {
+			Tables = append(Tables, ks.Name)
+			cfs := make(map[string]CFMetaData)
+			for _, cf := range ks.ColumnFamilies {
+				cfs[cf.Name] = cf.toCFMetaData(ks.Name)
+				ApplicationColumnFamilies[cf.Name] = true
+			}
+			TableToCFMetaData[ks.Name] = cfs
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShippedStorageConfIsValid(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "conf", "storage-conf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf, err := parseStorageConf(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStorageConfRejectsInvalidSettings(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown key":          `{"ReplicationFactr": 3}`,
+		"trailing data":        `{"ReplicationFactor": 3} {}`,
+		"wrong type":           `{"ReplicationFactor": "3"}`,
+		"port out of range":    `{"StoragePort": "70000"}`,
+		"port not a number":    `{"ControlPort": "http"}`,
+		"zero replication":     `{"ReplicationFactor": 0}`,
+		"negative gc grace":    `{"GcGraceInSeconds": -1}`,
+		"min above max":        `{"MinCompactionThres": 8, "MaxCompactionThres": 4}`,
+		"empty cluster name":   `{"ClusterName": " "}`,
+		"no data dirs":         `{"DataFileDirs": []}`,
+		"empty seed":           `{"Seeds": [""]}`,
+		"commit log sync":      `{"CommitLogSync": "sometimes"}`,
+		"hashing strategy":     `{"HashingStrategy": "MD5"}`,
+		"keyspace name":        `{"Keyspaces": [{"ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard"}]}]}`,
+		"system keyspace":      `{"Keyspaces": [{"Name": "system", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard"}]}]}`,
+		"no column families":   `{"Keyspaces": [{"Name": "t"}]}`,
+		"duplicate keyspace":   `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard"}]}, {"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard"}]}]}`,
+		"duplicate cf":         `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard"}, {"Name": "cf", "ColumnType": "Super"}]}]}`,
+		"column type":          `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Wide"}]}]}`,
+		"dash in cf name":      `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "c-f", "ColumnType": "Standard"}]}]}`,
+		"compression":          `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard", "Compression": "gzip"}]}]}`,
+		"negative default ttl": `{"Keyspaces": [{"Name": "t", "ColumnFamilies": [{"Name": "cf", "ColumnType": "Standard", "DefaultTTL": -1}]}]}`,
+	} {
+		conf, err := parseStorageConf([]byte(data))
+		if err == nil {
+			err = conf.validate()
+		}
+		if err == nil {
+			t.Errorf("%v: %v is accepted", name, data)
+		}
+	}
+}
+
+func TestLoadStorageConf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storageconf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	loaded, err := loadStorageConf("")
+	if loaded || err != nil {
+		t.Errorf("no config file gives %v, %v, want the defaults", loaded, err)
+	}
+	// a mistyped storage-config must not start with the defaults
+	loaded, err = loadStorageConf(filepath.Join(dir, "missing.json"))
+	if loaded || err == nil {
+		t.Errorf("a missing file gives %v, %v, want an error", loaded, err)
+	}
+	// an invalid file must not change any setting
+	replicationFactor, rpcTimeout := ReplicationFactor, RPCTimeoutInMillis
+	file := filepath.Join(dir, "storage-conf.json")
+	err = ioutil.WriteFile(file, []byte(`{"ReplicationFactor": 7, "RPCTimeoutInMillis": -1}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadStorageConf(file); err == nil {
+		t.Errorf("an invalid file is loaded")
+	}
+	if ReplicationFactor != replicationFactor || RPCTimeoutInMillis != rpcTimeout {
+		t.Errorf("an invalid file changed the settings")
+	}
+	err = ioutil.WriteFile(file, []byte(`{"ReplicationFactor": 7}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { ReplicationFactor = replicationFactor }()
+	if loaded, err := loadStorageConf(file); !loaded || err != nil {
+		t.Fatalf("a valid file gives %v, %v", loaded, err)
+	}
+	if ReplicationFactor != 7 || RPCTimeoutInMillis != rpcTimeout {
+		t.Errorf("ReplicationFactor = %v, RPCTimeoutInMillis = %v after the load", ReplicationFactor, RPCTimeoutInMillis)
+	}
+}
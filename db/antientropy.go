@@ -0,0 +1,148 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"crypto/md5"
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/dht"
+	"github.com/DistAlchemist/Mongongo/utils"
+)
+
+// ValidationArgs asks a replica for the merkle tree
+// of its rows of a column family in a range
+type ValidationArgs struct {
+	Table        string
+	ColumnFamily string
+	Range        dht.Range
+	Depth        int
+}
+
+// ValidationReply ...
+type ValidationReply struct {
+	Tree *utils.MerkleTree
+}
+
+// DoValidation builds the merkle tree of the local rows in args.Range.
+// Like a compaction it merges the memtables and sstables in the order
+// of the ring and reads every row once, but it only hashes the rows
+// instead of writing them. Tombstones are hashed too, so replicas
+// which missed a deletion are found.
+func DoValidation(args *ValidationArgs, reply *ValidationReply) error {
+	cfStore, err := lookupColumnFamilyStore(args.Table, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	partitioner := getPartitioner()
+	tree := utils.NewMerkleTree(args.Range, args.Depth)
+	rows := 0
+	err = cfStore.forEachRowIn(args.Range, func(key string, cf *ColumnFamily) {
+		row := NewRowT(args.Table, key)
+		row.addColumnFamily(cf)
+		hash := md5.Sum(append([]byte(key), row.Digest()...))
+		tree.Add(partitioner.GetToken(key), hash[:])
+		rows++
+	})
+	if err != nil {
+		return err
+	}
+	tree.Finish()
+	log.Printf("validated %v rows of %v.%v in %q to %q\n", rows, args.Table, args.ColumnFamily,
+		args.Range.Left, args.Range.Right)
+	reply.Tree = tree
+	return nil
+}
+
+// StreamRowsArgs asks a replica for a batch of its rows
+// of a column family in some ranges
+type StreamRowsArgs struct {
+	Table        string
+	ColumnFamily string
+	Ranges       []dht.Range
+	// After is the Next of the previous batch,
+	// empty for the first one
+	After string
+	// MaxRows is the most rows returned, 0 for all of them
+	MaxRows int
+}
+
+// StreamRowsReply ...
+type StreamRowsReply struct {
+	Rows []*Row
+	// Next is the decorated key of the last row if more rows
+	// may follow, empty if this is the last batch
+	Next string
+}
+
+// DoStreamRows returns the local rows in args.Ranges with their
+// tombstones, so that applying them to another replica gives it
+// everything this one has. The rows come in the order of the ring
+// in batches of args.MaxRows, each one starting after the last
+// row of the one before.
+func DoStreamRows(args *StreamRowsArgs, reply *StreamRowsReply) error {
+	cfStore, err := lookupColumnFamilyStore(args.Table, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	partitioner := getPartitioner()
+	reply.Rows = make([]*Row, 0)
+	iter := cfStore.newDecoratedKeyIterator()
+	// pick up where the previous batch stopped
+	iter.seek(args.After)
+	for decoratedKey := iter.next(); decoratedKey != ""; decoratedKey = iter.next() {
+		key := partitioner.UndecorateKey(decoratedKey)
+		if !containsToken(args.Ranges, partitioner.GetToken(key)) {
+			continue
+		}
+		cf, err := cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(args.ColumnFamily)))
+		if err != nil {
+			return err
+		}
+		if cf == nil {
+			continue
+		}
+		row := NewRowT(args.Table, key)
+		row.addColumnFamily(cf)
+		reply.Rows = append(reply.Rows, row)
+		if args.MaxRows > 0 && len(reply.Rows) >= args.MaxRows {
+			reply.Next = decoratedKey
+			break
+		}
+	}
+	return nil
+}
+
+func containsToken(ranges []dht.Range, token string) bool {
+	for _, r := range ranges {
+		if r.Contains(token) {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachRowIn calls f with every row of the column family whose
+// token is in r, in the order of the ring. Rows without columns
+// or deletions left are skipped.
+func (c *ColumnFamilyStore) forEachRowIn(r dht.Range, f func(key string, cf *ColumnFamily)) error {
+	partitioner := getPartitioner()
+	iter := c.newDecoratedKeyIterator()
+	for decoratedKey := iter.next(); decoratedKey != ""; decoratedKey = iter.next() {
+		key := partitioner.UndecorateKey(decoratedKey)
+		if !r.Contains(partitioner.GetToken(key)) {
+			continue
+		}
+		cf, err := c.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(c.columnFamilyName)))
+		if err != nil {
+			return err
+		}
+		if cf != nil {
+			f(key, cf)
+		}
+	}
+	return nil
+}
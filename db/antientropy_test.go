@@ -0,0 +1,93 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/dht"
+)
+
+func TestDecoratedKeyIteratorSeek(t *testing.T) {
+	sources := [][]string{{"a", "c", "e", "g"}, {"b", "c", "f"}, {"d"}, {"h", "i"}}
+	tests := []struct {
+		after string
+		want  []string
+	}{
+		{"", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}},
+		{"a", []string{"b", "c", "d", "e", "f", "g", "h", "i"}},
+		{"c", []string{"d", "e", "f", "g", "h", "i"}},
+		{"cc", []string{"d", "e", "f", "g", "h", "i"}},
+		{"g", []string{"h", "i"}},
+		{"i", []string{}},
+		{"z", []string{}},
+	}
+	for _, test := range tests {
+		it := &decoratedKeyIterator{}
+		for _, keys := range sources {
+			it.add(keys)
+		}
+		it.seek(test.after)
+		got := make([]string, 0)
+		for key := it.next(); key != ""; key = it.next() {
+			got = append(got, key)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("after %q: got %v, want %v", test.after, got, test.want)
+		}
+	}
+}
+
+func TestStreamRowsInBatches(t *testing.T) {
+	table := addTestTable(t, "StreamTable",
+		config.CFMetaData{CFName: "Standard1", ColumnType: "Standard"})
+	cfStore := table.getColumnFamilyStore("Standard1")
+	want := make([]string, 0)
+	for i := 0; i < 25; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		applyTestRow("StreamTable", "Standard1", key, NewColumn("c", key, 1, false))
+		want = append(want, key)
+		// spread the rows over sstables and the memtable
+		if i%10 == 9 {
+			flushTestStore(cfStore)
+		}
+	}
+	partitioner := getPartitioner()
+	sort.Slice(want, func(i, j int) bool {
+		return partitioner.DecorateKey(want[i]) < partitioner.DecorateKey(want[j])
+	})
+	for _, maxRows := range []int{0, 1, 7, 25, 100} {
+		args := &StreamRowsArgs{Table: "StreamTable", ColumnFamily: "Standard1",
+			Ranges: []dht.Range{dht.NewRange("", "")}, MaxRows: maxRows}
+		got := make([]string, 0)
+		for batches := 1; ; batches++ {
+			reply := &StreamRowsReply{}
+			if err := DoStreamRows(args, reply); err != nil {
+				t.Fatal(err)
+			}
+			if maxRows > 0 && len(reply.Rows) > maxRows {
+				t.Fatalf("max %v rows: a batch has %v rows", maxRows, len(reply.Rows))
+			}
+			for _, row := range reply.Rows {
+				got = append(got, row.Key)
+			}
+			if reply.Next == "" {
+				break
+			}
+			if batches > len(want) {
+				t.Fatalf("max %v rows: the batches do not end", maxRows)
+			}
+			args.After = reply.Next
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("max %v rows: streamed %v, want %v", maxRows, got, want)
+		}
+	}
+}
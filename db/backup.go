@@ -0,0 +1,84 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+)
+
+// With incremental backups on, every new sstable is hard linked
+// into the backups directory of its column family, next to the
+// data directory it was written to:
+//   <data dir>/backups/<cf>/flushed/<cf>-<index>-Data.db, ...
+//   <data dir>/backups/<cf>/compacted/<cf>-<index>-Data.db, ...
+// Flushed sstables hold data that was not on disk before, so
+// restoring all of them together with the commit log gives back
+// every write. Compacted sstables only rewrite older sstables and
+// may replace them. Nothing here is ever deleted, the job that
+// ships the files away is expected to remove them.
+
+const (
+	// flushedBackup holds the sstables written by memtable flushes
+	flushedBackup = "flushed"
+	// compactedBackup holds the sstables written by compactions
+	compactedBackup = "compacted"
+)
+
+var backupOrigins = []string{flushedBackup, compactedBackup}
+
+func getBackupDir(dataFileDir, cfName, origin string) string {
+	return path.Join(dataFileDir, "backups", cfName, origin)
+}
+
+// backupSSTable links the files of a new sstable into its backups
+// directory. A failed backup is logged and does not fail the write.
+func backupSSTable(sstable *SSTableReader, origin string) {
+	dataFile := sstable.getFilename()
+	dir := getBackupDir(path.Dir(dataFile), sstable.columnFamilyName, origin)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Printf("cannot back up %v: %v\n", dataFile, err)
+		return
+	}
+	for _, filename := range sstable.getComponentFilenames() {
+		_, err := os.Stat(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		err = linkOrCopy(filename, path.Join(dir, path.Base(filename)))
+		if err != nil {
+			log.Printf("cannot back up %v: %v\n", dataFile, err)
+			return
+		}
+	}
+	log.Printf("backed up %v into %v\n", dataFile, dir)
+}
+
+// getBackupIndices returns the indexes of the sstables of cfName
+// backed up from dataFileDir. New sstables must not reuse them even
+// after the sstables themselves are gone, or their backups would
+// clash.
+func getBackupIndices(dataFileDir, cfName string) []int {
+	indices := make([]int, 0)
+	for _, origin := range backupOrigins {
+		files, err := ioutil.ReadDir(getBackupDir(dataFileDir, cfName, origin))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, fileInfo := range files {
+			if getColumnFamilyFromFileName(fileInfo.Name()) == cfName {
+				indices = append(indices, getIndexFromFileName(fileInfo.Name()))
+			}
+		}
+	}
+	return indices
+}
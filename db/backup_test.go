@@ -0,0 +1,89 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestIncrementalBackupLinksNewSSTables(t *testing.T) {
+	setCompactionThresholds(t, 2, 32)
+	defer func(backups bool) { config.IncrementalBackups = backups }(config.IncrementalBackups)
+	table := addTestTable(t, "backuptest", config.CFMetaData{CFName: "cf", ColumnType: "Standard"})
+	cfStore := table.getColumnFamilyStore("cf")
+	flush := func(key string) *SSTableReader {
+		applyTestRow("backuptest", "cf", key, NewColumn("c", "v", 1, false))
+		before := make(map[string]bool)
+		for _, sstable := range cfStore.getSSTables() {
+			before[sstable.getFilename()] = true
+		}
+		flushTestStore(cfStore)
+		for _, sstable := range cfStore.getSSTables() {
+			if !before[sstable.getFilename()] {
+				return sstable
+			}
+		}
+		t.Fatalf("flushing %v wrote no sstable", key)
+		return nil
+	}
+	assertBackedUp := func(sstable *SSTableReader, origin string) {
+		dir := getBackupDir(path.Dir(sstable.getFilename()), "cf", origin)
+		for _, filename := range sstable.getComponentFilenames() {
+			live, err := os.Stat(filename)
+			if os.IsNotExist(err) {
+				continue
+			}
+			backup, err := os.Stat(path.Join(dir, path.Base(filename)))
+			if err != nil || !os.SameFile(live, backup) {
+				t.Errorf("%v is not linked into %v: %v", filename, dir, err)
+			}
+		}
+	}
+
+	config.IncrementalBackups = false
+	sstable := flush("key0")
+	dataDir := path.Dir(sstable.getFilename())
+	if _, err := os.Stat(path.Join(dataDir, "backups")); !os.IsNotExist(err) {
+		t.Fatalf("a flush backed up an sstable with incremental backups off: %v", err)
+	}
+	config.IncrementalBackups = true
+	flushed := flush("key1")
+	assertBackedUp(flushed, flushedBackup)
+	cfStore.doCompaction()
+	sstables := cfStore.getSSTables()
+	if len(sstables) != 1 {
+		t.Fatalf("compaction left %v sstables", len(sstables))
+	}
+	assertBackedUp(sstables[0], compactedBackup)
+	// the backups outlive the sstables compaction removed
+	if _, err := os.Stat(path.Join(getBackupDir(dataDir, "cf", flushedBackup), path.Base(flushed.getFilename()))); err != nil {
+		t.Errorf("the backup of a compacted sstable is gone: %v", err)
+	}
+	files, err := ioutil.ReadDir(getBackupDir(dataDir, "cf", flushedBackup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.Name() == path.Base(sstable.getFilename()) {
+			t.Errorf("%v was flushed with backups off but is backed up", file.Name())
+		}
+	}
+	// new sstables must not reuse the indexes of backed up ones
+	indices := make(map[int]bool)
+	for _, index := range getBackupIndices(dataDir, "cf") {
+		indices[index] = true
+	}
+	for _, sstable := range []*SSTableReader{flushed, sstables[0]} {
+		if !indices[getIndexFromFileName(sstable.getFilename())] {
+			t.Errorf("the index of %v is not reserved", sstable.getFilename())
+		}
+	}
+}
@@ -5,9 +5,17 @@
 
 package db
 
-import "sync"
+import (
+	"log"
+	"sort"
+	"sync"
+)
 
-// BinaryMemtable is the binary version of memtable
+// BinaryMemtable is the binary version of memtable. It holds rows
+// of bulk loads already serialized the way they go into sstables,
+// so they are neither logged nor resolved against each other: a
+// row put twice keeps only the last copy. Its rows cannot be read
+// before it is flushed.
 type BinaryMemtable struct {
 	threshold      int
 	currentSize    int32
@@ -31,3 +39,61 @@ func NewBinaryMemtable(table, cfName string) *BinaryMemtable {
 	b.cond = sync.NewCond(&b.mu)
 	return b
 }
+
+func (b *BinaryMemtable) put(key string, buffer []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if old, ok := b.columnFamilies[key]; ok {
+		b.currentSize -= int32(len(key) + len(old))
+	}
+	b.columnFamilies[key] = buffer
+	b.currentSize += int32(len(key) + len(buffer))
+}
+
+func (b *BinaryMemtable) isThresholdViolated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int(b.currentSize) >= b.threshold
+}
+
+func (b *BinaryMemtable) isClean() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.columnFamilies) == 0
+}
+
+func (b *BinaryMemtable) freeze() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.isFrozen = true
+}
+
+// flush writes the rows into a new sstable. The binary memtable
+// must be frozen, so that nothing is put into it meanwhile.
+func (b *BinaryMemtable) flush() {
+	cfStore := OpenTable(b.tableName).getColumnFamilyStore(b.cfName)
+	if cfStore == nil {
+		log.Printf("column family %v.%v was dropped, skip flush\n", b.tableName, b.cfName)
+		return
+	}
+	writer := NewSSTableWriter(cfStore.getTmpSSTablePath(), len(b.columnFamilies))
+	writer.origin = flushedBackup
+	orderedKeys := make([]string, 0, len(b.columnFamilies))
+	decoratedToKey := make(map[string]string)
+	for key := range b.columnFamilies {
+		decoratedKey := writer.partitioner.DecorateKey(key)
+		orderedKeys = append(orderedKeys, decoratedKey)
+		decoratedToKey[decoratedKey] = key
+	}
+	sort.Sort(ByKey(orderedKeys))
+	for _, decoratedKey := range orderedKeys {
+		writer.append(decoratedKey, b.columnFamilies[decoratedToKey[decoratedKey]])
+	}
+	ssTable := writer.closeAndOpenReader()
+	cfStore.storeLocation(ssTable)
+	// cached rows were read before the new sstable was there
+	for key := range b.columnFamilies {
+		cfStore.rowCache.remove(key)
+	}
+	log.Printf("Completed flushing %v rows of bulk load to %v\n", len(orderedKeys), ssTable.getFilename())
+}
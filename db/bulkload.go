@@ -0,0 +1,107 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// Bulk loads skip the commit log and the memtable: the rows come
+// serialized the way they are stored in sstables, are collected in
+// the binary memtable of the column family and written out sorted
+// once it is full or the loader asks for a flush. Rows that are not
+// flushed when the node stops are lost, so a bulk load is finished
+// with a flush and repeated if the node went down before.
+
+// BinaryRow is a row of a bulk load
+type BinaryRow struct {
+	Key string
+	// Data is the row as serialized by SerializeBinaryRow
+	Data []byte
+}
+
+// SerializeBinaryRow serializes a column family for a bulk load
+func SerializeBinaryRow(cf *ColumnFamily) []byte {
+	buf := make([]byte, 0)
+	CFSerializer.serializeWithIndexes(cf, &buf)
+	return buf
+}
+
+// BinaryLoadArgs ...
+type BinaryLoadArgs struct {
+	Table        string
+	ColumnFamily string
+	Rows         []BinaryRow
+	// Flush writes all rows loaded so far into sstables
+	Flush bool
+}
+
+// BinaryLoadReply ...
+type BinaryLoadReply struct {
+	Rows int
+}
+
+// DoBinaryLoad adds the rows of a bulk load to the binary memtable
+// of the column family. If one of them is invalid none is loaded.
+func DoBinaryLoad(args *BinaryLoadArgs, reply *BinaryLoadReply) error {
+	cfStore, err := lookupColumnFamilyStore(args.Table, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	if cfMetaData, _ := config.GetCFMetaData(args.Table, args.ColumnFamily); len(cfMetaData.IndexedColumns) > 0 {
+		// loaded rows do not pass through Table.apply
+		return fmt.Errorf("column family %v.%v has indexes, which bulk loads would bypass",
+			args.Table, args.ColumnFamily)
+	}
+	for _, row := range args.Rows {
+		if row.Key == "" {
+			return fmt.Errorf("row without a key")
+		}
+		err := validateBinaryRow(createColumnFamily(args.Table, args.ColumnFamily), row.Data)
+		if err != nil {
+			return fmt.Errorf("row %q: %v", row.Key, err)
+		}
+	}
+	for _, row := range args.Rows {
+		cfStore.applyBinary(row.Key, row.Data)
+	}
+	if args.Flush {
+		cfStore.forceBlockingFlushBinary()
+	}
+	reply.Rows = len(args.Rows)
+	log.Printf("bulk loaded %v rows into %v.%v\n", len(args.Rows), args.Table, args.ColumnFamily)
+	return nil
+}
+
+// validateBinaryRow checks that data is a whole row of cf. The
+// rows are written to sstables as they are, an invalid one would
+// make the sstable unreadable.
+func validateBinaryRow(cf *ColumnFamily, data []byte) error {
+	input := bytes.NewReader(data)
+	if _, err := skipBloomFilterAndIndex(input); err != nil {
+		return err
+	}
+	if _, err := CFSerializer.deserializeFromSSTableNoColumns(cf, input); err != nil {
+		return err
+	}
+	columnCount, err := readInt32B(input)
+	if err != nil {
+		return err
+	}
+	for i := int32(0); i < columnCount; i++ {
+		if _, err := cf.getColumnSerializer().deserializeB(input); err != nil {
+			return err
+		}
+	}
+	if input.Len() > 0 {
+		return fmt.Errorf("%v bytes after the last column", input.Len())
+	}
+	return nil
+}
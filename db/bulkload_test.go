@@ -0,0 +1,28 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import "testing"
+
+func TestValidateBinaryRowRejectsTruncatedRows(t *testing.T) {
+	cf := NewColumnFamily("cf", "Standard")
+	cf.addColumn(NewColumn("a", "1", 1, false))
+	cf.addColumn(NewColumn("b", "2", 2, false))
+	cf.addColumn(NewColumn("c", deletionTime(1600000000), 3, true))
+	data := SerializeBinaryRow(cf)
+	if err := validateBinaryRow(NewColumnFamily("cf", "Standard"), data); err != nil {
+		t.Fatalf("whole row: %v", err)
+	}
+	for n := 0; n < len(data); n++ {
+		if err := validateBinaryRow(NewColumnFamily("cf", "Standard"), data[:n]); err == nil {
+			t.Errorf("row truncated to %v of %v bytes is valid", n, len(data))
+		}
+	}
+	extra := append(append([]byte{}, data...), 0)
+	if err := validateBinaryRow(NewColumnFamily("cf", "Standard"), extra); err == nil {
+		t.Errorf("row with a trailing byte is valid")
+	}
+}
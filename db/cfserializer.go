@@ -6,9 +6,8 @@
 package db
 
 import (
-	"os"
-
-	"github.com/davecgh/go-spew/spew"
+	"fmt"
+	"io"
 )
 
 // CFSerializer ...
@@ -25,32 +24,76 @@ func NewCFSerializer() *ColumnFamilySerializer {
 	return c
 }
 
-func (c *ColumnFamilySerializer) serialize(cf *ColumnFamily, dos []byte) {
+func (c *ColumnFamilySerializer) serialize(cf *ColumnFamily, dos *[]byte) {
 	writeStringB(dos, cf.ColumnFamilyName)
 	writeStringB(dos, cf.ColumnType)
 	c.serializeForSSTable(cf, dos)
 }
 
-func (c *ColumnFamilySerializer) deserializeFromSSTableNoColumns(cf *ColumnFamily, input *os.File) *ColumnFamily {
-	localtime := readInt(input)
-	timestamp := readInt64(input)
-	cf.delete(localtime, timestamp)
-	return cf
+// deserialize reads a column family written by serialize
+func (c *ColumnFamilySerializer) deserialize(dis io.Reader) (*ColumnFamily, error) {
+	cfName, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
+	columnType, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
+	if columnType != "Standard" && columnType != "Super" {
+		return nil, fmt.Errorf("invalid column type %q", columnType)
+	}
+	cf := NewColumnFamily(cfName, columnType)
+	localtime, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	cf.delete(int(localtime), timestamp)
+	size, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid column count %v", size)
+	}
+	for i := int32(0); i < size; i++ {
+		column, err := cf.getColumnSerializer().deserializeB(dis)
+		if err != nil {
+			return nil, err
+		}
+		cf.addColumn(column)
+	}
+	return cf, nil
+}
+
+func (c *ColumnFamilySerializer) deserializeFromSSTableNoColumns(cf *ColumnFamily, input io.Reader) (*ColumnFamily, error) {
+	localtime, err := readInt32B(input)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := readInt64B(input)
+	if err != nil {
+		return nil, err
+	}
+	cf.delete(int(localtime), timestamp)
+	return cf, nil
 }
 
-func (c *ColumnFamilySerializer) serializeWithIndexes(columnFamily *ColumnFamily, dos []byte) {
+func (c *ColumnFamilySerializer) serializeWithIndexes(columnFamily *ColumnFamily, dos *[]byte) {
 	CIndexer.serialize(columnFamily, dos)
 	c.serializeForSSTable(columnFamily, dos)
 }
 
-func (c *ColumnFamilySerializer) serializeForSSTable(columnFamily *ColumnFamily, dos []byte) {
+func (c *ColumnFamilySerializer) serializeForSSTable(columnFamily *ColumnFamily, dos *[]byte) {
 	writeIntB(dos, columnFamily.localDeletionTime)
 	writeInt64B(dos, columnFamily.markedForDeleteAt)
 	columns := columnFamily.GetSortedColumns()
 	writeIntB(dos, len(columns))
 	for _, column := range columns {
-		spew.Printf("cf: %#+v\n", columnFamily)
-		spew.Printf("cf column serializer: %#+v\n", columnFamily.getColumnSerializer())
 		columnFamily.getColumnSerializer().serializeB(column, dos)
 	}
 }
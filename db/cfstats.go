@@ -0,0 +1,127 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// ColumnFamilyStats summarizes the state of a column family store
+type ColumnFamilyStats struct {
+	Table        string
+	ColumnFamily string
+	// Compression used for new sstables
+	Compression        string
+	CompactionStrategy string
+	SSTableCount       int
+	// SSTablesPerLevel counts the sstables at each level,
+	// all of them are at level 0 under SizeTiered
+	SSTablesPerLevel []int
+	// LiveDiskSpaceUsed is the size of all data files on disk
+	LiveDiskSpaceUsed int64
+	// UncompressedDataSize is the size the data files would
+	// have without compression
+	UncompressedDataSize int64
+	// CompressionRatio is LiveDiskSpaceUsed / UncompressedDataSize,
+	// 1 means nothing was saved
+	CompressionRatio     float64
+	MemtableColumnsCount int
+	MemtableDataSize     int
+	// KeyCacheCapacity is the number of keys cached for each
+	// sstable, the other key cache figures add up all sstables
+	KeyCacheCapacity int
+	KeyCacheSize     int
+	KeyCacheRequests int64
+	KeyCacheHits     int64
+	// KeyCacheHitRate is KeyCacheHits / KeyCacheRequests, 0 if
+	// there were no requests
+	KeyCacheHitRate  float64
+	RowCacheCapacity int
+	RowCacheSize     int
+	RowCacheRequests int64
+	RowCacheHits     int64
+	RowCacheHitRate  float64
+}
+
+func getHitRate(requests, hits int64) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(hits) / float64(requests)
+}
+
+func (c *ColumnFamilyStore) getStats() *ColumnFamilyStats {
+	stats := &ColumnFamilyStats{}
+	stats.Table = c.tableName
+	stats.ColumnFamily = c.columnFamilyName
+	cfMetaData, _ := config.GetCFMetaData(c.tableName, c.columnFamilyName)
+	stats.Compression = cfMetaData.Compression
+	if stats.Compression == "" {
+		stats.Compression = config.CompressionNone
+	}
+	stats.CompactionStrategy = cfMetaData.CompactionStrategy
+	if stats.CompactionStrategy == "" {
+		stats.CompactionStrategy = config.SizeTieredCompaction
+	}
+	stats.SSTablesPerLevel = make([]int, 0)
+	for _, sstable := range c.getSSTables() {
+		stats.SSTableCount++
+		for len(stats.SSTablesPerLevel) <= sstable.level {
+			stats.SSTablesPerLevel = append(stats.SSTablesPerLevel, 0)
+		}
+		stats.SSTablesPerLevel[sstable.level]++
+		size, requests, hits := sstable.keyCache.getStats()
+		stats.KeyCacheSize += size
+		stats.KeyCacheRequests += requests
+		stats.KeyCacheHits += hits
+		if sstable.compression != nil {
+			stats.LiveDiskSpaceUsed += sstable.compression.compressedLength
+			stats.UncompressedDataSize += sstable.compression.dataLength
+		} else {
+			size := getFileSizeFromName(sstable.getFilename())
+			stats.LiveDiskSpaceUsed += size
+			stats.UncompressedDataSize += size
+		}
+	}
+	stats.CompressionRatio = 1
+	if stats.UncompressedDataSize > 0 {
+		stats.CompressionRatio = float64(stats.LiveDiskSpaceUsed) / float64(stats.UncompressedDataSize)
+	}
+	stats.KeyCacheCapacity = cfMetaData.GetKeyCacheSize()
+	stats.KeyCacheHitRate = getHitRate(stats.KeyCacheRequests, stats.KeyCacheHits)
+	stats.RowCacheCapacity = c.rowCache.getCapacity()
+	stats.RowCacheSize, stats.RowCacheRequests, stats.RowCacheHits = c.rowCache.getStats()
+	stats.RowCacheHitRate = getHitRate(stats.RowCacheRequests, stats.RowCacheHits)
+	memtable := c.getMemtableThreadSafe()
+	stats.MemtableColumnsCount = int(atomic.LoadInt32(&memtable.currentObjectCnt))
+	stats.MemtableDataSize = int(atomic.LoadInt32(&memtable.currentSize))
+	return stats
+}
+
+// GetColumnFamilyStats returns the statistics of a column family
+func GetColumnFamilyStats(tableName, cfName string) (*ColumnFamilyStats, error) {
+	cfStore, err := lookupColumnFamilyStore(tableName, cfName)
+	if err != nil {
+		return nil, err
+	}
+	return cfStore.getStats(), nil
+}
+
+// lookupColumnFamilyStore finds the store of a column family
+// named by an admin command
+func lookupColumnFamilyStore(tableName, cfName string) (*ColumnFamilyStore, error) {
+	if config.GetTableMetaData(tableName) == nil {
+		return nil, fmt.Errorf("table %q does not exist", tableName)
+	}
+	cfStore := OpenTable(tableName).getColumnFamilyStore(cfName)
+	if cfStore == nil {
+		return nil, fmt.Errorf("column family %v.%v does not exist", tableName, cfName)
+	}
+	return cfStore, nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+// Every sstable has a <cf>-<index>-CRC.db file next to its data
+// file, holding the checksums of all its other components:
+//  * chunkLength int32
+//  * indexChecksum int64: crc32 of the Index.db file
+//  * filterChecksum int64: crc32 of the Filter.db file
+//  * chunkCount int32
+//  * chunkChecksums int64 * chunkCount
+// The data file is checked in chunks of chunkLength bytes of
+// uncompressed data, for compressed sstables these are the
+// compression chunks and their checksum is taken over the
+// compressed bytes. Sstables written before checksums existed
+// have no CRC file and are not checked.
+
+// errSSTableChecksum reports sstable data that does
+// not match its checksum
+var errSSTableChecksum = errors.New("checksum mismatch")
+
+// CorruptSSTableError reports an sstable that cannot be read
+type CorruptSSTableError struct {
+	// File is the data file of the sstable, empty if unknown
+	File string
+	Err  error
+}
+
+func (e *CorruptSSTableError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("corrupt sstable: %v", e.Err)
+	}
+	return fmt.Sprintf("corrupt sstable %v: %v", e.File, e.Err)
+}
+
+// newCorruptSSTableError wraps err, met while reading the sstable
+// with the given data file, into a CorruptSSTableError
+func newCorruptSSTableError(file string, err error) *CorruptSSTableError {
+	var corrupt *CorruptSSTableError
+	if !errors.As(err, &corrupt) {
+		return &CorruptSSTableError{File: file, Err: err}
+	}
+	if corrupt.File == "" {
+		return &CorruptSSTableError{File: file, Err: corrupt.Err}
+	}
+	return corrupt
+}
+
+// checksumInfo holds the checksums of an sstable
+type checksumInfo struct {
+	chunkLength    int
+	indexChecksum  uint32
+	filterChecksum uint32
+	chunkChecksums []uint32
+}
+
+func checksumFilename(dataFile string) string {
+	// input: /var/storage/data/tableName/<cf>-<index>-Data.db
+	// output:/var/storage/data/tableName/<cf>-<index>-CRC.db
+	parts := strings.Split(dataFile, "-")
+	parts[len(parts)-1] = "CRC.db"
+	return strings.Join(parts, "-")
+}
+
+func (c *checksumInfo) write(filename string) error {
+	buf := make([]byte, 0)
+	writeIntB(&buf, c.chunkLength)
+	writeInt64B(&buf, int64(c.indexChecksum))
+	writeInt64B(&buf, int64(c.filterChecksum))
+	writeIntB(&buf, len(c.chunkChecksums))
+	for _, checksum := range c.chunkChecksums {
+		writeInt64B(&buf, int64(checksum))
+	}
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(buf)
+	if err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// readChecksumInfo loads the checksums of the sstable with the
+// given data file, it returns nil if the sstable has none
+func readChecksumInfo(dataFile string) (*checksumInfo, error) {
+	file, err := os.Open(checksumFilename(dataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	c := &checksumInfo{}
+	chunkLength, err := readInt32B(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.chunkLength = int(chunkLength)
+	checksum, err := readInt64B(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.indexChecksum = uint32(checksum)
+	checksum, err = readInt64B(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.filterChecksum = uint32(checksum)
+	chunkCount, err := readInt32B(reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.chunkLength <= 0 || chunkCount < 0 {
+		return nil, fmt.Errorf("%v is corrupt", file.Name())
+	}
+	c.chunkChecksums = make([]uint32, chunkCount)
+	for i := range c.chunkChecksums {
+		checksum, err = readInt64B(reader)
+		if err != nil {
+			return nil, err
+		}
+		c.chunkChecksums[i] = uint32(checksum)
+	}
+	return c, nil
+}
+
+// fileChecksum returns the crc32 of the whole file
+func fileChecksum(filename string) (uint32, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	hash := crc32.NewIEEE()
+	_, err = io.Copy(hash, bufio.NewReader(file))
+	if err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
+
+// checkFile compares the checksum of a component of an sstable
+// with the one recorded when it was written
+func checkFile(filename string, expected uint32) error {
+	checksum, err := fileChecksum(filename)
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf("%v: %v", filename, errSSTableChecksum)
+	}
+	return nil
+}
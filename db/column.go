@@ -8,19 +8,33 @@ package db
 import (
 	"bytes"
 	"encoding/binary"
+	"io"
 	"log"
-	"os"
 	"strconv"
+	"time"
 )
 
 // Column stores name and value etc.
 type Column struct {
-	Name       string
-	Value      string
-	Timestamp  int64
-	size       int32
-	deleteMark bool
-}
+	Name      string
+	Value     string
+	Timestamp int64
+	// TTL is the time to live of an expiring column in seconds,
+	// 0 for columns that never expire
+	TTL int
+	// LocalExpirationTime is when an expiring column turns into a
+	// tombstone, in seconds since the epoch
+	LocalExpirationTime int
+	size                int32
+	deleteMark          bool
+}
+
+// the flags written in front of the timestamp of a serialized column
+const (
+	deletionMask   = 0x01
+	expirationMask = 0x02
+	counterMask    = 0x04
+)
 
 func (c Column) addColumn(column IColumn) {
 	log.Printf("Invalid method: Column doesn't support addColumn\n")
@@ -34,16 +48,52 @@ func (c Column) getMarkedForDeleteAt() int64 {
 }
 
 func (c Column) getLocalDeletionTime() int {
-	return 0 // miao miao miao ?
+	if c.isExpiring() {
+		return c.LocalExpirationTime
+	}
+	// a tombstone holds the time it was made as its value,
+	// it is kept until GcGraceInSeconds after that
+	if c.deleteMark && len(c.Value) == 4 {
+		return int(binary.BigEndian.Uint32([]byte(c.Value)))
+	}
+	return 0
+}
+
+func (c Column) isExpiring() bool {
+	return c.TTL > 0
+}
+
+// isExpired is true once an expiring column outlived its TTL
+func (c Column) isExpired() bool {
+	return c.isExpiring() && c.LocalExpirationTime <= int(time.Now().Unix())
 }
 
 func (c Column) isMarkedForDelete() bool {
-	return c.deleteMark
+	return c.deleteMark || c.isExpired()
 }
 
 // IsMarkedForDelete ...
 func (c Column) IsMarkedForDelete() bool {
-	return c.deleteMark
+	return c.isMarkedForDelete()
+}
+
+// toTombstone drops the value of an expired column, keeping its
+// expiration time as the time it was deleted at
+func (c Column) toTombstone() Column {
+	c.Value = ""
+	c.deleteMark = true
+	return c
+}
+
+func (c Column) getSerializationFlags() byte {
+	flags := byte(0)
+	if c.deleteMark {
+		flags |= deletionMask
+	}
+	if c.isExpiring() {
+		flags |= expirationMask
+	}
+	return flags
 }
 
 func (c Column) getValue() []byte {
@@ -62,7 +112,12 @@ func (c Column) getSize() int32 {
 	//  8 bytes for timestamp
 	//  4 bytes for value byte array
 	//  # bytes for value bytes
-	return int32(4 + 8 + 4 + len(c.Name) + len(c.Value))
+	//  8 bytes for ttl and expiration time of expiring columns
+	size := 4 + 8 + 4 + len(c.Name) + len(c.Value)
+	if c.isExpiring() {
+		size += 8
+	}
+	return int32(size)
 }
 
 // delete deletes a Column
@@ -86,6 +141,8 @@ func (c Column) diff(column Column) Column {
 		columnDiff.Name = column.Name
 		columnDiff.Value = column.Value
 		columnDiff.Timestamp = column.Timestamp
+		columnDiff.TTL = column.TTL
+		columnDiff.LocalExpirationTime = column.LocalExpirationTime
 	}
 	return columnDiff
 }
@@ -127,6 +184,15 @@ func NewColumn(name, value string, timestamp int64, deleteMark bool) Column {
 	return c
 }
 
+// NewExpiringColumn constructs a Column that turns into
+// a tombstone ttl seconds from now
+func NewExpiringColumn(name, value string, timestamp int64, ttl int) Column {
+	c := NewColumn(name, value, timestamp, false)
+	c.TTL = ttl
+	c.LocalExpirationTime = int(time.Now().Unix()) + ttl
+	return c
+}
+
 // NewColumnKV ..
 func NewColumnKV(name, value string) Column {
 	return NewColumn(name, value, 0, false)
@@ -140,11 +206,11 @@ func (c Column) toByteArray() []byte {
 	buf = append(buf, b4...)
 	// write column name
 	buf = append(buf, []byte(c.Name)...)
-	// write deleteMark
-	if c.deleteMark {
-		buf = append(buf, byte(1))
-	} else {
-		buf = append(buf, byte(0))
+	// write deletion and expiration flags
+	buf = append(buf, c.getSerializationFlags())
+	if c.isExpiring() {
+		writeIntB(&buf, c.TTL)
+		writeIntB(&buf, c.LocalExpirationTime)
 	}
 	// write timestamp
 	b8 := make([]byte, 8)
@@ -161,11 +227,16 @@ func (c Column) toByteArray() []byte {
 func (c Column) serializedSize() uint32 {
 	// 4 byte: length of column name
 	// # bytes: column name bytes
-	// 1 byte:  deleteMark
+	// 1 byte:  deletion and expiration flags
+	// 8 bytes: ttl and expiration time, only for expiring columns
 	// 8 bytes: timestamp
 	// 4 bytes: length of value
 	// # bytes: value bytes
-	return uint32(4 + 1 + 8 + 4 + len(c.Name) + len(c.Value))
+	size := 4 + 1 + 8 + 4 + len(c.Name) + len(c.Value)
+	if c.isExpiring() {
+		size += 8
+	}
+	return uint32(size)
 }
 
 func (c Column) getObjectCount() int {
@@ -246,24 +317,77 @@ func NewColumnSerializer() *ColumnSerializer {
 	return &ColumnSerializer{0}
 }
 
-func (c *ColumnSerializer) serialize(column IColumn, dos *os.File) {
-	writeString(dos, column.getName())
-	writeBool(dos, column.isMarkedForDelete())
-	writeInt64(dos, column.timestamp())
-	writeBytes(dos, column.getValue()) // will first write byte length, the bytes
+// Columns are serialized as their name, a byte of flags, for
+// expiring columns the ttl and expiration time as two int32, then
+// the timestamp and value. Columns written before TTLs existed have
+// a flags byte of 0 or 1, which reads the same. Counters are written
+// by serializeCounter.
+
+func (c *ColumnSerializer) serialize(column IColumn, dos io.Writer) {
+	if counter, ok := column.(CounterColumn); ok {
+		serializeCounter(counter, dos)
+		return
+	}
+	col := column.(Column)
+	writeString(dos, col.getName())
+	writeByte(dos, col.getSerializationFlags())
+	if col.isExpiring() {
+		writeInt(dos, col.TTL)
+		writeInt(dos, col.LocalExpirationTime)
+	}
+	writeInt64(dos, col.timestamp())
+	writeBytes(dos, col.getValue()) // will first write byte length, the bytes
 }
 
-func (c *ColumnSerializer) serializeB(column IColumn, dos []byte) {
-	writeStringB(dos, column.getName())
-	writeBoolB(dos, column.isMarkedForDelete())
-	writeInt64B(dos, column.timestamp())
-	writeBytesB(dos, column.getValue()) // will first write byte length, the bytes
+func (c *ColumnSerializer) serializeB(column IColumn, dos *[]byte) {
+	if counter, ok := column.(CounterColumn); ok {
+		serializeCounterB(counter, dos)
+		return
+	}
+	col := column.(Column)
+	writeStringB(dos, col.getName())
+	writeByteB(dos, col.getSerializationFlags())
+	if col.isExpiring() {
+		writeIntB(dos, col.TTL)
+		writeIntB(dos, col.LocalExpirationTime)
+	}
+	writeInt64B(dos, col.timestamp())
+	writeBytesB(dos, col.getValue()) // will first write byte length, the bytes
 }
 
-func (c *ColumnSerializer) deserialize(dis *os.File) IColumn {
-	name, _ := readString(dis)
-	deleteMark, _ := readBool(dis)
-	timestamp := readInt64(dis)
-	value, _ := readBytes(dis)
-	return NewColumn(name, string(value), timestamp, deleteMark)
+func (c *ColumnSerializer) deserializeB(dis io.Reader) (IColumn, error) {
+	name, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := readByteB(dis)
+	if err != nil {
+		return nil, err
+	}
+	if flags&counterMask != 0 {
+		return deserializeCounterB(name, dis)
+	}
+	ttl, localExpirationTime := int32(0), int32(0)
+	if flags&expirationMask != 0 {
+		ttl, err = readInt32B(dis)
+		if err != nil {
+			return nil, err
+		}
+		localExpirationTime, err = readInt32B(dis)
+		if err != nil {
+			return nil, err
+		}
+	}
+	timestamp, err := readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	value, err := readBytesB(dis)
+	if err != nil {
+		return nil, err
+	}
+	column := NewColumn(name, string(value), timestamp, flags&deletionMask != 0)
+	column.TTL = int(ttl)
+	column.LocalExpirationTime = int(localExpirationTime)
+	return column, nil
 }
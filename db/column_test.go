@@ -0,0 +1,99 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func deletionTime(t int) string {
+	b4 := make([]byte, 4)
+	binary.BigEndian.PutUint32(b4, uint32(t))
+	return string(b4)
+}
+
+func TestColumnLocalDeletionTime(t *testing.T) {
+	expiring := NewExpiringColumn("c", "v", 1, 60)
+	tests := []struct {
+		name   string
+		column Column
+		want   int
+	}{
+		{"tombstone", NewColumn("c", deletionTime(1600000000), 1, true), 1600000000},
+		{"tombstone without deletion time", NewColumn("c", "", 1, true), 0},
+		{"live column", NewColumn("c", deletionTime(1600000000), 1, false), 0},
+		{"expiring column", expiring, expiring.LocalExpirationTime},
+	}
+	for _, test := range tests {
+		if got := test.column.getLocalDeletionTime(); got != test.want {
+			t.Errorf("%v: getLocalDeletionTime() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRemoveDeletedKeepsTombstonesWithinGCGrace(t *testing.T) {
+	now := int(time.Now().Unix())
+	tests := []struct {
+		name     string
+		gcBefore int
+		kept     bool
+	}{
+		{"within gc grace", now - 3600, true},
+		{"after gc grace", now + 1, false},
+	}
+	for _, test := range tests {
+		cf := NewColumnFamily("cf", "Standard")
+		cf.addColumn(NewColumn("c", deletionTime(now), 1, true))
+		cf = removeDeleted(cf, test.gcBefore)
+		kept := cf != nil && cf.Columns["c"] != nil
+		if kept != test.kept {
+			t.Errorf("%v: tombstone kept = %v, want %v", test.name, kept, test.kept)
+		}
+	}
+}
+
+func TestColumnSerializationKeepsTTL(t *testing.T) {
+	expired := NewExpiringColumn("expired", "v", 2, 60)
+	expired.LocalExpirationTime = int(time.Now().Unix()) - 1
+	tests := []struct {
+		name    string
+		column  Column
+		deleted bool
+	}{
+		{"live column", NewColumn("c", "v", 1, false), false},
+		{"tombstone", NewColumn("c", deletionTime(1600000000), 1, true), true},
+		{"expiring column", NewExpiringColumn("c", "v", 1, 3600), false},
+		{"expired column", expired, true},
+	}
+	for _, test := range tests {
+		buf := make([]byte, 0)
+		CSerializer.serializeB(test.column, &buf)
+		var written bytes.Buffer
+		CSerializer.serialize(test.column, &written)
+		if !bytes.Equal(written.Bytes(), buf) || !bytes.Equal(test.column.toByteArray(), buf) {
+			t.Errorf("%v: serialize, serializeB and toByteArray disagree", test.name)
+		}
+		if int(test.column.serializedSize()) != len(buf) {
+			t.Errorf("%v: serializedSize() = %v, want %v", test.name, test.column.serializedSize(), len(buf))
+		}
+		read, err := CSerializer.deserializeB(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		column, ok := read.(Column)
+		if !ok || column.Name != test.column.Name || column.Value != test.column.Value ||
+			column.Timestamp != test.column.Timestamp || column.TTL != test.column.TTL ||
+			column.LocalExpirationTime != test.column.LocalExpirationTime {
+			t.Errorf("%v: read back as %+v, want %+v", test.name, read, test.column)
+		}
+		if column.isMarkedForDelete() != test.deleted {
+			t.Errorf("%v: isMarkedForDelete() = %v after the round trip", test.name, column.isMarkedForDelete())
+		}
+	}
+}
@@ -65,16 +65,21 @@ func (cf *ColumnFamily) addColumn(column IColumn) {
 	name := column.getName()
 	oldColumn, ok := cf.Columns[name]
 	if ok {
-		_, yes := oldColumn.(SuperColumn)
+		sc, yes := oldColumn.(SuperColumn)
 		if yes { // is SuperColumn
 			oldSize := oldColumn.getSize()
 			oldColumn.putColumn(column)
+			// the super column is held by value, so putColumn
+			// cannot change its deletion time in the map
+			if column.getMarkedForDeleteAt() > sc.markedForDeleteAt {
+				sc.markForDeleteAt(column.getLocalDeletionTime(), column.getMarkedForDeleteAt())
+				cf.Columns[name] = sc
+			}
 			atomic.AddInt32(&cf.size, int32(oldColumn.getSize()-oldSize))
 		} else {
-			if oldColumn.(Column).comparePriority(column.(Column)) <= 0 {
-				cf.Columns[name] = column
-				atomic.AddInt32(&cf.size, int32(column.getSize()))
-			}
+			column = reconcileColumns(oldColumn, column)
+			cf.Columns[name] = column
+			atomic.AddInt32(&cf.size, column.getSize()-oldColumn.getSize())
 		}
 	} else {
 		atomic.AddInt32(&cf.size, column.getSize())
@@ -84,14 +89,19 @@ func (cf *ColumnFamily) addColumn(column IColumn) {
 
 // with query path as argument
 // in most places the cf must be part of a query path but
-// here it is ignored.
-func (cf *ColumnFamily) addColumnQP(path *QueryPath, value string, timestamp int64, deleted bool) {
+// here it is ignored. The column expires after ttl seconds
+// unless ttl is 0.
+func (cf *ColumnFamily) addColumnQP(path *QueryPath, value string, timestamp int64, ttl int, deleted bool) {
+	newColumn := NewColumn(string(path.ColumnName), value, timestamp, deleted)
+	if ttl > 0 {
+		newColumn = NewExpiringColumn(string(path.ColumnName), value, timestamp, ttl)
+	}
 	var column IColumn
 	if path.SuperColumnName == nil {
-		column = NewColumn(string(path.ColumnName), value, timestamp, deleted)
+		column = newColumn
 	} else {
 		column = NewSuperColumn(string(path.SuperColumnName))
-		column.addColumn(NewColumn(string(path.ColumnFamilyName), value, timestamp, deleted))
+		column.addColumn(newColumn)
 	}
 	cf.addColumn(column)
 }
@@ -163,7 +173,7 @@ func (cf *ColumnFamily) getColumnCount() int {
 }
 
 func (cf *ColumnFamily) addColumns(columnFamily *ColumnFamily) {
-	columns := cf.Columns
+	columns := columnFamily.Columns
 	for _, column := range columns {
 		cf.addColumn(column)
 	}
@@ -12,9 +12,9 @@ import (
 	"container/heap"
 	"encoding/binary"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"path"
 	"sort"
@@ -24,8 +24,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/dht"
 	"github.com/DistAlchemist/Mongongo/network"
@@ -33,7 +31,9 @@ import (
 )
 
 var (
+	// memtables being flushed, keyed by <table>.<cf>
 	memtablesPendingFlush = make(map[string][]*Memtable)
+	pendingFlushMu        sync.RWMutex
 )
 
 // ColumnFamilyStore provides storage specification of
@@ -51,6 +51,8 @@ type ColumnFamilyStore struct {
 	// memtables associated with this cfStore
 	memtable       *Memtable
 	binaryMemtable *BinaryMemtable
+	// guards binaryMemtable
+	binaryMu sync.Mutex
 	// SSTable on disk for this cf
 	// ssTables map[string]bool
 	ssTables map[string]*SSTableReader
@@ -61,7 +63,17 @@ type ColumnFamilyStore struct {
 	sstableMu sync.RWMutex
 	// flag indicates if a compaction is in process
 	isCompacting bool
-	isSuper      bool
+	// keeps compactions and scrubs from working on
+	// the same sstables at the same time
+	compactionMu sync.Mutex
+	// last key of the sstable leveled compaction promoted
+	// from each level, guarded by compactionMu
+	lastPromotedKeys map[int]string
+	// whole rows recently read, writes drop the rows they change
+	rowCache *TouchKeyCache
+	isSuper  bool
+	// set once the column family is dropped
+	dropped int32
 }
 
 // NewColumnFamilyStore initializes a new ColumnFamilyStore
@@ -74,6 +86,9 @@ func NewColumnFamilyStore(table, columnFamily string) *ColumnFamilyStore {
 	c.columnFamilyName = columnFamily
 	c.fileIdxGenerator = 0
 	c.ssTables = make(map[string]*SSTableReader)
+	c.lastPromotedKeys = make(map[int]string)
+	cfMetaData, _ := config.GetCFMetaData(table, columnFamily)
+	c.rowCache = NewTouchKeyCache(cfMetaData.RowCacheSize)
 	c.isCompacting = false
 	c.isSuper = config.GetColumnTypeTableName(table, columnFamily) == "Super"
 	c.readStats = make([]int64, 0)
@@ -82,13 +97,20 @@ func NewColumnFamilyStore(table, columnFamily string) *ColumnFamilyStore {
 	// The names are <CfName>-<index>-Data.db, ...
 	// The max is n and increment it to be used as the next index.
 	indices := make([]int, 0)
-	dataFileDirs := config.DataFileDirs
+	dataFileDirs := config.GetAllDataFileLocationsForTable(table)
 	for _, dir := range dataFileDirs {
 		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
 		for _, fileInfo := range files {
+			if fileInfo.IsDir() {
+				// backups
+				continue
+			}
 			filename := fileInfo.Name() // base name <cf>-<index>-Data.db
 			cfName := getColumnFamilyFromFileName(filename)
 			if cfName == columnFamily {
@@ -96,6 +118,7 @@ func NewColumnFamilyStore(table, columnFamily string) *ColumnFamilyStore {
 				indices = append(indices, index)
 			}
 		}
+		indices = append(indices, getBackupIndices(dir, columnFamily)...)
 	}
 	sort.Ints(indices)
 	sz := len(indices)
@@ -152,6 +175,9 @@ func (c *ColumnFamilyStore) onStart() {
 	filenames := make(map[string]string) // map to full name with dir
 	for _, dir := range dataFileDirs {
 		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -181,6 +207,7 @@ func (c *ColumnFamilyStore) onStart() {
 		sstable := openSSTableReader(filename)
 		c.ssTables[filename] = sstable
 	}
+	c.loadLevels()
 	// filenames := make([]string, len(ssTables))
 	// for _, ssTable := range ssTables {
 	// 	filenames = append(filenames, ssTable.Name())
@@ -209,32 +236,17 @@ func (c *ColumnFamilyStore) stageOrderedCompaction(files []string) map[int][]str
 	min := int64(50 * 1024 * 1024)
 	i := 0
 	for _, file := range files {
-		f, err := os.Open(file)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fileInfo, err := f.Stat()
-		if err != nil {
-			log.Fatal(err)
-		}
-		size := fileInfo.Size()
+		size := getFileSizeFromName(file)
 		if (size > averages[i]/2 && size < 3*averages[i]/2) ||
 			(size < min && averages[i] < min) {
 			averages[i] = (averages[i] + size) / 2
-			fileList, ok := buckets[i]
-			if !ok {
-				fileList = make([]string, 0)
-				buckets[i] = fileList
-			}
-			fileList = append(fileList, file)
+			buckets[i] = append(buckets[i], file)
 		} else {
-			if i >= maxBuckets {
+			if i+1 >= maxBuckets {
 				break
 			}
 			i++
-			fileList := make([]string, 0)
-			buckets[i] = fileList
-			fileList = append(fileList, file)
+			buckets[i] = append(buckets[i], file)
 			averages[i] = size
 		}
 	}
@@ -326,14 +338,10 @@ func (pq FPQ) Len() int {
 
 // Less ...
 func (pq FPQ) Less(i, j int) bool {
-	switch config.HashingStrategy {
-	case config.Ophf:
-		return pq[i].row.key < pq[j].row.key
-	default:
-		lhs := strings.Split(pq[i].row.key, ":")[0]
-		rhs := strings.Split(pq[j].row.key, ":")[0]
-		return lhs < rhs
-	}
+	// rows must come out in the order the sstable writer
+	// expects, the hash prefix of a decorated key may
+	// itself contain ':' so it cannot be split off
+	return compare(pq[i].row.key, pq[j].row.key)
 }
 
 // Swap ...
@@ -357,7 +365,7 @@ func (pq *FPQ) Pop() interface{} {
 	return item
 }
 
-func (c *ColumnFamilyStore) initPriorityQueue(files []string, ranges []*dht.Range, minBufferSize int) *FPQ {
+func (c *ColumnFamilyStore) initPriorityQueue(files []string, ranges []*dht.Range, minBufferSize int) (*FPQ, error) {
 	pq := &FPQ{}
 	if len(files) > 1 || (ranges != nil && len(files) > 0) {
 		bufferSize := c.compactionMemoryThres / len(files)
@@ -366,15 +374,26 @@ func (c *ColumnFamilyStore) initPriorityQueue(files []string, ranges []*dht.Rang
 		}
 		for _, file := range files {
 			sstableReader, _ := openedFiles.get(file)
-			fs := sstableReader.getFileStruct()
-			fs.advance(true)
+			fs, err := sstableReader.getFileStruct()
+			if err == nil {
+				err = fs.advance(true)
+			}
+			if err != nil {
+				if fs != nil && !fs.isExhausted() {
+					fs.file.Close()
+				}
+				for _, opened := range *pq {
+					opened.file.Close()
+				}
+				return nil, err
+			}
 			if fs.isExhausted() {
 				continue
 			}
 			heap.Push(pq, fs)
 		}
 	}
-	return pq
+	return pq, nil
 }
 
 func readKV(file *os.File, buf []byte) (int, string, bool) {
@@ -457,7 +476,30 @@ func (c *ColumnFamilyStore) merge(columnFamilies []*ColumnFamily) {
 
 func resolveAndRemoveDeleted(columnFamilies []*ColumnFamily) *ColumnFamily {
 	cf := resolve(columnFamilies)
-	return removeDeletedGC(cf)
+	cf = removeDeletedGC(cf)
+	if cf != nil {
+		expireColumns(cf)
+	}
+	return cf
+}
+
+// expireColumns turns the expired columns of cf into tombstones,
+// which are kept until gc grace seconds after their expiration
+func expireColumns(cf *ColumnFamily) {
+	for name, c := range cf.Columns {
+		switch column := c.(type) {
+		case Column:
+			if column.isExpired() {
+				cf.Columns[name] = column.toTombstone()
+			}
+		case SuperColumn:
+			for subName, subColumn := range column.Columns {
+				if sub, ok := subColumn.(Column); ok && sub.isExpired() {
+					column.Columns[subName] = sub.toTombstone()
+				}
+			}
+		}
+	}
 }
 
 func removeDeletedGC(cf *ColumnFamily) *ColumnFamily {
@@ -509,7 +551,10 @@ func removeDeleted(cf *ColumnFamily, gcBefore int) *ColumnFamily {
 // written represents the new compacted file. Before writing
 // if there are keys that occur in multiple files and are
 // the same then a resolution is done to get the latest data.
-func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int) int {
+// The new sstables are put at level. If maxSSTableSize is
+// positive a new sstable is started whenever the current one
+// holds that many bytes, otherwise a single one is written.
+func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int, level int, maxSSTableSize int64) (filesCompacted int) {
 	// calculate the expected compacted filesize
 	expectedCompactedFileSize := getExpectedCompactedFileSize(files)
 	compactionFileLocation := config.GetDataFileLocationForTable(c.tableName, expectedCompactedFileSize)
@@ -518,25 +563,52 @@ func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int)
 	if compactionFileLocation == "" {
 		maxFile := getMaxSizeFile(files)
 		removeFromList(files, maxFile)
-		c.doFileCompaction(files, minBufferSize)
+		c.doFileCompaction(files, minBufferSize, level, maxSSTableSize)
 		return 0
 	}
-	newfile := ""
 	startTime := time.Now().UnixNano() / int64(time.Millisecond)
 	totalBytesRead := int64(0)
 	totalBytesWritten := int64(0)
 	totalKeysRead := int64(0)
 	totalKeysWritten := int64(0)
-	pq := c.initPriorityQueue(files, nil, minBufferSize)
+	var pq *FPQ
+	var writer *SSTableWriter
+	lfs := make([]*FileStruct, 0)
+	newSSTables := make([]*SSTableReader, 0)
+	var err error
+	defer func() {
+		if err == nil {
+			return
+		}
+		// keep the input sstables, they can be scrubbed
+		log.Printf("compaction of %v aborted: %v\n", files, err)
+		if pq != nil {
+			for _, fs := range *pq {
+				fs.file.Close()
+			}
+		}
+		for _, fs := range lfs {
+			if !fs.isExhausted() {
+				fs.file.Close()
+			}
+		}
+		if writer != nil {
+			writer.abort()
+		}
+		for _, sstable := range newSSTables {
+			sstable.delete()
+		}
+		filesCompacted = 0
+	}()
+	pq, err = c.initPriorityQueue(files, nil, minBufferSize)
+	if err != nil {
+		return 0
+	}
 	if pq.Len() == 0 {
 		log.Print("nothing to compact")
 		return 0
 	}
-	mergedFileName := c.getTmpFileName()
-	var writer *SSTableWriter
-	var ssTable *SSTableReader
 	lastkey := ""
-	lfs := make([]*FileStruct, 0)
 	bufOut := make([]byte, 0)
 	expectedBloomFilterSize := getApproximateKeyCount(files)
 	if expectedBloomFilterSize <= 0 {
@@ -549,7 +621,7 @@ func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int)
 	for pq.Len() > 0 || len(lfs) > 0 {
 		var fs *FileStruct
 		if pq.Len() > 0 {
-			fs = pq.Pop().(*FileStruct)
+			fs = heap.Pop(pq).(*FileStruct)
 		}
 		if fs != nil && (lastkey == "" || lastkey == fs.key) {
 			// The keys are the same so we need to add this to
@@ -575,21 +647,42 @@ func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int)
 				columnFamily = resolveAndRemoveDeleted(columnFamilies)
 				columnFamilies = make([]*ColumnFamily, 0)
 				if columnFamily != nil {
-					CFSerializer.serializeWithIndexes(columnFamily, bufOut)
+					CFSerializer.serializeWithIndexes(columnFamily, &bufOut)
 				}
 			} else {
+				// a row found in a single sstable may still
+				// hold expired columns and old tombstones
 				filestruct := lfs[0]
-				CFSerializer.serializeWithIndexes(filestruct.getColumnFamily(), bufOut)
+				columnFamily = resolveAndRemoveDeleted([]*ColumnFamily{filestruct.getColumnFamily()})
+				if columnFamily != nil {
+					CFSerializer.serializeWithIndexes(columnFamily, &bufOut)
+				}
 			}
-			if writer == nil {
-				// fname is the full path name!
-				fname := compactionFileLocation + string(os.PathSeparator) + mergedFileName
-				writer = NewSSTableWriter(fname, expectedBloomFilterSize)
+			if len(bufOut) > 0 {
+				// the row is gone if all of it was deleted
+				if writer == nil {
+					// fname is the full path name!
+					fname := compactionFileLocation + string(os.PathSeparator) + c.getTmpFileName()
+					writer = NewSSTableWriter(fname, expectedBloomFilterSize)
+					writer.origin = compactedBackup
+				}
+				writer.append(lastkey, bufOut)
+				totalKeysWritten++
+				if maxSSTableSize > 0 && writer.dataFile.getFilePointer() >= maxSSTableSize {
+					newSSTables = append(newSSTables, writer.closeAndOpenReader())
+					writer = nil
+				}
 			}
-			writer.append(lastkey, bufOut)
-			totalKeysWritten++
-			for _, filestruct := range lfs {
-				filestruct.advance(true)
+			for i, filestruct := range lfs {
+				err = filestruct.advance(true)
+				if err != nil {
+					// the ones before it are in pq
+					lfs = lfs[i:]
+					if fs != nil {
+						heap.Push(pq, fs)
+					}
+					return 0
+				}
 				if filestruct.isExhausted() {
 					continue
 				}
@@ -606,34 +699,33 @@ func (c *ColumnFamilyStore) doFileCompaction(files []string, minBufferSize int)
 		}
 	}
 	if writer != nil {
-		ssTable = writer.closeAndOpenReader()
-		newfile = writer.getFilename()
+		newSSTables = append(newSSTables, writer.closeAndOpenReader())
+		writer = nil
 	}
+	newfiles := make([]string, 0)
 	c.rwmu.Lock()
 	defer c.rwmu.Unlock()
+	c.sstableMu.Lock()
 	for _, file := range files {
 		delete(c.ssTables, file)
 	}
-	if newfile != "" {
-		c.ssTables[newfile] = ssTable
-		totalBytesWritten += getFileSizeFromName(newfile)
+	for _, ssTable := range newSSTables {
+		ssTable.level = level
+		c.ssTables[ssTable.getFilename()] = ssTable
+		newfiles = append(newfiles, ssTable.getFilename())
+		totalBytesWritten += getFileSizeFromName(ssTable.getFilename())
 	}
+	c.sstableMu.Unlock()
 	for _, file := range files {
 		getSSTableReader(file).delete()
 	}
-	log.Printf("Compacted to %v. %v/%v bytes for %v/%v keys read/written. Time: %vms.",
-		newfile, totalBytesRead, totalBytesWritten, totalKeysRead, totalKeysWritten,
+	c.saveLevels()
+	log.Printf("Compacted to %v at level %v. %v/%v bytes for %v/%v keys read/written. Time: %vms.",
+		newfiles, level, totalBytesRead, totalBytesWritten, totalKeysRead, totalKeysWritten,
 		time.Now().UnixNano()/int64(time.Millisecond)-startTime)
 	return len(files)
 }
 
-func readInt(r io.Reader) int {
-	b4 := make([]byte, 4)
-	r.Read(b4)
-	res := binary.BigEndian.Uint32(b4)
-	return int(res)
-}
-
 // ByName ...
 type ByName []*FileStruct
 
@@ -652,40 +744,36 @@ func (p ByName) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+// doCompaction runs the compactions the compaction strategy
+// of the column family asks for, until it asks for no more
 func (c *ColumnFamilyStore) doCompaction() int {
-	// break the files into buckets and then compact
+	c.compactionMu.Lock()
+	defer c.compactionMu.Unlock()
 	filesCompacted := 0
-	// c.rwmu.Lock()
-	// c.isCompacting = true
-	// c.rwmu.Unlock()
-	files := make([]string, 0)
-	for file := range c.ssTables {
-		files = append(files, file)
-	}
-	buckets := c.stageOrderedCompaction(files)
-	for _, fileList := range buckets {
-		if len(fileList) < config.MinCompactionThres {
+	for !c.isDropped() {
+		task := c.getCompactionStrategy().getNextCompaction(c)
+		if task == nil {
+			break
+		}
+		if len(task.files) == 1 && task.level > 0 {
+			// nothing overlaps it at the next level, it
+			// can move there without being rewritten
+			c.promote(task.files[0], task.level)
 			continue
 		}
-		sort.Sort(ByFileName(fileList))
-		files = make([]string, 0)
-		count := 0
-		mark := len(fileList)
-		if config.MaxCompactionThres < mark {
-			mark = config.MaxCompactionThres
-		}
-		for _, file := range fileList {
-			files = append(files, file)
-			count++
-			if count == mark {
+		if config.SnapshotBeforeCompaction {
+			err := c.snapshotBeforeCompaction()
+			if err != nil {
+				log.Printf("not compacting %v.%v, snapshot failed: %v\n", c.tableName, c.columnFamilyName, err)
 				break
 			}
 		}
-		filesCompacted += c.doFileCompaction(files, c.bufSize)
+		n := c.doFileCompaction(task.files, c.bufSize, task.level, task.maxSSTableSize)
+		if n == 0 {
+			break
+		}
+		filesCompacted += n
 	}
-	// c.rwmu.Lock()
-	// c.isCompacting = false
-	// c.rwmu.Unlock()
 	return filesCompacted
 }
 
@@ -693,7 +781,7 @@ func getCurrentTimeInMillis() int64 {
 	return time.Now().UnixNano() / int64(time.Millisecond)
 }
 
-func (c *ColumnFamilyStore) getColumnFamilyGC(filter QueryFilter, gcBefore int) *ColumnFamily {
+func (c *ColumnFamilyStore) getColumnFamilyGC(filter QueryFilter, gcBefore int) (*ColumnFamily, error) {
 	// get a list of columns starting from a given column, in a specified order
 	// only the latest version of a column is returned
 	start := getCurrentTimeInMillis()
@@ -704,9 +792,9 @@ func (c *ColumnFamilyStore) getColumnFamilyGC(filter QueryFilter, gcBefore int)
 			filter.getKey(),
 			NewQueryPathCF(c.columnFamilyName),
 			filter.getPath().SuperColumnName)
-		cf := c.getColumnFamily(nameFilter)
-		if cf == nil || cf.getColumnCount() == 0 {
-			return cf
+		cf, err := c.getColumnFamily(nameFilter)
+		if err != nil || cf == nil || cf.getColumnCount() == 0 {
+			return cf, err
 		}
 		sc := cf.GetSortedColumns()[0].(SuperColumn)
 		scFiltered := filter.filterSuperColumn(sc, gcBefore)
@@ -714,57 +802,132 @@ func (c *ColumnFamilyStore) getColumnFamilyGC(filter QueryFilter, gcBefore int)
 		cfFiltered.addColumn(scFiltered)
 		c.readStats = append(c.readStats, getCurrentTimeInMillis()-start)
 	}
+	var res *ColumnFamily
+	if c.rowCache.getCapacity() > 0 {
+		cf, err := c.getCachedRow(filter.getKey())
+		if err != nil {
+			return nil, err
+		}
+		res = removeDeleted(filter.filterColumnFamily(cf, gcBefore), gcBefore)
+	} else {
+		var err error
+		res, err = c.getTopLevelColumns(filter, gcBefore)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.readStats = append(c.readStats, getCurrentTimeInMillis()-start)
+	return res, nil
+}
+
+// getCachedRow returns the whole row from the row cache, reading
+// it into the cache first if needed. The row keeps its tombstones,
+// so it can answer reads with any gcBefore.
+func (c *ColumnFamilyStore) getCachedRow(key string) (*ColumnFamily, error) {
+	cached, ok := c.rowCache.get(key)
+	if ok {
+		return cached.(*ColumnFamily), nil
+	}
+	version := c.rowCache.getVersion()
+	filter := NewIdentityQueryFilter(key, NewQueryPathCF(c.columnFamilyName))
+	cf, err := c.getTopLevelColumns(filter, math.MinInt32)
+	if err != nil {
+		return nil, err
+	}
+	if cf == nil {
+		cf = createColumnFamily(c.tableName, c.columnFamilyName)
+	}
+	c.rowCache.putIfUnchanged(key, cf, version)
+	return cf, nil
+}
+
+// getTopLevelColumns merges the columns of the row in the memtables
+// and sstables. A corrupt sstable fails the read with a
+// CorruptSSTableError.
+func (c *ColumnFamilyStore) getTopLevelColumns(filter QueryFilter, gcBefore int) (*ColumnFamily, error) {
 	// we are querying top-level, do a merging fetch with indices
 	c.rwmu.RLock()
 	defer c.rwmu.RUnlock()
 	iterators := make([]ColumnIterator, 0)
-	spew.Printf("\tc.memtable: %#+v\n\n", c.memtable)
-	iter := filter.getMemColumnIterator(c.memtable)
-	spew.Printf("\titer: %#+v\n\n", iter)
+	iter := filter.getMemColumnIterator(c.getMemtableThreadSafe())
 	returnCF := iter.getColumnFamily()
-	spew.Printf("\treturnCF: %#+v\n\n", returnCF)
 	// return returnCF
 	iterators = append(iterators, iter)
 	// add the memtable being flushed
-	memtables := getUnflushedMemtables(filter.getPath().ColumnFamilyName)
+	memtables := getUnflushedMemtables(c.tableName, c.columnFamilyName)
 	for _, memtable := range memtables {
 		iter = filter.getMemColumnIterator(memtable)
 		returnCF.deleteCF(iter.getColumnFamily())
 		iterators = append(iterators, iter)
 	}
 	// add the SSTables on disk
-	sstables := make([]*SSTableReader, 0)
-	for _, sstable := range c.ssTables {
-		sstables = append(sstables, sstable)
-		iter = filter.getSSTableColumnIterator(sstable)
+	defer func() {
+		for _, ci := range iterators {
+			ci.close()
+		}
+	}()
+	sstables := c.getSSTables()
+	sstableIterators := make([]ColumnIterator, 0, len(sstables))
+	for _, sstable := range sstables {
+		if sstable.corruption != nil {
+			return nil, sstable.corruption
+		}
+		iter, err := filter.getSSTableColumnIterator(sstable)
+		if err != nil {
+			return nil, newCorruptSSTableError(sstable.dataFileName, err)
+		}
 		if iter.hasNext() { // initializes iter.CF
 			returnCF.deleteCF(iter.getColumnFamily())
 		}
 		iterators = append(iterators, iter)
+		sstableIterators = append(sstableIterators, iter)
+		if err = iter.getError(); err != nil {
+			return nil, newCorruptSSTableError(sstable.dataFileName, err)
+		}
 	}
 	collated := NewCollatedIterator(iterators)
 	filter.collectCollatedColumns(returnCF, collated, gcBefore)
-	res := removeDeleted(returnCF, gcBefore)
-	for _, ci := range iterators {
-		ci.close()
+	for i, iter := range sstableIterators {
+		if err := iter.getError(); err != nil {
+			return nil, newCorruptSSTableError(sstables[i].dataFileName, err)
+		}
 	}
-	c.readStats = append(c.readStats, getCurrentTimeInMillis()-start)
-	return res
-	// return iter.getColumnFamily()
+	return removeDeleted(returnCF, gcBefore), nil
+}
+
+// getSSTables returns a snapshot of the sstables of this cf
+func (c *ColumnFamilyStore) getSSTables() []*SSTableReader {
+	c.sstableMu.RLock()
+	defer c.sstableMu.RUnlock()
+	sstables := make([]*SSTableReader, 0, len(c.ssTables))
+	for _, sstable := range c.ssTables {
+		sstables = append(sstables, sstable)
+	}
+	return sstables
 }
 
-func getUnflushedMemtables(cfName string) []*Memtable {
-	return getMemtablePendingFlushNotNull(cfName)
+func getUnflushedMemtables(tableName, cfName string) []*Memtable {
+	pendingFlushMu.RLock()
+	defer pendingFlushMu.RUnlock()
+	memtables := memtablesPendingFlush[tableName+"."+cfName]
+	return append([]*Memtable{}, memtables...)
 }
 
-func getMemtablePendingFlushNotNull(columnFamilyName string) []*Memtable {
-	memtables, ok := memtablesPendingFlush[columnFamilyName]
-	if ok == false {
-		memtablesPendingFlush[columnFamilyName] = make([]*Memtable, 0)
-		// might not be the object we just put, if there was a race
-		memtables = memtablesPendingFlush[columnFamilyName]
+func addPendingFlush(memtable *Memtable) {
+	pendingFlushMu.Lock()
+	defer pendingFlushMu.Unlock()
+	key := memtable.tableName + "." + memtable.cfName
+	memtablesPendingFlush[key] = append(memtablesPendingFlush[key], memtable)
+}
+
+func removePendingFlush(memtable *Memtable) {
+	pendingFlushMu.Lock()
+	defer pendingFlushMu.Unlock()
+	key := memtable.tableName + "." + memtable.cfName
+	memtablesPendingFlush[key] = remove(memtablesPendingFlush[key], memtable)
+	if len(memtablesPendingFlush[key]) == 0 {
+		delete(memtablesPendingFlush, key)
 	}
-	return memtables
 }
 
 func getDefaultGCBefore() int {
@@ -772,7 +935,9 @@ func getDefaultGCBefore() int {
 	return int(curTime - int64(config.GcGraceInSeconds))
 }
 
-func (c *ColumnFamilyStore) getColumnFamily(filter QueryFilter) *ColumnFamily {
+// getColumnFamily reads the columns of filter, a corrupt
+// sstable fails the read instead of the node
+func (c *ColumnFamilyStore) getColumnFamily(filter QueryFilter) (*ColumnFamily, error) {
 	return c.getColumnFamilyGC(filter, getDefaultGCBefore())
 }
 
@@ -788,9 +953,43 @@ func (c *ColumnFamilyStore) apply(key string, columnFamily *ColumnFamily, cLogCt
 	c.memMu.Lock()
 	defer c.memMu.Unlock()
 	c.memtable.put(key, columnFamily)
+	c.rowCache.remove(key)
 	c.writeStates = append(c.writeStates, getCurrentTimeInMillis()-start)
 }
 
+// applyBinary adds a row of a bulk load to the binary memtable.
+// The loader that fills it up flushes it, which keeps bulk loads
+// from running ahead of the disk.
+func (c *ColumnFamilyStore) applyBinary(key string, buffer []byte) {
+	c.binaryMu.Lock()
+	c.binaryMemtable.put(key, buffer)
+	var full *BinaryMemtable
+	if c.binaryMemtable.isThresholdViolated() {
+		full = c.binaryMemtable
+		full.freeze()
+		c.binaryMemtable = NewBinaryMemtable(c.tableName, c.columnFamilyName)
+	}
+	c.binaryMu.Unlock()
+	if full != nil {
+		full.flush()
+	}
+}
+
+// forceBlockingFlushBinary flushes the binary memtable and
+// returns once its sstable is on disk
+func (c *ColumnFamilyStore) forceBlockingFlushBinary() {
+	c.binaryMu.Lock()
+	old := c.binaryMemtable
+	if old.isClean() {
+		c.binaryMu.Unlock()
+		return
+	}
+	old.freeze()
+	c.binaryMemtable = NewBinaryMemtable(c.tableName, c.columnFamilyName)
+	c.binaryMu.Unlock()
+	old.flush()
+}
+
 func (c *ColumnFamilyStore) getMemtableThreadSafe() *Memtable {
 	c.memMu.RLock()
 	defer c.memMu.RUnlock()
@@ -817,8 +1016,7 @@ func (c *ColumnFamilyStore) switchMemtableN(oldMemtable *Memtable, ctx *CommitLo
 		return
 	}
 	oldMemtable.freeze()
-	memtables := getMemtablePendingFlushNotNull(c.columnFamilyName)
-	memtables = append(memtables, oldMemtable)
+	addPendingFlush(oldMemtable)
 	submitFlush(oldMemtable, ctx)
 	c.memtable = NewMemtable(c.tableName, c.columnFamilyName)
 }
@@ -827,11 +1025,27 @@ func submitFlush(memtable *Memtable, cLogCtx *CommitLogContext) {
 	// submit memtables to be flushed to disk
 	go func() {
 		memtable.flush(cLogCtx)
-		memtables := getMemtablePendingFlushNotNull(memtable.cfName)
-		memtables = remove(memtables, memtable) // ?
+		removePendingFlush(memtable)
 	}()
 }
 
+// forceBlockingFlush flushes the current memtable and returns
+// once its sstable is on disk
+func (c *ColumnFamilyStore) forceBlockingFlush(cLogCtx *CommitLogContext) {
+	c.memMu.Lock()
+	oldMemtable := c.memtable
+	if oldMemtable.isClean() || oldMemtable.isFrozen {
+		c.memMu.Unlock()
+		return
+	}
+	oldMemtable.freeze()
+	addPendingFlush(oldMemtable)
+	c.memtable = NewMemtable(c.tableName, c.columnFamilyName)
+	c.memMu.Unlock()
+	oldMemtable.flush(cLogCtx)
+	removePendingFlush(oldMemtable)
+}
+
 func (c *ColumnFamilyStore) getNextFileName() string {
 	// increment twice to generate non-consecutive numbers
 	atomic.AddInt32(&c.fileIdxGenerator, 1)
@@ -866,6 +1080,11 @@ func (c *ColumnFamilyStore) storeLocation(sstable *SSTableReader) {
 	// the key. If invoked during recoveryMode the onMemtableFlush()
 	// need not be invoked.
 
+	if c.isDropped() {
+		// a flush raced with dropping this column family
+		sstable.delete()
+		return
+	}
 	c.sstableMu.Lock()
 	c.ssTables[sstable.getFilename()] = sstable
 	ssTableCount := len(c.ssTables)
@@ -880,6 +1099,41 @@ func (c *ColumnFamilyStore) storeLocation(sstable *SSTableReader) {
 	}
 }
 
+// updateCacheSizes resizes the caches after the column
+// family definition changed
+func (c *ColumnFamilyStore) updateCacheSizes() {
+	cfMetaData, _ := config.GetCFMetaData(c.tableName, c.columnFamilyName)
+	c.rowCache.setCapacity(cfMetaData.RowCacheSize)
+	for _, sstable := range c.getSSTables() {
+		sstable.keyCache.setCapacity(cfMetaData.GetKeyCacheSize())
+	}
+}
+
+func (c *ColumnFamilyStore) isDropped() bool {
+	return atomic.LoadInt32(&c.dropped) == 1
+}
+
+// drop discards the memtable and deletes every sstable of this
+// column family. The store must already be removed from its table.
+func (c *ColumnFamilyStore) drop() {
+	atomic.StoreInt32(&c.dropped, 1)
+	c.memMu.Lock()
+	c.memtable = NewMemtable(c.tableName, c.columnFamilyName)
+	c.memMu.Unlock()
+	c.binaryMu.Lock()
+	c.binaryMemtable = NewBinaryMemtable(c.tableName, c.columnFamilyName)
+	c.binaryMu.Unlock()
+	c.rowCache.clear()
+	c.sstableMu.Lock()
+	defer c.sstableMu.Unlock()
+	for filename, sstable := range c.ssTables {
+		log.Printf("deleting %v of dropped column family %v\n", filename, c.columnFamilyName)
+		sstable.delete()
+		delete(c.ssTables, filename)
+	}
+	os.Remove(c.getLevelsFilename())
+}
+
 func (c *ColumnFamilyStore) forceCompaction(ranges []*dht.Range, target *network.EndPoint, skip int64, fileList []string) bool {
 	// this method forces a compaction of the sstable on disk
 	// TODO
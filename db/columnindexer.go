@@ -18,13 +18,13 @@ var CIndexer = &ColumnIndexer{}
 // ColumnIndexer ...
 type ColumnIndexer struct{}
 
-func (c *ColumnIndexer) serialize(columnFamily *ColumnFamily, dos []byte) {
+func (c *ColumnIndexer) serialize(columnFamily *ColumnFamily, dos *[]byte) {
 	// currently it is sorted by key string
 	columns := columnFamily.GetSortedColumns()
 	bf := c.createColumnBloomFilter(columns)
 	// write out the bloom filter
 	buf := make([]byte, 0)
-	utils.BFSerializer.SerializeB(bf, buf)
+	utils.BFSerializer.SerializeB(bf, &buf)
 	// write the length of the serialized bloom filter
 	// and write the serialized bytes. 2 in 1 :)
 	writeBytesB(dos, buf)
@@ -53,7 +53,7 @@ func (c *ColumnIndexer) createColumnBloomFilter(columns []IColumn) *utils.BloomF
 	return bf
 }
 
-func (c *ColumnIndexer) doIndexing(columns []IColumn, dos []byte) {
+func (c *ColumnIndexer) doIndexing(columns []IColumn, dos *[]byte) {
 	// Given the collection of columns in the column family,
 	// the name index is generated and written into the provided
 	// stream
@@ -98,6 +98,7 @@ func (c *ColumnIndexer) doIndexing(columns []IColumn, dos []byte) {
 			int64(startPosition),
 			int64(endPosition-startPosition))
 		indexList = append(indexList, cIndexInfo)
+		indexSizeInBytes += cIndexInfo.serializedSize()
 	}
 	if indexSizeInBytes <= 0 {
 		log.Fatal("index size should > 0")
@@ -11,6 +11,9 @@ type ColumnIterator interface {
 	close()
 	hasNext() bool
 	next() IColumn
+	// getError returns the error that ended
+	// the iteration early, if any
+	getError() error
 }
 
 // SimpleColumnIterator ...
@@ -35,6 +38,9 @@ func (c *SimpleColumnIterator) getColumnFamily() *ColumnFamily {
 func (c *SimpleColumnIterator) close() {
 	return
 }
+func (c *SimpleColumnIterator) getError() error {
+	return nil
+}
 func (c *SimpleColumnIterator) hasNext() bool {
 	return c.curIndex < len(c.columns)
 }
@@ -69,6 +75,10 @@ func (c *AbstractColumnIterator) getColumnFamily() *ColumnFamily {
 
 func (c *AbstractColumnIterator) close() {}
 
+func (c *AbstractColumnIterator) getError() error {
+	return nil
+}
+
 func (c *AbstractColumnIterator) hasNext() bool {
 	return c.curIndex < len(c.columns)
 }
@@ -6,7 +6,11 @@
 package db
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"log"
 	"os"
 	"sort"
@@ -48,8 +52,15 @@ type CommitLog struct {
 	commitHeaderStartPos int64
 	forcedRollOver       bool
 	logWriter            *os.File
+	// serializes appends, rolls and header updates
+	mu     sync.Mutex
+	syncer *commitLogSyncer
 }
 
+// commitLogFrameSize is the number of bytes the framing adds
+// to every commit log entry: size, size checksum, data checksum
+const commitLogFrameSize = 8 + 8 + 8
+
 var (
 	clInstance  = map[string]*CommitLog{}
 	clSInstance *CommitLog // stands for Single Instance
@@ -97,9 +108,10 @@ func (c *CommitLog) writeCommitLogHeader() {
 	// cfSize := table.getNumberOfColumnFamilies() // number of cf
 	cfSize := getColumnFamilyCount()
 	c.commitHeaderStartPos = 0
-	// write the commit log header
+	// write the commit log header. Its size stays fixed for the
+	// life of the segment, so it can be rewritten in place.
 	c.clHeader = NewCommitLogHeader(cfSize)
-	c.writeCLH(c.clHeader.toByteArray(), false)
+	writeCommitLogHeader(c.logWriter, c.clHeader.toByteArray())
 }
 
 func (c *CommitLog) writeCommitLogHeaderB(bytes []byte, reset bool) {
@@ -121,7 +133,12 @@ func (c *CommitLog) writeCommitLogHeaderB(bytes []byte, reset bool) {
 }
 
 func (c *CommitLog) writeOldCommitLogHeader(oldFile string, header *CommitLogHeader) {
-	logWriter := createCLWriter(oldFile)
+	// overwrite the header in place, keep the logged rows
+	logWriter, err := os.OpenFile(oldFile, os.O_WRONLY, 0666)
+	if err != nil {
+		log.Print(err)
+		return
+	}
 	writeCommitLogHeader(logWriter, header.toByteArray())
 	logWriter.Close()
 }
@@ -149,6 +166,7 @@ func NewCommitLog(table string, recoveryMode bool) *CommitLog {
 	c := &CommitLog{}
 	c.table = table
 	c.forcedRollOver = false
+	c.syncer = newCommitLogSyncer()
 	if !recoveryMode {
 		c.setNextFileName()
 		c.logWriter = createCLWriter(c.logFile)
@@ -162,10 +180,14 @@ func NewCommitLogE(recoveryMode bool) *CommitLog {
 	c := &CommitLog{}
 	// c.table = table
 	c.forcedRollOver = false
+	c.syncer = newCommitLogSyncer()
 	if !recoveryMode {
 		c.setNextFileName()
 		c.logWriter = createCLWriter(c.logFile)
 		c.writeCommitLogHeader()
+		if config.CommitLogSync == config.Periodic {
+			c.startPeriodicSync()
+		}
 	}
 	return c
 }
@@ -197,7 +219,7 @@ func (c *CommitLog) maybeUpdateHeader(row *Row) {
 	table := OpenTable(row.Table)
 	for cfName := range row.getColumnFamilies() {
 		id := table.getColumnFamilyID(cfName)
-		if c.clHeader.isDirty(id) == false {
+		if !c.clHeader.isDirty(id) {
 			c.clHeader.turnOn(id, getCurrentPos(c.logWriter))
 			c.seekAndWriteCommitLogHeader(c.clHeader.toByteArray())
 		}
@@ -213,51 +235,112 @@ func (c *CommitLog) seekAndWriteCommitLogHeader(bytes []byte) {
 	c.logWriter.Seek(currentPos, 0)
 }
 
+// writeCommitLogHeader writes the header framed as its size, the
+// checksum of the size, the header bytes and their checksum. The
+// header is rewritten in place with the same size, so a torn rewrite
+// can only break the second checksum and replay still knows where
+// the logged rows start.
 func writeCommitLogHeader(logWriter *os.File, bytes []byte) {
-	writeInt64(logWriter, int64(len(bytes)))
-	writeBytes(logWriter, bytes)
+	logWriter.Write(frameCommitLogEntry(bytes))
+}
+
+// writeCommitLogRecord appends a serialized row framed the same
+// way as the header, in a single write
+func writeCommitLogRecord(logWriter *os.File, bytes []byte) {
+	logWriter.Write(frameCommitLogEntry(bytes))
+}
+
+// frameCommitLogEntry prefixes bytes with their size and the
+// checksum of the size and appends the checksum of bytes
+func frameCommitLogEntry(bytes []byte) []byte {
+	buf := make([]byte, 0, commitLogFrameSize+len(bytes))
+	size := make([]byte, 0, 8)
+	writeInt64B(&size, int64(len(bytes)))
+	buf = append(buf, size...)
+	writeInt64B(&buf, int64(crc32.ChecksumIEEE(size)))
+	buf = append(buf, bytes...)
+	writeInt64B(&buf, int64(crc32.ChecksumIEEE(bytes)))
+	return buf
 }
 
 func (c *CommitLog) maybeRollLog() bool {
 	if getFileSize(c.logWriter) >= config.LogRotationThres {
-		// rolls the current log file over to a new one
-		c.setNextFileName()
-		oldLogFile := c.logWriter.Name()
-		c.logWriter.Close()
-		// point reader/writer to a new commit log file
-		c.logWriter = createCLWriter(c.logFile)
-		// squirrel away the old commit log header
-		clHeaders[oldLogFile] = NewCommitLogHeaderC(c.clHeader)
-		c.clHeader.clear()
-		writeCommitLogHeader(c.logWriter, c.clHeader.toByteArray())
+		c.rollLog()
 		return true
 	}
 	return false
 }
 
+// rollLog switches to a new commit log segment
+func (c *CommitLog) rollLog() {
+	c.setNextFileName()
+	oldLogFile := c.logWriter.Name()
+	// entries in the old segment count as synced once it is rolled
+	err := c.logWriter.Sync()
+	if err != nil {
+		log.Fatalf("cannot sync commit log %v: %v\n", oldLogFile, err)
+	}
+	c.logWriter.Close()
+	// point reader/writer to a new commit log file
+	c.logWriter = createCLWriter(c.logFile)
+	// squirrel away the old commit log header
+	clHeaders[oldLogFile] = c.clHeader
+	c.writeCommitLogHeader()
+}
+
+// fitsHeader checks whether the header of the current segment
+// has a slot for every column family of row. Column families
+// created after the segment was opened do not, and need a new one.
+func (c *CommitLog) fitsHeader(row *Row) bool {
+	table := OpenTable(row.Table)
+	for cfName := range row.getColumnFamilies() {
+		if table.getColumnFamilyID(cfName) >= c.clHeader.size() {
+			return false
+		}
+	}
+	return true
+}
+
 // add the specified row to the commit log. This method will
 // reset the file offset to what it is before the start of
 // the operation in case of any problems. This way we can
 // assume that the subsequent commit log entry will override
-// the garbage left over by the previous write.
+// the garbage left over by the previous write. In Batch mode
+// it only returns once the row is synced to disk.
 func (c *CommitLog) add(row *Row) *CommitLogContext {
+	cLogCtx, seq := c.append(row)
+	if config.CommitLogSync == config.Batch {
+		c.waitForSync(seq)
+	}
+	return cLogCtx
+}
+
+// append writes row to the current segment and returns its
+// context together with its sequence number for the syncer
+func (c *CommitLog) append(row *Row) (*CommitLogContext, int64) {
 	curPos := int64(-1)
 	buf := make([]byte, 0)
 	// serialize the row
-	rowSerialize(row, buf)
+	rowSerialize(row, &buf)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fitsHeader(row) {
+		c.rollLog()
+	}
 	curPos = getCurrentPos(c.logWriter)
 	cLogCtx := NewCommitLogContext(c.logFile, curPos)
 	// update header
 	c.maybeUpdateHeader(row)
-	writeInt64(c.logWriter, int64(len(buf)))
-	writeBytes(c.logWriter, buf)
+	writeCommitLogRecord(c.logWriter, buf)
+	c.syncer.written++
+	seq := c.syncer.written
 	c.maybeRollLog()
-	return cLogCtx
+	return cLogCtx, seq
 }
 
 // writeString will first write string length(int32)
 // and then write string in bytes
-func writeString(file *os.File, s string) int {
+func writeString(file io.Writer, s string) int {
 	// write string length
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(len(s)))
@@ -267,54 +350,54 @@ func writeString(file *os.File, s string) int {
 	// return total bytes written
 	return 4 + len(s)
 }
-func writeStringB(file []byte, s string) int {
+func writeStringB(file *[]byte, s string) int {
 	// write string length
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(len(s)))
-	file = append(file, b4...)
+	*file = append(*file, b4...)
 	// write string bytes
-	file = append(file, []byte(s)...)
+	*file = append(*file, []byte(s)...)
 	// return total bytes written
 	return 4 + len(s)
 }
 
-func writeInt(file *os.File, num int) int {
+func writeInt(file io.Writer, num int) int {
 	return writeInt32(file, int32(num))
 }
 
-func writeIntB(buf []byte, num int) int {
+func writeIntB(buf *[]byte, num int) int {
 	return writeInt32B(buf, int32(num))
 }
 
-func writeInt32(file *os.File, num int32) int {
+func writeInt32(file io.Writer, num int32) int {
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(num))
 	file.Write(b4)
 	return 4
 }
 
-func writeInt32B(buf []byte, num int32) int {
+func writeInt32B(buf *[]byte, num int32) int {
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(num))
-	buf = append(buf, b4...)
+	*buf = append(*buf, b4...)
 	return 4
 }
 
-func writeInt64(file *os.File, num int64) int {
+func writeInt64(file io.Writer, num int64) int {
 	b8 := make([]byte, 8)
 	binary.BigEndian.PutUint64(b8, uint64(num))
 	file.Write(b8)
 	return 8
 }
 
-func writeInt64B(buf []byte, num int64) int {
+func writeInt64B(buf *[]byte, num int64) int {
 	b8 := make([]byte, 8)
 	binary.BigEndian.PutUint64(b8, uint64(num))
-	buf = append(buf, b8...)
+	*buf = append(*buf, b8...)
 	return 8
 }
 
-func writeBool(file *os.File, b bool) int {
+func writeBool(file io.Writer, b bool) int {
 	if b == true {
 		file.Write([]byte{1})
 	} else {
@@ -323,16 +406,26 @@ func writeBool(file *os.File, b bool) int {
 	return 1
 }
 
-func writeBoolB(file []byte, b bool) int {
+func writeBoolB(file *[]byte, b bool) int {
 	if b == true {
-		file = append(file, byte(1))
+		*file = append(*file, byte(1))
 	} else {
-		file = append(file, byte(0))
+		*file = append(*file, byte(0))
 	}
 	return 1
 }
 
-func writeBytes(file *os.File, b []byte) int {
+func writeByte(file io.Writer, b byte) int {
+	file.Write([]byte{b})
+	return 1
+}
+
+func writeByteB(file *[]byte, b byte) int {
+	*file = append(*file, b)
+	return 1
+}
+
+func writeBytes(file io.Writer, b []byte) int {
 	// write byte length
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(len(b)))
@@ -343,17 +436,83 @@ func writeBytes(file *os.File, b []byte) int {
 	return 4 + len(b)
 }
 
-func writeBytesB(buf []byte, b []byte) int {
+func writeBytesB(buf *[]byte, b []byte) int {
 	// write byte length
 	b4 := make([]byte, 4)
 	binary.BigEndian.PutUint32(b4, uint32(len(b)))
-	buf = append(buf, b4...)
+	*buf = append(*buf, b4...)
 	// write bytes
-	buf = append(buf, b...)
+	*buf = append(*buf, b...)
 	// return total bytes written
 	return 4 + len(b)
 }
 
+// readInt32B reads an int32 written by writeInt32B
+func readInt32B(r io.Reader) (int32, error) {
+	b4 := make([]byte, 4)
+	_, err := io.ReadFull(r, b4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b4)), nil
+}
+
+// readInt64B reads an int64 written by writeInt64B
+func readInt64B(r io.Reader) (int64, error) {
+	b8 := make([]byte, 8)
+	_, err := io.ReadFull(r, b8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b8)), nil
+}
+
+// readBoolB reads a bool written by writeBoolB
+func readByteB(r io.Reader) (byte, error) {
+	b := make([]byte, 1)
+	_, err := io.ReadFull(r, b)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func readBoolB(r io.Reader) (bool, error) {
+	b := make([]byte, 1)
+	_, err := io.ReadFull(r, b)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == 1, nil
+}
+
+// readBytesB reads a byte slice written by writeBytesB
+func readBytesB(r io.Reader) ([]byte, error) {
+	size, err := readInt32B(r)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid length %v", size)
+	}
+	// don't trust the length before the bytes are there
+	b := bytes.NewBuffer(nil)
+	n, err := io.CopyN(b, r, int64(size))
+	if err != nil {
+		return nil, err
+	}
+	if n != int64(size) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b.Bytes(), nil
+}
+
+// readStringB reads a string written by writeStringB
+func readStringB(r io.Reader) (string, error) {
+	b, err := readBytesB(r)
+	return string(b), err
+}
+
 // func (c *CommitLog) checkThresholdAndRollLog(fileSize int64) {
 // 	if fileSize >= config.LogRotationThres || c.forcedRollOver {
 // 		// rolls the current log file over to a new one
@@ -403,10 +562,25 @@ func (c *CommitLog) onMemtableFlush(tableName, cf string, cLogCtx *CommitLogCont
 	// in the header and this is used to decide if the log
 	// file can be deleted.
 	table := OpenTable(tableName)
-	id := table.tableMetadata.cfIDMap[cf]
+	id := table.getColumnFamilyID(cf)
 	c.discard(cLogCtx, id)
 }
 
+// onColumnFamilyDropped clears the dirty flag of a dropped column
+// family in every header, so that its unflushed entries no longer
+// keep commit log segments alive
+func (c *CommitLog) onColumnFamilyDropped(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, header := range clHeaders {
+		header.turnOff(id)
+	}
+	if c.clHeader.isDirty(id) {
+		c.clHeader.turnOff(id)
+		c.seekAndWriteCommitLogHeader(c.clHeader.toByteArray())
+	}
+}
+
 // ByTime provide struct to sort file by timestamp
 type ByTime []string
 
@@ -439,6 +613,8 @@ func getCreationTime(name string) int64 {
 // delete log segments whose contents have
 // been turned into SSTables
 func (c *CommitLog) discard(cLogCtx *CommitLogContext, id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	// Check if old commit logs can be deleted.
 	header, ok := clHeaders[cLogCtx.file]
 	if !ok {
@@ -480,8 +656,9 @@ func (c *CommitLog) discard(cLogCtx *CommitLogContext, id int) {
 			}
 			break
 		}
-		header.turnOff(id)
-		if header.isSafeToDelete() {
+		oldHeader := clHeaders[oldFile]
+		oldHeader.turnOff(id)
+		if oldHeader.isSafeToDelete() {
 			log.Printf("Deleting commit log: %v\n", oldFile)
 			err := os.Remove(oldFile)
 			if err != nil {
@@ -489,7 +666,7 @@ func (c *CommitLog) discard(cLogCtx *CommitLogContext, id int) {
 			}
 			delete(clHeaders, oldFile)
 		} else {
-			c.writeOldCommitLogHeader(oldFile, header)
+			c.writeOldCommitLogHeader(oldFile, oldHeader)
 		}
 	}
 }
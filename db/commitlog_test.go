@@ -0,0 +1,99 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCommitLogEntryRoundTrip(t *testing.T) {
+	var segment []byte
+	entries := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte{0xff}, 1000)}
+	for _, entry := range entries {
+		segment = append(segment, frameCommitLogEntry(entry)...)
+	}
+	reader := bytes.NewReader(segment)
+	for i, want := range entries {
+		got, size, err := readCommitLogEntry(reader)
+		if err != nil {
+			t.Fatalf("entry %v: %v", i, err)
+		}
+		if !bytes.Equal(got, want) || size != commitLogFrameSize+int64(len(want)) {
+			t.Errorf("entry %v is %v bytes with size %v, want %v bytes", i, len(got), size, len(want))
+		}
+	}
+	if _, _, err := readCommitLogEntry(reader); err != io.EOF {
+		t.Errorf("reading past the last entry gives %v, want io.EOF", err)
+	}
+}
+
+func TestCommitLogEntryTornWrite(t *testing.T) {
+	entry := frameCommitLogEntry([]byte("row"))
+	for n := 1; n < len(entry); n++ {
+		_, _, err := readCommitLogEntry(bytes.NewReader(entry[:n]))
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("an entry cut after %v bytes gives %v, want io.ErrUnexpectedEOF", n, err)
+		}
+	}
+}
+
+func TestCommitLogEntryCorruption(t *testing.T) {
+	entry := frameCommitLogEntry([]byte("row"))
+	// a corrupt size can't be trusted to skip the entry
+	corrupt := append([]byte{}, entry...)
+	corrupt[7] ^= 1
+	if _, _, err := readCommitLogEntry(bytes.NewReader(corrupt)); err == nil || errors.Is(err, errCommitLogChecksum) {
+		t.Errorf("a corrupt size gives %v", err)
+	}
+	// corrupt data still has a valid size
+	corrupt = append([]byte{}, entry...)
+	corrupt[16] ^= 1
+	_, size, err := readCommitLogEntry(bytes.NewReader(corrupt))
+	if !errors.Is(err, errCommitLogChecksum) || size != int64(len(entry)) {
+		t.Errorf("corrupt data gives size %v, %v, want size %v, %v", size, err, len(entry), errCommitLogChecksum)
+	}
+}
+
+func TestCommitLogHeaderRoundTrip(t *testing.T) {
+	header := NewCommitLogHeader(3)
+	header.turnOn(1, 1234)
+	header.turnOn(5, 99)
+	framed := frameCommitLogEntry(header.toByteArray())
+	read, size, err := readCommitLogHeader(bytes.NewReader(append(framed, "rows"...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(framed)) {
+		t.Errorf("header size is %v, want %v", size, len(framed))
+	}
+	if read.size() != 3 || read.isDirty(0) || !read.isDirty(1) || read.getPosition(1) != 1234 {
+		t.Errorf("header is read back as %v dirty=%v position=%v", read.size(), read.dirty, read.lastFlushedAt)
+	}
+}
+
+func TestLoggedRowRoundTrip(t *testing.T) {
+	row := NewRowT("table", "key")
+	cf := NewColumnFamily("cf", "Standard")
+	cf.addColumn(NewColumn("c", "v", 1, false))
+	row.addColumnFamily(cf)
+	buf := make([]byte, 0)
+	rowSerialize(row, &buf)
+	framed := frameCommitLogEntry(buf)
+	read, size, err := readLoggedRow(bytes.NewReader(framed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(framed)) || read.Table != "table" || read.Key != "key" {
+		t.Fatalf("row is read back as %v.%v with size %v", read.Table, read.Key, size)
+	}
+	column, ok := read.ColumnFamilies["cf"].Columns["c"].(Column)
+	if !ok || column.Value != "v" || column.Timestamp != 1 {
+		t.Errorf("column is read back as %+v", read.ColumnFamilies["cf"].Columns["c"])
+	}
+}
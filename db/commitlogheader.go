@@ -6,6 +6,9 @@
 package db
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"log"
 
 	"github.com/willf/bitset"
@@ -43,24 +46,38 @@ func NewCommitLogHeaderC(clHeader *CommitLogHeader) *CommitLogHeader {
 	c := &CommitLogHeader{}
 	c.dirty = clHeader.dirty.Clone()
 	c.lastFlushedAt = make([]int, len(clHeader.lastFlushedAt))
-	for _, x := range clHeader.lastFlushedAt {
-		c.lastFlushedAt = append(c.lastFlushedAt, x)
-	}
+	copy(c.lastFlushedAt, clHeader.lastFlushedAt)
 	return c
 }
 
+// size is the number of column family slots in this header
+func (c *CommitLogHeader) size() int {
+	return len(c.lastFlushedAt)
+}
+
 func (c *CommitLogHeader) getPosition(index int) int {
+	if index >= len(c.lastFlushedAt) {
+		return 0
+	}
 	return c.lastFlushedAt[index]
 }
 
 func (c *CommitLogHeader) turnOn(index int, position int64) {
+	// the header is rewritten in place and must keep its size.
+	// A column family created after this segment was opened has
+	// never been logged here, so there is nothing to track.
+	if index >= len(c.lastFlushedAt) {
+		return
+	}
 	c.dirty.Set(uint(index))
 	c.lastFlushedAt[index] = int(position)
 }
 
 func (c *CommitLogHeader) turnOff(index int) {
 	c.dirty.Clear(uint(index))
-	c.lastFlushedAt[index] = 0
+	if index < len(c.lastFlushedAt) {
+		c.lastFlushedAt[index] = 0
+	}
 }
 
 func (c *CommitLogHeader) isDirty(index int) bool {
@@ -68,7 +85,7 @@ func (c *CommitLogHeader) isDirty(index int) bool {
 }
 
 func (c *CommitLogHeader) isSafeToDelete() bool {
-	return c.dirty.Any()
+	return !c.dirty.Any()
 }
 
 func (c *CommitLogHeader) clear() {
@@ -78,23 +95,61 @@ func (c *CommitLogHeader) clear() {
 
 func (c *CommitLogHeader) toByteArray() []byte {
 	bos := make([]byte, 0)
-	clhSerialize(c, bos)
+	clhSerialize(c, &bos)
 	return bos
 }
 
-func clhSerialize(clHeader *CommitLogHeader, dos []byte) {
+func clhSerialize(clHeader *CommitLogHeader, dos *[]byte) {
 	dbytes, err := clHeader.dirty.MarshalBinary()
 	if err != nil {
 		log.Fatal(err)
 	}
 	writeIntB(dos, len(dbytes))
-	dos = append(dos, dbytes...)
+	*dos = append(*dos, dbytes...)
 	writeIntB(dos, len(clHeader.lastFlushedAt))
 	for _, position := range clHeader.lastFlushedAt {
 		writeIntB(dos, position)
 	}
 }
 
-// func clhDeserialize(dis []byte) *CommitLogHeader {
-// 	dirtyLen := readInt(dis)
-// }
+func clhDeserialize(dis io.Reader) (*CommitLogHeader, error) {
+	dbytes, err := readBytesB(dis)
+	if err != nil {
+		return nil, err
+	}
+	dirty := &bitset.BitSet{}
+	err = dirty.UnmarshalBinary(dbytes)
+	if err != nil {
+		return nil, err
+	}
+	size, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid header size %v", size)
+	}
+	lastFlushedAt := make([]int, 0, size)
+	for i := int32(0); i < size; i++ {
+		position, err := readInt32B(dis)
+		if err != nil {
+			return nil, err
+		}
+		lastFlushedAt = append(lastFlushedAt, int(position))
+	}
+	return NewCommitLogHeaderD(dirty, lastFlushedAt), nil
+}
+
+// readCommitLogHeader reads the header at the beginning of a
+// commit log segment, leaving reader at the first logged row.
+// It also returns the size of the framed header. If only the
+// header bytes are corrupt the size is still valid and the error
+// is errCommitLogChecksum.
+func readCommitLogHeader(reader io.Reader) (*CommitLogHeader, int64, error) {
+	buf, size, err := readCommitLogEntry(reader)
+	if err != nil {
+		return nil, size, err
+	}
+	header, err := clhDeserialize(bytes.NewReader(buf))
+	return header, size, err
+}
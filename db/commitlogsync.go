@@ -0,0 +1,106 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// commitLogSyncer makes appended commit log entries durable.
+// In Batch mode each writer waits in add until an fsync covering
+// its entry completed. Writers that arrive while an fsync is in
+// flight are grouped and acked together by the next one, so the
+// cost of a single fsync is shared by all of them. In Periodic mode
+// writers are acked right away and a background goroutine fsyncs
+// every CommitLogSyncPeriodInMS, so at most that much of the
+// acked writes can be lost on a crash.
+type commitLogSyncer struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// sequence number of the last appended entry, guarded
+	// by the commit log mutex
+	written int64
+	// sequence number of the last entry known to be on disk
+	synced  int64
+	syncing bool
+}
+
+func newCommitLogSyncer() *commitLogSyncer {
+	s := &commitLogSyncer{}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// startPeriodicSync runs the background syncer of Periodic mode
+func (c *CommitLog) startPeriodicSync() {
+	go func() {
+		for {
+			time.Sleep(time.Millisecond * time.Duration(config.CommitLogSyncPeriodInMS))
+			c.sync()
+		}
+	}()
+}
+
+// waitForSync blocks until the entry with sequence number seq
+// is on disk. The first waiter to find no fsync in flight runs
+// one for everything appended so far, later waiters sleep until
+// it is done and then check again.
+func (c *CommitLog) waitForSync(seq int64) {
+	s := c.syncer
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.synced < seq {
+		if s.syncing {
+			s.cond.Wait()
+			continue
+		}
+		s.syncing = true
+		s.mu.Unlock()
+		synced := c.syncSegment()
+		s.mu.Lock()
+		s.syncing = false
+		if synced > s.synced {
+			s.synced = synced
+		}
+		s.cond.Broadcast()
+	}
+}
+
+// sync forces everything appended so far to disk
+func (c *CommitLog) sync() {
+	c.waitForSync(c.lastWritten())
+}
+
+func (c *CommitLog) lastWritten() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.syncer.written
+}
+
+// syncSegment fsyncs the current segment without holding the
+// commit log mutex, so appends go on meanwhile. It returns the
+// sequence number of the last entry that is now durable.
+func (c *CommitLog) syncSegment() int64 {
+	c.mu.Lock()
+	logWriter := c.logWriter
+	written := c.syncer.written
+	c.mu.Unlock()
+	err := logWriter.Sync()
+	if errors.Is(err, os.ErrClosed) {
+		// the segment was rolled, rollLog synced it before closing
+		return written
+	}
+	if err != nil {
+		log.Fatalf("cannot sync commit log %v: %v\n", logWriter.Name(), err)
+	}
+	return written
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// compactionTask is a compaction asked for by a compaction strategy
+type compactionTask struct {
+	files []string
+	// level the new sstables are put at
+	level int
+	// size at which the output is split, 0 for a single sstable
+	maxSSTableSize int64
+}
+
+// compactionStrategy picks the sstables to compact next. It is
+// looked up from the column family definition before every
+// compaction, so changing it takes effect without a restart.
+type compactionStrategy interface {
+	// getNextCompaction returns nil if nothing needs compacting
+	getNextCompaction(c *ColumnFamilyStore) *compactionTask
+}
+
+func (c *ColumnFamilyStore) getCompactionStrategy() compactionStrategy {
+	cfMetaData, _ := config.GetCFMetaData(c.tableName, c.columnFamilyName)
+	if cfMetaData.CompactionStrategy == config.LeveledCompaction {
+		return &leveledCompactionStrategy{}
+	}
+	return &sizeTieredCompactionStrategy{}
+}
+
+// getCompactableSSTables returns the sstables compactions may
+// work on, corrupt ones are left alone until they are scrubbed
+func (c *ColumnFamilyStore) getCompactableSSTables() []*SSTableReader {
+	sstables := make([]*SSTableReader, 0)
+	for _, sstable := range c.getSSTables() {
+		if sstable.corruption == nil {
+			sstables = append(sstables, sstable)
+		}
+	}
+	return sstables
+}
+
+// sizeTieredCompactionStrategy compacts buckets of sstables
+// of similar size, see stageOrderedCompaction
+type sizeTieredCompactionStrategy struct{}
+
+func (s *sizeTieredCompactionStrategy) getNextCompaction(c *ColumnFamilyStore) *compactionTask {
+	files := make([]string, 0)
+	for _, sstable := range c.getCompactableSSTables() {
+		files = append(files, sstable.getFilename())
+	}
+	buckets := c.stageOrderedCompaction(files)
+	indexes := make([]int, 0, len(buckets))
+	for i := range buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		fileList := buckets[i]
+		if len(fileList) < config.MinCompactionThres {
+			continue
+		}
+		sort.Sort(ByFileName(fileList))
+		if len(fileList) > config.MaxCompactionThres {
+			fileList = fileList[:config.MaxCompactionThres]
+		}
+		return &compactionTask{files: fileList}
+	}
+	return nil
+}
+
+// leveledCompactionStrategy keeps the sstables in levels. Flushed
+// sstables go to level 0, where they may overlap. Every higher level
+// holds sstables of about LeveledSSTableSizeInMB that do not overlap
+// each other, and level n may hold 10^n times that many bytes. A
+// read therefore has to look into all of level 0 but into at most
+// one sstable of every other level.
+//   - once level 0 has MinCompactionThres sstables, they are merged
+//     with the sstables of level 1 they overlap into level 1
+//   - a level holding too many bytes hands one sstable, taken round
+//     robin over the key range, to the next level, merging it with
+//     the sstables it overlaps there
+type leveledCompactionStrategy struct{}
+
+// levelFanout is how many times larger each level is than the one before
+const levelFanout = 10
+
+func getLeveledSSTableSize() int64 {
+	return int64(config.LeveledSSTableSizeInMB) * 1024 * 1024
+}
+
+func getMaxBytesForLevel(level int) int64 {
+	size := getLeveledSSTableSize()
+	for i := 0; i < level; i++ {
+		size *= levelFanout
+	}
+	return size
+}
+
+func (s *leveledCompactionStrategy) getNextCompaction(c *ColumnFamilyStore) *compactionTask {
+	levels := make(map[int][]*SSTableReader)
+	maxLevel := 0
+	for _, sstable := range c.getCompactableSSTables() {
+		levels[sstable.level] = append(levels[sstable.level], sstable)
+		if sstable.level > maxLevel {
+			maxLevel = sstable.level
+		}
+	}
+	if len(levels[0]) >= config.MinCompactionThres {
+		sort.Slice(levels[0], func(i, j int) bool {
+			return getIndexFromFileName(levels[0][i].getFilename()) < getIndexFromFileName(levels[0][j].getFilename())
+		})
+		count := len(levels[0])
+		if count > config.MaxCompactionThres {
+			count = config.MaxCompactionThres
+		}
+		candidates := append([]*SSTableReader{}, levels[0][:count]...)
+		candidates = addOverlapping(candidates, levels[0], levels[1])
+		return newLeveledCompactionTask(candidates, 1)
+	}
+	for level := 1; level <= maxLevel; level++ {
+		if getTotalDataLength(levels[level]) <= getMaxBytesForLevel(level) {
+			continue
+		}
+		sstable := c.getNextToPromote(levels[level], level)
+		candidates := addOverlapping([]*SSTableReader{sstable}, levels[level+1])
+		return newLeveledCompactionTask(candidates, level+1)
+	}
+	return nil
+}
+
+// addOverlapping adds the sstables that overlap the key range of all
+// candidates together, until no more do. The output of a compaction
+// spans that whole range, so leaving out an sstable that sits in a
+// gap between two candidates would make the output overlap it.
+func addOverlapping(candidates []*SSTableReader, levels ...[]*SSTableReader) []*SSTableReader {
+	picked := make(map[*SSTableReader]bool)
+	for _, sstable := range candidates {
+		picked[sstable] = true
+	}
+	for {
+		others := make([]*SSTableReader, 0)
+		for _, sstables := range levels {
+			for _, sstable := range sstables {
+				if !picked[sstable] {
+					others = append(others, sstable)
+				}
+			}
+		}
+		overlapping := getOverlapping(others, candidates)
+		if len(overlapping) == 0 {
+			return candidates
+		}
+		for _, sstable := range overlapping {
+			picked[sstable] = true
+		}
+		candidates = append(candidates, overlapping...)
+	}
+}
+
+func newLeveledCompactionTask(candidates []*SSTableReader, level int) *compactionTask {
+	task := &compactionTask{}
+	task.level = level
+	task.maxSSTableSize = getLeveledSSTableSize()
+	for _, sstable := range candidates {
+		task.files = append(task.files, sstable.getFilename())
+	}
+	return task
+}
+
+// getNextToPromote picks the sstable of level that comes after the
+// last one promoted from it, so that the whole key range of the
+// level takes its turn
+func (c *ColumnFamilyStore) getNextToPromote(sstables []*SSTableReader, level int) *SSTableReader {
+	sort.Slice(sstables, func(i, j int) bool {
+		return sstables[i].getFirstKey() < sstables[j].getFirstKey()
+	})
+	next := sstables[0]
+	for _, sstable := range sstables {
+		if sstable.getFirstKey() > c.lastPromotedKeys[level] {
+			next = sstable
+			break
+		}
+	}
+	c.lastPromotedKeys[level] = next.getLastKey()
+	return next
+}
+
+// getOverlapping returns the sstables whose key range overlaps
+// the key range spanned by all candidates together
+func getOverlapping(sstables []*SSTableReader, candidates []*SSTableReader) []*SSTableReader {
+	overlapping := make([]*SSTableReader, 0)
+	if len(candidates) == 0 {
+		return overlapping
+	}
+	firstKey, lastKey := candidates[0].getFirstKey(), candidates[0].getLastKey()
+	for _, candidate := range candidates[1:] {
+		if candidate.getFirstKey() < firstKey {
+			firstKey = candidate.getFirstKey()
+		}
+		if candidate.getLastKey() > lastKey {
+			lastKey = candidate.getLastKey()
+		}
+	}
+	for _, sstable := range sstables {
+		if sstable.getFirstKey() <= lastKey && firstKey <= sstable.getLastKey() {
+			overlapping = append(overlapping, sstable)
+		}
+	}
+	return overlapping
+}
+
+func getTotalDataLength(sstables []*SSTableReader) int64 {
+	size := int64(0)
+	for _, sstable := range sstables {
+		size += sstable.getDataLength()
+	}
+	return size
+}
+
+// promote moves an sstable to level without rewriting it
+func (c *ColumnFamilyStore) promote(file string, level int) {
+	c.sstableMu.Lock()
+	sstable, ok := c.ssTables[file]
+	if ok {
+		sstable.level = level
+	}
+	c.sstableMu.Unlock()
+	log.Printf("moved %v to level %v\n", file, level)
+	c.saveLevels()
+}
+
+// The levels of the sstables are kept in <cf>.levels.json in the
+// first data directory of the table, a map from the base name of
+// the data file to the level. Sstables not listed are at level 0,
+// so if the file is lost nothing but compaction work is lost.
+
+func (c *ColumnFamilyStore) getLevelsFilename() string {
+	return path.Join(config.GetAllDataFileLocationsForTable(c.tableName)[0], c.columnFamilyName+".levels.json")
+}
+
+// saveLevels persists the levels of the current sstables
+func (c *ColumnFamilyStore) saveLevels() {
+	levels := make(map[string]int)
+	for _, sstable := range c.getSSTables() {
+		if sstable.level > 0 {
+			levels[path.Base(sstable.getFilename())] = sstable.level
+		}
+	}
+	filename := c.getLevelsFilename()
+	if len(levels) == 0 {
+		err := os.Remove(filename)
+		if err != nil && !os.IsNotExist(err) {
+			log.Print(err)
+		}
+		return
+	}
+	data, err := json.Marshal(levels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmpFilename := filename + "." + SSTableTmpFile
+	err = ioutil.WriteFile(tmpFilename, data, 0666)
+	if err != nil {
+		log.Printf("cannot save sstable levels: %v\n", err)
+		return
+	}
+	err = os.Rename(tmpFilename, filename)
+	if err != nil {
+		log.Printf("cannot save sstable levels: %v\n", err)
+	}
+}
+
+// loadLevels restores the levels of the sstables opened on start
+func (c *ColumnFamilyStore) loadLevels() {
+	data, err := ioutil.ReadFile(c.getLevelsFilename())
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("cannot load sstable levels, putting all sstables at level 0: %v\n", err)
+		return
+	}
+	levels := make(map[string]int)
+	err = json.Unmarshal(data, &levels)
+	if err != nil {
+		log.Printf("cannot load sstable levels, putting all sstables at level 0: %v\n", err)
+		return
+	}
+	for _, sstable := range c.getSSTables() {
+		sstable.level = levels[path.Base(sstable.getFilename())]
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// newLevelTestStore returns a column family store holding sstables
+// that only have a key range, a level and a data file of some size
+func newLevelTestStore(t *testing.T) (*ColumnFamilyStore, func(level int, firstKey, lastKey string, size int64) *SSTableReader) {
+	dir, err := ioutil.TempDir("", "compactionstrategy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	c := &ColumnFamilyStore{}
+	c.ssTables = make(map[string]*SSTableReader)
+	c.lastPromotedKeys = make(map[int]string)
+	add := func(level int, firstKey, lastKey string, size int64) *SSTableReader {
+		filename := filepath.Join(dir, fmt.Sprintf("cf-%v-Data.db", len(c.ssTables)+1))
+		if err := ioutil.WriteFile(filename, nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(filename, size); err != nil {
+			t.Fatal(err)
+		}
+		sstable := &SSTableReader{SSTable: &SSTable{dataFileName: filename}}
+		sstable.indexPositions = []*KeyPositionInfo{NewKeyPositionInfo(firstKey, 0)}
+		sstable.lastKey = lastKey
+		sstable.level = level
+		c.ssTables[filename] = sstable
+		return sstable
+	}
+	return c, add
+}
+
+func setCompactionThresholds(t *testing.T, min, max int) {
+	oldMin, oldMax := config.MinCompactionThres, config.MaxCompactionThres
+	config.MinCompactionThres, config.MaxCompactionThres = min, max
+	t.Cleanup(func() { config.MinCompactionThres, config.MaxCompactionThres = oldMin, oldMax })
+}
+
+func assertTask(t *testing.T, name string, task *compactionTask, level int, sstables ...*SSTableReader) {
+	want := make([]string, 0)
+	for _, sstable := range sstables {
+		want = append(want, sstable.getFilename())
+	}
+	if task == nil {
+		t.Fatalf("%v: no compaction, want %v to level %v", name, want, level)
+	}
+	got := append([]string{}, task.files...)
+	sort.Strings(got)
+	sort.Strings(want)
+	if task.level != level || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("%v: compacts %v to level %v, want %v to level %v", name, got, task.level, want, level)
+	}
+}
+
+func TestLeveledCompactionLevelSelection(t *testing.T) {
+	setCompactionThresholds(t, 2, 32)
+	c, add := newLevelTestStore(t)
+	strategy := &leveledCompactionStrategy{}
+	levelSize := getLeveledSSTableSize()
+	l1a := add(1, "a", "c", levelSize*4)
+	l1b := add(1, "d", "f", levelSize*4)
+	if task := strategy.getNextCompaction(c); task != nil {
+		t.Fatalf("levels within their size compact %v", task.files)
+	}
+	// level 1 holds more than 10 sstables worth of data
+	l1c := add(1, "g", "i", levelSize*4)
+	l2a := add(2, "b", "e", levelSize)
+	add(2, "j", "k", levelSize)
+	assertTask(t, "full level 1", strategy.getNextCompaction(c), 2, l1a, l2a)
+	// the next sstable of the level takes its turn
+	assertTask(t, "round robin", strategy.getNextCompaction(c), 2, l1b, l2a)
+	assertTask(t, "round robin", strategy.getNextCompaction(c), 2, l1c)
+	// level 0 goes first once it has enough sstables
+	l0a := add(0, "h", "h", 10)
+	if task := strategy.getNextCompaction(c); task == nil || task.level != 2 {
+		t.Fatalf("a single sstable at level 0 is compacted")
+	}
+	l0b := add(0, "x", "y", 10)
+	assertTask(t, "level 0", strategy.getNextCompaction(c), 1, l0a, l0b, l1c)
+}
+
+func TestLeveledCompactionTakesSSTablesInTheGaps(t *testing.T) {
+	setCompactionThresholds(t, 2, 2)
+	c, add := newLevelTestStore(t)
+	strategy := &leveledCompactionStrategy{}
+	l0a := add(0, "b", "c", 10)
+	l0b := add(0, "m", "n", 10)
+	// lies between the two level 0 sstables
+	l1gap := add(1, "f", "g", 10)
+	// widens the range to p, which then takes in the newest
+	// sstable at level 0 and the level 1 sstable after it
+	l1widen := add(1, "n", "p", 10)
+	l0c := add(0, "o", "q", 10)
+	l1after := add(1, "q", "r", 10)
+	add(1, "s", "t", 10)
+	add(1, "a", "a", 10)
+	assertTask(t, "gaps", strategy.getNextCompaction(c), 1, l0a, l0b, l0c, l1gap, l1widen, l1after)
+}
+
+func TestSwitchingCompactionStrategyKeepsSSTablesReadable(t *testing.T) {
+	setCompactionThresholds(t, 2, 32)
+	cfMetaData := config.CFMetaData{CFName: "cf", ColumnType: "Standard"}
+	table := addTestTable(t, "compactiontest", cfMetaData)
+	cfStore := table.getColumnFamilyStore("cf")
+	keys := 0
+	writeSSTable := func() {
+		for i := 0; i < 10; i++ {
+			applyTestRow("compactiontest", "cf", fmt.Sprintf("key%v", keys), NewColumn("c", fmt.Sprint(keys), 1, false))
+			keys++
+		}
+		flushTestStore(cfStore)
+	}
+	assertReadable := func(name string) {
+		for i := 0; i < keys; i++ {
+			cf, err := readTestRow(cfStore, fmt.Sprintf("key%v", i))
+			if err != nil || cf == nil || cf.GetColumn("c") == nil || string(cf.GetColumn("c").getValue()) != fmt.Sprint(i) {
+				t.Fatalf("%v: key%v is read as %v, %v", name, i, cf, err)
+			}
+		}
+	}
+	for _, strategy := range []string{config.LeveledCompaction, config.SizeTieredCompaction, config.LeveledCompaction} {
+		writeSSTable()
+		writeSSTable()
+		cfMetaData.TableName = "compactiontest"
+		cfMetaData.IndexProperty = "Name"
+		cfMetaData.CompactionStrategy = strategy
+		if err := UpdateColumnFamily(cfMetaData); err != nil {
+			t.Fatal(err)
+		}
+		cfStore.doCompaction()
+		assertReadable(strategy)
+	}
+	for _, sstable := range cfStore.getSSTables() {
+		if sstable.level == 0 {
+			t.Errorf("%v is left at level 0 by leveled compaction", sstable.getFilename())
+		}
+	}
+}
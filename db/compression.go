@@ -0,0 +1,472 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// Compressed sstables store their data file as a sequence of
+// independently compressed chunks, each holding chunkLength bytes
+// of the uncompressed data (the last one may hold less). The chunk
+// offset map and the compressor name are kept in a separate
+// <cf>-<index>-CompressionInfo.db file:
+//  * compressor string
+//  * chunkLength int32
+//  * dataLength int64: size of the uncompressed data
+//  * compressedLength int64: size of the data file
+//  * chunkCount int32
+//  * chunkOffsets int64 * chunkCount
+// All positions handed out to the index and the iterators are
+// positions in the uncompressed data, so the layout of a row is
+// the same whether its sstable is compressed or not. An sstable
+// without a CompressionInfo file is not compressed.
+
+// compressor compresses whole chunks of an sstable data file
+type compressor interface {
+	compress(dst, src []byte) []byte
+	decompress(dst, src []byte) ([]byte, error)
+}
+
+type snappyCompressor struct{}
+
+func (c *snappyCompressor) compress(dst, src []byte) []byte {
+	return snappy.Encode(dst[:cap(dst)], src)
+}
+
+func (c *snappyCompressor) decompress(dst, src []byte) ([]byte, error) {
+	return snappy.Decode(dst[:cap(dst)], src)
+}
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+var (
+	zstdInstance *zstdCompressor
+	zstdOnce     sync.Once
+)
+
+func getZstdCompressor() *zstdCompressor {
+	zstdOnce.Do(func() {
+		c := &zstdCompressor{}
+		var err error
+		c.encoder, err = zstd.NewWriter(nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.decoder, err = zstd.NewReader(nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		zstdInstance = c
+	})
+	return zstdInstance
+}
+
+func (c *zstdCompressor) compress(dst, src []byte) []byte {
+	return c.encoder.EncodeAll(src, dst[:0])
+}
+
+func (c *zstdCompressor) decompress(dst, src []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(src, dst[:0])
+}
+
+// getCompressor returns the compressor called name,
+// nil stands for no compression
+func getCompressor(name string) (compressor, error) {
+	switch name {
+	case "", config.CompressionNone:
+		return nil, nil
+	case config.CompressionSnappy:
+		return &snappyCompressor{}, nil
+	case config.CompressionZstd:
+		return getZstdCompressor(), nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", name)
+}
+
+// compressionInfo is the chunk offset map of a compressed sstable
+type compressionInfo struct {
+	compressorName   string
+	compressor       compressor
+	chunkLength      int
+	dataLength       int64
+	compressedLength int64
+	chunkOffsets     []int64
+}
+
+func compressionInfoFilename(dataFile string) string {
+	// input: /var/storage/data/tableName/<cf>-<index>-Data.db
+	// output:/var/storage/data/tableName/<cf>-<index>-CompressionInfo.db
+	parts := strings.Split(dataFile, "-")
+	parts[len(parts)-1] = "CompressionInfo.db"
+	return strings.Join(parts, "-")
+}
+
+func (c *compressionInfo) write(filename string) {
+	buf := make([]byte, 0)
+	writeStringB(&buf, c.compressorName)
+	writeIntB(&buf, c.chunkLength)
+	writeInt64B(&buf, c.dataLength)
+	writeInt64B(&buf, c.compressedLength)
+	writeIntB(&buf, len(c.chunkOffsets))
+	for _, offset := range c.chunkOffsets {
+		writeInt64B(&buf, offset)
+	}
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = file.Write(buf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file.Sync()
+	file.Close()
+}
+
+// readCompressionInfo loads the chunk offset map of the sstable
+// with the given data file, it returns nil if the sstable is not
+// compressed
+func readCompressionInfo(dataFile string) (*compressionInfo, error) {
+	file, err := os.Open(compressionInfoFilename(dataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	c := &compressionInfo{}
+	c.compressorName, err = readStringB(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.compressor, err = getCompressor(c.compressorName)
+	if err != nil {
+		return nil, err
+	}
+	if c.compressor == nil {
+		return nil, fmt.Errorf("%v names no compressor", file.Name())
+	}
+	chunkLength, err := readInt32B(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.chunkLength = int(chunkLength)
+	c.dataLength, err = readInt64B(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.compressedLength, err = readInt64B(reader)
+	if err != nil {
+		return nil, err
+	}
+	chunkCount, err := readInt32B(reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.chunkLength <= 0 || chunkCount < 0 ||
+		int64(chunkCount) != (c.dataLength+int64(c.chunkLength)-1)/int64(c.chunkLength) {
+		return nil, fmt.Errorf("%v is corrupt", file.Name())
+	}
+	c.chunkOffsets = make([]int64, chunkCount)
+	for i := range c.chunkOffsets {
+		c.chunkOffsets[i], err = readInt64B(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// getChunkBounds returns where chunk i starts and ends in the data file
+func (c *compressionInfo) getChunkBounds(i int) (int64, int64) {
+	end := c.compressedLength
+	if i+1 < len(c.chunkOffsets) {
+		end = c.chunkOffsets[i+1]
+	}
+	return c.chunkOffsets[i], end
+}
+
+// getRatio returns the size of the data file relative to the
+// size of the data it holds
+func (c *compressionInfo) getRatio() float64 {
+	if c.dataLength == 0 {
+		return 1
+	}
+	return float64(c.compressedLength) / float64(c.dataLength)
+}
+
+// dataFileWriter writes the data file of a new sstable in chunks,
+// compressing them if the column family asks for compression and
+// recording the checksum of each chunk
+type dataFileWriter struct {
+	file *os.File
+	// chunk offset map, nil if the data is not compressed
+	info *compressionInfo
+	// checksums of the chunks written so far, the checksums of the
+	// other components are filled in by the sstable writer
+	checksums *checksumInfo
+	// uncompressed bytes not yet written as a chunk
+	buffer []byte
+	// bytes of uncompressed data written so far
+	position int64
+}
+
+func newDataFileWriter(filename, compression string) *dataFileWriter {
+	w := &dataFileWriter{}
+	var err error
+	w.file, err = os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := getCompressor(compression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chunkLength := config.CompressionChunkLengthInKB * 1024
+	if c != nil {
+		w.info = &compressionInfo{}
+		w.info.compressorName = compression
+		w.info.compressor = c
+		w.info.chunkLength = chunkLength
+		w.info.chunkOffsets = make([]int64, 0)
+	}
+	w.checksums = &checksumInfo{}
+	w.checksums.chunkLength = chunkLength
+	w.checksums.chunkChecksums = make([]uint32, 0)
+	w.buffer = make([]byte, 0, chunkLength)
+	return w
+}
+
+func (w *dataFileWriter) Write(p []byte) (int, error) {
+	w.position += int64(len(p))
+	w.buffer = append(w.buffer, p...)
+	chunkLength := w.checksums.chunkLength
+	for len(w.buffer) >= chunkLength {
+		w.writeChunk(w.buffer[:chunkLength])
+		w.buffer = append(w.buffer[:0], w.buffer[chunkLength:]...)
+	}
+	return len(p), nil
+}
+
+func (w *dataFileWriter) writeChunk(chunk []byte) {
+	if w.info != nil {
+		w.info.chunkOffsets = append(w.info.chunkOffsets, w.info.compressedLength)
+		chunk = w.info.compressor.compress(nil, chunk)
+		w.info.compressedLength += int64(len(chunk))
+	}
+	w.checksums.chunkChecksums = append(w.checksums.chunkChecksums, crc32.ChecksumIEEE(chunk))
+	_, err := w.file.Write(chunk)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// getFilePointer returns the position in the uncompressed data
+func (w *dataFileWriter) getFilePointer() int64 {
+	return w.position
+}
+
+// close writes the last chunk and the chunk offset map, and
+// syncs the data file to disk
+func (w *dataFileWriter) close() {
+	if len(w.buffer) > 0 {
+		w.writeChunk(w.buffer)
+		w.buffer = w.buffer[:0]
+	}
+	if w.info != nil {
+		w.info.dataLength = w.position
+		w.info.write(compressionInfoFilename(w.file.Name()))
+	}
+	w.file.Sync()
+	w.file.Close()
+}
+
+// dataInput is an sstable data file opened for reading. It reads
+// and seeks in the uncompressed data whether the file on disk is
+// compressed or not.
+type dataInput interface {
+	io.ReadSeeker
+	io.Closer
+	Name() string
+}
+
+// openDataInput opens the data file of sstable for reading
+func openDataInput(sstable *SSTableReader) (dataInput, error) {
+	file, err := os.Open(sstable.dataFileName)
+	if err != nil {
+		return nil, err
+	}
+	if sstable.compression == nil && sstable.checksums == nil {
+		return file, nil
+	}
+	return newChunkedDataInput(file, sstable.compression, sstable.checksums), nil
+}
+
+// chunkedDataInput reads a data file one chunk at a time, checking
+// the chunk against its checksum and decompressing it if the
+// sstable is compressed
+type chunkedDataInput struct {
+	file        *os.File
+	compression *compressionInfo
+	checksums   *checksumInfo
+	chunkLength int
+	dataLength  int64
+	position    int64
+	chunkIndex  int
+	chunk       []byte
+	compressed  []byte
+}
+
+func newChunkedDataInput(file *os.File, compression *compressionInfo, checksums *checksumInfo) *chunkedDataInput {
+	r := &chunkedDataInput{}
+	r.file = file
+	r.compression = compression
+	r.checksums = checksums
+	if compression != nil {
+		r.chunkLength = compression.chunkLength
+		r.dataLength = compression.dataLength
+	} else {
+		r.chunkLength = checksums.chunkLength
+		r.dataLength = getFileSize(file)
+	}
+	r.chunkIndex = -1
+	return r
+}
+
+func (r *chunkedDataInput) Read(p []byte) (int, error) {
+	if r.position >= r.dataLength {
+		return 0, io.EOF
+	}
+	i := int(r.position / int64(r.chunkLength))
+	if i != r.chunkIndex {
+		err := r.loadChunk(i)
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.chunk[r.position-int64(i)*int64(r.chunkLength):])
+	r.position += int64(n)
+	return n, nil
+}
+
+// getChunkCount returns the number of chunks of the data file
+func (r *chunkedDataInput) getChunkCount() int {
+	return int((r.dataLength + int64(r.chunkLength) - 1) / int64(r.chunkLength))
+}
+
+// getChunkBounds returns where chunk i starts and ends in the data file
+func (r *chunkedDataInput) getChunkBounds(i int) (int64, int64) {
+	if r.compression != nil {
+		return r.compression.getChunkBounds(i)
+	}
+	start := int64(i) * int64(r.chunkLength)
+	end := start + int64(r.chunkLength)
+	if end > r.dataLength {
+		end = r.dataLength
+	}
+	return start, end
+}
+
+func (r *chunkedDataInput) loadChunk(i int) error {
+	r.chunkIndex = -1
+	start, end := r.getChunkBounds(i)
+	region := fmt.Sprintf("chunk %v at data position %v", i, int64(i)*int64(r.chunkLength))
+	if end < start {
+		return r.corrupt(fmt.Errorf("%v: invalid bounds", region))
+	}
+	if int64(cap(r.compressed)) < end-start {
+		r.compressed = make([]byte, end-start)
+	}
+	r.compressed = r.compressed[:end-start]
+	_, err := r.file.ReadAt(r.compressed, start)
+	if err != nil {
+		return r.corrupt(fmt.Errorf("%v: %v", region, err))
+	}
+	if r.checksums != nil {
+		if i >= len(r.checksums.chunkChecksums) {
+			return r.corrupt(fmt.Errorf("%v: no checksum", region))
+		}
+		if crc32.ChecksumIEEE(r.compressed) != r.checksums.chunkChecksums[i] {
+			return r.corrupt(fmt.Errorf("%v: %v", region, errSSTableChecksum))
+		}
+	}
+	if r.compression != nil {
+		r.chunk, err = r.compression.compressor.decompress(r.chunk, r.compressed)
+		if err != nil {
+			return r.corrupt(fmt.Errorf("%v: cannot decompress: %v", region, err))
+		}
+	} else {
+		r.chunk = append(r.chunk[:0], r.compressed...)
+	}
+	expected := int64(r.chunkLength)
+	if rest := r.dataLength - int64(i)*expected; rest < expected {
+		expected = rest
+	}
+	if int64(len(r.chunk)) != expected {
+		return r.corrupt(fmt.Errorf("%v: has %v bytes, expected %v", region, len(r.chunk), expected))
+	}
+	r.chunkIndex = i
+	return nil
+}
+
+func (r *chunkedDataInput) corrupt(err error) error {
+	return &CorruptSSTableError{File: r.file.Name(), Err: err}
+}
+
+func (r *chunkedDataInput) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.position
+	case io.SeekEnd:
+		offset += r.dataLength
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.position = offset
+	return offset, nil
+}
+
+func (r *chunkedDataInput) Close() error {
+	return r.file.Close()
+}
+
+func (r *chunkedDataInput) Name() string {
+	return r.file.Name()
+}
+
+// getDataLength returns the length of the uncompressed data of input
+func getDataLength(input io.Seeker) int64 {
+	current := getCurrentPos(input)
+	size, err := input.Seek(0, io.SeekEnd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	input.Seek(current, io.SeekStart)
+	return size
+}
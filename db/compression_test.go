@@ -0,0 +1,135 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// writeDataFile writes data to a new data file below dir in pieces
+// of different sizes and opens it for reading again
+func writeDataFile(t *testing.T, dir, compression string, data []byte) *chunkedDataInput {
+	dir, err := ioutil.TempDir(dir, compression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "cf-1-Data.db")
+	w := newDataFileWriter(filename, compression)
+	for rest, n := data, 1; len(rest) > 0; n = n*3 + 1 {
+		if n > len(rest) {
+			n = len(rest)
+		}
+		w.Write(rest[:n])
+		rest = rest[n:]
+	}
+	if w.getFilePointer() != int64(len(data)) {
+		t.Fatalf("%v: file pointer is %v after %v bytes", compression, w.getFilePointer(), len(data))
+	}
+	w.close()
+	info, err := readCompressionInfo(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (info == nil) != (compression == config.CompressionNone) {
+		t.Fatalf("%v: compression info is %v", compression, info)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newChunkedDataInput(file, info, w.checksums)
+}
+
+func TestCompressedDataFileRoundTrip(t *testing.T) {
+	defer func(chunkLength int) { config.CompressionChunkLengthInKB = chunkLength }(config.CompressionChunkLengthInKB)
+	config.CompressionChunkLengthInKB = 1
+	dir, err := ioutil.TempDir("", "compression")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// compressible text followed by random bytes, not a whole number of chunks
+	data := bytes.Repeat([]byte("mongongo "), 500)
+	random := make([]byte, 3000)
+	rand.New(rand.NewSource(1)).Read(random)
+	data = append(data, random...)
+	for _, compression := range []string{config.CompressionNone, config.CompressionSnappy, config.CompressionZstd} {
+		input := writeDataFile(t, dir, compression, data)
+		got, err := ioutil.ReadAll(input)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%v: read back %v bytes, %v, want the %v bytes written", compression, len(got), err, len(data))
+		}
+		if input.getChunkCount() != (len(data)+1023)/1024 {
+			t.Errorf("%v: %v chunks for %v bytes", compression, input.getChunkCount(), len(data))
+		}
+		// positions are positions in the uncompressed data
+		for _, position := range []int64{0, 1023, 1024, 4000, int64(len(data)) - 1} {
+			input.Seek(position, io.SeekStart)
+			b := make([]byte, 1)
+			if _, err := io.ReadFull(input, b); err != nil || b[0] != data[position] {
+				t.Errorf("%v: byte at %v is %v, %v, want %v", compression, position, b[0], err, data[position])
+			}
+		}
+		if getDataLength(input) != int64(len(data)) {
+			t.Errorf("%v: data length is %v, want %v", compression, getDataLength(input), len(data))
+		}
+		input.Close()
+	}
+}
+
+func TestCompressedDataFileCorruption(t *testing.T) {
+	defer func(chunkLength int) { config.CompressionChunkLengthInKB = chunkLength }(config.CompressionChunkLengthInKB)
+	config.CompressionChunkLengthInKB = 1
+	dir, err := ioutil.TempDir("", "compression")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := bytes.Repeat([]byte("0123456789"), 300)
+	for _, compression := range []string{config.CompressionNone, config.CompressionSnappy, config.CompressionZstd} {
+		input := writeDataFile(t, dir, compression, data)
+		// flip a bit of the second chunk on disk
+		start, _ := input.getChunkBounds(1)
+		b := make([]byte, 1)
+		input.file.ReadAt(b, start)
+		file, err := os.OpenFile(input.Name(), os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.WriteAt([]byte{b[0] ^ 1}, start)
+		file.Close()
+		got := make([]byte, 1024)
+		if _, err := io.ReadFull(input, got); err != nil || !bytes.Equal(got, data[:1024]) {
+			t.Errorf("%v: the first chunk can't be read: %v", compression, err)
+		}
+		_, err = io.ReadFull(input, got)
+		var corrupt *CorruptSSTableError
+		if !errors.As(err, &corrupt) || corrupt.File != input.Name() {
+			t.Errorf("%v: a corrupt chunk gives %v", compression, err)
+		}
+		input.Close()
+	}
+}
+
+func TestGetCompressor(t *testing.T) {
+	for _, name := range []string{"", config.CompressionNone} {
+		if c, err := getCompressor(name); c != nil || err != nil {
+			t.Errorf("compression %q gives %v, %v", name, c, err)
+		}
+	}
+	if _, err := getCompressor("gzip"); err == nil {
+		t.Errorf("an unknown compression is accepted")
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// CounterShard is the part of a counter one replica is responsible for
+type CounterShard struct {
+	Count int64 `json:"count"`
+	// Clock orders the versions of a shard, the higher one wins
+	Clock int64 `json:"clock"`
+}
+
+// CounterColumn is a column of a counter column family. Every node
+// that coordinated an increment of the counter owns a shard of it,
+// which no other node changes, and the value of the counter is the
+// sum of the shards. Two versions of a counter are reconciled by
+// keeping the newest version of every shard, so increments are never
+// lost to a higher timestamp like the values of plain columns are.
+type CounterColumn struct {
+	Name string
+	// Timestamp is when the counter last changed, a tombstone
+	// newer than it deletes the counter
+	Timestamp int64
+	// Shards maps the ids of nodes to their shards
+	Shards map[string]CounterShard
+}
+
+// NewCounterColumn constructs a CounterColumn
+func NewCounterColumn(name string, timestamp int64, shards map[string]CounterShard) CounterColumn {
+	c := CounterColumn{}
+	c.Name = name
+	c.Timestamp = timestamp
+	c.Shards = shards
+	return c
+}
+
+// total returns the value of the counter
+func (c CounterColumn) total() int64 {
+	res := int64(0)
+	for _, shard := range c.Shards {
+		res += shard.Count
+	}
+	return res
+}
+
+// reconcile merges two versions of a counter. A new map is
+// built as both versions may still be read by others.
+func (c CounterColumn) reconcile(o CounterColumn) CounterColumn {
+	shards := make(map[string]CounterShard, len(c.Shards))
+	for id, shard := range c.Shards {
+		shards[id] = shard
+	}
+	for id, shard := range o.Shards {
+		if old, ok := shards[id]; !ok || old.Clock < shard.Clock {
+			shards[id] = shard
+		}
+	}
+	timestamp := c.Timestamp
+	if o.Timestamp > timestamp {
+		timestamp = o.Timestamp
+	}
+	return NewCounterColumn(c.Name, timestamp, shards)
+}
+
+func (c CounterColumn) getSortedShardIDs() []string {
+	ids := make([]string, 0, len(c.Shards))
+	for id := range c.Shards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (c CounterColumn) addColumn(column IColumn) {
+	log.Printf("Invalid method: CounterColumn doesn't support addColumn\n")
+}
+
+func (c CounterColumn) getName() string {
+	return c.Name
+}
+
+// GetName ...
+func (c CounterColumn) GetName() string {
+	return c.Name
+}
+
+func (c CounterColumn) getSize() int32 {
+	// 4 bytes for name length, # bytes for name, 8 bytes for
+	// timestamp, then for every shard 4 bytes for id length,
+	// # bytes for id, 8 bytes for count and 8 bytes for clock
+	size := 4 + 8 + len(c.Name)
+	for id := range c.Shards {
+		size += 4 + len(id) + 8 + 8
+	}
+	return int32(size)
+}
+
+func (c CounterColumn) toByteArray() []byte {
+	buf := make([]byte, 0)
+	CSerializer.serializeB(c, &buf)
+	return buf
+}
+
+func (c CounterColumn) serializedSize() uint32 {
+	// 4 bytes: length of column name
+	// # bytes: column name bytes
+	// 1 byte:  flags
+	// 8 bytes: timestamp
+	// 4 bytes: number of shards
+	// for each shard 4 bytes of id length, # bytes of id,
+	// 8 bytes of count and 8 bytes of clock
+	size := 4 + 1 + 8 + 4 + len(c.Name)
+	for id := range c.Shards {
+		size += 4 + len(id) + 8 + 8
+	}
+	return uint32(size)
+}
+
+func (c CounterColumn) getObjectCount() int {
+	return 1
+}
+
+func (c CounterColumn) timestamp() int64 {
+	return c.Timestamp
+}
+
+// GetTimestamp ...
+func (c CounterColumn) GetTimestamp() int64 {
+	return c.Timestamp
+}
+
+func (c CounterColumn) putColumn(column IColumn) bool {
+	log.Fatal("CounterColumn doesn't support putColumn")
+	return false
+}
+
+func (c CounterColumn) getSubColumns() map[string]IColumn {
+	log.Fatal("This operation is not supported on counter columns")
+	return nil
+}
+
+// GetSubColumns ...
+func (c CounterColumn) GetSubColumns() map[string]IColumn {
+	log.Fatal("This operation is not supported on counter columns")
+	return nil
+}
+
+// counters are deleted by tombstones, which are plain columns
+func (c CounterColumn) isMarkedForDelete() bool {
+	return false
+}
+
+// IsMarkedForDelete ...
+func (c CounterColumn) IsMarkedForDelete() bool {
+	return false
+}
+
+// getValue returns the value of the counter as a decimal string
+func (c CounterColumn) getValue() []byte {
+	return []byte(strconv.FormatInt(c.total(), 10))
+}
+
+// GetValue ...
+func (c CounterColumn) GetValue() []byte {
+	return c.getValue()
+}
+
+func (c CounterColumn) getMarkedForDeleteAt() int64 {
+	log.Fatal("counter column is not marked for delete")
+	return 0
+}
+
+func (c CounterColumn) getLocalDeletionTime() int {
+	return 0
+}
+
+func (c CounterColumn) mostRecentChangeAt() int64 {
+	return c.Timestamp
+}
+
+// reconcileColumns returns the version of a column to keep when
+// two versions of it meet. Counters are merged, and a counter and
+// a tombstone are resolved by timestamp like plain columns.
+func reconcileColumns(oldColumn, column IColumn) IColumn {
+	oldCounter, oldIsCounter := oldColumn.(CounterColumn)
+	counter, isCounter := column.(CounterColumn)
+	if oldIsCounter && isCounter {
+		return oldCounter.reconcile(counter)
+	}
+	if oldIsCounter || isCounter {
+		// tombstone always wins ties
+		if oldColumn.timestamp() < column.timestamp() ||
+			oldColumn.timestamp() == column.timestamp() && column.isMarkedForDelete() {
+			return column
+		}
+		return oldColumn
+	}
+	if oldColumn.(Column).comparePriority(column.(Column)) <= 0 {
+		return column
+	}
+	return oldColumn
+}
+
+// The counter of a column is serialized with the counterMask flag
+// set, followed by the timestamp, the number of shards and the
+// shards sorted by id, each as id, count and clock.
+
+func serializeCounter(c CounterColumn, dos io.Writer) {
+	writeString(dos, c.Name)
+	writeByte(dos, counterMask)
+	writeInt64(dos, c.Timestamp)
+	writeInt(dos, len(c.Shards))
+	for _, id := range c.getSortedShardIDs() {
+		writeString(dos, id)
+		writeInt64(dos, c.Shards[id].Count)
+		writeInt64(dos, c.Shards[id].Clock)
+	}
+}
+
+func serializeCounterB(c CounterColumn, dos *[]byte) {
+	writeStringB(dos, c.Name)
+	writeByteB(dos, counterMask)
+	writeInt64B(dos, c.Timestamp)
+	writeIntB(dos, len(c.Shards))
+	for _, id := range c.getSortedShardIDs() {
+		writeStringB(dos, id)
+		writeInt64B(dos, c.Shards[id].Count)
+		writeInt64B(dos, c.Shards[id].Clock)
+	}
+}
+
+// deserializeCounterB reads a counter whose name and flags were read
+func deserializeCounterB(name string, dis io.Reader) (IColumn, error) {
+	timestamp, err := readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	count, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("invalid counter shard count %v", count)
+	}
+	shards := make(map[string]CounterShard, count)
+	for i := int32(0); i < count; i++ {
+		id, err := readStringB(dis)
+		if err != nil {
+			return nil, err
+		}
+		shard := CounterShard{}
+		shard.Count, err = readInt64B(dis)
+		if err != nil {
+			return nil, err
+		}
+		shard.Clock, err = readInt64B(dis)
+		if err != nil {
+			return nil, err
+		}
+		shards[id] = shard
+	}
+	return NewCounterColumn(name, timestamp, shards), nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestCounterReconcile(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, new   map[string]CounterShard
+		wantShards map[string]CounterShard
+		wantTotal  int64
+	}{
+		{
+			"same node with a newer clock",
+			map[string]CounterShard{"a": {3, 1}},
+			map[string]CounterShard{"a": {5, 2}},
+			map[string]CounterShard{"a": {5, 2}},
+			5,
+		},
+		{
+			"same node with an older clock",
+			map[string]CounterShard{"a": {5, 2}},
+			map[string]CounterShard{"a": {3, 1}},
+			map[string]CounterShard{"a": {5, 2}},
+			5,
+		},
+		{
+			"different nodes summed",
+			map[string]CounterShard{"a": {3, 1}},
+			map[string]CounterShard{"b": {4, 1}},
+			map[string]CounterShard{"a": {3, 1}, "b": {4, 1}},
+			7,
+		},
+		{
+			"shards merged one by one",
+			map[string]CounterShard{"a": {3, 2}, "b": {4, 1}},
+			map[string]CounterShard{"a": {1, 1}, "b": {-2, 2}, "c": {6, 1}},
+			map[string]CounterShard{"a": {3, 2}, "b": {-2, 2}, "c": {6, 1}},
+			7,
+		},
+	}
+	for _, test := range tests {
+		old := NewCounterColumn("c", 1, test.old)
+		column := NewCounterColumn("c", 2, test.new)
+		for _, order := range [][2]CounterColumn{{old, column}, {column, old}} {
+			got := order[0].reconcile(order[1])
+			if !reflect.DeepEqual(got.Shards, test.wantShards) {
+				t.Errorf("%v: shards = %v, want %v", test.name, got.Shards, test.wantShards)
+			}
+			if got.total() != test.wantTotal {
+				t.Errorf("%v: total = %v, want %v", test.name, got.total(), test.wantTotal)
+			}
+			if got.Timestamp != 2 {
+				t.Errorf("%v: timestamp = %v, want 2", test.name, got.Timestamp)
+			}
+		}
+		if len(old.Shards) != len(test.old) {
+			t.Errorf("%v: reconcile changed the shards of its receiver", test.name)
+		}
+	}
+}
+
+func TestReconcileCounterWithTombstone(t *testing.T) {
+	counter := func(timestamp int64) IColumn {
+		return NewCounterColumn("c", timestamp, map[string]CounterShard{"a": {5, 1}})
+	}
+	tombstone := func(timestamp int64) IColumn {
+		return NewColumn("c", deletionTime(1600000000), timestamp, true)
+	}
+	tests := []struct {
+		name          string
+		old, column   IColumn
+		wantTombstone bool
+	}{
+		{"increment after the deletion", tombstone(10), counter(20), false},
+		{"deletion after the increment", counter(10), tombstone(20), true},
+		{"deletion before the increment", counter(20), tombstone(10), false},
+		{"increment before the deletion", tombstone(20), counter(10), true},
+		{"tie won by the tombstone", counter(10), tombstone(10), true},
+		{"tie won by the old tombstone", tombstone(10), counter(10), true},
+	}
+	for _, test := range tests {
+		got := reconcileColumns(test.old, test.column)
+		if got.isMarkedForDelete() != test.wantTombstone {
+			t.Errorf("%v: got %#v, want tombstone = %v", test.name, got, test.wantTombstone)
+		}
+	}
+}
+
+func TestApplyCounterMutations(t *testing.T) {
+	table := addTestTable(t, "CounterTable",
+		config.CFMetaData{CFName: "Counters", ColumnType: "Standard", Counter: true})
+	cfStore := table.getColumnFamilyStore("Counters")
+	path := NewQueryPath("Counters", nil, []byte("c"))
+	applyShard := func(key, id string, shard CounterShard, timestamp int64) {
+		rm := NewRowMutation("CounterTable", key)
+		cf := createColumnFamily("CounterTable", "Counters")
+		cf.addColumn(NewCounterColumn("c", timestamp, map[string]CounterShard{id: shard}))
+		rm.AddCF(cf)
+		rm.ApplyE()
+	}
+	read := func(key string) (IColumn, bool) {
+		cf, err := readTestRow(cfStore, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cf == nil {
+			return nil, false
+		}
+		column := cf.GetColumn("c")
+		return column, column != nil
+	}
+	total := func(key string) int64 {
+		column, ok := read(key)
+		if !ok {
+			t.Fatalf("counter of %v not found", key)
+		}
+		counter, ok := column.(CounterColumn)
+		if !ok {
+			t.Fatalf("counter of %v is %#v", key, column)
+		}
+		return counter.total()
+	}
+
+	// shards of other nodes, as sent by their leaders
+	applyShard("k1", "a", CounterShard{3, 1}, 1)
+	applyShard("k1", "a", CounterShard{5, 2}, 2)
+	applyShard("k1", "a", CounterShard{4, 1}, 3) // late, older clock
+	applyShard("k1", "b", CounterShard{7, 1}, 4)
+	if got := total("k1"); got != 12 {
+		t.Errorf("k1 = %v, want 12", got)
+	}
+
+	// increments led by this node add to its own shard
+	for _, delta := range []int64{2, 3, -1} {
+		if _, err := ApplyCounterAdd("CounterTable", "k2", path, delta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := total("k2"); got != 4 {
+		t.Errorf("k2 = %v, want 4", got)
+	}
+
+	// a deletion drops the counter, an increment after it
+	// starts the counter again from zero. The deletion is dated
+	// back as a tombstone wins a tie with the increment.
+	applyShard("k3", "a", CounterShard{5, 1}, 1)
+	rm := NewRowMutation("CounterTable", "k3")
+	rm.Delete(path, getCurrentTimeInMillis()-1)
+	rm.ApplyE()
+	if column, ok := read("k3"); ok && !column.isMarkedForDelete() {
+		t.Fatalf("k3 = %#v after the deletion", column)
+	}
+	if _, err := ApplyCounterAdd("CounterTable", "k3", path, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := total("k3"); got != 2 {
+		t.Errorf("k3 = %v after the increment, want 2", got)
+	}
+
+	// a stale shard older than the deletion stays deleted
+	applyShard("k4", "a", CounterShard{5, 1}, 1)
+	rm = NewRowMutation("CounterTable", "k4")
+	rm.Delete(path, 10)
+	rm.ApplyE()
+	applyShard("k4", "b", CounterShard{3, 1}, 5)
+	if column, ok := read("k4"); ok && !column.isMarkedForDelete() {
+		t.Errorf("k4 = %#v, want the tombstone to win", column)
+	}
+
+	if _, err := ApplyCounterAdd("CounterTable", "k5", NewQueryPath("Counters", nil, nil), 1); err == nil {
+		t.Errorf("adding to a counter without a column name should fail")
+	}
+}
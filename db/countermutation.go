@@ -0,0 +1,127 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// CounterAddArgs asks the node owning a shard to add to a counter
+type CounterAddArgs struct {
+	Table string
+	Key   string
+	Path  QueryPath
+	Delta int64
+	// ConsistencyLevel is the number of replicas the new
+	// shard must reach before the add returns
+	ConsistencyLevel int
+}
+
+// CounterAddReply ...
+type CounterAddReply struct {
+	// RM holds the new shard, to be sent to the other replicas
+	RM RowMutation
+}
+
+// DoCounterAdd adds to the shard of this node of a counter
+func DoCounterAdd(args *CounterAddArgs, reply *CounterAddReply) error {
+	rm, err := ApplyCounterAdd(args.Table, args.Key, &args.Path, args.Delta)
+	if err != nil {
+		return err
+	}
+	reply.RM = *rm
+	return nil
+}
+
+// counterMu serializes the read-modify-write of the shards of
+// this node, see ApplyCounterAdd
+var counterMu sync.Mutex
+
+// getCounterNodeID returns the id of the shards owned by this node,
+// the token it saved in the system table when it first started in hex
+func getCounterNodeID() string {
+	return hex.EncodeToString([]byte(sysInitMetadata().StorageID))
+}
+
+// ApplyCounterAdd adds delta to the shard of this node of the counter
+// at path. It returns the mutation holding the new version of the
+// shard, which the other replicas merge into their copies of the
+// counter. Only one node changes a shard, so the read of the old
+// value cannot race with another node.
+func ApplyCounterAdd(tableName, key string, path *QueryPath, delta int64) (*RowMutation, error) {
+	cfMetaData, ok := config.GetCFMetaData(tableName, path.ColumnFamilyName)
+	if !ok {
+		return nil, fmt.Errorf("column family %v.%v does not exist", tableName, path.ColumnFamilyName)
+	}
+	if !cfMetaData.Counter {
+		return nil, fmt.Errorf("column family %v.%v is not a counter column family", tableName, path.ColumnFamilyName)
+	}
+	if path.ColumnName == nil {
+		return nil, fmt.Errorf("counter path has no column name")
+	}
+	if cfMetaData.ColumnType == "Super" && path.SuperColumnName == nil {
+		return nil, fmt.Errorf("counter path has no super column name")
+	}
+	if cfMetaData.ColumnType != "Super" && path.SuperColumnName != nil {
+		return nil, fmt.Errorf("standard column family %v has no super columns", path.ColumnFamilyName)
+	}
+	cfStore, err := lookupColumnFamilyStore(tableName, path.ColumnFamilyName)
+	if err != nil {
+		return nil, err
+	}
+	counterMu.Lock()
+	defer counterMu.Unlock()
+	id := getCounterNodeID()
+	shard := CounterShard{}
+	cf, err := cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(path.ColumnFamilyName)))
+	if err != nil {
+		return nil, err
+	}
+	if old, ok := findCounter(cf, path); ok {
+		shard = old.Shards[id]
+	}
+	shard.Count += delta
+	// the clock also moves with time, so that a shard written
+	// after the counter was deleted beats the deleted shard
+	shard.Clock++
+	if now := time.Now().UnixNano() / int64(time.Microsecond); now > shard.Clock {
+		shard.Clock = now
+	}
+	column := NewCounterColumn(string(path.ColumnName), getCurrentTimeInMillis(),
+		map[string]CounterShard{id: shard})
+	columnFamily := createColumnFamily(tableName, path.ColumnFamilyName)
+	if path.SuperColumnName == nil {
+		columnFamily.addColumn(column)
+	} else {
+		sc := NewSuperColumn(string(path.SuperColumnName))
+		sc.addColumn(column)
+		columnFamily.addColumn(sc)
+	}
+	rm := NewRowMutation(tableName, key)
+	rm.AddCF(columnFamily)
+	rm.ApplyE()
+	return &rm, nil
+}
+
+// findCounter looks up the counter at path in cf
+func findCounter(cf *ColumnFamily, path *QueryPath) (CounterColumn, bool) {
+	if cf == nil {
+		return CounterColumn{}, false
+	}
+	var column IColumn
+	if path.SuperColumnName == nil {
+		column = cf.GetColumn(string(path.ColumnName))
+	} else if sc, ok := cf.GetColumn(string(path.SuperColumnName)).(SuperColumn); ok {
+		column = sc.Columns[string(path.ColumnName)]
+	}
+	counter, ok := column.(CounterColumn)
+	return counter, ok
+}
@@ -8,11 +8,10 @@ package db
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"sync"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/dht"
 	"github.com/DistAlchemist/Mongongo/network"
@@ -47,11 +46,8 @@ func GetManagerInstance() *Manager {
 func (d *Manager) init() {
 	// read the config file
 	tableToColumnFamily := config.Init()
-	storeMetadata(tableToColumnFamily)
-	for table := range tableToColumnFamily {
-		tbl := OpenTable(table)
-		tbl.onStart()
-	}
+	// assign column family ids and open the tables
+	initSchema(tableToColumnFamily)
 	recoveryMgr := GetRecoveryManager()
 	recoveryMgr.doRecovery()
 	// config.Init()
@@ -59,13 +55,29 @@ func (d *Manager) init() {
 
 }
 
+// storeMetadata hands out column family ids starting at nextCFID.
+// Tables and column families are visited in sorted order so the
+// ids do not depend on map iteration order.
 func storeMetadata(tableToColumnFamily map[string]map[string]config.CFMetaData) {
-	cfID := 0
-	for table, columnFamilies := range tableToColumnFamily {
+	tables := make([]string, 0, len(tableToColumnFamily))
+	for table := range tableToColumnFamily {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
 		tmetadata := getTableMetadataInstance(table)
-		for columnFamily := range columnFamilies {
-			tmetadata.Add(columnFamily, cfID, config.GetColumnTypeTableName(table, columnFamily))
-			cfID++
+		columnFamilies := make([]string, 0, len(tableToColumnFamily[table]))
+		for columnFamily := range tableToColumnFamily[table] {
+			columnFamilies = append(columnFamilies, columnFamily)
+		}
+		sort.Strings(columnFamilies)
+		for _, columnFamily := range columnFamilies {
+			tmetadata.Add(columnFamily, nextCFID, config.GetColumnTypeTableName(table, columnFamily))
+			nextCFID++
+			for _, column := range tableToColumnFamily[table][columnFamily].IndexedColumns {
+				tmetadata.Add(config.IndexCFName(columnFamily, column), nextCFID, "Standard")
+				nextCFID++
+			}
 		}
 	}
 }
@@ -111,7 +123,10 @@ func sysInitMetadata() *StorageMetadata {
 	// and the generation
 	table := OpenTable(config.SysTableName)
 	filter := NewIdentityQueryFilter(sysLocationKey, NewQueryPathCF(sysLocationCF))
-	cf := table.getColumnFamilyStore(sysLocationCF).getColumnFamily(filter)
+	cf, err := table.getColumnFamilyStore(sysLocationCF).getColumnFamily(filter)
+	if err != nil {
+		log.Fatalf("cannot read the saved token: %v\n", err)
+	}
 	p := dht.RandomPartInstance // hard code here
 	if cf == nil {
 		token := p.GetDefaultToken()
@@ -177,7 +192,6 @@ type RowMutationReply struct {
 func DoRowMutation(args *RowMutationArgs, reply *RowMutationReply) error {
 	utils.LoggerInstance().Printf("enter db.DoRowMutation\n")
 	log.Printf("enter db.DoRowMutation\n")
-	spew.Printf("args: %+v\n", args)
 	rm := args.RM
 	if args.HeaderKey == HINT {
 		hint := args.HeaderValue
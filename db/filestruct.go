@@ -15,42 +15,51 @@ type FileStruct struct {
 	key       string
 	row       *IteratingRow
 	exhausted bool
-	file      *os.File
+	file      dataInput
 	sstable   *SSTableReader
 }
 
 // NewFileStruct ...
-func NewFileStruct(s *SSTableReader) *FileStruct {
+func NewFileStruct(s *SSTableReader) (*FileStruct, error) {
 	f := &FileStruct{}
 	f.exhausted = false
 	var err error
-	f.file, err = os.Open(s.dataFileName)
+	f.file, err = openDataInput(s)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	f.sstable = s
-	return f
+	return f, nil
 }
 
-func (f *FileStruct) advance(materialize bool) {
+func (f *FileStruct) advance(materialize bool) error {
 	// Read the next key from the data file.
 	if f.exhausted {
 		log.Fatal("index out of bounds!")
 	}
-	if getCurrentPos(f.file) == getFileSize(f.file) {
+	if getCurrentPos(f.file) == getDataLength(f.file) {
 		f.file.Close()
 		f.exhausted = true
-		return
+		return nil
+	}
+	var err error
+	f.row, err = NewIteratingRow(f.file, f.sstable)
+	if err != nil {
+		return newCorruptSSTableError(f.sstable.dataFileName, err)
 	}
-	f.row = NewIteratingRow(f.file, f.sstable)
+	f.key = f.row.key
 	if materialize {
 		for f.row.hasNext() {
-			column := f.row.next()
+			column, err := f.row.next()
+			if err != nil {
+				return newCorruptSSTableError(f.sstable.dataFileName, err)
+			}
 			f.row.getEmptyColumnFamily().addColumn(column)
 		}
 	} else {
 		f.row.skipRemaining()
 	}
+	return nil
 }
 
 func (f *FileStruct) getFileName() string {
@@ -70,6 +79,7 @@ func getFileSizeFromName(name string) int64 {
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer file.Close()
 	return getFileSize(file)
 }
 
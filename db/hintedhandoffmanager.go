@@ -11,6 +11,7 @@ import (
 	"math"
 	"net/rpc"
 	"sync"
+	"time"
 
 	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/gms"
@@ -23,52 +24,90 @@ var (
 	hmu          sync.Mutex
 )
 
-// HintedHandOffManager ...
-type HintedHandOffManager struct{}
+// HintedHandOffManager replays the writes this node kept for
+// replicas that were down. A hint is a column in the hints
+// column family of the system table, in the row of the table
+// the write went to: its super column is the key written and
+// its sub column the host the write was meant for.
+type HintedHandOffManager struct {
+	mu sync.Mutex
+	// delivering are the hosts hints are being sent to
+	delivering map[string]bool
+}
 
 // GetHintedHandOffManagerInstance ...
 func GetHintedHandOffManagerInstance() *HintedHandOffManager {
+	hmu.Lock()
+	defer hmu.Unlock()
 	if HHOMInstance == nil {
-		hmu.Lock()
-		defer hmu.Unlock()
 		HHOMInstance = &HintedHandOffManager{}
+		HHOMInstance.delivering = make(map[string]bool)
 	}
 	return HHOMInstance
 }
 
-// DeliverHintsToEndpoint ...
+// DeliverHintsToEndpoint sends the rows hinted for endpoint to it,
+// pausing HintedHandoffThrottleInMillis between two rows. A hint is
+// deleted once its row is delivered, and the row itself once no
+// other host is hinted for it. It stops at the first row that cannot
+// be delivered, the remaining hints are kept for the next time.
 func DeliverHintsToEndpoint(endpoint *network.EndPoint) {
 	log.Printf("started hinted handoff for endpoint %v\n", endpoint.HostName)
-	targetEPBytes := endpoint.HostName
 	// 1. scan through all the keys that we need to handoff
 	// 2. for each key read the list of recipients if the endpoint matches send
-	// 3. delete that recipient from theke if write was successful
+	// 3. delete that recipient from the key if write was successful
 	systemTable := OpenTable(config.SysTableName)
+	delivered := 0
 	for _, tableName := range config.GetTables() {
-		hintedColumnFamily := systemTable.getCF(tableName, config.HintsCF)
+		hints, err := systemTable.getCF(tableName, config.HintsCF)
+		if err != nil {
+			log.Printf("stopped hinted handoff for endpoint %v, cannot read the hints of %v: %v\n",
+				endpoint.HostName, tableName, err)
+			return
+		}
+		hintedColumnFamily := removeDeleted(hints, math.MaxInt32)
 		if hintedColumnFamily == nil {
 			continue
 		}
-		keys := hintedColumnFamily.GetSortedColumns()
-		for _, keyColumn := range keys {
+		for _, keyColumn := range hintedColumnFamily.GetSortedColumns() {
 			keyStr := keyColumn.getName()
 			endpoints := keyColumn.GetSubColumns()
-			for _, hintEndPoint := range endpoints {
-				if hintEndPoint.getName() == targetEPBytes && sendMessage(endpoint.HostName, "", keyStr) {
-					deleteEndPoint(hintEndPoint.getName(), tableName, keyColumn.getName(), keyColumn.timestamp())
-					if len(endpoints) == 1 {
-						deleteHintedData(tableName, keyStr)
-					}
-				}
+			hintEndPoint, ok := endpoints[endpoint.HostName]
+			if !ok {
+				continue
+			}
+			if !sendMessage(endpoint.HostName, tableName, keyStr) {
+				log.Printf("stopped hinted handoff for endpoint %v after %v rows\n",
+					endpoint.HostName, delivered)
+				return
 			}
+			deleteEndPoint(endpoint.HostName, tableName, keyStr, hintEndPoint.timestamp())
+			if len(endpoints) == 1 {
+				deleteHintedData(tableName, keyStr)
+			}
+			delivered++
+			throttleHintedHandoff()
 		}
 	}
-	log.Printf("finished hinted handoff for endpoint %v\n", endpoint.HostName)
+	log.Printf("finished hinted handoff for endpoint %v, delivered %v rows\n",
+		endpoint.HostName, delivered)
 }
 
-// DeliverHints ...
+// DeliverHints replays the hints for to in the background,
+// unless they are already being sent
 func (h *HintedHandOffManager) DeliverHints(to *network.EndPoint) {
-	go DeliverHintsToEndpoint(to)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.delivering[to.HostName] {
+		return
+	}
+	h.delivering[to.HostName] = true
+	go func() {
+		DeliverHintsToEndpoint(to)
+		h.mu.Lock()
+		delete(h.delivering, to.HostName)
+		h.mu.Unlock()
+	}()
 }
 
 func (h *HintedHandOffManager) submit(columnFamilyStore *ColumnFamilyStore) {
@@ -84,7 +123,12 @@ func (h *HintedHandOffManager) deliverAllHints(hintStore *ColumnFamilyStore) {
 	// 5. Now force a flush
 	// 6. Do major compaction to clean up all deletes etc.
 	for _, tableName := range config.GetTables() {
-		hintColumnFamily := removeDeleted(hintStore.getColumnFamily(NewIdentityQueryFilter(tableName, NewQueryPathCF(config.HintsCF))), math.MaxInt32)
+		hints, err := hintStore.getColumnFamily(NewIdentityQueryFilter(tableName, NewQueryPathCF(config.HintsCF)))
+		if err != nil {
+			log.Printf("cannot read the hints of %v: %v\n", tableName, err)
+			continue
+		}
+		hintColumnFamily := removeDeleted(hints, math.MaxInt32)
 		if hintColumnFamily == nil {
 			continue
 		}
@@ -93,10 +137,11 @@ func (h *HintedHandOffManager) deliverAllHints(hintStore *ColumnFamilyStore) {
 			endpoints := keyColumn.GetSubColumns()
 			keyStr := keyColumn.getName()
 			deleted := 0
-			for endpointStr := range endpoints {
+			for endpointStr, hintEndPoint := range endpoints {
 				if sendMessage(endpointStr, tableName, keyStr) {
-					deleteEndPoint(endpointStr, tableName, keyStr, keyColumn.timestamp())
+					deleteEndPoint(endpointStr, tableName, keyStr, hintEndPoint.timestamp())
 					deleted++
+					throttleHintedHandoff()
 				}
 			}
 			if deleted == len(endpoints) {
@@ -109,6 +154,10 @@ func (h *HintedHandOffManager) deliverAllHints(hintStore *ColumnFamilyStore) {
 	log.Print("Finished deliverAllHints")
 }
 
+func throttleHintedHandoff() {
+	time.Sleep(time.Duration(config.HintedHandoffThrottleInMillis) * time.Millisecond)
+}
+
 func deleteEndPoint(endpointAddr, tableName, key string, timestamp int64) {
 	rm := NewRowMutation(config.SysTableName, tableName)
 	rm.Delete(NewQueryPath(config.HintsCF, []byte(key), []byte(endpointAddr)), timestamp)
@@ -130,7 +179,11 @@ func deleteHintedData(tableName, key string) {
 	// finish the job in this corner case.
 	rm := NewRowMutation(tableName, key)
 	table := OpenTable(tableName)
-	row := table.get(key) // not necessary to do removeDeleted here
+	row, err := table.get(key) // not necessary to do removeDeleted here
+	if err != nil {
+		log.Printf("cannot delete hinted row %v of %v: %v\n", key, tableName, err)
+		return
+	}
 	cfs := row.getColumnFamilies()
 	for _, cf := range cfs {
 		maxTS := int64(math.MinInt64)
@@ -164,10 +217,17 @@ func sendMessage(endpointAddr, tableName, key string) bool {
 		return false
 	}
 	table := OpenTable(tableName)
-	row := table.get(key)
-	purgedRow := NewRow(key)
+	row, err := table.get(key)
+	if err != nil {
+		log.Printf("cannot read hinted row %v of %v: %v\n", key, tableName, err)
+		return false
+	}
+	purgedRow := NewRowT(tableName, key)
 	for _, cf := range row.getColumnFamilies() {
-		purgedRow.addColumnFamily(removeDeletedGC(cf))
+		cf = removeDeletedGC(cf)
+		if cf != nil {
+			purgedRow.addColumnFamily(cf)
+		}
 	}
 	rm := NewRowMutationR(tableName, purgedRow)
 	return sendEndPointRM(endPoint, rm)
@@ -177,16 +237,18 @@ func sendEndPointRM(end *network.EndPoint, rm *RowMutation) bool {
 	gob.Register(SuperColumnFactory{})
 	gob.Register(SuperColumn{})
 	c, err := rpc.DialHTTP("tcp", end.HostName+":"+end.Port)
-	defer c.Close()
 	if err != nil {
-		log.Fatal("dialing:", err)
+		log.Printf("cannot send hinted row to %v: %v\n", end.HostName, err)
+		return false
 	}
+	defer c.Close()
 	args := RowMutationArgs{}
 	args.RM = *rm
 	reply := RowMutationReply{}
 	err = c.Call("StorageService.DoRowMutation", &args, &reply)
 	if err != nil {
-		log.Fatal("calling:", err)
+		log.Printf("cannot send hinted row to %v: %v\n", end.HostName, err)
+		return false
 	}
 	// fmt.Printf("DoRowMutation.Result for %v:%v: %+v\n",
 	// 	end.HostName, end.Port, reply.Result)
@@ -6,7 +6,7 @@
 package db
 
 import (
-	"os"
+	"io"
 )
 
 // IColumn provide interface for Column and SuperColumn
@@ -34,7 +34,7 @@ type IColumn interface {
 
 // IColumnSerializer ...
 type IColumnSerializer interface {
-	serialize(column IColumn, dos *os.File)
-	serializeB(column IColumn, dos []byte)
-	deserialize(dis *os.File) IColumn
+	serialize(column IColumn, dos io.Writer)
+	serializeB(column IColumn, dos *[]byte)
+	deserializeB(dis io.Reader) (IColumn, error)
 }
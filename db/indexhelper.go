@@ -6,7 +6,7 @@
 package db
 
 import (
-	"os"
+	"io"
 	"sort"
 
 	"github.com/DistAlchemist/Mongongo/utils"
@@ -30,7 +30,7 @@ func NewIndexInfo(firstName []byte, lastName []byte, offset int64, width int64)
 	return r
 }
 
-func (r *IndexInfo) serialize(dos []byte) {
+func (r *IndexInfo) serialize(dos *[]byte) {
 	writeBytesB(dos, r.firstName)
 	writeBytesB(dos, r.lastName)
 	writeInt64B(dos, r.offset)
@@ -41,25 +41,43 @@ func (r *IndexInfo) serializedSize() int {
 	return 4 + len(r.firstName) + 4 + len(r.lastName) + 8 + 8
 }
 
-func indexInfoDeserialize(dis *os.File) *IndexInfo {
+func indexInfoDeserialize(dis io.Reader) (*IndexInfo, error) {
 	r := &IndexInfo{}
-	str, _ := readString(dis)
-	r.firstName = []byte(str)
-	str, _ = readString(dis)
-	r.lastName = []byte(str)
-	r.offset = readInt64(dis)
-	r.width = readInt64(dis)
-	return r
+	var err error
+	r.firstName, err = readBytesB(dis)
+	if err != nil {
+		return nil, err
+	}
+	r.lastName, err = readBytesB(dis)
+	if err != nil {
+		return nil, err
+	}
+	r.offset, err = readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	r.width, err = readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
-func deserializeIndex(in *os.File) []*IndexInfo {
+func deserializeIndex(in io.ReadSeeker) ([]*IndexInfo, error) {
 	indexList := make([]*IndexInfo, 0)
-	columnIndexSize := readInt(in)
+	columnIndexSize, err := readInt32B(in)
+	if err != nil {
+		return nil, err
+	}
 	start := getCurrentPos(in)
 	for getCurrentPos(in) < start+int64(columnIndexSize) {
-		indexList = append(indexList, indexInfoDeserialize(in))
+		indexInfo, err := indexInfoDeserialize(in)
+		if err != nil {
+			return nil, err
+		}
+		indexList = append(indexList, indexInfo)
 	}
-	return indexList
+	return indexList, nil
 }
 
 func indexFor(name []byte, indexList []*IndexInfo, reversed bool) int {
@@ -78,8 +96,10 @@ func indexFor(name []byte, indexList []*IndexInfo, reversed bool) int {
 	return index
 }
 
-func defreezeBloomFilter(file *os.File) *utils.BloomFilter {
-	// size := readInt(file)
-	bytes, _ := readBytes(file)
+func defreezeBloomFilter(file io.Reader) (*utils.BloomFilter, error) {
+	bytes, err := readBytesB(file)
+	if err != nil {
+		return nil, err
+	}
 	return utils.BFSerializer.DeserializeB(bytes)
 }
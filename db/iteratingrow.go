@@ -6,8 +6,7 @@
 package db
 
 import (
-	"log"
-	"os"
+	"io"
 )
 
 // IteratingRow ...
@@ -15,33 +14,48 @@ type IteratingRow struct {
 	key               string
 	finishedAt        int64
 	emptyColumnFamily *ColumnFamily
-	file              *os.File
+	file              dataInput
 }
 
 // NewIteratingRow ...
-func NewIteratingRow(file *os.File, sstable *SSTableReader) *IteratingRow {
+func NewIteratingRow(file dataInput, sstable *SSTableReader) (*IteratingRow, error) {
 	r := &IteratingRow{}
 	r.file = file
-	r.key, _ = readString(file)
-	dataSize := int64(readInt(file))
+	var err error
+	r.key, err = readStringB(file)
+	if err != nil {
+		return nil, err
+	}
+	dataSize, err := readInt32B(file)
+	if err != nil {
+		return nil, err
+	}
 	dataStart := getCurrentPos(file)
-	r.finishedAt = dataStart + dataSize
-	skipBloomFilterAndIndex(file)
-	r.emptyColumnFamily = CFSerializer.deserializeFromSSTableNoColumns(sstable.makeColumnFamily(), file)
-	readInt(file) // read column count
-	return r
+	r.finishedAt = dataStart + int64(dataSize)
+	_, err = skipBloomFilterAndIndex(file)
+	if err != nil {
+		return nil, err
+	}
+	r.emptyColumnFamily, err = CFSerializer.deserializeFromSSTableNoColumns(sstable.makeColumnFamily(), file)
+	if err != nil {
+		return nil, err
+	}
+	// read column count
+	if _, err = readInt32B(file); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 func (r *IteratingRow) hasNext() bool {
 	return r.finishedAt != getCurrentPos(r.file)
 }
 
-func (r *IteratingRow) next() IColumn {
+func (r *IteratingRow) next() (IColumn, error) {
 	if r.finishedAt == getCurrentPos(r.file) {
-		log.Fatal("reach end of row")
-		return Column{}
+		return nil, io.EOF
 	}
-	return r.emptyColumnFamily.columnSerializer.deserialize(r.file)
+	return r.emptyColumnFamily.columnSerializer.deserializeB(r.file)
 }
 
 func (r *IteratingRow) getEmptyColumnFamily() *ColumnFamily {
@@ -52,41 +66,49 @@ func (r *IteratingRow) skipRemaining() {
 	r.file.Seek(r.finishedAt, 0)
 }
 
-func skipBloomFilterAndIndex(in *os.File) int {
-	return skipBloomFilter(in) + skipIndex(in)
+func skipBloomFilterAndIndex(in io.ReadSeeker) (int, error) {
+	bloomFilterSize, err := skipBloomFilter(in)
+	if err != nil {
+		return 0, err
+	}
+	indexSize, err := skipIndex(in)
+	return bloomFilterSize + indexSize, err
 }
 
-func skipBloomFilter(in *os.File) int {
-	totalBytesRead := 0
+func skipBloomFilter(in io.ReadSeeker) (int, error) {
 	// size of the bloom filter
-	size := readInt(in)
-	totalBytesRead += 4
-	// skip the serialized bloom filter
-	curPos := getCurrentPos(in)
-	n, err := in.Seek(curPos+int64(size), 0)
+	size, err := readInt32B(in)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
-	if int64(size) != n {
-		log.Fatal("reach EOF")
-	}
-	totalBytesRead += size
-	return totalBytesRead
+	// skip the serialized bloom filter
+	err = skipBytes(in, int64(size))
+	return 4 + int(size), err
 }
 
-func skipIndex(file *os.File) int {
+func skipIndex(file io.ReadSeeker) (int, error) {
 	// read only the column index list
-	columnIndexSize := readInt(file)
-	totalBytesRead := 4
+	columnIndexSize, err := readInt32B(file)
+	if err != nil {
+		return 0, err
+	}
 	// skip the column index data
-	curPos := getCurrentPos(file)
-	n, err := file.Seek(curPos+int64(columnIndexSize), 0)
+	err = skipBytes(file, int64(columnIndexSize))
+	return 4 + int(columnIndexSize), err
+}
+
+// skipBytes seeks n bytes forward, failing if
+// that is before the start or past the end
+func skipBytes(in io.ReadSeeker, n int64) error {
+	curPos := getCurrentPos(in)
+	end, err := in.Seek(0, io.SeekEnd)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	if int64(columnIndexSize) != n {
-		log.Fatal("read EOF")
+	if n < 0 || curPos+n > end {
+		in.Seek(curPos, io.SeekStart)
+		return io.ErrUnexpectedEOF
 	}
-	totalBytesRead += columnIndexSize
-	return totalBytesRead
+	_, err = in.Seek(curPos+n, io.SeekStart)
+	return err
 }
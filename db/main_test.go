@@ -0,0 +1,77 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// TestMain starts the storage engine in a temporary directory with
+// the built-in configuration. Tests that need column family stores
+// add their own tables with addTestTable.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "mongongo-db")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Unsetenv("storage-config")
+	config.MetadataDir = filepath.Join(dir, "system")
+	config.SnapshotDir = filepath.Join(config.MetadataDir, "snapshot")
+	config.DataFileDirs = []string{filepath.Join(dir, "data")}
+	config.LogFileDir = filepath.Join(dir, "commitlog")
+	config.BootstrapFileDir = filepath.Join(dir, "bootstrap")
+	// the engine is only started by the tests that need it
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// addTestTable starts the storage engine if needed and creates a
+// table with the given column families, which the test drops again
+func addTestTable(t *testing.T, tableName string, cfMetaDatas ...config.CFMetaData) *Table {
+	GetManagerInstance()
+	for i := range cfMetaDatas {
+		if cfMetaDatas[i].IndexProperty == "" {
+			cfMetaDatas[i].IndexProperty = "Name"
+		}
+	}
+	if err := AddTable(tableName, cfMetaDatas); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := DropTable(tableName); err != nil {
+			t.Error(err)
+		}
+	})
+	return OpenTable(tableName)
+}
+
+// applyTestRow writes the columns to a standard column family
+func applyTestRow(tableName, cfName, key string, columns ...Column) {
+	rm := NewRowMutation(tableName, key)
+	cf := createColumnFamily(tableName, cfName)
+	for _, column := range columns {
+		cf.addColumn(column)
+	}
+	rm.AddCF(cf)
+	rm.ApplyE()
+}
+
+// readTestRow reads the whole row of key from a column family store
+func readTestRow(cfStore *ColumnFamilyStore, key string) (*ColumnFamily, error) {
+	return cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(cfStore.columnFamilyName)))
+}
+
+// flushTestStore writes the memtable of a column family store to an sstable
+func flushTestStore(cfStore *ColumnFamilyStore) {
+	cfStore.forceBlockingFlush(openCommitLogE().getContext())
+}
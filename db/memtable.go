@@ -12,8 +12,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/DistAlchemist/Mongongo/config"
 )
 
@@ -78,6 +76,8 @@ func (m *Memtable) runResolve(key string, columnFamily *ColumnFamily) {
 		m.resolveSize(oldSize, newSize)
 		m.resolveCount(oldObjectCount, newObjectCount)
 		oldCf.deleteCF(columnFamily)
+		// oldCf is a copy, store back size and deletion info
+		m.columnFamilies[key] = oldCf
 	} else {
 		m.columnFamilies[key] = *columnFamily
 		atomic.AddInt32(&m.currentSize, columnFamily.size+int32(len(key)))
@@ -102,23 +102,30 @@ func (m *Memtable) isThresholdViolated() bool {
 
 func (m *Memtable) flush(cLogCtx *CommitLogContext) {
 	// flush this memtable to disk
-	cfStore := OpenTable(m.tableName).columnFamilyStores[m.cfName]
+	cfStore := OpenTable(m.tableName).getColumnFamilyStore(m.cfName)
+	if cfStore == nil {
+		log.Printf("column family %v.%v was dropped, skip flush\n", m.tableName, m.cfName)
+		return
+	}
 	writer := NewSSTableWriter(cfStore.getTmpSSTablePath(), len(m.columnFamilies))
+	writer.origin = flushedBackup
 	// sort keys in the order they would be in when decorated
 	orderedKeys := make([]string, 0)
-	for cfName := range m.columnFamilies {
-		orderedKeys = append(orderedKeys, writer.partitioner.DecorateKey(cfName))
+	decoratedToKey := make(map[string]string)
+	for key := range m.columnFamilies {
+		decoratedKey := writer.partitioner.DecorateKey(key)
+		orderedKeys = append(orderedKeys, decoratedKey)
+		decoratedToKey[decoratedKey] = key
 	}
 	sort.Sort(ByKey(orderedKeys))
-	for _, key := range orderedKeys {
-		k := writer.partitioner.DecorateKey(key)
+	for _, decoratedKey := range orderedKeys {
 		buf := make([]byte, 0)
-		columnFamily, ok := m.columnFamilies[k]
+		columnFamily, ok := m.columnFamilies[decoratedToKey[decoratedKey]]
 		if ok {
 			// serialize the cf with column indexes
-			CFSerializer.serializeWithIndexes(&columnFamily, buf)
+			CFSerializer.serializeWithIndexes(&columnFamily, &buf)
 			// now write the key and value to disk
-			writer.append(key, buf)
+			writer.append(decoratedKey, buf)
 		}
 	}
 	ssTable := writer.closeAndOpenReader()
@@ -140,14 +147,12 @@ func reverse(a []IColumn) {
 
 func (m *Memtable) getNamesIterator(filter *NamesQueryFilter) ColumnIterator {
 	cf, ok := m.columnFamilies[filter.key]
-	spew.Printf("\tcf get from memtable: %#+v\n\n", cf)
 	var columnFamily *ColumnFamily
 	if ok == false {
 		columnFamily = createColumnFamily(m.tableName, filter.path.ColumnFamilyName)
 	} else {
 		// columnFamily = cf.cloneMeShallow()
 		columnFamily = &cf
-		spew.Printf("\tshould enter here, cf: %#+v\n\n", columnFamily)
 	}
 	return NewSColumnIterator(0, columnFamily, filter.columns)
 }
@@ -156,35 +161,10 @@ func (m *Memtable) getNamesIterator(filter *NamesQueryFilter) ColumnIterator {
 // order starting from a given column
 func (m *Memtable) getSliceIterator(filter *SliceQueryFilter) ColumnIterator {
 	cf, ok := m.columnFamilies[filter.key] // rowKey -> column family
-	var columnFamily *ColumnFamily
-	var columns []IColumn
 	if ok == false {
-		columnFamily = createColumnFamily(m.tableName, filter.path.ColumnFamilyName)
-		columns = columnFamily.GetSortedColumns()
-	} else {
-		columnFamily = cf.cloneMeShallow()
-		columns = cf.GetSortedColumns()
-	}
-	if filter.reversed == true {
-		reverse(columns)
-	}
-	var startIColumn IColumn
-	if config.GetColumnTypeTableName(m.tableName, filter.path.ColumnFamilyName) == "Standard" {
-		startIColumn = NewColumn(string(filter.start), "", 0, false)
-	} else {
-		startIColumn = NewSuperColumn(string(filter.start))
-	}
-	index := 0
-	if len(filter.start) == 0 && filter.reversed {
-		// scan from the largest column in descending order
-		index = 0
-	} else {
-		index = sort.Search(len(columns), func(i int) bool {
-			return columns[i].getName() >= startIColumn.getName()
-		})
+		return filter.getColumnFamilyIterator(createColumnFamily(m.tableName, filter.path.ColumnFamilyName))
 	}
-	startIndex := index
-	return NewAColumnIterator(startIndex, columnFamily, columns)
+	return filter.getColumnFamilyIterator(&cf)
 }
 
 func (m *Memtable) isClean() bool {
@@ -58,8 +58,23 @@ func (n *NamesQueryFilter) getMemColumnIterator(memtable *Memtable) ColumnIterat
 	return memtable.getNamesIterator(n)
 }
 
-func (n *NamesQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) ColumnIterator {
-	return NewSSTableNamesIterator(sstable, n.key, n.columns)
+func (n *NamesQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) (ColumnIterator, error) {
+	iter, err := NewSSTableNamesIterator(sstable, n.key, n.columns)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+func (n *NamesQueryFilter) filterColumnFamily(cf *ColumnFamily, gcBefore int) *ColumnFamily {
+	returnCF := cf.cloneMeShallow()
+	for _, name := range n.columns {
+		column := cf.GetColumn(string(name))
+		if column != nil {
+			returnCF.addColumn(column)
+		}
+	}
+	return returnCF
 }
 
 func (n *NamesQueryFilter) collectCollatedColumns(returnCF *ColumnFamily, collatedColumns *CollatedIterator, gcBefore int) {
@@ -11,8 +11,11 @@ type QueryFilter interface {
 	getPath() *QueryPath
 	filterSuperColumn(superColumn SuperColumn, gcBefore int) SuperColumn
 	getMemColumnIterator(memtable *Memtable) ColumnIterator
-	getSSTableColumnIterator(sstable *SSTableReader) ColumnIterator
+	getSSTableColumnIterator(sstable *SSTableReader) (ColumnIterator, error)
 	collectCollatedColumns(returnCF *ColumnFamily, collatedColumns *CollatedIterator, gcBefore int)
+	// filterColumnFamily returns the columns of a whole row
+	// the filter asks for, used to answer from the row cache
+	filterColumnFamily(cf *ColumnFamily, gcBefore int) *ColumnFamily
 }
 
 // AQueryFilter ...
@@ -45,6 +48,6 @@ func (p *AQueryFilter) getMemColumnIterator(memtable *Memtable) ColumnIterator {
 	return nil
 }
 
-func (p *AQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) ColumnIterator {
-	return nil
+func (p *AQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) (ColumnIterator, error) {
+	return nil, nil
 }
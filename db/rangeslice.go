@@ -0,0 +1,217 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/dht"
+)
+
+// RangeSliceArgs asks for the keys of the rows in a part of the
+// ring that also fall in a key range or in a token range
+type RangeSliceArgs struct {
+	Table        string
+	ColumnFamily string
+	// Range is the part of the ring scanned
+	Range dht.Range
+	// StartKey and EndKey are the smallest and largest keys
+	// returned in partitioner order, empty for no bound
+	StartKey string
+	EndKey   string
+	// StartToken and EndToken bound the tokens of the keys
+	// like the ends of a dht.Range, they are only used if
+	// StartKey and EndKey are both empty
+	StartToken string
+	EndToken   string
+	// MaxKeys is the most keys returned, 0 for all of them
+	MaxKeys int
+}
+
+// RangeSliceReply ...
+type RangeSliceReply struct {
+	// Keys are in partitioner order
+	Keys []string
+}
+
+func getPartitioner() dht.IPartitioner {
+	if config.HashingStrategy == config.Random {
+		return dht.NewRandomPartitioner()
+	}
+	return dht.NewOPP()
+}
+
+// DoRangeSlice finds the keys of the local rows matching args. The
+// keys of the memtables and of the sstables are merged in the order
+// of their decorated keys, which is the order of the ring, so the
+// scan stops as soon as it has args.MaxKeys keys. Deleted rows are
+// returned as well, reading them gives no columns.
+func DoRangeSlice(args *RangeSliceArgs, reply *RangeSliceReply) error {
+	cfStore, err := lookupColumnFamilyStore(args.Table, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	if (args.StartKey != "" || args.EndKey != "") && (args.StartToken != "" || args.EndToken != "") {
+		return fmt.Errorf("a range is given either by keys or by tokens")
+	}
+	partitioner := getPartitioner()
+	startKey, endKey := "", ""
+	if args.StartKey != "" {
+		startKey = partitioner.DecorateKey(args.StartKey)
+	}
+	if args.EndKey != "" {
+		endKey = partitioner.DecorateKey(args.EndKey)
+	}
+	var tokens *dht.Range
+	if args.StartKey == "" && args.EndKey == "" {
+		r := dht.NewRange(args.StartToken, args.EndToken)
+		tokens = &r
+	}
+	reply.Keys = make([]string, 0)
+	iter := cfStore.newDecoratedKeyIterator()
+	for {
+		decoratedKey := iter.next()
+		if decoratedKey == "" || endKey != "" && decoratedKey > endKey {
+			break
+		}
+		if decoratedKey < startKey {
+			continue
+		}
+		key := partitioner.UndecorateKey(decoratedKey)
+		token := partitioner.GetToken(key)
+		if !args.Range.Contains(token) || tokens != nil && !tokens.Contains(token) {
+			continue
+		}
+		reply.Keys = append(reply.Keys, key)
+		if args.MaxKeys > 0 && len(reply.Keys) >= args.MaxKeys {
+			break
+		}
+	}
+	return nil
+}
+
+// decoratedKeySource is a sorted list of decorated keys being merged
+type decoratedKeySource struct {
+	keys     []string
+	position int
+}
+
+// decoratedKeyIterator merges the sorted keys of the memtables
+// and sstables of a column family, returning every key once
+type decoratedKeyIterator struct {
+	sources []*decoratedKeySource
+	lastKey string
+}
+
+// Len ...
+func (it *decoratedKeyIterator) Len() int {
+	return len(it.sources)
+}
+
+// Less ...
+func (it *decoratedKeyIterator) Less(i, j int) bool {
+	return it.sources[i].keys[it.sources[i].position] < it.sources[j].keys[it.sources[j].position]
+}
+
+// Swap ...
+func (it *decoratedKeyIterator) Swap(i, j int) {
+	it.sources[i], it.sources[j] = it.sources[j], it.sources[i]
+}
+
+// Push ...
+func (it *decoratedKeyIterator) Push(x interface{}) {
+	it.sources = append(it.sources, x.(*decoratedKeySource))
+}
+
+// Pop ...
+func (it *decoratedKeyIterator) Pop() interface{} {
+	n := len(it.sources)
+	source := it.sources[n-1]
+	it.sources[n-1] = nil
+	it.sources = it.sources[:n-1]
+	return source
+}
+
+func (it *decoratedKeyIterator) add(keys []string) {
+	if len(keys) > 0 {
+		heap.Push(it, &decoratedKeySource{keys, 0})
+	}
+}
+
+// seek skips the keys up to and including after, so that
+// next returns the smallest key after it
+func (it *decoratedKeyIterator) seek(after string) {
+	sources := it.sources
+	it.sources = make([]*decoratedKeySource, 0, len(sources))
+	for _, source := range sources {
+		source.position += sort.Search(len(source.keys)-source.position, func(i int) bool {
+			return source.keys[source.position+i] > after
+		})
+		if source.position < len(source.keys) {
+			it.sources = append(it.sources, source)
+		}
+	}
+	heap.Init(it)
+}
+
+// next returns the smallest key not returned yet, or
+// the empty string once every source is exhausted
+func (it *decoratedKeyIterator) next() string {
+	for it.Len() > 0 {
+		source := it.sources[0]
+		key := source.keys[source.position]
+		source.position++
+		if source.position == len(source.keys) {
+			heap.Pop(it)
+		} else {
+			heap.Fix(it, 0)
+		}
+		// keys are never empty
+		if key != it.lastKey {
+			it.lastKey = key
+			return key
+		}
+	}
+	return ""
+}
+
+func decorateMemtableKeys(memtable *Memtable, partitioner dht.IPartitioner) []string {
+	keys := make([]string, 0, len(memtable.columnFamilies))
+	for key := range memtable.columnFamilies {
+		keys = append(keys, partitioner.DecorateKey(key))
+	}
+	sort.Sort(ByKey(keys))
+	return keys
+}
+
+func (c *ColumnFamilyStore) newDecoratedKeyIterator() *decoratedKeyIterator {
+	partitioner := getPartitioner()
+	it := &decoratedKeyIterator{}
+	c.memMu.RLock()
+	it.add(decorateMemtableKeys(c.memtable, partitioner))
+	c.memMu.RUnlock()
+	// memtables being flushed are frozen, no lock needed
+	for _, memtable := range getUnflushedMemtables(c.tableName, c.columnFamilyName) {
+		it.add(decorateMemtableKeys(memtable, partitioner))
+	}
+	for _, sstable := range c.getSSTables() {
+		entries, err := readIndexEntries(sstable)
+		if err != nil {
+			log.Printf("cannot read the keys of %v: %v\n", sstable.getFilename(), err)
+			continue
+		}
+		keys := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			keys = append(keys, entry.key)
+		}
+		it.add(keys)
+	}
+	return it
+}
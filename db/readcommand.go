@@ -16,7 +16,7 @@ type ReadCommand interface {
 	GetQPath() QueryPath
 	GetCFName() string
 	GetTable() string
-	GetRow(table *Table) *Row
+	GetRow(table *Table) (*Row, error)
 }
 
 // AReadCommand ...
@@ -0,0 +1,107 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"crypto/md5"
+	"sort"
+)
+
+// Digest hashes the data of the row, so that the versions
+// of a row read from two replicas can be compared without
+// sending both of them. Column families without columns
+// or deletion are left out, a replica may or may not
+// return them for a row it does not have.
+func (r *Row) Digest() []byte {
+	buf := make([]byte, 0)
+	names := make([]string, 0, len(r.ColumnFamilies))
+	for name := range r.ColumnFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cf := r.ColumnFamilies[name]
+		if cf == nil || len(cf.Columns) == 0 && cf.getMarkedForDeleteAt() <= 0 {
+			continue
+		}
+		writeDigestData(&buf, cf)
+	}
+	digest := md5.Sum(buf)
+	return digest[:]
+}
+
+func writeDigestData(buf *[]byte, cf *ColumnFamily) {
+	writeStringB(buf, cf.ColumnFamilyName)
+	writeInt64B(buf, cf.getMarkedForDeleteAt())
+	for _, column := range cf.GetSortedColumns() {
+		superColumn, ok := column.(SuperColumn)
+		if !ok {
+			CSerializer.serializeB(column, buf)
+			continue
+		}
+		// sub columns are kept in a map, so they
+		// are sorted for the digest to be stable
+		writeStringB(buf, superColumn.Name)
+		writeInt64B(buf, superColumn.getMarkedForDeleteAt())
+		names := make([]string, 0, len(superColumn.Columns))
+		for name := range superColumn.Columns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			CSerializer.serializeB(superColumn.Columns[name], buf)
+		}
+	}
+}
+
+// ResolveRows merges the versions of a row read from several
+// replicas, keeping the newest version of every column
+func ResolveRows(table, key string, versions []*Row) *Row {
+	resolved := NewRowT(table, key)
+	for _, version := range versions {
+		if version == nil {
+			continue
+		}
+		for cfName, cf := range version.ColumnFamilies {
+			if cf == nil {
+				continue
+			}
+			resolvedCF, ok := resolved.ColumnFamilies[cfName]
+			if !ok {
+				resolvedCF = NewColumnFamily(cfName, cf.ColumnType)
+				resolved.ColumnFamilies[cfName] = resolvedCF
+			}
+			resolvedCF.deleteCF(cf)
+			for _, column := range cf.Columns {
+				resolvedCF.addColumn(cloneColumn(column))
+			}
+		}
+	}
+	for cfName, cf := range resolved.ColumnFamilies {
+		// drop what the newest deletions shadow
+		cf = removeDeletedGC(cf)
+		if cf == nil {
+			delete(resolved.ColumnFamilies, cfName)
+			continue
+		}
+		resolved.addColumnFamily(cf)
+	}
+	return resolved
+}
+
+// cloneColumn copies the sub columns of super columns, which
+// addColumn would otherwise merge into the map of the version
+func cloneColumn(column IColumn) IColumn {
+	superColumn, ok := column.(SuperColumn)
+	if !ok {
+		return column
+	}
+	clone := superColumn.cloneMeShallow()
+	for _, subColumn := range superColumn.Columns {
+		clone.addColumn(subColumn)
+	}
+	return clone
+}
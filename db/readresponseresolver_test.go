@@ -0,0 +1,145 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func newTestRow(cfs ...*ColumnFamily) *Row {
+	row := NewRowT("table", "key")
+	for _, cf := range cfs {
+		row.addColumnFamily(cf)
+	}
+	return row
+}
+
+func newStandardCF(columns ...Column) *ColumnFamily {
+	cf := NewColumnFamily("cf", "Standard")
+	for _, column := range columns {
+		cf.addColumn(column)
+	}
+	return cf
+}
+
+func newSuperCF(name string, subColumns ...Column) *ColumnFamily {
+	cf := NewColumnFamily("super", "Super")
+	sc := NewSuperColumn(name)
+	for _, column := range subColumns {
+		sc.addColumn(column)
+	}
+	cf.addColumn(sc)
+	return cf
+}
+
+func TestRowDigest(t *testing.T) {
+	a, b := NewColumn("a", "1", 1, false), NewColumn("b", "2", 2, false)
+	digest := newTestRow(newStandardCF(a, b), newSuperCF("sc", a, b)).Digest()
+	same := newTestRow(newSuperCF("sc", b, a), newStandardCF(b, a), NewColumnFamily("empty", "Standard"))
+	if !bytes.Equal(same.Digest(), digest) {
+		t.Errorf("the digest depends on the order of the columns or on empty column families")
+	}
+	for name, row := range map[string]*Row{
+		"value":      newTestRow(newStandardCF(a, NewColumn("b", "3", 2, false)), newSuperCF("sc", a, b)),
+		"timestamp":  newTestRow(newStandardCF(a, NewColumn("b", "2", 3, false)), newSuperCF("sc", a, b)),
+		"tombstone":  newTestRow(newStandardCF(a, NewColumn("b", "2", 2, true)), newSuperCF("sc", a, b)),
+		"sub column": newTestRow(newStandardCF(a, b), newSuperCF("sc", a)),
+		"missing":    newTestRow(newStandardCF(a, b)),
+	} {
+		if bytes.Equal(row.Digest(), digest) {
+			t.Errorf("a different %v gives the same digest", name)
+		}
+	}
+	deleted := NewColumnFamily("cf", "Standard")
+	deleted.delete(int(time.Now().Unix()), 5)
+	if bytes.Equal(newTestRow(deleted).Digest(), newTestRow().Digest()) {
+		t.Errorf("a deleted column family gives the digest of a missing row")
+	}
+}
+
+func TestResolveRows(t *testing.T) {
+	now := int(time.Now().Unix())
+	old := newTestRow(newStandardCF(NewColumn("a", "old", 1, false), NewColumn("b", "b", 1, false)),
+		newSuperCF("sc", NewColumn("x", "x", 1, false)))
+	deletedCF := newStandardCF(NewColumn("a", "new", 3, false), NewColumn("c", "c", 1, false))
+	deletedCF.delete(now, 2)
+	newer := newTestRow(deletedCF, newSuperCF("sc", NewColumn("y", "y", 1, false)))
+	resolved := ResolveRows("table", "key", []*Row{old, nil, newer})
+	if resolved.Table != "table" || resolved.Key != "key" {
+		t.Fatalf("resolved row is %v.%v", resolved.Table, resolved.Key)
+	}
+	cf := resolved.ColumnFamilies["cf"]
+	if cf == nil || cf.getMarkedForDeleteAt() != 2 {
+		t.Fatalf("the row tombstone is not resolved: %+v", cf)
+	}
+	if column, ok := cf.Columns["a"].(Column); !ok || column.Value != "new" {
+		t.Errorf("column a is %+v, want the newest value", cf.Columns["a"])
+	}
+	for _, name := range []string{"b", "c"} {
+		if _, ok := cf.Columns[name]; ok {
+			t.Errorf("column %v older than the row tombstone is resolved", name)
+		}
+	}
+	sc, ok := resolved.ColumnFamilies["super"].Columns["sc"].(SuperColumn)
+	if !ok || len(sc.Columns) != 2 {
+		t.Fatalf("super column is %+v, want the sub columns of both versions", resolved.ColumnFamilies["super"].Columns["sc"])
+	}
+	// the versions are left as they were read
+	if len(old.ColumnFamilies["super"].Columns["sc"].(SuperColumn).Columns) != 1 {
+		t.Errorf("resolving changed a version")
+	}
+	// replicas that are repaired with the resolved row agree on it
+	repaired := ResolveRows("table", "key", []*Row{resolved, old})
+	if !bytes.Equal(repaired.Digest(), resolved.Digest()) {
+		t.Errorf("resolving an older version into the resolved row changed it")
+	}
+	if empty := ResolveRows("table", "key", []*Row{nil, NewRowT("table", "key")}); len(empty.ColumnFamilies) != 0 {
+		t.Errorf("rows without data resolve to %v column families", len(empty.ColumnFamilies))
+	}
+}
+
+func TestResolveRowsNewestVersionWins(t *testing.T) {
+	tombstone := func(timestamp int64) Column {
+		return NewColumn("c", deletionTime(int(time.Now().Unix())), timestamp, true)
+	}
+	live := func(value string, timestamp int64) Column {
+		return NewColumn("c", value, timestamp, false)
+	}
+	tests := []struct {
+		name     string
+		versions []Column
+		want     Column
+	}{
+		{"newest value", []Column{live("old", 1), live("new", 2)}, live("new", 2)},
+		{"newest value read first", []Column{live("new", 2), live("old", 1)}, live("new", 2)},
+		{"tombstone beats older value", []Column{live("old", 1), tombstone(2)}, tombstone(2)},
+		{"tombstone read first", []Column{tombstone(2), live("old", 1)}, tombstone(2)},
+		{"value newer than tombstone", []Column{tombstone(1), live("new", 2)}, live("new", 2)},
+		{"three replicas", []Column{live("a", 1), tombstone(2), live("c", 3)}, live("c", 3)},
+	}
+	for _, test := range tests {
+		rows := make([]*Row, 0)
+		for _, column := range test.versions {
+			rows = append(rows, newTestRow(newStandardCF(column)))
+		}
+		resolved := ResolveRows("table", "key", rows)
+		cf := resolved.ColumnFamilies["cf"]
+		if cf == nil {
+			t.Errorf("%v: the column family is gone", test.name)
+			continue
+		}
+		// tombstones are kept, so the repair deletes
+		// the column on the replicas that still have it
+		column, ok := cf.Columns["c"].(Column)
+		if !ok || column.Timestamp != test.want.Timestamp ||
+			column.isMarkedForDelete() != test.want.isMarkedForDelete() ||
+			!test.want.isMarkedForDelete() && column.Value != test.want.Value {
+			t.Errorf("%v: resolved to %+v, want %+v", test.name, cf.Columns["c"], test.want)
+		}
+	}
+}
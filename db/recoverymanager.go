@@ -5,9 +5,31 @@
 
 package db
 
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
 // RecoveryManager manages recovery
 type RecoveryManager struct {
-	//
+	// commit log segments left behind by the previous run,
+	// sorted from old to new
+	clogs []string
+	once  sync.Once
 }
 
 var recoveryManagerInstance *RecoveryManager
@@ -27,6 +49,231 @@ func NewRecoveryManager() *RecoveryManager {
 	return r
 }
 
+// getSegments lists the commit log segments once, before the
+// current run writes any segment of its own
+func (r *RecoveryManager) getSegments() []string {
+	r.once.Do(func() {
+		files, err := ioutil.ReadDir(config.LogFileDir)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		clogs := make([]string, 0)
+		for _, fileInfo := range files {
+			name := fileInfo.Name()
+			if strings.HasPrefix(name, "CommitLog-") && strings.HasSuffix(name, ".log") {
+				clogs = append(clogs, path.Join(config.LogFileDir, name))
+			}
+		}
+		sort.Sort(ByTime(clogs))
+		r.clogs = clogs
+	})
+	return r.clogs
+}
+
+// recoverSystemTable replays the rows of the system table only.
+// It runs before the persisted schema is read, so that schema
+// changes which only made it into the commit log are not lost.
+func (r *RecoveryManager) recoverSystemTable() {
+	for _, file := range r.getSegments() {
+		replaySegment(file, func(table string) bool {
+			return table == config.SysTableName
+		})
+	}
+}
+
+// doRecovery replays the rows of every application table that were
+// logged but not yet flushed when the node went down, flushes all
+// memtables and then deletes the replayed segments.
 func (r *RecoveryManager) doRecovery() {
-	// TODO
+	clogs := r.getSegments()
+	if len(clogs) == 0 {
+		return
+	}
+	log.Printf("recovering from %v commit log segments\n", len(clogs))
+	for _, file := range clogs {
+		replaySegment(file, func(table string) bool {
+			return table != config.SysTableName
+		})
+	}
+	// make the replayed rows durable before the segments go away
+	for _, tableName := range config.GetTables() {
+		for _, cfStore := range OpenTable(tableName).getColumnFamilyStores() {
+			cfStore.forceBlockingFlush(NewCommitLogContext("", -1))
+		}
+	}
+	for _, file := range clogs {
+		log.Printf("deleting replayed commit log %v\n", file)
+		err := os.Remove(file)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	r.clogs = nil
+}
+
+// errCommitLogChecksum reports a commit log entry whose
+// data does not match its checksum
+var errCommitLogChecksum = errors.New("checksum mismatch")
+
+// replaySegment reapplies the rows of one commit log segment whose
+// table is accepted by filter. A column family is only replayed if
+// the header marks it dirty and the row lies at or after the position
+// it was last flushed at. If the header itself is corrupt every row
+// of the segment is replayed, which is safe since applying a row
+// twice does not change the result. Replay of a segment stops at the
+// first record that is incomplete or fails its checksum, which is
+// what a write torn by a crash looks like, and the rest of the
+// segment is discarded.
+func replaySegment(file string, filter func(table string) bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer f.Close()
+	fileSize := getFileSize(f)
+	reader := bufio.NewReader(f)
+	header, headerSize, err := readCommitLogHeader(reader)
+	if errors.Is(err, errCommitLogChecksum) {
+		log.Printf("commit log %v has a corrupt header, replaying all of it\n", file)
+	} else if err != nil {
+		log.Printf("skipping commit log %v: cannot read header: %v, discarding %v bytes\n",
+			file, err, fileSize)
+		return
+	}
+	// skip everything before the lowest position we need
+	lowPos := headerSize
+	if header != nil {
+		lowPos = -1
+		for id := 0; id < header.size(); id++ {
+			if header.isDirty(id) {
+				position := int64(header.getPosition(id))
+				if lowPos < 0 || position < lowPos {
+					lowPos = position
+				}
+			}
+		}
+		if lowPos < 0 {
+			log.Printf("commit log %v has no dirty column families\n", file)
+			return
+		}
+		if lowPos < headerSize {
+			lowPos = headerSize
+		}
+	}
+	_, err = reader.Discard(int(lowPos - headerSize))
+	if err != nil {
+		log.Printf("commit log %v ends before replay position %v\n", file, lowPos)
+		return
+	}
+	position := lowPos
+	replayed := 0
+	for {
+		row, size, err := readLoggedRow(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("stop replaying %v at offset %v: %v, discarding %v bytes\n",
+				file, position, err, fileSize-position)
+			break
+		}
+		if filter(row.Table) && replayRow(row, header, position) {
+			replayed++
+		}
+		position += size
+	}
+	log.Printf("replayed %v rows from %v\n", replayed, file)
+}
+
+// readCommitLogEntry reads one entry framed by frameCommitLogEntry
+// and returns its data together with its size on disk. It returns
+// io.EOF only if reader ends exactly before the entry. The size
+// can only be trusted if the error is nil or errCommitLogChecksum.
+func readCommitLogEntry(reader io.Reader) ([]byte, int64, error) {
+	sizeBytes := make([]byte, 8)
+	_, err := io.ReadFull(reader, sizeBytes)
+	if err != nil {
+		return nil, 0, err
+	}
+	checksum, err := readInt64B(reader)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if uint32(checksum) != crc32.ChecksumIEEE(sizeBytes) {
+		return nil, 0, fmt.Errorf("corrupt entry size: %v", errCommitLogChecksum)
+	}
+	size := int64(binary.BigEndian.Uint64(sizeBytes))
+	if size < 0 {
+		return nil, 0, fmt.Errorf("invalid entry size %v", size)
+	}
+	// don't trust the size before the bytes are there
+	buf := bytes.NewBuffer(nil)
+	_, err = io.CopyN(buf, reader, size)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	checksum, err = readInt64B(reader)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	entrySize := commitLogFrameSize + size
+	if uint32(checksum) != crc32.ChecksumIEEE(buf.Bytes()) {
+		return nil, entrySize, errCommitLogChecksum
+	}
+	return buf.Bytes(), entrySize, nil
+}
+
+// readLoggedRow reads one record appended by CommitLog.add and
+// returns the row together with the size of the record. It returns
+// io.EOF only if the segment ends exactly at a record boundary.
+func readLoggedRow(reader io.Reader) (*Row, int64, error) {
+	buf, size, err := readCommitLogEntry(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	row, err := rowDeserialize(bytes.NewReader(buf))
+	if err != nil {
+		return nil, 0, err
+	}
+	return row, size, nil
+}
+
+// replayRow applies the column families of row that were not yet
+// flushed when the record at position was written. With a nil
+// header all of them are applied.
+func replayRow(row *Row, header *CommitLogHeader, position int64) bool {
+	if config.GetTableMetaData(row.Table) == nil {
+		// the table was dropped
+		return false
+	}
+	table := OpenTable(row.Table)
+	for cfName := range row.ColumnFamilies {
+		if !table.isValidColumnFamily(cfName) {
+			delete(row.ColumnFamilies, cfName)
+			continue
+		}
+		if header == nil {
+			continue
+		}
+		id := table.getColumnFamilyID(cfName)
+		if !header.isDirty(id) || position < int64(header.getPosition(id)) {
+			delete(row.ColumnFamilies, cfName)
+		}
+	}
+	if len(row.ColumnFamilies) == 0 {
+		return false
+	}
+	table.applyToMemtables(row.Key, row, NewCommitLogContext("", -1))
+	return true
 }
@@ -6,7 +6,10 @@
 package db
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io"
 	"sync/atomic"
 )
 
@@ -69,7 +72,7 @@ func (r *Row) clear() {
 	r.ColumnFamilies = make(map[string]*ColumnFamily)
 }
 
-func rowSerialize(row *Row, dos []byte) {
+func rowSerialize(row *Row, dos *[]byte) {
 	writeStringB(dos, row.Table)
 	writeStringB(dos, row.Key)
 	columnFamilies := row.getColumnFamilies()
@@ -79,3 +82,49 @@ func rowSerialize(row *Row, dos []byte) {
 		CFSerializer.serialize(cf, dos)
 	}
 }
+
+// GobEncode sends the row like the commit log stores it, gob
+// would lose the deletion times kept in unexported fields
+func (r Row) GobEncode() ([]byte, error) {
+	buf := make([]byte, 0)
+	rowSerialize(&r, &buf)
+	return buf, nil
+}
+
+// GobDecode ...
+func (r *Row) GobDecode(data []byte) error {
+	row, err := rowDeserialize(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*r = *row
+	return nil
+}
+
+// rowDeserialize reads a row written by rowSerialize
+func rowDeserialize(dis io.Reader) (*Row, error) {
+	table, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
+	key, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
+	row := NewRowT(table, key)
+	size, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid column family count %v", size)
+	}
+	for i := int32(0); i < size; i++ {
+		cf, err := CFSerializer.deserialize(dis)
+		if err != nil {
+			return nil, err
+		}
+		row.addColumnFamily(cf)
+	}
+	return row, nil
+}
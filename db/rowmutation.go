@@ -56,11 +56,21 @@ func (rm *RowMutation) AddHints(key, host string) {
 
 // AddQ ...
 func (rm *RowMutation) AddQ(path *QueryPath, value []byte, timestamp int64) {
+	rm.AddQWithTTL(path, value, timestamp, 0)
+}
+
+// AddQWithTTL adds a column that expires after ttl seconds,
+// with ttl 0 it takes the DefaultTTL of its column family
+func (rm *RowMutation) AddQWithTTL(path *QueryPath, value []byte, timestamp int64, ttl int) {
+	if ttl == 0 {
+		cfMetaData, _ := config.GetCFMetaData(rm.TableName, path.ColumnFamilyName)
+		ttl = cfMetaData.DefaultTTL
+	}
 	columnFamily := rm.Modification[path.ColumnFamilyName]
 	if columnFamily == nil {
 		columnFamily = createColumnFamily(rm.TableName, path.ColumnFamilyName)
 	}
-	columnFamily.addColumnQP(path, string(value), timestamp, false)
+	columnFamily.addColumnQP(path, string(value), timestamp, ttl, false)
 	rm.Modification[path.ColumnFamilyName] = columnFamily
 }
 
@@ -94,6 +104,27 @@ func (rm *RowMutation) Add(columnFamilyColumn, value string, timestamp int64) {
 	rm.Modification[columnFamilyName] = columnFamily
 }
 
+// GobEncode sends the mutation like the commit log stores it, gob
+// would lose the deletion times kept in unexported fields
+func (rm RowMutation) GobEncode() ([]byte, error) {
+	row := NewRowT(rm.TableName, rm.RowKey)
+	for _, cf := range rm.Modification {
+		row.addColumnFamily(cf)
+	}
+	return row.GobEncode()
+}
+
+// GobDecode ...
+func (rm *RowMutation) GobDecode(data []byte) error {
+	row := &Row{}
+	err := row.GobDecode(data)
+	if err != nil {
+		return err
+	}
+	*rm = *NewRowMutationR(row.Table, row)
+	return nil
+}
+
 // Apply is equivalent to calling commit. This will
 // applies the changes to the table that is obtained
 // by calling Table.open()
@@ -115,15 +146,16 @@ func (rm *RowMutation) ApplyE() {
 	rm.Apply(row)
 }
 
-// Delete ...
+// Delete removes the column family, the super column or the column
+// of path as of timestamp
 func (rm *RowMutation) Delete(path *QueryPath, timestamp int64) {
 	cfName := path.ColumnFamilyName
-	_, ok := rm.Modification[cfName]
-	if ok {
-		log.Fatal("ColumnFamily " + cfName + " is already being modified")
-	}
 	localDeleteTime := int(getCurrentTimeInMillis() / 1000)
-	columnFamily := createColumnFamily(rm.TableName, cfName)
+	// a batch may insert and delete in the same column family
+	columnFamily := rm.Modification[cfName]
+	if columnFamily == nil {
+		columnFamily = createColumnFamily(rm.TableName, cfName)
+	}
 	if path.SuperColumnName == nil && path.ColumnName == nil {
 		columnFamily.delete(localDeleteTime, timestamp)
 	} else if path.ColumnName == nil {
@@ -133,6 +165,7 @@ func (rm *RowMutation) Delete(path *QueryPath, timestamp int64) {
 	} else {
 		b4 := make([]byte, 4)
 		binary.BigEndian.PutUint32(b4, uint32(localDeleteTime))
-		columnFamily.addColumnQP(path, string(b4), timestamp, true)
+		columnFamily.addColumnQP(path, string(b4), timestamp, 0, true)
 	}
+	rm.Modification[cfName] = columnFamily
 }
@@ -13,6 +13,9 @@ type RowReadArgs struct {
 	HeaderValue network.EndPoint
 	From        network.EndPoint
 	RCommand    ReadCommand
+	// DigestQuery asks for the digest of the row
+	// instead of the row itself
+	DigestQuery bool
 	// RM          RowMutation
 }
 
@@ -21,14 +24,106 @@ type RowReadReply struct {
 	Result string
 	Status bool
 	R      *Row
+	Digest []byte
+	// Exists tells whether the row has a live column in the
+	// column family read, set unless DigestQuery is
+	Exists bool
 }
 
 // DoRowRead ...
 func DoRowRead(args *RowReadArgs, reply *RowReadReply) error {
 	readCommand := args.RCommand
 	table := OpenTable(readCommand.GetTable())
-	row := readCommand.GetRow(table)
-	reply.R = row
+	row, err := readCommand.GetRow(table)
+	if err != nil {
+		return err
+	}
+	if args.DigestQuery {
+		reply.Digest = row.Digest()
+	} else {
+		reply.R = row
+		reply.Exists, err = rowExists(readCommand, row)
+		if err != nil {
+			return err
+		}
+	}
 	reply.Result = "SUCESS"
 	return nil
 }
+
+// MultiRowReadArgs carries the reads of several rows to a replica
+type MultiRowReadArgs struct {
+	From     network.EndPoint
+	Commands []ReadCommand
+}
+
+// MultiRowReadReply ...
+type MultiRowReadReply struct {
+	// Rows are in the order of the commands
+	Rows []*Row
+	// Exists tells for each row whether it has a live
+	// column in the column family read
+	Exists []bool
+}
+
+// DoMultiRowRead ...
+func DoMultiRowRead(args *MultiRowReadArgs, reply *MultiRowReadReply) error {
+	reply.Rows = make([]*Row, 0, len(args.Commands))
+	reply.Exists = make([]bool, 0, len(args.Commands))
+	for _, readCommand := range args.Commands {
+		row, err := readCommand.GetRow(OpenTable(readCommand.GetTable()))
+		if err != nil {
+			return err
+		}
+		exists, err := rowExists(readCommand, row)
+		if err != nil {
+			return err
+		}
+		reply.Rows = append(reply.Rows, row)
+		reply.Exists = append(reply.Exists, exists)
+	}
+	return nil
+}
+
+// rowExists tells whether the row read by command has a live column
+// in its column family. Only if row, the columns command selects,
+// holds none of them the rest of the row is looked at, for a column.
+func rowExists(command ReadCommand, row *Row) (bool, error) {
+	cfName := command.GetCFName()
+	if hasLiveColumn(row.ColumnFamilies[cfName]) {
+		return true, nil
+	}
+	cfStore := OpenTable(command.GetTable()).getColumnFamilyStore(cfName)
+	if cfStore == nil {
+		return false, nil
+	}
+	filter := NewSliceQueryFilter(command.GetKey(), NewQueryPathCF(cfName), nil, nil, false, 1)
+	cf, err := cfStore.getColumnFamily(filter)
+	if err != nil {
+		return false, err
+	}
+	return hasLiveColumn(cf), nil
+}
+
+// hasLiveColumn tells whether cf holds a column, or sub
+// column, that is neither deleted nor shadowed by a tombstone
+func hasLiveColumn(cf *ColumnFamily) bool {
+	if cf == nil {
+		return false
+	}
+	for _, column := range cf.Columns {
+		if sc, ok := column.(SuperColumn); ok {
+			for _, subColumn := range sc.getSubColumns() {
+				if !subColumn.isMarkedForDelete() && subColumn.timestamp() > sc.getMarkedForDeleteAt() &&
+					subColumn.timestamp() > cf.getMarkedForDeleteAt() {
+					return true
+				}
+			}
+			continue
+		}
+		if !column.isMarkedForDelete() && column.timestamp() > cf.getMarkedForDeleteAt() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import "testing"
+
+func TestHasLiveColumn(t *testing.T) {
+	standard := func(deletedAt int64, columns ...Column) *ColumnFamily {
+		cf := NewColumnFamily("cf", "Standard")
+		cf.delete(0, deletedAt)
+		for _, column := range columns {
+			cf.addColumn(column)
+		}
+		return cf
+	}
+	super := func(deletedAt int64, subColumns ...Column) *ColumnFamily {
+		cf := NewColumnFamily("cf", "Super")
+		sc := NewSuperColumn("sc")
+		sc.markForDeleteAt(0, deletedAt)
+		for _, column := range subColumns {
+			sc.addColumn(column)
+		}
+		cf.addColumn(sc)
+		return cf
+	}
+	tests := []struct {
+		name string
+		cf   *ColumnFamily
+		want bool
+	}{
+		{"no column family", nil, false},
+		{"no columns", standard(-1), false},
+		{"live column", standard(-1, NewColumn("a", "1", 2, false)), true},
+		{"tombstone", standard(-1, NewColumn("a", "", 2, true)), false},
+		{"column older than the row tombstone", standard(3, NewColumn("a", "1", 2, false)), false},
+		{"column newer than the row tombstone", standard(1, NewColumn("a", "1", 2, false)), true},
+		{"live sub column", super(-1, NewColumn("a", "1", 2, false)), true},
+		{"sub column older than the super column tombstone", super(3, NewColumn("a", "1", 2, false)), false},
+		{"empty super column", super(-1), false},
+	}
+	for _, test := range tests {
+		if got := hasLiveColumn(test.cf); got != test.want {
+			t.Errorf("%v: hasLiveColumn() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
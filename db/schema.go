@@ -0,0 +1,363 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/utils"
+)
+
+var (
+	// schemaMu serializes schema migrations
+	schemaMu sync.Mutex
+	// nextCFID is the id handed out to the next new column family.
+	// Ids are never reused because commit log headers refer to them.
+	nextCFID int
+	// ids below this are reserved for the system table, so that
+	// new system column families never collide with persisted ids
+	firstApplicationCFID = 64
+	schemaRowKey         = "S" // only one row in schema cf
+	schemaDefinitions    = "Definitions"
+)
+
+// schemaDefinition is the persisted form of all application
+// tables, stored as json in the system table
+type schemaDefinition struct {
+	NextCFID int
+	Tables   map[string]map[string]cfDefinition
+}
+
+// cfDefinition is a column family definition with its id
+type cfDefinition struct {
+	ID       int
+	MetaData config.CFMetaData
+	// IndexIDs are the ids of the index column
+	// families, by indexed column
+	IndexIDs map[string]int
+}
+
+// initSchema assigns ids to the system column families, opens and
+// recovers the system table and then restores the application tables from the
+// persisted schema. If no schema was persisted yet, the tables in
+// tableToColumnFamily (read from the config) are registered and
+// stored, so later restarts see exactly the same column family ids.
+func initSchema(tableToColumnFamily map[string]map[string]config.CFMetaData) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	nextCFID = 0
+	storeMetadata(map[string]map[string]config.CFMetaData{
+		config.SysTableName: tableToColumnFamily[config.SysTableName],
+	})
+	OpenTable(config.SysTableName).onStart()
+	// the latest schema may only be in the commit log
+	GetRecoveryManager().recoverSystemTable()
+	nextCFID = firstApplicationCFID
+	if def := readSchema(); def != nil {
+		log.Printf("restoring schema of %v tables from system table\n", len(def.Tables))
+		tables := make(map[string]map[string]config.CFMetaData)
+		for table, cfs := range def.Tables {
+			tables[table] = make(map[string]config.CFMetaData)
+			tmetadata := getTableMetadataInstance(table)
+			for cfName, cfDef := range cfs {
+				tables[table][cfName] = cfDef.MetaData
+				tmetadata.Add(cfName, cfDef.ID, cfDef.MetaData.ColumnType)
+				for column, id := range cfDef.IndexIDs {
+					tmetadata.Add(config.IndexCFName(cfName, column), id, "Standard")
+				}
+			}
+		}
+		config.SetApplicationTables(tables)
+		if def.NextCFID > nextCFID {
+			nextCFID = def.NextCFID
+		}
+	} else {
+		applicationTables := make(map[string]map[string]config.CFMetaData)
+		for table, cfs := range tableToColumnFamily {
+			if table != config.SysTableName {
+				applicationTables[table] = cfs
+			}
+		}
+		storeMetadata(applicationTables)
+		writeSchema()
+	}
+	for _, table := range config.GetTables() {
+		if table != config.SysTableName {
+			OpenTable(table).onStart()
+		}
+	}
+}
+
+// readSchema loads the schema persisted in the system table,
+// it returns nil if there is none
+func readSchema() *schemaDefinition {
+	table := OpenTable(config.SysTableName)
+	filter := NewIdentityQueryFilter(schemaRowKey, NewQueryPathCF(config.SchemaCF))
+	cf, err := table.getColumnFamilyStore(config.SchemaCF).getColumnFamily(filter)
+	if err != nil {
+		log.Fatalf("cannot read persisted schema: %v\n", err)
+	}
+	if cf == nil {
+		return nil
+	}
+	column := cf.GetColumn(schemaDefinitions)
+	if column == nil {
+		return nil
+	}
+	def := &schemaDefinition{}
+	err = json.Unmarshal(column.(Column).getValue(), def)
+	if err != nil {
+		log.Fatalf("cannot parse persisted schema: %v\n", err)
+	}
+	return def
+}
+
+// writeSchema stores the current application tables
+// together with their column family ids in the system table
+func writeSchema() {
+	def := schemaDefinition{}
+	def.NextCFID = nextCFID
+	def.Tables = make(map[string]map[string]cfDefinition)
+	for table, cfs := range config.CloneApplicationTables() {
+		tmetadata := getTableMetadataInstance(table)
+		def.Tables[table] = make(map[string]cfDefinition)
+		for cfName, cfMetaData := range cfs {
+			cfDef := cfDefinition{tmetadata.getColumnFamilyID(cfName), cfMetaData, nil}
+			if len(cfMetaData.IndexedColumns) > 0 {
+				cfDef.IndexIDs = make(map[string]int)
+				for _, column := range cfMetaData.IndexedColumns {
+					cfDef.IndexIDs[column] = tmetadata.getColumnFamilyID(config.IndexCFName(cfName, column))
+				}
+			}
+			def.Tables[table][cfName] = cfDef
+		}
+	}
+	value, err := json.Marshal(def)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rm := NewRowMutation(config.SysTableName, schemaRowKey)
+	cf := createColumnFamily(config.SysTableName, config.SchemaCF)
+	cf.addColumn(NewColumn(schemaDefinitions, string(value), utils.CurrentTimeMillis(), false))
+	rm.AddCF(cf)
+	rm.ApplyE()
+}
+
+// AddTable creates a new table with the given column families
+// while the node is running
+func AddTable(tableName string, cfMetaDatas []config.CFMetaData) error {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	err := config.AddTable(tableName, cfMetaDatas)
+	if err != nil {
+		return err
+	}
+	for _, dir := range config.DataFileDirs {
+		err := os.MkdirAll(path.Join(dir, tableName), 0755)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	table := OpenTable(tableName)
+	for _, cfMetaData := range cfMetaDatas {
+		table.addColumnFamilyStore(cfMetaData.CFName, nextCFID, cfMetaData.ColumnType)
+		nextCFID++
+		addIndexes(table, cfMetaData.CFName, cfMetaData.IndexedColumns)
+	}
+	writeSchema()
+	log.Printf("added table %v\n", tableName)
+	return nil
+}
+
+// DropTable drops a table, its column families and all their data
+func DropTable(tableName string) error {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	err := config.DropTable(tableName)
+	if err != nil {
+		return err
+	}
+	table := OpenTable(tableName)
+	for cfName := range table.getColumnFamilies() {
+		dropColumnFamilyStore(table, cfName)
+	}
+	closeTable(tableName)
+	for _, dir := range config.DataFileDirs {
+		err := os.RemoveAll(path.Join(dir, tableName))
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	writeSchema()
+	log.Printf("dropped table %v\n", tableName)
+	return nil
+}
+
+// UpdateTable makes the column families of an existing table match
+// cfMetaDatas: missing ones are created, changed ones are updated
+// and the ones not listed any more are dropped with their data.
+func UpdateTable(tableName string, cfMetaDatas []config.CFMetaData) error {
+	cfs := config.GetTableMetaData(tableName)
+	if tableName == config.SysTableName || cfs == nil {
+		return fmt.Errorf("table %q does not exist", tableName)
+	}
+	// validate everything first, so that a bad definition
+	// does not leave the table half updated
+	wanted := make(map[string]config.CFMetaData)
+	for _, cfMetaData := range cfMetaDatas {
+		cfMetaData.TableName = tableName
+		err := config.ValidateCFMetaData(cfMetaData)
+		if err != nil {
+			return err
+		}
+		if _, ok := wanted[cfMetaData.CFName]; ok {
+			return fmt.Errorf("duplicate column family %q", cfMetaData.CFName)
+		}
+		if old, ok := cfs[cfMetaData.CFName]; ok && old.ColumnType != cfMetaData.ColumnType {
+			return fmt.Errorf("column family %v.%v: cannot change ColumnType from %v to %v",
+				tableName, cfMetaData.CFName, old.ColumnType, cfMetaData.ColumnType)
+		}
+		wanted[cfMetaData.CFName] = cfMetaData
+	}
+	names := make([]string, 0, len(wanted))
+	for cfName := range wanted {
+		names = append(names, cfName)
+	}
+	sort.Strings(names)
+	for _, cfName := range names {
+		var err error
+		if _, ok := cfs[cfName]; ok {
+			err = UpdateColumnFamily(wanted[cfName])
+		} else {
+			err = AddColumnFamily(wanted[cfName])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for cfName := range cfs {
+		if _, ok := wanted[cfName]; !ok {
+			err := DropColumnFamily(tableName, cfName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddColumnFamily creates a new column family in an existing table
+func AddColumnFamily(cfMetaData config.CFMetaData) error {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	err := config.AddColumnFamily(cfMetaData)
+	if err != nil {
+		return err
+	}
+	table := OpenTable(cfMetaData.TableName)
+	table.addColumnFamilyStore(cfMetaData.CFName, nextCFID, cfMetaData.ColumnType)
+	nextCFID++
+	addIndexes(table, cfMetaData.CFName, cfMetaData.IndexedColumns)
+	writeSchema()
+	log.Printf("added column family %v.%v\n", cfMetaData.TableName, cfMetaData.CFName)
+	return nil
+}
+
+// UpdateColumnFamily changes the definition of an existing column family
+func UpdateColumnFamily(cfMetaData config.CFMetaData) error {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	old, _ := config.GetCFMetaData(cfMetaData.TableName, cfMetaData.CFName)
+	err := config.UpdateColumnFamily(cfMetaData)
+	if err != nil {
+		return err
+	}
+	table := OpenTable(cfMetaData.TableName)
+	updateIndexes(table, old, cfMetaData)
+	writeSchema()
+	log.Printf("updated column family %v.%v\n", cfMetaData.TableName, cfMetaData.CFName)
+	cfStore := table.getColumnFamilyStore(cfMetaData.CFName)
+	if cfStore == nil {
+		return nil
+	}
+	cfStore.updateCacheSizes()
+	for _, column := range cfMetaData.IndexedColumns {
+		if indexStore := table.getColumnFamilyStore(config.IndexCFName(cfMetaData.CFName, column)); indexStore != nil {
+			indexStore.updateCacheSizes()
+		}
+	}
+	if old.CompactionStrategy != cfMetaData.CompactionStrategy {
+		// let the new strategy reorganize the sstables
+		go cfStore.doCompaction()
+	}
+	return nil
+}
+
+// DropColumnFamily drops a column family and all its data
+func DropColumnFamily(tableName, cfName string) error {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	old, _ := config.GetCFMetaData(tableName, cfName)
+	err := config.DropColumnFamily(tableName, cfName)
+	if err != nil {
+		return err
+	}
+	table := OpenTable(tableName)
+	dropColumnFamilyStore(table, cfName)
+	for _, column := range old.IndexedColumns {
+		dropColumnFamilyStore(table, config.IndexCFName(cfName, column))
+	}
+	writeSchema()
+	log.Printf("dropped column family %v.%v\n", tableName, cfName)
+	return nil
+}
+
+func dropColumnFamilyStore(table *Table, cfName string) {
+	id := table.getColumnFamilyID(cfName)
+	cfStore := table.removeColumnFamilyStore(cfName)
+	if cfStore == nil {
+		return
+	}
+	cfStore.drop()
+	openCommitLogE().onColumnFamilyDropped(id)
+}
+
+// addIndexes creates the index column families of the given columns
+// of column family cfName and indexes the rows already stored
+func addIndexes(table *Table, cfName string, columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	for _, column := range columns {
+		table.addColumnFamilyStore(config.IndexCFName(cfName, column), nextCFID, "Standard")
+		nextCFID++
+	}
+	table.buildIndexes(cfName, columns)
+}
+
+// updateIndexes drops the indexes of the columns old indexed but
+// cfMetaData does not and adds the ones of the newly indexed columns
+func updateIndexes(table *Table, old, cfMetaData config.CFMetaData) {
+	for _, column := range old.IndexedColumns {
+		if !cfMetaData.IsIndexed(column) {
+			dropColumnFamilyStore(table, config.IndexCFName(old.CFName, column))
+		}
+	}
+	added := make([]string, 0)
+	for _, column := range cfMetaData.IndexedColumns {
+		if !old.IsIndexed(column) {
+			added = append(added, column)
+		}
+	}
+	addIndexes(table, cfMetaData.CFName, added)
+}
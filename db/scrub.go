@@ -0,0 +1,277 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+)
+
+// SSTableVerifyResult is what verify found in one sstable
+type SSTableVerifyResult struct {
+	File string
+	// Checksummed is false for sstables written without
+	// checksums, only their rows could be checked
+	Checksummed bool
+	// Rows is the number of rows that could be read
+	Rows int
+	// Errors describes the corrupt regions
+	Errors []string
+}
+
+// SSTableScrubResult is what scrub did to one sstable
+type SSTableScrubResult struct {
+	File string
+	// NewFile is the sstable that replaced File, empty if
+	// File was left in place
+	NewFile string
+	// Rows is the number of rows kept
+	Rows int
+	// Errors describes the regions that were skipped
+	Errors []string
+}
+
+// VerifyColumnFamily checks every sstable of a column family
+// against its checksums and reads all of its rows
+func VerifyColumnFamily(tableName, cfName string) ([]*SSTableVerifyResult, error) {
+	cfStore, err := lookupColumnFamilyStore(tableName, cfName)
+	if err != nil {
+		return nil, err
+	}
+	cfStore.compactionMu.Lock()
+	defer cfStore.compactionMu.Unlock()
+	results := make([]*SSTableVerifyResult, 0)
+	for _, sstable := range getSortedSSTables(cfStore) {
+		result := verifySSTable(sstable)
+		log.Printf("verified %v: %v rows, %v errors\n", result.File, result.Rows, len(result.Errors))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ScrubColumnFamily rewrites every sstable of a column family,
+// leaving out the rows that cannot be read
+func ScrubColumnFamily(tableName, cfName string) ([]*SSTableScrubResult, error) {
+	cfStore, err := lookupColumnFamilyStore(tableName, cfName)
+	if err != nil {
+		return nil, err
+	}
+	cfStore.compactionMu.Lock()
+	defer cfStore.compactionMu.Unlock()
+	results := make([]*SSTableScrubResult, 0)
+	for _, sstable := range getSortedSSTables(cfStore) {
+		result := cfStore.scrubSSTable(sstable)
+		log.Printf("scrubbed %v into %q: %v rows kept, %v errors\n",
+			result.File, result.NewFile, result.Rows, len(result.Errors))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func getSortedSSTables(cfStore *ColumnFamilyStore) []*SSTableReader {
+	sstables := cfStore.getSSTables()
+	sort.Slice(sstables, func(i, j int) bool {
+		return sstables[i].getFilename() < sstables[j].getFilename()
+	})
+	return sstables
+}
+
+func verifySSTable(sstable *SSTableReader) *SSTableVerifyResult {
+	result := &SSTableVerifyResult{}
+	result.File = sstable.getFilename()
+	result.Checksummed = sstable.checksums != nil
+	result.Errors = make([]string, 0)
+	if sstable.corruption != nil {
+		result.Errors = append(result.Errors, sstable.corruption.Error())
+	}
+	if sstable.checksums != nil {
+		err := checkFile(sstable.filterFilename(sstable.getFilename()), sstable.checksums.filterChecksum)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		input, err := openDataInput(sstable)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return result
+		}
+		chunked := input.(*chunkedDataInput)
+		for i := 0; i < chunked.getChunkCount(); i++ {
+			err := chunked.loadChunk(i)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+		input.Close()
+	}
+	rows, errs := walkRows(sstable, func(key string, cf *ColumnFamily) {})
+	result.Rows = rows
+	result.Errors = append(result.Errors, errs...)
+	return result
+}
+
+// scrubSSTable copies the readable rows of sstable into a new
+// sstable, which then replaces it. If no row can be read the
+// sstable is left in place.
+func (c *ColumnFamilyStore) scrubSSTable(sstable *SSTableReader) *SSTableScrubResult {
+	result := &SSTableScrubResult{}
+	result.File = sstable.getFilename()
+	var writer *SSTableWriter
+	lastKey := ""
+	outOfOrder := make([]string, 0)
+	_, errs := walkRows(sstable, func(key string, cf *ColumnFamily) {
+		if lastKey != "" && !compare(lastKey, key) {
+			outOfOrder = append(outOfOrder, fmt.Sprintf("row %q is out of order", key))
+			return
+		}
+		if writer == nil {
+			keyCount := getApproximateKeyCount([]string{sstable.getFilename()})
+			writer = NewSSTableWriter(c.getTmpSSTablePath(), keyCount)
+			// a scrubbed sstable only holds rows that were
+			// backed up before, like a compacted one
+			writer.origin = compactedBackup
+		}
+		buf := make([]byte, 0)
+		CFSerializer.serializeWithIndexes(cf, &buf)
+		writer.append(key, buf)
+		lastKey = key
+		result.Rows++
+	})
+	result.Errors = append(errs, outOfOrder...)
+	if writer == nil {
+		if len(result.Errors) > 0 {
+			result.Errors = append(result.Errors, "no row could be read, sstable left in place")
+		}
+		return result
+	}
+	newSSTable := writer.closeAndOpenReader()
+	// the rows are a subset of the old ones, so the new
+	// sstable overlaps nothing the old one did not
+	newSSTable.level = sstable.level
+	result.NewFile = newSSTable.getFilename()
+	c.rwmu.Lock()
+	c.sstableMu.Lock()
+	delete(c.ssTables, result.File)
+	c.ssTables[result.NewFile] = newSSTable
+	c.sstableMu.Unlock()
+	c.rwmu.Unlock()
+	sstable.delete()
+	c.saveLevels()
+	return result
+}
+
+// walkRows calls fn with every row of sstable that can be read and
+// returns how many there were, together with the errors of the
+// others. The rows are found through the index file, so a damaged
+// row only costs that row. If the index cannot be trusted the data
+// file is read from the start up to the first damaged row.
+func walkRows(sstable *SSTableReader, fn func(key string, cf *ColumnFamily)) (int, []string) {
+	rows := 0
+	errs := make([]string, 0)
+	input, err := openDataInput(sstable)
+	if err != nil {
+		return 0, append(errs, err.Error())
+	}
+	defer input.Close()
+	dataLength := getDataLength(input)
+	entries, err := readIndexEntries(sstable)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("cannot use index: %v", err))
+		position := int64(0)
+		for position < dataLength {
+			key, cf, end, err := readRowAt(input, sstable, position)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("row at %v: %v, skipped the remaining %v bytes",
+					position, err, dataLength-position))
+				break
+			}
+			fn(key, cf)
+			rows++
+			position = end
+		}
+		return rows, errs
+	}
+	for i, entry := range entries {
+		next := dataLength
+		if i+1 < len(entries) {
+			next = entries[i+1].position
+		}
+		key, cf, end, err := readRowAt(input, sstable, entry.position)
+		if err == nil && key != entry.key {
+			err = fmt.Errorf("found key %q", key)
+		}
+		if err == nil && end != next {
+			err = fmt.Errorf("row ends at %v, next row starts at %v", end, next)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("row %q at %v: %v", entry.key, entry.position, err))
+			continue
+		}
+		fn(key, cf)
+		rows++
+	}
+	return rows, errs
+}
+
+// readIndexEntries returns the keys of sstable with their
+// positions in the data file as listed in its index file
+func readIndexEntries(sstable *SSTableReader) ([]*KeyPositionInfo, error) {
+	filename := sstable.indexFilename(sstable.getFilename())
+	if sstable.checksums != nil {
+		err := checkFile(filename, sstable.checksums.indexChecksum)
+		if err != nil {
+			return nil, err
+		}
+	}
+	input, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+	indexSize := getFileSize(input)
+	reader := bufio.NewReader(input)
+	entries := make([]*KeyPositionInfo, 0)
+	for position := int64(0); position < indexSize; position += 8 {
+		key, err := readStringB(reader)
+		if err != nil {
+			return nil, err
+		}
+		position += int64(4 + len(key))
+		dataPosition, err := readInt64B(reader)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, NewKeyPositionInfo(key, dataPosition))
+	}
+	return entries, nil
+}
+
+// readRowAt reads the whole row stored at position of the data
+// file and returns it with the position where the next row starts
+func readRowAt(input dataInput, sstable *SSTableReader, position int64) (string, *ColumnFamily, int64, error) {
+	_, err := input.Seek(position, io.SeekStart)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	row, err := NewIteratingRow(input, sstable)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	for row.hasNext() && getCurrentPos(input) < row.finishedAt {
+		column, err := row.next()
+		if err != nil {
+			return "", nil, 0, err
+		}
+		row.getEmptyColumnFamily().addColumn(column)
+	}
+	if getCurrentPos(input) != row.finishedAt {
+		return "", nil, 0, fmt.Errorf("columns overrun the row")
+	}
+	return row.key, row.getEmptyColumnFamily(), row.finishedAt, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestNewCorruptSSTableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want CorruptSSTableError
+	}{
+		{"plain error", io.ErrUnexpectedEOF, CorruptSSTableError{"f-1-Data.db", io.ErrUnexpectedEOF}},
+		{"without file", &CorruptSSTableError{Err: errSSTableChecksum},
+			CorruptSSTableError{"f-1-Data.db", errSSTableChecksum}},
+		{"with file", &CorruptSSTableError{"g-2-Data.db", errSSTableChecksum},
+			CorruptSSTableError{"g-2-Data.db", errSSTableChecksum}},
+	}
+	for _, test := range tests {
+		got := newCorruptSSTableError("f-1-Data.db", test.err)
+		if got.File != test.want.File || !errors.Is(got.Err, test.want.Err) {
+			t.Errorf("%v: got %v, want %v", test.name, got, &test.want)
+		}
+	}
+}
+
+func TestScrubKeepsReadableRows(t *testing.T) {
+	defer func(chunkLength int) { config.CompressionChunkLengthInKB = chunkLength }(config.CompressionChunkLengthInKB)
+	config.CompressionChunkLengthInKB = 1
+	table := addTestTable(t, "scrubtest", config.CFMetaData{CFName: "cf", ColumnType: "Standard"})
+	cfStore := table.getColumnFamilyStore("cf")
+	value := strings.Repeat("v", 100)
+	keys := make([]string, 0)
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%v", i)
+		keys = append(keys, key)
+		applyTestRow("scrubtest", "cf", key, NewColumn("c", value, 1, false))
+	}
+	flushTestStore(cfStore)
+	sstables := cfStore.getSSTables()
+	if len(sstables) != 1 || sstables[0].checksums == nil {
+		t.Fatalf("flush wrote %v sstables", len(sstables))
+	}
+	// flip a byte in the middle of the third chunk
+	file, err := os.OpenFile(sstables[0].getFilename(), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	position := int64(2*1024 + 512)
+	b := make([]byte, 1)
+	file.ReadAt(b, position)
+	file.WriteAt([]byte{b[0] ^ 0xff}, position)
+	file.Close()
+
+	readable := make(map[string]bool)
+	for _, key := range keys {
+		cf, err := readTestRow(cfStore, key)
+		var corrupt *CorruptSSTableError
+		if errors.As(err, &corrupt) {
+			continue
+		}
+		if err != nil || cf == nil || cf.GetColumn("c") == nil {
+			t.Fatalf("row %v is read as %v, %v", key, cf, err)
+		}
+		readable[key] = true
+	}
+	if len(readable) == 0 || len(readable) == len(keys) {
+		t.Fatalf("%v of %v rows can be read, want only the ones outside the corrupt chunk",
+			len(readable), len(keys))
+	}
+
+	results, err := ScrubColumnFamily("scrubtest", "cf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].NewFile == "" || len(results[0].Errors) == 0 ||
+		results[0].Rows != len(readable) {
+		t.Fatalf("scrub gives %+v, want %v rows kept", results[0], len(readable))
+	}
+	for _, key := range keys {
+		cf, err := readTestRow(cfStore, key)
+		if err != nil {
+			t.Fatalf("row %v can't be read after the scrub: %v", key, err)
+		}
+		found := cf != nil && cf.GetColumn("c") != nil
+		if found != readable[key] {
+			t.Errorf("row %v found = %v after the scrub, want %v", key, found, readable[key])
+		}
+		if found && string(cf.GetColumn("c").getValue()) != value {
+			t.Errorf("row %v has value %q after the scrub", key, cf.GetColumn("c").getValue())
+		}
+	}
+}
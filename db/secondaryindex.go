@@ -0,0 +1,306 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// Every indexed column of a column family has an index column family,
+// named by config.IndexCFName. An index row is keyed by a value of the
+// column and holds an empty column named after each row key that has
+// the value. The index is local: every node indexes the rows it stores,
+// and it is updated by Table.apply together with the rows. Rows cannot
+// have empty keys, so empty values are not indexed.
+
+// indexLockCount is the number of locks the row keys of
+// indexed column families are spread over
+const indexLockCount = 64
+
+// IndexExpression asks for the rows whose column
+// ColumnName holds Value
+type IndexExpression struct {
+	ColumnName string
+	Value      string
+}
+
+// IndexScanArgs ...
+type IndexScanArgs struct {
+	Table        string
+	ColumnFamily string
+	// Expressions must all hold for a row, at least
+	// one of their columns has to be indexed
+	Expressions []IndexExpression
+	// StartKey is the smallest key returned
+	StartKey string
+	// Count is the most keys returned, 0 for all of them
+	Count int
+}
+
+// IndexScanReply ...
+type IndexScanReply struct {
+	// Keys are sorted
+	Keys []string
+}
+
+// DoIndexScan finds the keys of the local rows matching
+// args.Expressions through the index of one of their columns
+func DoIndexScan(args *IndexScanArgs, reply *IndexScanReply) error {
+	cfStore, err := lookupColumnFamilyStore(args.Table, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	if len(args.Expressions) == 0 {
+		return fmt.Errorf("an index scan needs at least one expression")
+	}
+	cfMetaData, _ := config.GetCFMetaData(args.Table, args.ColumnFamily)
+	var indexed *IndexExpression
+	for i := range args.Expressions {
+		if cfMetaData.IsIndexed(args.Expressions[i].ColumnName) {
+			indexed = &args.Expressions[i]
+			break
+		}
+	}
+	if indexed == nil {
+		return fmt.Errorf("none of the columns of the expressions is indexed in %v.%v",
+			args.Table, args.ColumnFamily)
+	}
+	reply.Keys = make([]string, 0)
+	if indexed.Value == "" {
+		return nil
+	}
+	indexName := config.IndexCFName(args.ColumnFamily, indexed.ColumnName)
+	indexStore := OpenTable(args.Table).getColumnFamilyStore(indexName)
+	if indexStore == nil {
+		return fmt.Errorf("index %v.%v does not exist", args.Table, indexName)
+	}
+	entries, err := indexStore.getColumnFamily(NewIdentityQueryFilter(indexed.Value, NewQueryPathCF(indexName)))
+	if err != nil || entries == nil {
+		return err
+	}
+	for _, entry := range entries.GetSortedColumns() {
+		key := entry.getName()
+		if entry.isMarkedForDelete() || key < args.StartKey {
+			continue
+		}
+		// the entry may be older than the row, so
+		// the row itself decides whether it matches
+		cf, err := cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(args.ColumnFamily)))
+		if err != nil {
+			return err
+		}
+		if !matchesExpressions(cf, args.Expressions) {
+			continue
+		}
+		reply.Keys = append(reply.Keys, key)
+		if args.Count > 0 && len(reply.Keys) >= args.Count {
+			break
+		}
+	}
+	return nil
+}
+
+func matchesExpressions(cf *ColumnFamily, expressions []IndexExpression) bool {
+	for _, expression := range expressions {
+		column := getLiveColumn(cf, expression.ColumnName)
+		if column == nil || string(column.getValue()) != expression.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// getLiveColumn returns the column name of cf, or nil if
+// cf has no such column or it is deleted
+func getLiveColumn(cf *ColumnFamily, name string) IColumn {
+	if cf == nil {
+		return nil
+	}
+	column, ok := cf.Columns[name]
+	if !ok || column.isMarkedForDelete() || column.timestamp() <= cf.getMarkedForDeleteAt() {
+		return nil
+	}
+	return column
+}
+
+func newIndexEntry(key string, column Column) Column {
+	entry := NewColumn(key, "", column.Timestamp, false)
+	// the entry expires together with the column
+	entry.TTL = column.TTL
+	entry.LocalExpirationTime = column.LocalExpirationTime
+	return entry
+}
+
+// indexRows collects the changes to index rows, by index row key
+type indexRows struct {
+	tableName string
+	rows      map[string]*Row
+}
+
+func newIndexRows(tableName string) *indexRows {
+	return &indexRows{tableName, make(map[string]*Row)}
+}
+
+func (r *indexRows) add(indexName, value string, entry Column) {
+	row, ok := r.rows[value]
+	if !ok {
+		row = NewRowT(r.tableName, value)
+		r.rows[value] = row
+	}
+	cf, ok := row.ColumnFamilies[indexName]
+	if !ok {
+		cf = NewColumnFamily(indexName, "Standard")
+		row.ColumnFamilies[indexName] = cf
+	}
+	cf.addColumn(entry)
+}
+
+func (r *indexRows) getRows() []*Row {
+	rows := make([]*Row, 0, len(r.rows))
+	for _, row := range r.rows {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// getIndexedColumns returns the indexed columns of the
+// column families of row that have any
+func getIndexedColumns(row *Row) map[string][]string {
+	res := make(map[string][]string)
+	for cfName := range row.ColumnFamilies {
+		cfMetaData, _ := config.GetCFMetaData(row.Table, cfName)
+		if len(cfMetaData.IndexedColumns) > 0 {
+			res[cfName] = cfMetaData.IndexedColumns
+		}
+	}
+	return res
+}
+
+func (t *Table) getIndexLock(key string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return &t.indexLocks[hash.Sum32()%indexLockCount]
+}
+
+// getIndexUpdates compares the indexed columns of the row stored
+// under row.Key with their values once row is applied. It returns
+// the index entries of the new values and the tombstones of the
+// entries of the old ones. The caller holds the index lock of the key.
+func (t *Table) getIndexUpdates(row *Row, indexedColumns map[string][]string) (additions, removals []*Row) {
+	added := newIndexRows(t.tableName)
+	removed := newIndexRows(t.tableName)
+	for cfName, columns := range indexedColumns {
+		cfStore := t.getColumnFamilyStore(cfName)
+		if cfStore == nil {
+			continue
+		}
+		mutation := row.ColumnFamilies[cfName]
+		// keep the tombstones, a write may be older than a delete
+		old, err := cfStore.readWithTombstones(row.Key)
+		if err != nil {
+			// stale entries are filtered out by scans
+			log.Printf("cannot read %v from %v.%v to update its indexes: %v\n", row.Key, t.tableName, cfName, err)
+		}
+		merged := NewColumnFamily(cfName, "Standard")
+		if old != nil {
+			merged.addColumns(old)
+			merged.deleteCF(old)
+		}
+		merged.addColumns(mutation)
+		merged.deleteCF(mutation)
+		for _, column := range columns {
+			oldColumn, _ := getLiveColumn(old, column).(Column)
+			newColumn, _ := getLiveColumn(merged, column).(Column)
+			indexName := config.IndexCFName(cfName, column)
+			if oldColumn.Value != "" && newColumn.Value != oldColumn.Value {
+				removed.add(indexName, oldColumn.Value, NewColumn(row.Key, "", oldColumn.Timestamp, true))
+			}
+			if newColumn.Value != "" && (newColumn.Value != oldColumn.Value ||
+				newColumn.Timestamp != oldColumn.Timestamp || newColumn.TTL != oldColumn.TTL) {
+				added.add(indexName, newColumn.Value, newIndexEntry(row.Key, newColumn))
+			}
+		}
+	}
+	return added.getRows(), removed.getRows()
+}
+
+func (c *ColumnFamilyStore) readWithTombstones(key string) (*ColumnFamily, error) {
+	filter := NewIdentityQueryFilter(key, NewQueryPathCF(c.columnFamilyName))
+	return c.getColumnFamilyGC(filter, math.MinInt32)
+}
+
+// buildIndexes indexes the existing rows of column family cfName by
+// the given columns, whose index column families must already exist.
+// Writes going on meanwhile update the indexes themselves.
+func (t *Table) buildIndexes(cfName string, columns []string) {
+	cfStore := t.getColumnFamilyStore(cfName)
+	if cfStore == nil {
+		return
+	}
+	keys := cfStore.getKeys()
+	for _, key := range keys {
+		lock := t.getIndexLock(key)
+		lock.Lock()
+		cf, err := cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(cfName)))
+		if err != nil {
+			lock.Unlock()
+			log.Printf("cannot index %v of %v.%v: %v\n", key, t.tableName, cfName, err)
+			continue
+		}
+		added := newIndexRows(t.tableName)
+		for _, column := range columns {
+			live, _ := getLiveColumn(cf, column).(Column)
+			if live.Value != "" {
+				added.add(config.IndexCFName(cfName, column), live.Value, newIndexEntry(key, live))
+			}
+		}
+		for _, indexRow := range added.getRows() {
+			t.applyLogged(indexRow)
+		}
+		lock.Unlock()
+	}
+	log.Printf("built indexes %v of %v.%v over %v rows\n", columns, t.tableName, cfName, len(keys))
+}
+
+// getKeys returns the sorted keys of all rows of this column family
+// in its memtables and sstables, including the deleted ones
+func (c *ColumnFamilyStore) getKeys() []string {
+	seen := make(map[string]bool)
+	c.memMu.RLock()
+	for key := range c.memtable.columnFamilies {
+		seen[key] = true
+	}
+	c.memMu.RUnlock()
+	// memtables being flushed are frozen, no lock needed
+	for _, memtable := range getUnflushedMemtables(c.tableName, c.columnFamilyName) {
+		for key := range memtable.columnFamilies {
+			seen[key] = true
+		}
+	}
+	for _, sstable := range c.getSSTables() {
+		entries, err := readIndexEntries(sstable)
+		if err != nil {
+			log.Printf("cannot read the keys of %v: %v\n", sstable.getFilename(), err)
+			continue
+		}
+		for _, entry := range entries {
+			seen[sstable.partitioner.UndecorateKey(entry.key)] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestSecondaryIndexFollowsUpdatesAndDeletes(t *testing.T) {
+	table := addTestTable(t, "IndexTable", config.CFMetaData{
+		CFName: "Users", ColumnType: "Standard", IndexedColumns: []string{"state"},
+	})
+	indexStore := table.getColumnFamilyStore(config.IndexCFName("Users", "state"))
+	if indexStore == nil {
+		t.Fatalf("index column family %v was not created", config.IndexCFName("Users", "state"))
+	}
+	// entry returns whether the index row of value has a live
+	// entry for key and whether it has one at all
+	entry := func(value, key string) (live, found bool) {
+		cf, err := indexStore.readWithTombstones(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cf == nil || cf.Columns[key] == nil {
+			return false, false
+		}
+		return !cf.Columns[key].isMarkedForDelete(), true
+	}
+	scan := func(value string) []string {
+		args := &IndexScanArgs{Table: "IndexTable", ColumnFamily: "Users",
+			Expressions: []IndexExpression{{"state", value}}}
+		reply := &IndexScanReply{}
+		if err := DoIndexScan(args, reply); err != nil {
+			t.Fatal(err)
+		}
+		return reply.Keys
+	}
+	check := func(step string, want map[string][]string) {
+		for value, keys := range want {
+			if got := scan(value); !reflect.DeepEqual(got, keys) {
+				t.Errorf("%v: scan of state %v = %v, want %v", step, value, got, keys)
+			}
+		}
+	}
+
+	applyTestRow("IndexTable", "Users", "k1", NewColumn("state", "CA", 1, false))
+	applyTestRow("IndexTable", "Users", "k2", NewColumn("state", "CA", 1, false))
+	check("insert", map[string][]string{"CA": {"k1", "k2"}, "NY": {}})
+
+	// an update moves the entry to the new value
+	applyTestRow("IndexTable", "Users", "k1", NewColumn("state", "NY", 2, false))
+	if live, found := entry("CA", "k1"); !found || live {
+		t.Errorf("update: entry of k1 under CA is live = %v, found = %v, want a tombstone", live, found)
+	}
+	if live, _ := entry("NY", "k1"); !live {
+		t.Errorf("update: no live entry of k1 under NY")
+	}
+	check("update", map[string][]string{"CA": {"k2"}, "NY": {"k1"}})
+
+	// a write older than the stored column changes nothing
+	applyTestRow("IndexTable", "Users", "k1", NewColumn("state", "TX", 1, false))
+	if _, found := entry("TX", "k1"); found {
+		t.Errorf("stale write: k1 was indexed under TX")
+	}
+	check("stale write", map[string][]string{"NY": {"k1"}, "TX": {}})
+
+	// deleting the column removes its entry
+	rm := NewRowMutation("IndexTable", "k2")
+	rm.Delete(NewQueryPath("Users", nil, []byte("state")), 3)
+	rm.ApplyE()
+	if live, found := entry("CA", "k2"); !found || live {
+		t.Errorf("column delete: entry of k2 under CA is live = %v, found = %v, want a tombstone", live, found)
+	}
+	check("column delete", map[string][]string{"CA": {}})
+
+	// so does deleting the whole row
+	rm = NewRowMutation("IndexTable", "k1")
+	rm.Delete(NewQueryPathCF("Users"), 4)
+	rm.ApplyE()
+	if live, found := entry("NY", "k1"); !found || live {
+		t.Errorf("row delete: entry of k1 under NY is live = %v, found = %v, want a tombstone", live, found)
+	}
+	check("row delete", map[string][]string{"NY": {}})
+
+	// a write newer than the deletion is indexed again, also
+	// once the index and the rows are in sstables
+	applyTestRow("IndexTable", "Users", "k2", NewColumn("state", "NY", 5, false))
+	flushTestStore(table.getColumnFamilyStore("Users"))
+	flushTestStore(indexStore)
+	check("rewrite", map[string][]string{"CA": {}, "NY": {"k2"}})
+}
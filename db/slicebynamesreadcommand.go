@@ -41,6 +41,6 @@ func (s *SliceByNamesReadCommand) GetTable() string {
 }
 
 // GetRow ...
-func (s *SliceByNamesReadCommand) GetRow(table *Table) *Row {
+func (s *SliceByNamesReadCommand) GetRow(table *Table) (*Row, error) {
 	return table.getRow(NewNamesQueryFilterS(s.Key, &s.QPath, s.columnNames))
 }
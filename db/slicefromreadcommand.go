@@ -47,7 +47,7 @@ func (s *SliceFromReadCommand) GetTable() string {
 }
 
 // GetRow ...
-func (s *SliceFromReadCommand) GetRow(table *Table) *Row {
+func (s *SliceFromReadCommand) GetRow(table *Table) (*Row, error) {
 	return table.getRow(NewSliceQueryFilter(s.Key, &s.QPath,
 		s.Start, s.Finish, s.Reversed, s.Count))
 }
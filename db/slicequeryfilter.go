@@ -5,7 +5,10 @@
 
 package db
 
-import "log"
+import (
+	"log"
+	"sort"
+)
 
 // SliceQueryFilter ...
 type SliceQueryFilter struct {
@@ -32,8 +35,46 @@ func (s *SliceQueryFilter) getMemColumnIterator(memtable *Memtable) ColumnIterat
 	return memtable.getSliceIterator(s)
 }
 
-func (s *SliceQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) ColumnIterator {
-	return NewSSTableSliceIterator(sstable, s.key, s.start, s.reversed)
+// getColumnFamilyIterator returns an iterator of the columns of cf
+// in the specified order starting from the start column
+func (s *SliceQueryFilter) getColumnFamilyIterator(cf *ColumnFamily) ColumnIterator {
+	columnFamily := cf.cloneMeShallow()
+	columns := cf.GetSortedColumns()
+	if s.reversed == true {
+		reverse(columns)
+	}
+	var startIColumn IColumn
+	if !cf.isSuper() {
+		startIColumn = NewColumn(string(s.start), "", 0, false)
+	} else {
+		startIColumn = NewSuperColumn(string(s.start))
+	}
+	index := 0
+	if len(s.start) == 0 && s.reversed {
+		// scan from the largest column in descending order
+		index = 0
+	} else {
+		index = sort.Search(len(columns), func(i int) bool {
+			return columns[i].getName() >= startIColumn.getName()
+		})
+	}
+	startIndex := index
+	return NewAColumnIterator(startIndex, columnFamily, columns)
+}
+
+func (s *SliceQueryFilter) filterColumnFamily(cf *ColumnFamily, gcBefore int) *ColumnFamily {
+	iter := s.getColumnFamilyIterator(cf)
+	returnCF := iter.getColumnFamily()
+	s.collectCollatedColumns(returnCF, NewCollatedIterator([]ColumnIterator{iter}), gcBefore)
+	return returnCF
+}
+
+func (s *SliceQueryFilter) getSSTableColumnIterator(sstable *SSTableReader) (ColumnIterator, error) {
+	iter, err := NewSSTableSliceIterator(sstable, s.key, s.start, s.reversed)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
 }
 
 func (s *SliceQueryFilter) collectCollatedColumns(returnCF *ColumnFamily, collatedColumns *CollatedIterator, gcBefore int) {
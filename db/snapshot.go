@@ -0,0 +1,226 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// A snapshot is a directory of config.SnapshotDir holding hard
+// links to the sstables that were live when it was taken:
+//   <SnapshotDir>/<name>/<table>/<cf>-<index>-Data.db, ...
+// The links keep the data after compactions delete the sstables
+// and take no space until then. To restore a snapshot, stop the
+// node and copy its files back into the data directory.
+
+// SnapshotInfo describes a snapshot
+type SnapshotInfo struct {
+	Name string
+	// Tables maps every table in the snapshot
+	// to its column families
+	Tables map[string][]string
+	Files  int
+	// Size is the total size of the files, including
+	// the space they still share with live sstables
+	Size int64
+}
+
+// TakeSnapshot flushes the memtables of a column family, or of all
+// the column families of the table if cfName is empty, and links
+// their sstables into the named snapshot. An empty name picks the
+// current time in milliseconds. It returns the snapshot name.
+func TakeSnapshot(tableName, cfName, name string) (string, error) {
+	if name == "" {
+		name = strconv.FormatInt(getCurrentTimeInMillis(), 10)
+	}
+	err := validateSnapshotName(name)
+	if err != nil {
+		return "", err
+	}
+	var cfStores []*ColumnFamilyStore
+	if cfName == "" {
+		if config.GetTableMetaData(tableName) == nil {
+			return "", fmt.Errorf("table %q does not exist", tableName)
+		}
+		cfStores = OpenTable(tableName).getColumnFamilyStores()
+	} else {
+		cfStore, err := lookupColumnFamilyStore(tableName, cfName)
+		if err != nil {
+			return "", err
+		}
+		cfStores = []*ColumnFamilyStore{cfStore}
+	}
+	for _, cfStore := range cfStores {
+		cfStore.forceBlockingFlush(openCommitLogE().getContext())
+	}
+	for _, cfStore := range cfStores {
+		cfStore.compactionMu.Lock()
+		err := cfStore.snapshot(name)
+		cfStore.compactionMu.Unlock()
+		if err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// ListSnapshots describes the snapshots taken on this node
+func ListSnapshots() ([]*SnapshotInfo, error) {
+	dirs, err := ioutil.ReadDir(config.SnapshotDir)
+	if os.IsNotExist(err) {
+		return []*SnapshotInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*SnapshotInfo, 0)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		snapshot, err := readSnapshotInfo(dir.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// ClearSnapshot deletes the named snapshot, or all snapshots if
+// name is empty, and returns the names of the deleted snapshots
+func ClearSnapshot(name string) ([]string, error) {
+	names := make([]string, 0)
+	if name != "" {
+		err := validateSnapshotName(name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = os.Stat(path.Join(config.SnapshotDir, name))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot %q does not exist", name)
+		}
+		names = append(names, name)
+	} else {
+		snapshots, err := ListSnapshots()
+		if err != nil {
+			return nil, err
+		}
+		for _, snapshot := range snapshots {
+			names = append(names, snapshot.Name)
+		}
+	}
+	for _, name := range names {
+		err := os.RemoveAll(path.Join(config.SnapshotDir, name))
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("cleared snapshot %v\n", name)
+	}
+	return names, nil
+}
+
+func validateSnapshotName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, string(os.PathSeparator)) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+func readSnapshotInfo(name string) (*SnapshotInfo, error) {
+	snapshot := &SnapshotInfo{}
+	snapshot.Name = name
+	snapshot.Tables = make(map[string][]string)
+	tables, err := ioutil.ReadDir(path.Join(config.SnapshotDir, name))
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		files, err := ioutil.ReadDir(path.Join(config.SnapshotDir, name, table.Name()))
+		if err != nil {
+			return nil, err
+		}
+		cfNames := make(map[string]bool)
+		for _, file := range files {
+			snapshot.Files++
+			snapshot.Size += file.Size()
+			cfNames[getColumnFamilyFromFileName(file.Name())] = true
+		}
+		for cfName := range cfNames {
+			snapshot.Tables[table.Name()] = append(snapshot.Tables[table.Name()], cfName)
+		}
+		sort.Strings(snapshot.Tables[table.Name()])
+	}
+	return snapshot, nil
+}
+
+// snapshot links the live sstables into the named snapshot, the
+// caller holds compactionMu so that none of them goes away meanwhile
+func (c *ColumnFamilyStore) snapshot(name string) error {
+	dir := path.Join(config.SnapshotDir, name, c.tableName)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, sstable := range c.getSSTables() {
+		for _, filename := range sstable.getComponentFilenames() {
+			_, err := os.Stat(filename)
+			if os.IsNotExist(err) {
+				continue
+			}
+			err = linkOrCopy(filename, path.Join(dir, path.Base(filename)))
+			if err != nil {
+				return err
+			}
+		}
+		count++
+	}
+	log.Printf("snapshot %v: linked %v sstables of %v.%v\n", name, count, c.tableName, c.columnFamilyName)
+	return nil
+}
+
+// snapshotBeforeCompaction is called by doCompaction when
+// SnapshotBeforeCompaction is set
+func (c *ColumnFamilyStore) snapshotBeforeCompaction() error {
+	name := fmt.Sprintf("%v-compact-%v", getCurrentTimeInMillis(), c.columnFamilyName)
+	return c.snapshot(name)
+}
+
+// linkOrCopy hard links src to dst, or copies it if the snapshot
+// directory is on another file system than the data directory
+func linkOrCopy(src, dst string) error {
+	err := os.Link(src, dst)
+	if err == nil || os.IsExist(err) {
+		return err
+	}
+	log.Printf("cannot link %v, copying it: %v\n", src, err)
+	input, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	output, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	_, err = io.Copy(output, input)
+	if err != nil {
+		return err
+	}
+	return output.Sync()
+}
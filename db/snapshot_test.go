@@ -0,0 +1,89 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestSnapshotAndClear(t *testing.T) {
+	setCompactionThresholds(t, 2, 32)
+	table := addTestTable(t, "snapshottest", config.CFMetaData{CFName: "cf", ColumnType: "Standard"})
+	cfStore := table.getColumnFamilyStore("cf")
+	for i := 0; i < 10; i++ {
+		applyTestRow("snapshottest", "cf", fmt.Sprintf("key%v", i), NewColumn("c", "v", 1, false))
+	}
+	// the snapshot flushes the memtable itself
+	name, err := TakeSnapshot("snapshottest", "", "snap")
+	if err != nil || name != "snap" {
+		t.Fatalf("TakeSnapshot() = %v, %v", name, err)
+	}
+	sstables := cfStore.getSSTables()
+	if len(sstables) != 1 {
+		t.Fatalf("the snapshot flushed %v sstables", len(sstables))
+	}
+	dataFile := sstables[0].getFilename()
+	linked := path.Join(config.SnapshotDir, "snap", "snapshottest", path.Base(dataFile))
+	live, err := os.Stat(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotted, err := os.Stat(linked)
+	if err != nil || !os.SameFile(live, snapshotted) {
+		t.Fatalf("%v is not linked into the snapshot: %v", dataFile, err)
+	}
+	snapshots, err := ListSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, snapshot := range snapshots {
+		if snapshot.Name == "snap" {
+			found = true
+			if fmt.Sprint(snapshot.Tables) != "map[snapshottest:[cf]]" || snapshot.Files == 0 {
+				t.Errorf("snapshot is listed as %+v", snapshot)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("snapshot is not listed")
+	}
+	// the snapshot keeps the data after a compaction deletes the sstable
+	applyTestRow("snapshottest", "cf", "key10", NewColumn("c", "v", 1, false))
+	flushTestStore(cfStore)
+	cfStore.doCompaction()
+	if _, err := os.Stat(dataFile); !os.IsNotExist(err) {
+		t.Fatalf("compaction left %v: %v", dataFile, err)
+	}
+	if info, err := os.Stat(linked); err != nil || info.Size() != live.Size() {
+		t.Fatalf("the snapshot lost %v: %v", linked, err)
+	}
+
+	if _, err := TakeSnapshot("snapshottest", "", "../snap"); err == nil {
+		t.Errorf("a snapshot name with a path separator is accepted")
+	}
+	if _, err := TakeSnapshot("snapshottest", "nocf", "snap2"); err == nil {
+		t.Errorf("a snapshot of a missing column family is taken")
+	}
+	names, err := ClearSnapshot("snap")
+	if err != nil || fmt.Sprint(names) != "[snap]" {
+		t.Fatalf("ClearSnapshot() = %v, %v", names, err)
+	}
+	if _, err := os.Stat(path.Join(config.SnapshotDir, "snap")); !os.IsNotExist(err) {
+		t.Errorf("the snapshot directory is left: %v", err)
+	}
+	if _, err := ClearSnapshot("snap"); err == nil {
+		t.Errorf("clearing a missing snapshot succeeds")
+	}
+	if _, err := os.Stat(cfStore.getSSTables()[0].getFilename()); err != nil {
+		t.Errorf("clearing the snapshot removed a live sstable: %v", err)
+	}
+}
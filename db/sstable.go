@@ -7,6 +7,7 @@ package db
 
 import (
 	"encoding/binary"
+	"io"
 	"log"
 	"os"
 	"sort"
@@ -36,11 +37,9 @@ var (
 	// as value. This BloomFilter will tell us if a key/
 	// column pair is in the SSTable. If not, we can avoid
 	// scanning it.
-	SSTbfs = make(map[string]*utils.BloomFilter)
-	// maintains a touched set of keys
-	SSTTouchCache = NewTouchKeyCache(config.TouchKeyCacheSize)
-	bfMarker      = "Bloom-Filter"
-	SSTBlkIdxKey  = "BLOCK-INDEX"
+	SSTbfs       = make(map[string]*utils.BloomFilter)
+	bfMarker     = "Bloom-Filter"
+	SSTBlkIdxKey = "BLOCK-INDEX"
 )
 
 // KeyPositionInfo contains index key and its corresponding
@@ -138,21 +137,31 @@ func getColumnFamilyFromFullPath(filename string) string {
 	return cfName
 }
 
-func (s *SSTable) loadIndexFile() {
+func (s *SSTable) loadIndexFile() error {
 	// filename of the type:
 	//  var/storage/data/tableName/<columnFamilyName>-<index>-Data.db
 	file, err := os.Open(s.dataFileName)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer file.Close()
 	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
 	size := fileInfo.Size()
-	s.loadBloomFilter(file, size)
+	err = s.loadBloomFilter(file, size)
+	if err != nil {
+		return err
+	}
 	// start building index
 	// the first block index position is stored
 	// at the 16 bytes before the end of the file
 	file.Seek(size-16, 0)
-	firstBlockIndexPosition := readInt64(file)
+	firstBlockIndexPosition, err := readInt64B(file)
+	if err != nil {
+		return err
+	}
 	keyPositionInfos := make([]*KeyPositionInfo, 0)
 	SSTIndexMetadataMap[s.dataFileName] = keyPositionInfos
 	nextPosition := size - 16 - firstBlockIndexPosition
@@ -173,113 +182,57 @@ func (s *SSTable) loadIndexFile() {
 	for {
 		currentPosition = nextPosition
 		b11 := make([]byte, 11)
-		blockIdxKey := readBlockIdxKey(file, b11)
+		_, err = io.ReadFull(file, b11)
+		if err != nil {
+			return err
+		}
 		nextPosition -= 11
-		if blockIdxKey != SSTBlkIdxKey {
+		if string(b11) != SSTBlkIdxKey {
 			log.Printf("Done reading the block indexes\n")
 			break
 		}
-		readInt32(file) // read block index size
+		// read block index size
+		if _, err = readInt32B(file); err != nil {
+			return err
+		}
 		nextPosition -= 4
-		numKeys := readInt32(file)
+		numKeys, err := readInt32B(file)
+		if err != nil {
+			return err
+		}
 		nextPosition -= 4
 		for i := int32(0); i < numKeys; i++ {
-			keyInBlock, size := readString(file)
-			nextPosition -= size
+			keyInBlock, err := readStringB(file)
+			if err != nil {
+				return err
+			}
+			nextPosition -= int64(4 + len(keyInBlock))
 			if i == 0 {
 				keyPositionInfos = append(keyPositionInfos,
 					&KeyPositionInfo{keyInBlock, currentPosition})
 			}
-			readInt64(file) // read relative offset
-			readInt64(file) // read dataSize
+			// skip relative offset and dataSize
+			if _, err = io.ReadFull(file, make([]byte, 16)); err != nil {
+				return err
+			}
 			nextPosition -= 16
 		}
 	}
 	// should also sort KeyPositionInfos, but I omit it. :)
+	return nil
 }
 
-func readBool(file *os.File) (bool, int) {
-	b := make([]byte, 1)
-	file.Read(b)
-	if b[0] == 1 {
-		return true, 1
-	}
-	return false, 1
-}
-
-func readBytes(file *os.File) ([]byte, int) {
-	size := readInt(file)
-	b := make([]byte, size)
-	file.Read(b)
-	return b, size + 4
-}
-
-func readString(file *os.File) (string, int64) {
-	size := int(readInt32(file))
-	bs := make([]byte, size)
-	return readBlockIdxKey(file, bs), int64(size + 4)
-}
-
-func readBlockIdxKey(file *os.File, b []byte) string {
-	n, err := file.Read(b)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if n != len(b) {
-		log.Fatal("should read len(b) byte for block index key")
-	}
-	return string(b)
-}
-
-func readInt64(file *os.File) int64 {
-	b8 := make([]byte, 8)
-	n, err := file.Read(b8)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if n != 8 {
-		log.Fatal("should read 8 bytes")
-	}
-	return int64(binary.BigEndian.Uint64(b8))
-}
-
-func readInt32(file *os.File) int32 {
-	b4 := make([]byte, 4)
-	n, err := file.Read(b4)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if n != 4 {
-		log.Fatal("should read 4 bytes")
-	}
-	return int32(binary.BigEndian.Uint32(b4))
-}
-
-func readUint64(file *os.File) uint64 {
-	b8 := make([]byte, 8)
-	n, err := file.Read(b8)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if n != 8 {
-		log.Fatal("should read 8 bytes")
-	}
-	return binary.BigEndian.Uint64(b8)
-}
-
-func (s *SSTable) loadBloomFilter(file *os.File, size int64) {
+func (s *SSTable) loadBloomFilter(file *os.File, size int64) error {
 	if _, ok := SSTbfs[s.dataFileName]; ok {
-		return // bloom filter already exists in memory
+		return nil // bloom filter already exists in memory
 	}
 	// the last 8 bytes form a int64 denoting
 	// relative position of bloom filter
 	file.Seek(size-8, 0)
-	b8 := make([]byte, 8)
-	_, err := file.Read(b8)
+	position, err := readInt64B(file)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	position := int64(binary.BigEndian.Uint64(b8))
 	// seek to the position of bloom filter
 	file.Seek(size-8-position, 0)
 	// the contents of bf are as follows:
@@ -290,28 +243,38 @@ func (s *SSTable) loadBloomFilter(file *os.File, size int64) {
 	// size, int32
 	// bitset, BitSet, stored as []uint64
 	// Start decoding!
-	n, err := file.Read(b8)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if n != 8 {
-		log.Fatal("should read 8 bytes")
-	}
 	// don't need this variable
 	// totalDataSize := int64(binary.BigEndian.Uint64(b8))
-	count := readInt32(file)
+	if _, err = readInt64B(file); err != nil {
+		return err
+	}
+	count, err := readInt32B(file)
+	if err != nil {
+		return err
+	}
 	// read hashes: the number of hash functions
-	hashes := readInt32(file)
+	hashes, err := readInt32B(file)
+	if err != nil {
+		return err
+	}
 	// read size: the number of bits of BitSet
-	bitsize := readInt32(file)
+	bitsize, err := readInt32B(file)
+	if err != nil {
+		return err
+	}
 	// convert to number of uint64
 	num8byte := (bitsize-1)/64 + 1
 	buf := make([]uint64, num8byte)
 	for i := int32(0); i < num8byte; i++ {
-		buf = append(buf, readUint64(file))
+		word, err := readInt64B(file)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, uint64(word))
 	}
 	bs := bitset.From(buf)
 	SSTbfs[s.dataFileName] = utils.NewBloomFilterS(count, hashes, bitsize, bs)
+	return nil
 
 	// reader := bufio.NewReader(file)
 	// key, err := reader.ReadString(' ') // read key
@@ -360,18 +323,6 @@ func (s *SSTable) close() {
 	s.closeByte(make([]byte, 0), 0)
 }
 
-// TouchKeyCache implements LRU cache
-type TouchKeyCache struct {
-	size int
-}
-
-// NewTouchKeyCache initializes a cache with given size
-func NewTouchKeyCache(size int) *TouchKeyCache {
-	t := &TouchKeyCache{}
-	t.size = size
-	return t
-}
-
 // NewSSTableP is used for DB writes into the SSTable
 // Use this version to write to the SSTable
 func NewSSTableP(directory, filename, pType string) *SSTable {
@@ -606,12 +557,11 @@ func (s *SSTable) closeByte(footer []byte, size int) {
 	s.dataWriter.Sync()
 }
 
-func getCurrentPos(file *os.File) int64 {
-	res, err := file.Seek(0, 0)
+func getCurrentPos(file io.Seeker) int64 {
+	res, err := file.Seek(0, io.SeekCurrent)
 	if err != nil {
 		log.Fatal(err)
 	}
-	file.Seek(res, 0)
 	return res
 }
 
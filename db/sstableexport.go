@@ -0,0 +1,326 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// ExportSSTable and ImportSSTable convert sstables from and to json,
+// a list of rows in the order of the sstable:
+//   [
+//     {"key": "row1", "localDeletionTime": 0, "markedForDeleteAt": 0,
+//      "columns": [{"name": "c1", "value": "7631", "timestamp": 1, "deleted": false}]},
+//     {"key": "row2", "localDeletionTime": 0, "markedForDeleteAt": 0,
+//      "superColumns": [{"name": "sc1", "localDeletionTime": -2147483648,
+//        "markedForDeleteAt": -9223372036854775808, "subColumns": [...]}]}
+//   ]
+// Rows of standard column families have columns, rows of super column
+// families have super columns. Keys are written undecorated, values are
+// hex encoded since they may hold any bytes. Tombstones
+// are columns with deleted set, the deletion times of rows and super
+// columns are copied as they are. Expiring columns also have "ttl"
+// and "localExpirationTime", counters have their "shards" by node id
+// and their total as value.
+
+// jsonRow is a row of an exported sstable
+type jsonRow struct {
+	Key               string             `json:"key"`
+	LocalDeletionTime int                `json:"localDeletionTime"`
+	MarkedForDeleteAt int64              `json:"markedForDeleteAt"`
+	Columns           []*jsonColumn      `json:"columns,omitempty"`
+	SuperColumns      []*jsonSuperColumn `json:"superColumns,omitempty"`
+}
+
+type jsonColumn struct {
+	Name                string                  `json:"name"`
+	Value               string                  `json:"value"`
+	Timestamp           int64                   `json:"timestamp"`
+	Deleted             bool                    `json:"deleted"`
+	TTL                 int                     `json:"ttl,omitempty"`
+	LocalExpirationTime int                     `json:"localExpirationTime,omitempty"`
+	Shards              map[string]CounterShard `json:"shards,omitempty"`
+}
+
+type jsonSuperColumn struct {
+	Name              string        `json:"name"`
+	LocalDeletionTime int           `json:"localDeletionTime"`
+	MarkedForDeleteAt int64         `json:"markedForDeleteAt"`
+	SubColumns        []*jsonColumn `json:"subColumns"`
+}
+
+// UnmarshalJSON leaves super columns without deletion times undeleted
+func (c *jsonSuperColumn) UnmarshalJSON(data []byte) error {
+	type plainSuperColumn jsonSuperColumn
+	sc := plainSuperColumn{LocalDeletionTime: math.MinInt32, MarkedForDeleteAt: math.MinInt64}
+	err := json.Unmarshal(data, &sc)
+	if err != nil {
+		return err
+	}
+	*c = jsonSuperColumn(sc)
+	return nil
+}
+
+func newJSONColumn(column IColumn) *jsonColumn {
+	if counter, ok := column.(CounterColumn); ok {
+		return &jsonColumn{Name: counter.Name, Value: hex.EncodeToString(counter.getValue()),
+			Timestamp: counter.Timestamp, Shards: counter.Shards}
+	}
+	c := column.(Column)
+	return &jsonColumn{c.Name, hex.EncodeToString([]byte(c.Value)), c.Timestamp, c.deleteMark,
+		c.TTL, c.LocalExpirationTime, nil}
+}
+
+func (c *jsonColumn) toColumn() (IColumn, error) {
+	if c.Shards != nil {
+		// the value of a counter is the total of its shards
+		return NewCounterColumn(c.Name, c.Timestamp, c.Shards), nil
+	}
+	value, err := hex.DecodeString(c.Value)
+	if err != nil {
+		return nil, fmt.Errorf("column %q: value is not hex: %v", c.Name, err)
+	}
+	column := NewColumn(c.Name, string(value), c.Timestamp, c.Deleted)
+	column.TTL = c.TTL
+	column.LocalExpirationTime = c.LocalExpirationTime
+	return column, nil
+}
+
+func newJSONRow(key string, cf *ColumnFamily) *jsonRow {
+	row := &jsonRow{}
+	row.Key = key
+	row.LocalDeletionTime = cf.getLocalDeletionTime()
+	row.MarkedForDeleteAt = cf.getMarkedForDeleteAt()
+	for _, column := range cf.GetSortedColumns() {
+		superColumn, ok := column.(SuperColumn)
+		if !ok {
+			row.Columns = append(row.Columns, newJSONColumn(column))
+			continue
+		}
+		sc := &jsonSuperColumn{}
+		sc.Name = superColumn.Name
+		sc.LocalDeletionTime = superColumn.getLocalDeletionTime()
+		sc.MarkedForDeleteAt = superColumn.getMarkedForDeleteAt()
+		sc.SubColumns = make([]*jsonColumn, 0)
+		names := make([]string, 0, len(superColumn.Columns))
+		for name := range superColumn.Columns {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sc.SubColumns = append(sc.SubColumns, newJSONColumn(superColumn.Columns[name]))
+		}
+		row.SuperColumns = append(row.SuperColumns, sc)
+	}
+	return row
+}
+
+func (row *jsonRow) toColumnFamily(cfName, columnType string) (*ColumnFamily, error) {
+	cf := NewColumnFamily(cfName, columnType)
+	cf.delete(row.LocalDeletionTime, row.MarkedForDeleteAt)
+	if columnType == "Super" {
+		if len(row.Columns) > 0 {
+			return nil, fmt.Errorf("row %q: super column family %v has no plain columns", row.Key, cfName)
+		}
+		for _, sc := range row.SuperColumns {
+			superColumn := NewSuperColumn(sc.Name)
+			superColumn.localDeletionTime = sc.LocalDeletionTime
+			superColumn.markedForDeleteAt = sc.MarkedForDeleteAt
+			for _, c := range sc.SubColumns {
+				column, err := c.toColumn()
+				if err != nil {
+					return nil, fmt.Errorf("row %q, super column %q: %v", row.Key, sc.Name, err)
+				}
+				superColumn.addColumn(column)
+			}
+			cf.addColumn(superColumn)
+		}
+		return cf, nil
+	}
+	if len(row.SuperColumns) > 0 {
+		return nil, fmt.Errorf("row %q: standard column family %v has no super columns", row.Key, cfName)
+	}
+	for _, c := range row.Columns {
+		column, err := c.toColumn()
+		if err != nil {
+			return nil, fmt.Errorf("row %q: %v", row.Key, err)
+		}
+		cf.addColumn(column)
+	}
+	return cf, nil
+}
+
+// ExportSSTable writes the rows of an sstable to out as json, either
+// all of them or only those with the given keys. Rows that cannot be
+// read are left out and reported in the returned error.
+func ExportSSTable(dataFile string, keys []string, out io.Writer) error {
+	sstable := openSSTableReader(dataFile)
+	if sstable.corruption != nil {
+		return sstable.corruption
+	}
+	_, err := io.WriteString(out, "[")
+	if err != nil {
+		return err
+	}
+	count := 0
+	var writeErr error
+	writeRow := func(key string, cf *ColumnFamily) {
+		if writeErr != nil {
+			return
+		}
+		data, err := json.MarshalIndent(newJSONRow(sstable.partitioner.UndecorateKey(key), cf), "  ", "  ")
+		if err != nil {
+			writeErr = err
+			return
+		}
+		separator := "\n  "
+		if count > 0 {
+			separator = ",\n  "
+		}
+		_, writeErr = io.WriteString(out, separator+string(data))
+		count++
+	}
+	var errs []string
+	if len(keys) == 0 {
+		_, errs = walkRows(sstable, writeRow)
+	} else {
+		errs = exportKeys(sstable, keys, writeRow)
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	_, err = io.WriteString(out, "\n]\n")
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v rows could not be read:\n%v", len(errs), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func exportKeys(sstable *SSTableReader, keys []string, fn func(key string, cf *ColumnFamily)) []string {
+	errs := make([]string, 0)
+	input, err := openDataInput(sstable)
+	if err != nil {
+		return append(errs, err.Error())
+	}
+	defer input.Close()
+	decoratedKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		decoratedKeys = append(decoratedKeys, sstable.partitioner.DecorateKey(key))
+	}
+	sort.Sort(ByKey(decoratedKeys))
+	for _, decoratedKey := range decoratedKeys {
+		position, err := sstable.getPosition(decoratedKey)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("row %q: %v", decoratedKey, err))
+			continue
+		}
+		if position < 0 {
+			log.Printf("key %q is not in %v\n", sstable.partitioner.UndecorateKey(decoratedKey), sstable.getFilename())
+			continue
+		}
+		key, cf, _, err := readRowAt(input, sstable, position)
+		if err == nil && key != decoratedKey {
+			err = fmt.Errorf("found key %q", key)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("row %q at %v: %v", decoratedKey, position, err))
+			continue
+		}
+		fn(key, cf)
+	}
+	return errs
+}
+
+// ImportSSTable reads rows in the json format written by ExportSSTable
+// and writes them into a new sstable at dataFile, which is named like
+// any other data file of its column family, as in
+// <data dir>/<table>/<cf>-<index>-Data.db
+func ImportSSTable(in io.Reader, dataFile string) error {
+	if _, err := os.Stat(dataFile); err == nil {
+		return fmt.Errorf("%v already exists", dataFile)
+	}
+	sstable := NewSSTable(dataFile)
+	tableName := sstable.parseTableName(dataFile)
+	cfName := sstable.getColumnFamilyName()
+	columnType := config.GetColumnTypeTableName(tableName, cfName)
+	if columnType == "" {
+		return fmt.Errorf("column family %v.%v does not exist", tableName, cfName)
+	}
+	rows := make([]*jsonRow, 0)
+	err := json.NewDecoder(in).Decode(&rows)
+	if err != nil {
+		return err
+	}
+	decoratedKeys := make([]string, 0, len(rows))
+	decoratedToCF := make(map[string]*ColumnFamily)
+	for _, row := range rows {
+		if row.Key == "" {
+			return fmt.Errorf("row without a key")
+		}
+		decoratedKey := sstable.partitioner.DecorateKey(row.Key)
+		if _, ok := decoratedToCF[decoratedKey]; ok {
+			return fmt.Errorf("row %q appears twice", row.Key)
+		}
+		cf, err := row.toColumnFamily(cfName, columnType)
+		if err != nil {
+			return err
+		}
+		decoratedKeys = append(decoratedKeys, decoratedKey)
+		decoratedToCF[decoratedKey] = cf
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no rows to import")
+	}
+	sort.Sort(ByKey(decoratedKeys))
+	// written under a temporary name like every other sstable, so
+	// that a node started meanwhile does not load half of it
+	tmpFile := path.Join(path.Dir(dataFile), fmt.Sprintf("%v-%v-%v-Data.db",
+		cfName, SSTableTmpFile, getIndexFromFileName(path.Base(dataFile))))
+	writer := NewSSTableWriter(tmpFile, len(decoratedKeys))
+	for _, decoratedKey := range decoratedKeys {
+		buf := make([]byte, 0)
+		CFSerializer.serializeWithIndexes(decoratedToCF[decoratedKey], &buf)
+		writer.append(decoratedKey, buf)
+	}
+	writer.closeAndOpenReader()
+	log.Printf("imported %v rows into %v\n", len(decoratedKeys), dataFile)
+	return nil
+}
+
+// DefineColumnFamily makes the column family of dataFile known to
+// this process. The sstable tools only read storage-conf.json, they
+// use it for column families created at runtime. It does nothing if
+// the column family is already defined.
+func DefineColumnFamily(dataFile, columnType, compression string) error {
+	sstable := NewSSTable(dataFile)
+	cfMetaData := config.CFMetaData{}
+	cfMetaData.TableName = sstable.parseTableName(dataFile)
+	cfMetaData.CFName = sstable.getColumnFamilyName()
+	if _, ok := config.GetCFMetaData(cfMetaData.TableName, cfMetaData.CFName); ok {
+		return nil
+	}
+	cfMetaData.ColumnType = columnType
+	cfMetaData.IndexProperty = "Name"
+	cfMetaData.Compression = compression
+	if config.GetTableMetaData(cfMetaData.TableName) == nil {
+		return config.AddTable(cfMetaData.TableName, []config.CFMetaData{cfMetaData})
+	}
+	return config.AddColumnFamily(cfMetaData)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONRowKeepsBinaryValues(t *testing.T) {
+	value := string([]byte{0, 0xff, 0xfe, '"', '\n', 0x80})
+	cf := NewColumnFamily("cf", "Standard")
+	cf.addColumn(NewColumn("binary", value, 1, false))
+	cf.addColumn(NewColumn("text", "v1", 2, false))
+	data, err := json.Marshal(newJSONRow("key", cf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := &jsonRow{}
+	if err := json.Unmarshal(data, row); err != nil {
+		t.Fatal(err)
+	}
+	if row.Columns[1].Value != "7631" {
+		t.Errorf("text value is written as %q, want hex", row.Columns[1].Value)
+	}
+	imported, err := row.toColumnFamily("cf", "Standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"binary": value, "text": "v1"} {
+		column, ok := imported.Columns[name].(Column)
+		if !ok || column.Value != want {
+			t.Errorf("column %v is %q after the round trip, want %q", name, column.Value, want)
+		}
+	}
+}
+
+func TestJSONRowRejectsValuesThatAreNotHex(t *testing.T) {
+	row := &jsonRow{Key: "key", Columns: []*jsonColumn{{Name: "c", Value: "v1", Timestamp: 1}}}
+	if _, err := row.toColumnFamily("cf", "Standard"); err == nil {
+		t.Errorf("a value that is not hex was imported")
+	}
+}
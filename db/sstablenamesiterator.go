@@ -6,8 +6,7 @@
 package db
 
 import (
-	"log"
-	"os"
+	"fmt"
 )
 
 // SSTableNamesIterator ...
@@ -19,38 +18,68 @@ type SSTableNamesIterator struct {
 }
 
 // NewSSTableNamesIterator ...
-func NewSSTableNamesIterator(sstable *SSTableReader, key string, columns [][]byte) *SSTableNamesIterator {
+func NewSSTableNamesIterator(sstable *SSTableReader, key string, columns [][]byte) (*SSTableNamesIterator, error) {
 	r := &SSTableNamesIterator{}
 	r.columns = columns
 	r.curIndex = 0
 	decoratedKey := sstable.partitioner.DecorateKey(key)
-	position := sstable.getPosition(decoratedKey)
-	if position < 0 {
-		return r
+	position, err := sstable.getPosition(decoratedKey)
+	if err != nil || position < 0 {
+		return r, err
 	}
-	file, err := os.Open(sstable.dataFileName)
+	file, err := openDataInput(sstable)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	defer file.Close()
+	err = r.readColumns(sstable, file, decoratedKey, position)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// readColumns reads the columns of r.columns in the
+// row of decoratedKey at position of file
+func (r *SSTableNamesIterator) readColumns(sstable *SSTableReader, file dataInput, decoratedKey string, position int64) error {
+	file.Seek(position, 0)
+	keyInDisk, err := readStringB(file)
+	if err != nil {
+		return err
 	}
-	keyInDisk, _ := readString(file)
 	if keyInDisk != decoratedKey {
-		log.Fatal("keyInDisk should == decoratedKey")
+		return fmt.Errorf("found key %q at %v, expected %q", keyInDisk, position, decoratedKey)
+	}
+	if _, err = readInt32B(file); err != nil {
+		return err
 	}
-	readInt(file)
 	// read the bloom filter that summarizing the columns
-	bf := defreezeBloomFilter(file)
-	filteredColumnNames := make([][]byte, len(columns))
-	for _, name := range columns {
+	bf, err := defreezeBloomFilter(file)
+	if err != nil {
+		return err
+	}
+	filteredColumnNames := make([][]byte, 0, len(r.columns))
+	for _, name := range r.columns {
 		if bf.IsPresent(string(name)) {
 			filteredColumnNames = append(filteredColumnNames, name)
 		}
 	}
 	if len(filteredColumnNames) == 0 {
-		return r
+		return nil
+	}
+	indexList, err := deserializeIndex(file)
+	if err != nil {
+		return err
+	}
+	cf, err := CFSerializer.deserializeFromSSTableNoColumns(sstable.makeColumnFamily(), file)
+	if err != nil {
+		return err
+	}
+	r.cf = cf
+	// columncount
+	if _, err = readInt32B(file); err != nil {
+		return err
 	}
-	indexList := deserializeIndex(file)
-	cf := CFSerializer.deserializeFromSSTableNoColumns(sstable.makeColumnFamily(), file)
-	readInt(file) // columncount
 	ranges := make([]*IndexInfo, 0)
 	// get the various column ranges we have to read
 	for _, name := range filteredColumnNames {
@@ -70,17 +99,19 @@ func NewSSTableNamesIterator(sstable *SSTableReader, key string, columns [][]byt
 	for _, indexInfo := range ranges {
 		file.Seek(columnBegin+indexInfo.offset, 0)
 		for getCurrentPos(file) < columnBegin+indexInfo.offset+indexInfo.width {
-			column := cf.getColumnSerializer().deserialize(file)
+			column, err := cf.getColumnSerializer().deserializeB(file)
+			if err != nil {
+				return err
+			}
 			// we check vs the origin list, not the filtered list
 			// for efficiency
-			if containsC(columns, column.getName()) {
+			if containsC(r.columns, column.getName()) {
 				cf.addColumn(column)
 			}
 		}
 	}
-	file.Close()
 	r.iter = cf.GetSortedColumns()
-	return r
+	return nil
 }
 
 func (r *SSTableNamesIterator) getColumnFamily() *ColumnFamily {
@@ -104,3 +135,7 @@ func (r *SSTableNamesIterator) next() IColumn {
 }
 
 func (r *SSTableNamesIterator) close() {}
+
+func (r *SSTableNamesIterator) getError() error {
+	return nil
+}
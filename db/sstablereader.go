@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/utils"
 )
 
@@ -24,6 +25,19 @@ var (
 // SSTableReader ...
 type SSTableReader struct {
 	*SSTable
+	// chunk offset map, nil if the data file is not compressed
+	compression *compressionInfo
+	// nil if the sstable was written without checksums
+	checksums *checksumInfo
+	// set if a component of the sstable failed to load, reads
+	// from the sstable fail until it is scrubbed
+	corruption error
+	// largest key in the sstable
+	lastKey string
+	// level of the sstable under leveled compaction
+	level int
+	// data file positions of recently read keys
+	keyCache *TouchKeyCache
 }
 
 // filename is the full path name with dir
@@ -32,8 +46,7 @@ func openSSTableReader(dataFilename string) *SSTableReader {
 	if !ok {
 		sstable = NewSSTableReader(dataFilename)
 		start := time.Now().UnixNano() / int64(time.Millisecond.Milliseconds())
-		sstable.loadIndexFile()
-		sstable.loadBloomFilter()
+		sstable.load()
 		log.Printf("index load time for %v: %v ms.",
 			dataFilename, time.Now().UnixNano()/int64(time.Millisecond)-start)
 		openedFiles.put(dataFilename, sstable)
@@ -52,61 +65,154 @@ func getSSTableReader(dataFileName string) *SSTableReader {
 func NewSSTableReader(filename string) *SSTableReader {
 	s := &SSTableReader{}
 	s.SSTable = NewSSTable(filename)
+	s.keyCache = NewTouchKeyCache(s.getKeyCacheSize())
 	return s
 }
 
 // NewSSTableReaderI ...
-func NewSSTableReaderI(filename string, indexPositions []*KeyPositionInfo, bf *utils.BloomFilter) *SSTableReader {
+func NewSSTableReaderI(filename string, indexPositions []*KeyPositionInfo, bf *utils.BloomFilter,
+	compression *compressionInfo, checksums *checksumInfo) *SSTableReader {
 	s := &SSTableReader{}
 	s.SSTable = NewSSTable(filename)
+	s.keyCache = NewTouchKeyCache(s.getKeyCacheSize())
+	s.indexPositions = indexPositions
 	s.bf = bf
+	s.compression = compression
+	s.checksums = checksums
 	srmu.Lock()
 	defer srmu.Unlock()
 	openedFiles.put(filename, s)
 	return s
 }
 
-func (s *SSTableReader) loadIndexFile() {
+// load reads every component of the sstable but the data file.
+// If one of them is corrupt the sstable is opened all the same,
+// so that the node can start and the sstable can be scrubbed.
+func (s *SSTableReader) load() {
+	err := s.loadComponents()
+	if err != nil {
+		s.corruption = newCorruptSSTableError(s.dataFileName, err)
+		log.Printf("%v, reads from it fail until it is scrubbed\n", s.corruption)
+	}
+}
+
+func (s *SSTableReader) loadComponents() error {
+	if err := s.loadChecksumInfo(); err != nil {
+		return err
+	}
+	if err := s.loadIndexFile(); err != nil {
+		return err
+	}
+	if err := s.loadBloomFilter(); err != nil {
+		return err
+	}
+	return s.loadCompressionInfo()
+}
+
+func (s *SSTableReader) loadChecksumInfo() error {
+	var err error
+	s.checksums, err = readChecksumInfo(s.dataFileName)
+	return err
+}
+
+func (s *SSTableReader) loadIndexFile() error {
 	/** Index file structure:
 	 * decoratedKey (int32+string)
 	 * index (int64)
 	 * (repeat above two)
 	 * */
+	if s.checksums != nil {
+		err := checkFile(s.indexFilename(s.dataFileName), s.checksums.indexChecksum)
+		if err != nil {
+			return err
+		}
+	}
 	s.indexPositions = make([]*KeyPositionInfo, 0)
 	input, err := os.Open(s.indexFilename(s.dataFileName))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	fileInfo, err := input.Stat()
 	if err != nil {
-		log.Fatal(err)
+		input.Close()
+		return err
 	}
 	// length in bytes
 	indexSize := fileInfo.Size()
+	defer input.Close()
 	i := 0
 	for {
 		indexPosition := getCurrentPos(input)
 		if indexPosition == indexSize {
 			break
 		}
-		decoratedKey, _ := readString(input)
-		readInt64(input)
+		decoratedKey, err := readStringB(input)
+		if err != nil {
+			return err
+		}
+		if _, err = readInt64B(input); err != nil {
+			return err
+		}
 		if i%s.indexInterval == 0 {
 			s.indexPositions = append(s.indexPositions,
 				NewKeyPositionInfo(decoratedKey, indexPosition))
 		}
+		s.lastKey = decoratedKey
+		i++
 	}
+	return nil
 }
 
-func (s *SSTableReader) loadBloomFilter() {
+func (s *SSTableReader) loadBloomFilter() error {
+	if s.checksums != nil {
+		err := checkFile(s.filterFilename(s.dataFileName), s.checksums.filterChecksum)
+		if err != nil {
+			return err
+		}
+	}
 	stream, err := os.Open(s.filterFilename(s.dataFileName))
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	defer stream.Close()
+	s.bf, err = utils.BFSerializer.Deserialize(stream)
+	return err
+}
+
+func (s *SSTableReader) loadCompressionInfo() error {
+	var err error
+	s.compression, err = readCompressionInfo(s.dataFileName)
+	return err
+}
+
+// getDataLength returns the size of the data in the data file
+// before compression
+func (s *SSTableReader) getDataLength() int64 {
+	if s.compression != nil {
+		return s.compression.dataLength
+	}
+	return getFileSizeFromName(s.dataFileName)
+}
+
+// getFirstKey returns the smallest key in the sstable
+func (s *SSTableReader) getFirstKey() string {
+	if len(s.indexPositions) == 0 {
+		return ""
 	}
-	s.bf = utils.BFSerializer.Deserialize(stream)
+	return s.indexPositions[0].key
+}
+
+// getLastKey returns the largest key in the sstable
+func (s *SSTableReader) getLastKey() string {
+	return s.lastKey
+}
+
+func (s *SSTableReader) getKeyCacheSize() int {
+	cfMetaData, _ := config.GetCFMetaData(s.getTableName(), s.getColumnFamilyName())
+	return cfMetaData.GetKeyCacheSize()
 }
 
-func (s *SSTableReader) getFileStruct() *FileStruct {
+func (s *SSTableReader) getFileStruct() (*FileStruct, error) {
 	return NewFileStruct(s)
 }
 
@@ -122,10 +228,22 @@ func (s *SSTableReader) getIndexPositions() []*KeyPositionInfo {
 	return s.indexPositions
 }
 
+// getComponentFilenames returns the data file of the sstable and
+// the files that go with it, some of which may not exist
+func (s *SSTableReader) getComponentFilenames() []string {
+	return []string{
+		s.dataFileName,
+		s.indexFilename(s.dataFileName),
+		s.filterFilename(s.dataFileName),
+		compressionInfoFilename(s.dataFileName),
+		checksumFilename(s.dataFileName),
+	}
+}
+
 func (s *SSTableReader) delete() {
-	os.Remove(s.dataFileName)
-	os.Remove(s.indexFilename(s.dataFileName))
-	os.Remove(s.filterFilename(s.dataFileName))
+	for _, filename := range s.getComponentFilenames() {
+		os.Remove(filename)
+	}
 	srmu.Lock()
 	defer srmu.Unlock()
 	openedFiles.remove(s.dataFileName)
@@ -154,40 +272,56 @@ func (s *SSTableReader) getIndexScanPosition(decoratedKey string) int64 {
 	return s.indexPositions[index].position
 }
 
-func (s *SSTableReader) getPosition(decoratedKey string) int64 {
+func (s *SSTableReader) getPosition(decoratedKey string) (int64, error) {
 	// returns the position in the data file to
 	// find the given key, or -1 if the key is not
 	// present
+	if decoratedKey < s.getFirstKey() || decoratedKey > s.getLastKey() {
+		// sstables are sorted with plain string comparison,
+		// see SSTableWriter.beforeAppend
+		return -1, nil
+	}
 	if s.bf.IsPresent(decoratedKey) == false {
-		return -1
+		return -1, nil
+	}
+	if position, ok := s.keyCache.get(decoratedKey); ok {
+		return position.(int64), nil
 	}
 	start := s.getIndexScanPosition(decoratedKey)
 	if start < 0 {
-		return -1
+		return -1, nil
 	}
 	input, err := os.Open(s.indexFilename(s.dataFileName))
 	if err != nil {
-		log.Fatal(err)
+		return -1, err
 	}
+	defer input.Close()
+	indexSize := getFileSize(input)
 	input.Seek(start, 0)
 	i := 0
-	for {
-		indexDecoratedKey, _ := readString(input)
-		position := readInt64(input) // this is file position in Data file
+	for getCurrentPos(input) < indexSize {
+		indexDecoratedKey, err := readStringB(input)
+		if err != nil {
+			return -1, err
+		}
+		position, err := readInt64B(input) // this is file position in Data file
+		if err != nil {
+			return -1, err
+		}
 		v := s.partitioner.Compare(indexDecoratedKey, decoratedKey)
 		if v == 0 {
-			return position
+			s.keyCache.put(decoratedKey, position)
+			return position, nil
 		}
 		if v > 0 {
-			return -1
+			return -1, nil
 		}
 		i++
 		if i >= SSTIndexInterval {
 			break
 		}
 	}
-	input.Close()
-	return -1
+	return -1, nil
 }
 
 // FileSSTableMap ...
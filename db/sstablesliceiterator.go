@@ -6,8 +6,7 @@
 package db
 
 import (
-	"log"
-	"os"
+	"fmt"
 
 	"gopkg.in/karalabe/cookiejar.v1/collections/deque"
 )
@@ -19,22 +18,30 @@ type SSTableSliceIterator struct {
 	reader      *ColumnGroupReader
 	nextValue   IColumn
 	nextRead    bool
+	// err is the error that ended the iteration early
+	err error
 }
 
 // NewSSTableSliceIterator ...
-func NewSSTableSliceIterator(ssTable *SSTableReader, key string, startColumn []byte, reversed bool) *SSTableSliceIterator {
+func NewSSTableSliceIterator(ssTable *SSTableReader, key string, startColumn []byte, reversed bool) (*SSTableSliceIterator, error) {
 	s := &SSTableSliceIterator{}
 	s.reversed = reversed
 	decoratedKey := ssTable.partitioner.DecorateKey(key)
 	// get key position in the data file
-	position := ssTable.getPosition(decoratedKey)
+	position, err := ssTable.getPosition(decoratedKey)
+	if err != nil {
+		return nil, err
+	}
 	s.startColumn = startColumn
 	if position >= 0 {
-		s.reader = NewColumnGroupReader(ssTable, decoratedKey, position, startColumn, reversed)
+		s.reader, err = NewColumnGroupReader(ssTable, decoratedKey, position, startColumn, reversed)
+		if err != nil {
+			return nil, err
+		}
 	}
 	s.nextValue = nil
 	s.nextRead = false
-	return s
+	return s, nil
 }
 
 func (s *SSTableSliceIterator) hasNext() bool {
@@ -59,7 +66,11 @@ func (s *SSTableSliceIterator) computeNext() IColumn {
 		return nil
 	}
 	for {
-		column := s.reader.pollColumn()
+		column, err := s.reader.pollColumn()
+		if err != nil {
+			s.err = err
+			return nil
+		}
 		if column == nil {
 			return nil
 		}
@@ -86,6 +97,10 @@ func (s *SSTableSliceIterator) getColumnFamily() *ColumnFamily {
 	return s.reader.getEmptyColumnFamily()
 }
 
+func (s *SSTableSliceIterator) getError() error {
+	return s.err
+}
+
 // ColumnGroupReader finds the block for a starting
 // column and returns blocks before/after it for each
 // next call. This function assumes that the CF is
@@ -94,38 +109,63 @@ type ColumnGroupReader struct {
 	emptyColumnFamily   *ColumnFamily
 	indices             []*IndexInfo
 	columnStartPosition int64
-	file                *os.File
+	file                dataInput
 	curRangeIndex       int
 	blockColumns        *deque.Deque
 	reversed            bool
 }
 
 // NewColumnGroupReader ...
-func NewColumnGroupReader(ssTable *SSTableReader, key string, position int64, startColumn []byte, reversed bool) *ColumnGroupReader {
+func NewColumnGroupReader(ssTable *SSTableReader, key string, position int64, startColumn []byte, reversed bool) (*ColumnGroupReader, error) {
 	c := &ColumnGroupReader{}
 	var err error
-	c.file, err = os.Open(ssTable.getFilename())
+	c.file, err = openDataInput(ssTable)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	c.file.Seek(position, 0)
-	keyInDisk, _ := readString(c.file)
-	if key != keyInDisk {
-		log.Fatal("key should equals to keyInDisk")
+	err = c.readHeader(ssTable, key, position)
+	if err != nil {
+		c.file.Close()
+		return nil, err
 	}
-	readInt(c.file)         // read row size
-	skipBloomFilter(c.file) // skip bloom filter
-	c.indices = deserializeIndex(c.file)
-
-	c.emptyColumnFamily = CFSerializer.deserializeFromSSTableNoColumns(ssTable.makeColumnFamily(), c.file)
-	readInt(c.file) // column count
 	c.columnStartPosition = getCurrentPos(c.file)
 	c.curRangeIndex = indexFor(startColumn, c.indices, reversed)
 	c.reversed = reversed
+	c.blockColumns = deque.New()
 	if reversed && c.curRangeIndex == len(c.indices) {
 		c.curRangeIndex--
 	}
-	return c
+	return c, nil
+}
+
+// readHeader reads the row at position up to its first column
+func (c *ColumnGroupReader) readHeader(ssTable *SSTableReader, key string, position int64) error {
+	c.file.Seek(position, 0)
+	keyInDisk, err := readStringB(c.file)
+	if err != nil {
+		return err
+	}
+	if key != keyInDisk {
+		return fmt.Errorf("found key %q at %v, expected %q", keyInDisk, position, key)
+	}
+	// read row size
+	if _, err = readInt32B(c.file); err != nil {
+		return err
+	}
+	if _, err = skipBloomFilter(c.file); err != nil {
+		return err
+	}
+	c.indices, err = deserializeIndex(c.file)
+	if err != nil {
+		return err
+	}
+	c.emptyColumnFamily, err = CFSerializer.deserializeFromSSTableNoColumns(ssTable.makeColumnFamily(), c.file)
+	if err != nil {
+		return err
+	}
+	// column count
+	_, err = readInt32B(c.file)
+	return err
 }
 
 func (c *ColumnGroupReader) getEmptyColumnFamily() *ColumnFamily {
@@ -136,25 +176,29 @@ func (c *ColumnGroupReader) close() {
 	c.file.Close()
 }
 
-func (c *ColumnGroupReader) pollColumn() IColumn {
-	if c.blockColumns.Size() == 0 {
-		if c.getNextBlock() {
-			return c.blockColumns.PopLeft().(IColumn)
+func (c *ColumnGroupReader) pollColumn() (IColumn, error) {
+	for c.blockColumns.Size() == 0 {
+		ok, err := c.getNextBlock()
+		if err != nil || !ok {
+			return nil, err
 		}
 	}
-	return c.blockColumns.PopLeft().(IColumn)
+	return c.blockColumns.PopLeft().(IColumn), nil
 }
 
-func (c *ColumnGroupReader) getNextBlock() bool {
+func (c *ColumnGroupReader) getNextBlock() (bool, error) {
 	if c.curRangeIndex < 0 || c.curRangeIndex >= len(c.indices) {
-		return false
+		return false, nil
 	}
 	// seek to the correct offset to the data, and
 	// calculate the data size
 	curColPosition := c.indices[c.curRangeIndex]
 	c.file.Seek(c.columnStartPosition+curColPosition.offset, 0)
 	for getCurrentPos(c.file) < c.columnStartPosition+curColPosition.offset+curColPosition.width {
-		column := c.emptyColumnFamily.getColumnSerializer().deserialize(c.file)
+		column, err := c.emptyColumnFamily.getColumnSerializer().deserializeB(c.file)
+		if err != nil {
+			return false, err
+		}
 		if c.reversed {
 			c.blockColumns.PushLeft(column)
 		} else {
@@ -166,5 +210,5 @@ func (c *ColumnGroupReader) getNextBlock() bool {
 	} else {
 		c.curRangeIndex++
 	}
-	return true
+	return true, nil
 }
@@ -10,26 +10,28 @@ import (
 	"os"
 	"strings"
 
+	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/utils"
 )
 
 // SSTableWriter ...
 type SSTableWriter struct {
 	*SSTable
-	dataFile  *os.File
+	dataFile  *dataFileWriter
 	indexFile *os.File
+	// origin is the backups directory the sstable is linked
+	// into when incremental backups are on, see backup.go
+	origin string
 }
 
 // NewSSTableWriter ...
 func NewSSTableWriter(filename string, keyCount int) *SSTableWriter {
 	s := &SSTableWriter{}
 	s.SSTable = NewSSTable(filename)
+	cfMetaData, _ := config.GetCFMetaData(s.parseTableName(filename), s.columnFamilyName)
+	s.dataFile = newDataFileWriter(s.dataFileName, cfMetaData.Compression)
 	var err error
-	s.dataFile, err = os.OpenFile(s.dataFileName, os.O_RDWR, 0666)
-	if err != nil {
-		log.Fatal(err)
-	}
-	s.indexFile, err = os.OpenFile(s.indexFilename(s.dataFileName), os.O_RDWR, 0666)
+	s.indexFile, err = os.OpenFile(s.indexFilename(s.dataFileName), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -56,7 +58,7 @@ func (s *SSTableWriter) beforeAppend(decoratedKey string) int64 {
 	if s.lastWrittenKey == "" {
 		return 0
 	}
-	return getCurrentPos(s.dataFile)
+	return s.dataFile.getFilePointer()
 }
 
 func (s *SSTableWriter) afterAppend(decoratedKey string, position int64) {
@@ -87,7 +89,7 @@ func (s *SSTableWriter) append(decoratedKey string, buf []byte) {
 func (s *SSTableWriter) closeAndOpenReader() *SSTableReader {
 	// renames temp SSTable files to valid data, index and bloom filter files
 	// bloom filter file
-	fos, err := os.OpenFile(s.filterFilename(s.dataFileName), os.O_RDWR, 0666)
+	fos, err := os.OpenFile(s.filterFilename(s.dataFileName), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -98,13 +100,47 @@ func (s *SSTableWriter) closeAndOpenReader() *SSTableReader {
 	s.indexFile.Sync()
 	s.indexFile.Close()
 	// main data
-	s.dataFile.Sync()
-	s.dataFile.Close()
+	s.dataFile.close()
+	// checksums of all the above
+	checksums := s.dataFile.checksums
+	checksums.indexChecksum, err = fileChecksum(s.indexFilename(s.dataFileName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	checksums.filterChecksum, err = fileChecksum(s.filterFilename(s.dataFileName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = checksums.write(checksumFilename(s.dataFileName))
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	s.rename(s.indexFilename(s.dataFileName))
 	s.rename(s.filterFilename(s.dataFileName))
+	if s.dataFile.info != nil {
+		s.rename(compressionInfoFilename(s.dataFileName))
+	}
+	s.rename(checksumFilename(s.dataFileName))
 	s.dataFileName = s.rename(s.dataFileName)
-	return NewSSTableReaderI(s.dataFileName, s.indexPositions, s.bf)
+	reader := NewSSTableReaderI(s.dataFileName, s.indexPositions, s.bf, s.dataFile.info, checksums)
+	reader.lastKey = s.lastWrittenKey
+	if config.IncrementalBackups && s.origin != "" {
+		backupSSTable(reader, s.origin)
+	}
+	return reader
+}
+
+// abort closes and deletes the files of an sstable that
+// is not going to be finished
+func (s *SSTableWriter) abort() {
+	s.indexFile.Close()
+	s.dataFile.file.Close()
+	os.Remove(s.dataFileName)
+	os.Remove(s.indexFilename(s.dataFileName))
+	os.Remove(s.filterFilename(s.dataFileName))
+	os.Remove(compressionInfoFilename(s.dataFileName))
+	os.Remove(checksumFilename(s.dataFileName))
 }
 
 func (s *SSTableWriter) rename(tmpFilename string) string {
@@ -7,9 +7,10 @@ package db
 
 import (
 	"encoding/binary"
+	"fmt"
+	"io"
 	"log"
 	"math"
-	"os"
 	"sync/atomic"
 )
 
@@ -24,7 +25,7 @@ type SuperColumn struct {
 	markedForDeleteAt int64
 }
 
-func (sc SuperColumn) markForDeleteAt(localDeletionTime int, timestamp int64) {
+func (sc *SuperColumn) markForDeleteAt(localDeletionTime int, timestamp int64) {
 	sc.localDeletionTime = localDeletionTime
 	sc.markedForDeleteAt = timestamp
 }
@@ -64,13 +65,12 @@ func (sc SuperColumn) addColumn(column IColumn) {
 		sc.Columns[name] = column
 		atomic.AddInt32(&sc.size, column.getSize())
 	} else {
-		if oldColumn.timestamp() <= column.timestamp() {
-			sc.Columns[name] = column
-			delta := int32(-1 * oldColumn.getSize())
-			// subtruct the size of the oldColumn
-			atomic.AddInt32(&sc.size, delta)
-			atomic.AddInt32(&sc.size, int32(column.getSize()))
-		}
+		column = reconcileColumns(oldColumn, column)
+		sc.Columns[name] = column
+		delta := int32(-1 * oldColumn.getSize())
+		// subtruct the size of the oldColumn
+		atomic.AddInt32(&sc.size, delta)
+		atomic.AddInt32(&sc.size, int32(column.getSize()))
 	}
 }
 
@@ -233,39 +233,60 @@ func NewSuperColumnSerializer() *SuperColumnSerializer {
 	return &SuperColumnSerializer{0}
 }
 
-func (s *SuperColumnSerializer) serialize(column IColumn, dos *os.File) {
+func (s *SuperColumnSerializer) serialize(column IColumn, dos io.Writer) {
 	superColumn := column.(SuperColumn)
 	writeString(dos, column.getName())
 	writeInt(dos, superColumn.getLocalDeletionTime())
 	writeInt64(dos, superColumn.getMarkedForDeleteAt())
 	columns := column.GetSubColumns()
+	writeInt(dos, len(columns))
 	for _, subColumn := range columns {
 		CSerializer.serialize(subColumn, dos)
 	}
 }
 
-func (s *SuperColumnSerializer) serializeB(column IColumn, dos []byte) {
+func (s *SuperColumnSerializer) serializeB(column IColumn, dos *[]byte) {
 	superColumn := column.(SuperColumn)
 	writeStringB(dos, column.getName())
 	writeIntB(dos, superColumn.getLocalDeletionTime())
 	writeInt64B(dos, superColumn.getMarkedForDeleteAt())
 	columns := column.GetSubColumns()
+	writeIntB(dos, len(columns))
 	for _, subColumn := range columns {
 		CSerializer.serializeB(subColumn, dos)
 	}
 }
 
-func (s *SuperColumnSerializer) deserialize(dis *os.File) IColumn {
-	name, _ := readString(dis)
+func (s *SuperColumnSerializer) deserializeB(dis io.Reader) (IColumn, error) {
+	name, err := readStringB(dis)
+	if err != nil {
+		return nil, err
+	}
 	superColumn := NewSuperColumn(name)
-	localDeletionTime := readInt(dis)
-	timestamp := readInt64(dis)
-	superColumn.markForDeleteAt(localDeletionTime, timestamp)
+	localDeletionTime, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := readInt64B(dis)
+	if err != nil {
+		return nil, err
+	}
+	superColumn.localDeletionTime = int(localDeletionTime)
+	superColumn.markedForDeleteAt = timestamp
 	// read the number of columns
-	size := readInt(dis)
-	for i := 0; i < size; i++ {
-		subColumn := CSerializer.deserialize(dis)
+	size, err := readInt32B(dis)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid sub column count %v", size)
+	}
+	for i := int32(0); i < size; i++ {
+		subColumn, err := CSerializer.deserializeB(dis)
+		if err != nil {
+			return nil, err
+		}
 		superColumn.addColumn(subColumn)
 	}
-	return superColumn
+	return superColumn, nil
 }
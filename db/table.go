@@ -7,13 +7,14 @@ package db
 
 import (
 	"bufio"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/DistAlchemist/Mongongo/config"
 )
 
 var (
@@ -21,6 +22,8 @@ var (
 	tableMetadataMap = map[string]*TableMetadata{}
 	idCFMap          = map[int]string{}
 	tCreateLock      sync.Mutex
+	idMu             sync.RWMutex
+	tmMu             sync.Mutex
 )
 
 // Table ...
@@ -29,24 +32,41 @@ type Table struct {
 	tableMetadata      *TableMetadata
 	tableName          string
 	columnFamilyStores map[string]*ColumnFamilyStore
+	// protects columnFamilyStores against online schema changes
+	mu sync.RWMutex
+	// keep a row from changing between reading its old
+	// values and updating the indexes, see getIndexLock
+	indexLocks [indexLockCount]sync.Mutex
 }
 
 // OpenTable ...
 func OpenTable(table string) *Table {
+	tCreateLock.Lock()
+	defer tCreateLock.Unlock()
 	tableInstance, ok := tableInstances[table]
 	if !ok {
 		// read config to know the column families for
 		// this table.
-		tCreateLock.Lock()
-		defer tCreateLock.Unlock()
 		tableInstance = NewTable(table)
 		tableInstances[table] = tableInstance
 	}
 	return tableInstance
 }
 
+// getColumnFamilyCount returns the number of slots a commit log
+// header needs. Ids of dropped column families are never reused,
+// so this is the largest id in use plus one rather than the
+// number of live column families.
 func getColumnFamilyCount() int {
-	return len(idCFMap)
+	idMu.RLock()
+	defer idMu.RUnlock()
+	count := 0
+	for id := range idCFMap {
+		if id+1 > count {
+			count = id + 1
+		}
+	}
+	return count
 }
 
 // NewTable create a Table
@@ -55,42 +75,89 @@ func NewTable(tableName string) *Table {
 	t.tableName = tableName
 	t.tableMetadata = getTableMetadataInstance(t.tableName)
 	t.columnFamilyStores = make(map[string]*ColumnFamilyStore)
-	cfIDMap := t.tableMetadata.cfIDMap
+	cfIDMap := t.tableMetadata.getColumnFamilies()
 	for columnFamily := range cfIDMap {
 		t.columnFamilyStores[columnFamily] = NewColumnFamilyStore(tableName, columnFamily)
 	}
 	return t
 }
 
-func (t *Table) get(key string) *Row {
+func (t *Table) get(key string) (*Row, error) {
 	// selects the row associated with the given key
 	row := NewRowT(t.tableName, key)
 	for columnFamily := range t.getColumnFamilies() {
-		cf := t.getCF(key, columnFamily)
+		if config.IsIndexCF(t.tableName, columnFamily) {
+			continue
+		}
+		cf, err := t.getCF(key, columnFamily)
+		if err != nil {
+			return nil, err
+		}
 		if cf != nil {
 			row.addColumnFamily(cf)
 		}
 	}
-	return row
+	return row, nil
 }
 
-func (t *Table) getCF(key, cfName string) *ColumnFamily {
-	cfStore, ok := t.columnFamilyStores[cfName]
-	if ok == false {
+func (t *Table) getCF(key, cfName string) (*ColumnFamily, error) {
+	cfStore := t.getColumnFamilyStore(cfName)
+	if cfStore == nil {
 		log.Fatal("Column family" + cfName + " has not been defined")
 	}
 	return cfStore.getColumnFamily(NewIdentityQueryFilter(key, NewQueryPathCF(cfName)))
 }
 
 func (t *Table) getColumnFamilies() map[string]int {
-	return t.tableMetadata.cfIDMap
+	return t.tableMetadata.getColumnFamilies()
 }
 
 func (t *Table) getColumnFamilyStore(cfName string) *ColumnFamilyStore {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.columnFamilyStores[cfName]
 }
 
+// getColumnFamilyStores returns a snapshot of all cf stores of this table
+func (t *Table) getColumnFamilyStores() []*ColumnFamilyStore {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make([]*ColumnFamilyStore, 0, len(t.columnFamilyStores))
+	for _, cfStore := range t.columnFamilyStores {
+		res = append(res, cfStore)
+	}
+	return res
+}
+
+// addColumnFamilyStore registers column family cfName under id
+// and opens a store for it
+func (t *Table) addColumnFamilyStore(cfName string, id int, columnType string) *ColumnFamilyStore {
+	t.tableMetadata.Add(cfName, id, columnType)
+	cfStore := NewColumnFamilyStore(t.tableName, cfName)
+	t.mu.Lock()
+	t.columnFamilyStores[cfName] = cfStore
+	t.mu.Unlock()
+	cfStore.onStart()
+	return cfStore
+}
+
+// removeColumnFamilyStore unregisters column family cfName and
+// returns its store, or nil if the table has no such cf
+func (t *Table) removeColumnFamilyStore(cfName string) *ColumnFamilyStore {
+	t.mu.Lock()
+	cfStore, ok := t.columnFamilyStores[cfName]
+	delete(t.columnFamilyStores, cfName)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	t.tableMetadata.remove(cfName)
+	return cfStore
+}
+
 func getTableMetadataInstance(tableName string) *TableMetadata {
+	tmMu.Lock()
+	defer tmMu.Unlock()
 	tableMetadata, ok := tableMetadataMap[tableName]
 	if !ok {
 		tableMetadata = NewTableMetadata()
@@ -99,6 +166,17 @@ func getTableMetadataInstance(tableName string) *TableMetadata {
 	return tableMetadata
 }
 
+// closeTable forgets the table instance and its metadata,
+// the column family stores must have been dropped already
+func closeTable(tableName string) {
+	tCreateLock.Lock()
+	delete(tableInstances, tableName)
+	tCreateLock.Unlock()
+	tmMu.Lock()
+	delete(tableMetadataMap, tableName)
+	tmMu.Unlock()
+}
+
 func (t *Table) loadTableMetadata(fileName string) {
 	f, err := os.Open(fileName)
 	if err != nil {
@@ -137,9 +215,7 @@ func (t *Table) loadTableMetadata(fileName string) {
 }
 
 func (t *Table) onStart() {
-	cfIDMap := t.tableMetadata.cfIDMap
-	for columnFamily := range cfIDMap {
-		cfStore := t.columnFamilyStores[columnFamily]
+	for _, cfStore := range t.getColumnFamilyStores() {
 		cfStore.onStart()
 	}
 }
@@ -154,37 +230,76 @@ func (t *Table) getNumberOfColumnFamilies() int {
 
 // First adds the row to the commit log associated with this
 // table. Then the data associated with the individual column
-// families is also written to the column family store's memtable
+// families is also written to the column family store's memtable.
+// Indexes of the column families are updated alongside.
 func (t *Table) apply(row *Row) {
-	key := row.Key
 	// add row to commit log
 	start := time.Now().UnixNano() / int64(time.Millisecond)
 	// cLogCtx := openCommitLog(t.tableName).add(row) // first write to commitlog
-	spew.Printf("table: %v \n -- table: %+v\n", t, t)
 	log.Printf("size: %v\n", t.tableMetadata.getSize())
-	cLogCtx := openCommitLogE().add(row) // first write to commitlog
-	for cName, columnFamily := range row.ColumnFamilies {
-		cfStore := t.columnFamilyStores[cName]
-		cfStore.apply(key, columnFamily, cLogCtx) // then write to memtable
+	var additions, removals []*Row
+	if indexedColumns := getIndexedColumns(row); len(indexedColumns) > 0 {
+		lock := t.getIndexLock(row.Key)
+		lock.Lock()
+		defer lock.Unlock()
+		additions, removals = t.getIndexUpdates(row, indexedColumns)
+	}
+	// new index entries go first and old ones are removed last,
+	// so a crash in between leaves stale entries, which scans
+	// filter out, instead of rows missing from the index
+	for _, indexRow := range additions {
+		t.applyLogged(indexRow)
+	}
+	t.applyLogged(row)
+	for _, indexRow := range removals {
+		t.applyLogged(indexRow)
 	}
 	// row.clear()
 	timeTaken := time.Now().UnixNano()/int64(time.Millisecond) - start
 	log.Printf("table.apply(row) took %v ms\n", timeTaken)
 }
 
+// applyLogged writes row to the commit log and then to the memtables
+func (t *Table) applyLogged(row *Row) {
+	cLogCtx := openCommitLogE().add(row)      // first write to commitlog
+	t.applyToMemtables(row.Key, row, cLogCtx) // then write to memtable
+}
+
+// applyToMemtables writes the column families of row to their
+// memtables without logging them. It is used directly by commit
+// log replay, with an invalid context so flushes don't discard.
+func (t *Table) applyToMemtables(key string, row *Row, cLogCtx *CommitLogContext) {
+	for cName, columnFamily := range row.ColumnFamilies {
+		cfStore := t.getColumnFamilyStore(cName)
+		if cfStore == nil {
+			// the column family was dropped meanwhile
+			log.Printf("column family %v.%v does not exist, skip\n", t.tableName, cName)
+			continue
+		}
+		cfStore.apply(key, columnFamily, cLogCtx)
+	}
+}
+
 func (t *Table) getColumnFamilyID(cfName string) int {
 	return t.tableMetadata.getColumnFamilyID(cfName)
 }
 
-func (t *Table) getRow(filter QueryFilter) *Row {
-	cfStore := t.columnFamilyStores[filter.getPath().ColumnFamilyName]
+// getRow reads the columns of filter. A row that cannot be read
+// fails the read, an empty row would pass for a missing one.
+func (t *Table) getRow(filter QueryFilter) (*Row, error) {
+	cfStore := t.getColumnFamilyStore(filter.getPath().ColumnFamilyName)
 	row := NewRowT(t.tableName, filter.getKey())
-	columnFamily := cfStore.getColumnFamily(filter)
-	spew.Printf("\tcfStore: %#+v\n\n", cfStore)
-	spew.Printf("\trow: %#+v\n\n", row)
-	spew.Printf("\tcf: %#+v\n\n", columnFamily)
+	if cfStore == nil {
+		return nil, fmt.Errorf("column family %v.%v does not exist", t.tableName, filter.getPath().ColumnFamilyName)
+	}
+	columnFamily, err := cfStore.getColumnFamily(filter)
+	if err != nil {
+		log.Printf("cannot read %v from %v.%v: %v\n", filter.getKey(), t.tableName,
+			filter.getPath().ColumnFamilyName, err)
+		return nil, err
+	}
 	if columnFamily != nil {
 		row.addColumnFamily(columnFamily)
 	}
-	return row
+	return row, nil
 }
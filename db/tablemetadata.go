@@ -7,6 +7,7 @@ package db
 
 import (
 	"os"
+	"sync"
 
 	"github.com/DistAlchemist/Mongongo/config"
 )
@@ -15,6 +16,7 @@ var tableMetadata *TableMetadata
 
 // TableMetadata stores infos about table and its columnFamilies
 type TableMetadata struct {
+	mu        sync.RWMutex
 	cfIDMap   map[string]int
 	cfTypeMap map[string]string
 }
@@ -33,26 +35,63 @@ func (t *TableMetadata) isEmpty() bool {
 
 // Add adds column family, id and typename to table metadata
 func (t *TableMetadata) Add(cf string, id int, tp string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.cfIDMap[cf] = id
-	idCFMap[id] = cf
 	t.cfTypeMap[cf] = tp
+	idMu.Lock()
+	defer idMu.Unlock()
+	idCFMap[id] = cf
+}
+
+// remove drops column family cf from the table metadata,
+// its id is never handed out again
+func (t *TableMetadata) remove(cf string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id, ok := t.cfIDMap[cf]
+	if !ok {
+		return
+	}
+	delete(t.cfIDMap, cf)
+	delete(t.cfTypeMap, cf)
+	idMu.Lock()
+	defer idMu.Unlock()
+	delete(idCFMap, id)
+}
+
+// getColumnFamilies returns a copy of the cf name to id map
+func (t *TableMetadata) getColumnFamilies() map[string]int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	res := make(map[string]int, len(t.cfIDMap))
+	for cf, id := range t.cfIDMap {
+		res[cf] = id
+	}
+	return res
 }
 
 func getFileName() string {
-	table := config.Tables[0]
+	table := config.GetTables()[0]
 	return config.MetadataDir + string(os.PathSeparator) +
 		table + "-Metadata.db"
 }
 
 func (t *TableMetadata) isValidColumnFamily(cfName string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	_, ok := t.cfIDMap[cfName]
 	return ok
 }
 
 func (t *TableMetadata) getSize() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return len(t.cfIDMap)
 }
 
 func (t *TableMetadata) getColumnFamilyID(cfName string) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.cfIDMap[cfName]
 }
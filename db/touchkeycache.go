@@ -0,0 +1,144 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"container/list"
+	"sync"
+)
+
+// TouchKeyCache is an LRU cache that counts its hits. Every
+// sstable keeps the data file positions of recently read keys
+// in one, and every column family its recently read rows. A
+// cache with capacity 0 holds nothing and counts nothing.
+type TouchKeyCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// most recently used first
+	lru      *list.List
+	requests int64
+	hits     int64
+	// bumped whenever an entry is removed, see putIfUnchanged
+	version int64
+}
+
+type touchKeyCacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// NewTouchKeyCache initializes a cache holding at most capacity entries
+func NewTouchKeyCache(capacity int) *TouchKeyCache {
+	t := &TouchKeyCache{}
+	t.capacity = capacity
+	t.entries = make(map[string]*list.Element)
+	t.lru = list.New()
+	return t
+}
+
+func (t *TouchKeyCache) get(key string) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.capacity == 0 {
+		return nil, false
+	}
+	t.requests++
+	element, ok := t.entries[key]
+	if !ok {
+		return nil, false
+	}
+	t.hits++
+	t.lru.MoveToFront(element)
+	return element.Value.(*touchKeyCacheEntry).value, true
+}
+
+func (t *TouchKeyCache) put(key string, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.putLocked(key, value)
+}
+
+// putIfUnchanged adds an entry unless something was removed from
+// the cache since getVersion returned version. Readers use it so
+// that a value read before a write cannot be cached after the
+// write dropped the entry.
+func (t *TouchKeyCache) putIfUnchanged(key string, value interface{}, version int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.version == version {
+		t.putLocked(key, value)
+	}
+}
+
+func (t *TouchKeyCache) putLocked(key string, value interface{}) {
+	if t.capacity == 0 {
+		return
+	}
+	element, ok := t.entries[key]
+	if ok {
+		element.Value.(*touchKeyCacheEntry).value = value
+		t.lru.MoveToFront(element)
+		return
+	}
+	t.entries[key] = t.lru.PushFront(&touchKeyCacheEntry{key, value})
+	t.evict()
+}
+
+func (t *TouchKeyCache) evict() {
+	for t.lru.Len() > t.capacity {
+		element := t.lru.Back()
+		t.lru.Remove(element)
+		delete(t.entries, element.Value.(*touchKeyCacheEntry).key)
+	}
+}
+
+func (t *TouchKeyCache) remove(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.version++
+	element, ok := t.entries[key]
+	if ok {
+		t.lru.Remove(element)
+		delete(t.entries, key)
+	}
+}
+
+func (t *TouchKeyCache) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.version++
+	t.entries = make(map[string]*list.Element)
+	t.lru.Init()
+}
+
+func (t *TouchKeyCache) getVersion() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.version
+}
+
+func (t *TouchKeyCache) getCapacity() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.capacity
+}
+
+// setCapacity resizes the cache, evicting the least
+// recently used entries that no longer fit
+func (t *TouchKeyCache) setCapacity(capacity int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.capacity = capacity
+	t.evict()
+}
+
+// getStats returns the number of entries, lookups and hits
+func (t *TouchKeyCache) getStats() (size int, requests, hits int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lru.Len(), t.requests, t.hits
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package db
+
+import (
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+func TestTouchKeyCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewTouchKeyCache(2)
+	cache.put("a", 1)
+	cache.put("b", 2)
+	cache.get("a")
+	cache.put("c", 3)
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("the least recently used entry is kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("%v is evicted", key)
+		}
+	}
+	size, requests, hits := cache.getStats()
+	if size != 2 || requests != 4 || hits != 3 {
+		t.Errorf("stats are %v entries, %v requests, %v hits", size, requests, hits)
+	}
+	cache.setCapacity(1)
+	if _, ok := cache.get("a"); ok {
+		t.Errorf("shrinking the cache kept the least recently used entry")
+	}
+	cache.setCapacity(0)
+	cache.put("d", 4)
+	if _, ok := cache.get("d"); ok {
+		t.Errorf("a cache with capacity 0 holds entries")
+	}
+}
+
+func TestTouchKeyCachePutIfUnchanged(t *testing.T) {
+	cache := NewTouchKeyCache(10)
+	// a read starts, a write drops the key, then the read
+	// tries to cache the row it read before the write
+	version := cache.getVersion()
+	cache.remove("k")
+	cache.putIfUnchanged("k", "stale", version)
+	if value, ok := cache.get("k"); ok {
+		t.Errorf("a row read before a write is cached as %v", value)
+	}
+	version = cache.getVersion()
+	cache.putIfUnchanged("k", "fresh", version)
+	if value, ok := cache.get("k"); !ok || value != "fresh" {
+		t.Errorf("the cache holds %v, %v", value, ok)
+	}
+	version = cache.getVersion()
+	cache.clear()
+	cache.putIfUnchanged("k", "stale", version)
+	if _, ok := cache.get("k"); ok {
+		t.Errorf("a row read before the cache was cleared is cached")
+	}
+}
+
+func TestRowCacheSeesWritesAfterReads(t *testing.T) {
+	table := addTestTable(t, "rowcachetest", config.CFMetaData{CFName: "cf", ColumnType: "Standard", RowCacheSize: 10})
+	cfStore := table.getColumnFamilyStore("cf")
+	assertValue := func(name, want string) {
+		cf, err := readTestRow(cfStore, "key")
+		if err != nil || cf == nil || cf.GetColumn("c") == nil || string(cf.GetColumn("c").getValue()) != want {
+			t.Fatalf("%v: row is read as %v, %v, want value %v", name, cf, err, want)
+		}
+	}
+	applyTestRow("rowcachetest", "cf", "key", NewColumn("c", "v1", 1, false))
+	assertValue("first read", "v1")
+	if _, ok := cfStore.rowCache.get("key"); !ok {
+		t.Fatalf("the row is not cached")
+	}
+	applyTestRow("rowcachetest", "cf", "key", NewColumn("c", "v2", 2, false))
+	assertValue("read after a write", "v2")
+	flushTestStore(cfStore)
+	applyTestRow("rowcachetest", "cf", "key", NewColumn("c", "v3", 3, false))
+	assertValue("read after a flush and a write", "v3")
+}
@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/DistAlchemist/Mongongo/config"
@@ -46,8 +45,8 @@ func (r *RandomPartitioner) DecorateKey(key string) string {
 
 // UndecorateKey ...
 func (r *RandomPartitioner) UndecorateKey(decoratedKey string) string {
-	parts := strings.Split(decoratedKey, ":")
-	return parts[1]
+	// the hash is raw md5 bytes and may contain ':' itself
+	return decoratedKey[md5.Size+1:]
 }
 
 // Compare ...
@@ -5,9 +5,65 @@
 
 package dht
 
+import "math/big"
+
 // Range is a representation of the range that
 // a node is responsible for on the DHT ring.
+// It holds the tokens after Left up to and including
+// Right. The empty token is the start of the ring, so
+// a range whose Right is empty runs to the end of the
+// ring, and a range whose ends are equal is the whole ring.
 type Range struct {
-	left  string
-	right string
+	Left  string
+	Right string
+}
+
+// NewRange ...
+func NewRange(left, right string) Range {
+	r := Range{}
+	r.Left = left
+	r.Right = right
+	return r
+}
+
+// IsWrapAround tells whether the range runs past
+// the end of the ring back to its start
+func (r Range) IsWrapAround() bool {
+	return r.Left >= r.Right
+}
+
+// Contains tells whether token falls in the range
+func (r Range) Contains(token string) bool {
+	if r.IsWrapAround() {
+		return token > r.Left || token <= r.Right
+	}
+	return token > r.Left && token <= r.Right
+}
+
+// Midpoint returns the token halfway through the range. Tokens
+// are read as fractions of the ring, one byte after the other,
+// so the midpoint can be a byte longer than the longer end.
+func (r Range) Midpoint() string {
+	n := len(r.Left)
+	if len(r.Right) > n {
+		n = len(r.Right)
+	}
+	n++
+	ringSize := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+	left := tokenValue(r.Left, n)
+	right := tokenValue(r.Right, n)
+	if r.IsWrapAround() {
+		right.Add(right, ringSize)
+	}
+	mid := left.Add(left, right)
+	mid.Rsh(mid, 1)
+	mid.Mod(mid, ringSize)
+	return string(mid.FillBytes(make([]byte, n)))
+}
+
+// tokenValue reads token as an n byte number
+func tokenValue(token string, n int) *big.Int {
+	b := make([]byte, n)
+	copy(b, token)
+	return new(big.Int).SetBytes(b)
 }
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package dht
+
+import "testing"
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     Range
+		token string
+		want  bool
+	}{
+		{"before", NewRange("b", "d"), "a", false},
+		{"left end", NewRange("b", "d"), "b", false},
+		{"inside", NewRange("b", "d"), "c", true},
+		{"right end", NewRange("b", "d"), "d", true},
+		{"after", NewRange("b", "d"), "e", false},
+		{"wrapping after left", NewRange("d", "b"), "e", true},
+		{"wrapping before right", NewRange("d", "b"), "a", true},
+		{"wrapping right end", NewRange("d", "b"), "b", true},
+		{"wrapping gap", NewRange("d", "b"), "c", false},
+		{"wrapping left end", NewRange("d", "b"), "d", false},
+		{"to the end of the ring", NewRange("m", ""), "z", true},
+		{"not past the end of the ring", NewRange("m", ""), "a", false},
+		{"whole ring", NewRange("", ""), "a", true},
+		{"whole ring from a token", NewRange("m", "m"), "a", true},
+	}
+	for _, test := range tests {
+		if got := test.r.Contains(test.token); got != test.want {
+			t.Errorf("%v: %q.Contains(%q) = %v, want %v", test.name, test.r, test.token, got, test.want)
+		}
+	}
+}
+
+func TestRangeMidpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Range
+		want string
+	}{
+		{"whole ring", NewRange("", ""), "\x80"},
+		{"first half", NewRange("", "\x80"), "\x40\x00"},
+		{"second half", NewRange("\x80", ""), "\xc0\x00"},
+		{"keys", NewRange("a", "c"), "b\x00"},
+		{"longer right end", NewRange("a", "a\x02"), "a\x01\x00"},
+		{"wrapping", NewRange("\xc0", "\x40"), "\x00\x00"},
+		{"wrapping past the start", NewRange("\xe0", "\x40"), "\x10\x00"},
+		{"wrapping before the start", NewRange("\xc0", "\x20"), "\xf0\x00"},
+	}
+	for _, test := range tests {
+		mid := test.r.Midpoint()
+		if mid != test.want {
+			t.Errorf("%v: %q.Midpoint() = %q, want %q", test.name, test.r, mid, test.want)
+		}
+		if !test.r.Contains(mid) {
+			t.Errorf("%v: %q does not contain its midpoint %q", test.name, test.r, mid)
+		}
+	}
+}
@@ -5,14 +5,20 @@
 
 package gms
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // EndPointState contains the HeartBeatState and
 // ApplicationState.
 type EndPointState struct {
+	// updateTimestamp is read by writers deciding whether to
+	// hint while the gossiper updates it, so it is only accessed
+	// atomically. It comes first to be 64-bit aligned.
+	updateTimestamp  int64
 	hbState          *HeartBeatState
 	applicationState map[string]*ApplicationState
-	updateTimestamp  int64
 	isAlive          bool
 	isAGossiper      bool
 }
@@ -27,7 +33,7 @@ func NewEndPointState(hbState *HeartBeatState) *EndPointState {
 	e := &EndPointState{}
 	e.hbState = hbState
 	e.applicationState = make(map[string]*ApplicationState)
-	e.updateTimestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	atomic.StoreInt64(&e.updateTimestamp, time.Now().UnixNano()/int64(time.Millisecond))
 	e.isAlive = true
 	e.isAGossiper = false
 	return e
@@ -64,7 +70,13 @@ func (e *EndPointState) SetHeartBeatState(hbState *HeartBeatState) {
 	e.hbState = hbState
 }
 
+// GetUpdateTimestamp returns when the state was last
+// updated, in milliseconds since the epoch
+func (e *EndPointState) GetUpdateTimestamp() int64 {
+	return atomic.LoadInt64(&e.updateTimestamp)
+}
+
 // UpdateTimestamp ...
 func (e *EndPointState) UpdateTimestamp() {
-	e.updateTimestamp = getCurrentTimeInMillis()
+	atomic.StoreInt64(&e.updateTimestamp, getCurrentTimeInMillis())
 }
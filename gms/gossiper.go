@@ -194,7 +194,7 @@ func (g *Gossiper) doStatusCheck() {
 		if epState == nil {
 			continue
 		}
-		duration := getCurrentTimeInMillis() - epState.updateTimestamp
+		duration := getCurrentTimeInMillis() - epState.GetUpdateTimestamp()
 		if epState.isAlive == false && duration > g.aVeryLongTime {
 			g.evictFromMembership(endpoint)
 		}
@@ -494,6 +494,8 @@ func (g *Gossiper) markAlive(addr network.EndPoint, localState *EndPointState) {
 	if localState.isAlive == false {
 		g.isAlive(addr, localState, true)
 		log.Printf("Endpoint %v is now UP\n", addr)
+		// let subscribers replay what the endpoint missed
+		g.doNotifications(addr, localState)
 	}
 }
 
@@ -5,6 +5,8 @@ go 1.15
 require (
 	github.com/antlr/antlr4 v0.0.0-20201029161626-9a95f0cc3d7c
 	github.com/davecgh/go-spew v1.1.1
+	github.com/golang/snappy v0.0.2
+	github.com/klauspost/compress v1.11.3
 	github.com/peterh/liner v1.2.0
 	github.com/stretchr/testify v1.6.1 // indirect
 	github.com/willf/bitset v1.1.11
@@ -8,7 +8,10 @@ package mql
 import (
 	"fmt"
 	"log"
+	"math"
 	"net/rpc"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/antlr/antlr4/runtime/Go/antlr"
@@ -22,53 +25,106 @@ var cc *rpc.Client
 
 // Result embeds error message and results
 type Result struct {
+	// ErrorCode is one of the error codes of package service,
+	// service.ErrorCodeNone if the query succeeded
 	ErrorCode int
 	ErrorText string
 	ResultSet map[string]string
 }
 
+// call calls an rpc of the server, the errors it fails
+// with are turned back into the typed errors of service
+func call(serviceMethod string, args interface{}, reply interface{}) error {
+	return service.ParseError(cc.Call(serviceMethod, args, reply))
+}
+
+// defaultRangeLimit is the most rows a RANGE without LIMIT returns
+const defaultRangeLimit = 100
+
 // ExecuteQuery first compile query and execute it
 func ExecuteQuery(c *rpc.Client, query string) Result {
 	cc = c // somewhat ugly workaround
+	var res Result
+	queryTree, err := parseQuery(query)
+	if err == nil {
+		err = executeCLIStmt(queryTree.children[0]) // stmt -> setStmt/getStmt...
+	}
+	// plan := doSemanticAnalysis(queryTree.children[0])
+	// plan.execute()
+	if err != nil {
+		log.Print(err)
+		res.ErrorCode = service.GetErrorCode(err)
+		res.ErrorText = err.Error()
+	}
+	return res
+}
+
+// parseQuery builds the abstract syntax tree of query
+// and returns its stmt node, or the first syntax error
+func parseQuery(query string) (*node, error) {
+	errors := &syntaxErrorListener{DefaultErrorListener: antlr.NewDefaultErrorListener()}
 	// setup the input
 	is := antlr.NewInputStream(query)
 	// create the lexer
 	lexer := parser.NewMqlLexer(is)
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errors)
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	// create the parser
 	p := parser.NewMqlParser(stream)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errors)
+	tree := p.Stmt()
+	if errors.err != nil {
+		return nil, errors.err
+	}
 	// finally parse the expression (by walking the tree)
 	var listener mqlListener
 	listener.init()
 	// during the Walk, we build the abstract syntax tree
-	antlr.ParseTreeWalkerDefault.Walk(&listener, p.Stmt())
+	antlr.ParseTreeWalkerDefault.Walk(&listener, tree)
 
 	// do semantic phase
-	queryTree := listener.root.children[0] // root -> stmt -> setStmt/getStmt...
-	executeCLIStmt(queryTree.children[0])  // stmt -> setStmt/getStmt
-	// plan := doSemanticAnalysis(queryTree.children[0])
-	// plan.execute()
-	var res Result
-	res.ErrorCode = 0
-	return res
+	return listener.root.children[0], nil // root -> stmt -> setStmt/getStmt...
 }
 
-func executeCLIStmt(ast *node) {
+func executeCLIStmt(ast *node) error {
 	switch ast.id {
 	case parser.MqlParserRULE_setStmt:
-		executeSet(ast)
+		return executeSet(ast)
 	case parser.MqlParserRULE_getStmt:
-		executeGet(ast)
+		return executeGet(ast)
+	case parser.MqlParserRULE_incrStmt:
+		return executeIncr(ast)
+	case parser.MqlParserRULE_getWhereStmt:
+		return executeWhere(ast)
+	case parser.MqlParserRULE_getRangeStmt:
+		return executeRange(ast)
 	default:
 		log.Printf("Invalid statement\n")
 	}
+	return nil
 }
 
-func executeSet(ast *node) {
+// getTTL returns the TTL in seconds of a set statement,
+// 0 if it has no TTL clause
+func getTTL(ast *node) (int, error) {
+	// setStmt.columnSpec, setStmt.valueExpr and setStmt.ttl
+	if len(ast.children) < 3 {
+		return 0, nil
+	}
+	ttl, err := strconv.Atoi(ast.children[2].text)
+	if err != nil || ttl > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid TTL %v", ast.children[2].text)
+	}
+	return ttl, nil
+}
+
+func executeSet(ast *node) error {
 	// execute set statement
-	childCount := len(ast.children)
-	if childCount != 2 {
-		log.Printf("should be set columnSpec = valueExpr\n")
+	ttl, err := getTTL(ast)
+	if err != nil {
+		return err
 	}
 	columnFamilySpec := ast.children[0]
 	tableName := getTableName(columnFamilySpec)
@@ -91,15 +147,154 @@ func executeSet(ast *node) {
 		args.CPath = service.NewColumnPath(columnFamily, nil, []byte(columnName))
 		args.Value = []byte(value)
 		args.Timestamp = currentTimeMillis()
-		args.ConsistencyLevel = 0
-		err := cc.Call("Mongongo.Insert", &args, &reply)
+		args.ConsistencyLevel = service.ConsistencyZero
+		args.TTL = ttl
+		err := call("Mongongo.Insert", &args, &reply)
 		if err != nil {
-			log.Fatal("calling:", err)
+			return err
 		}
 		log.Printf("reply.result: %+v\n", reply.Result)
 	} else {
 		log.Printf("currently only support set table.cf['key']['column']='value'\n")
 	}
+	return nil
+}
+
+// getDelta returns the increment of an incr statement,
+// 1 if it has no BY clause
+func getDelta(ast *node) (int64, error) {
+	// incrStmt.columnSpec and incrStmt.delta
+	if len(ast.children) < 2 {
+		return 1, nil
+	}
+	delta, err := strconv.ParseInt(ast.children[1].text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid increment %v", ast.children[1].text)
+	}
+	return delta, nil
+}
+
+func executeIncr(ast *node) error {
+	delta, err := getDelta(ast)
+	if err != nil {
+		return err
+	}
+	columnFamilySpec := ast.children[0]
+	args := service.AddArgs{}
+	args.Table = getTableName(columnFamilySpec)
+	args.Key = getKey(columnFamilySpec)
+	columnFamily := getColumnFamily(columnFamilySpec)
+	switch numColumnSpecifiers(columnFamilySpec) {
+	case 1:
+		// incr table.cf['key']['column']
+		args.CPath = service.NewColumnPath(columnFamily, nil, []byte(getColumn(columnFamilySpec, 0)))
+	case 2:
+		// incr table.superCF['key']['superColumn']['column']
+		args.CPath = service.NewColumnPath(columnFamily, []byte(getColumn(columnFamilySpec, 0)),
+			[]byte(getColumn(columnFamilySpec, 1)))
+	default:
+		return fmt.Errorf("INCR needs the column of the counter")
+	}
+	args.Delta = delta
+	args.ConsistencyLevel = service.ConsistencyOne
+	reply := service.AddReply{}
+	err = call("Mongongo.Add", &args, &reply)
+	if err != nil {
+		return err
+	}
+	log.Printf("reply.result: %+v\n", reply.Result)
+	return nil
+}
+
+// getIndexExpressions returns the expressions of a WHERE clause,
+// as in column1 = 'value1' AND 'column 2' = 'value2'
+func getIndexExpressions(ast *node) []service.IndexExpression {
+	// getWhereStmt.tableName, getWhereStmt.columnFamilyName
+	// and getWhereStmt.indexExpr ...
+	expressions := make([]service.IndexExpression, 0)
+	for _, expr := range ast.children[2:] {
+		column := unquote(expr.children[0].text)
+		value := unquote(expr.children[1].text)
+		expressions = append(expressions, service.NewIndexExpression([]byte(column), service.EQ, []byte(value)))
+	}
+	return expressions
+}
+
+// unquote returns the value of a string literal,
+// other text is returned as it is
+func unquote(text string) string {
+	if len(text) < 2 || text[0] != '\'' || text[len(text)-1] != '\'' {
+		return text
+	}
+	return strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+}
+
+func executeWhere(ast *node) error {
+	args := service.GetIndexedSlicesArgs{}
+	args.Keyspace = ast.children[0].text
+	args.ColumnParent = service.NewColumnParent(ast.children[1].text, nil)
+	args.IndexClause = service.NewIndexClause(getIndexExpressions(ast), "", 0)
+	args.Predicate = service.NewSlicePredicate(nil, service.NewSliceRange(nil, nil, false, 1000000))
+	args.ConsistencyLevel = service.ConsistencyOne
+	reply := service.GetIndexedSlicesReply{}
+	err := call("Mongongo.GetIndexedSlices", &args, &reply)
+	if err != nil {
+		return err
+	}
+	printKeySlices(reply.KeySlices)
+	return nil
+}
+
+// getLimit returns the most rows a range statement reads,
+// defaultRangeLimit if it has no LIMIT clause
+func getLimit(ast *node) (int, error) {
+	// getRangeStmt.tableName, getRangeStmt.columnFamilyName,
+	// getRangeStmt.startKey, getRangeStmt.endKey and getRangeStmt.limit
+	if len(ast.children) < 5 {
+		return defaultRangeLimit, nil
+	}
+	limit, err := strconv.Atoi(ast.children[4].text)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit %v", ast.children[4].text)
+	}
+	return limit, nil
+}
+
+func executeRange(ast *node) error {
+	count, err := getLimit(ast)
+	if err != nil {
+		return err
+	}
+	startKey := unquote(ast.children[2].text)
+	endKey := unquote(ast.children[3].text)
+	args := service.GetRangeSlicesArgs{}
+	args.Keyspace = ast.children[0].text
+	args.ColumnParent = service.NewColumnParent(ast.children[1].text, nil)
+	args.Predicate = service.NewSlicePredicate(nil, service.NewSliceRange(nil, nil, false, 1000000))
+	args.Range = service.NewKeyRange(startKey, endKey, count)
+	args.ConsistencyLevel = service.ConsistencyOne
+	reply := service.GetRangeSlicesReply{}
+	err = call("Mongongo.GetRangeSlices", &args, &reply)
+	if err != nil {
+		return err
+	}
+	printKeySlices(reply.KeySlices)
+	return nil
+}
+
+func printKeySlices(keySlices []service.KeySlice) {
+	for _, keySlice := range keySlices {
+		fmt.Printf("key=%v\n", keySlice.Key)
+		for _, cosc := range keySlice.Columns {
+			column := cosc.Column
+			if column == nil {
+				continue
+			}
+			fmt.Printf("\tcolumn=%v, value=%v, timestamp=%v\n", column.Name,
+				column.Value, column.Timestamp)
+		}
+	}
+	fmt.Printf("returned %v rows.\n", len(keySlices))
 }
 
 func currentTimeMillis() int64 {
@@ -130,7 +325,7 @@ func getTableName(ast *node) string {
 	return ast.children[0].text
 }
 
-func executeGet(ast *node) {
+func executeGet(ast *node) error {
 	// execute get statement
 	// getStmt.columnSpec
 	childCount := len(ast.children)
@@ -151,11 +346,11 @@ func executeGet(ast *node) {
 		args.Key = key
 		args.ColumnParent = service.NewColumnParent(columnFamily, nil)
 		args.Predicate = service.NewSlicePredicate(nil, srange)
-		args.ConsistencyLevel = 1
+		args.ConsistencyLevel = service.ConsistencyOne
 		reply := service.GetSliceReply{}
-		err := cc.Call("Mongongo.GetSlice", &args, &reply)
+		err := call("Mongongo.GetSlice", &args, &reply)
 		if err != nil {
-			log.Fatal("calling:", err)
+			return err
 		}
 		columns := reply.Columns
 		size := len(columns)
@@ -172,18 +367,19 @@ func executeGet(ast *node) {
 		args.Keyspace = tableName
 		args.Key = key
 		args.ColumnPath = service.NewColumnPath(columnFamily, nil, []byte(columnName))
-		args.ConsistencyLevel = 1
+		args.ConsistencyLevel = service.ConsistencyOne
 		reply := service.GetReply{}
-		err := cc.Call("Mongongo.Get", &args, &reply)
+		err := call("Mongongo.Get", &args, &reply)
 		if err != nil {
-			log.Fatal("calling:", err)
+			return err
 		}
 		column := reply.Cosc.Column
 		spew.Printf("get column: %#+v\n\n", column)
 		if column == nil {
-			return
+			return nil
 		}
 		fmt.Printf("name=%v, value=%v, timestamp=%v\n", column.Name,
 			column.Value, column.Timestamp)
 	}
+	return nil
 }
@@ -0,0 +1,218 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package mql
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/mql/parser"
+	"github.com/DistAlchemist/Mongongo/service"
+)
+
+func TestParseSetWithTTL(t *testing.T) {
+	for query, want := range map[string]int{
+		"SET Table1.Standard1['key']['column'] = 'value'":                0,
+		"SET Table1.Standard1['key']['column'] = 'value' TTL 3600":       3600,
+		"SET Table1.Standard1['key']['column'] = 'value' ttl 60;":        60,
+		"SET Table1.Standard1['key']['column'] = 'value' TTL 2147483647": 2147483647,
+	} {
+		stmt, err := parseQuery(query)
+		if err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		if stmt.children[0].id != parser.MqlParserRULE_setStmt {
+			t.Fatalf("%v is parsed as rule %v", query, stmt.children[0].id)
+		}
+		ttl, err := getTTL(stmt.children[0])
+		if err != nil || ttl != want {
+			t.Errorf("%v has TTL %v, %v, want %v", query, ttl, err, want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidTTL(t *testing.T) {
+	for _, query := range []string{
+		"SET Table1.Standard1['key']['column'] = 'value' TTL",
+		"SET Table1.Standard1['key']['column'] = 'value' TTL -1",
+		"SET Table1.Standard1['key']['column'] = 'value' TTL 10 20",
+		"GET Table1.Standard1['key']['column'] TTL 10",
+	} {
+		if _, err := parseQuery(query); err == nil {
+			t.Errorf("%v is parsed", query)
+		}
+	}
+	stmt, err := parseQuery("SET Table1.Standard1['key']['column'] = 'value' TTL 2147483648")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTTL(stmt.children[0]); err == nil {
+		t.Errorf("a TTL above the largest int32 is accepted")
+	}
+}
+
+func TestParseIncr(t *testing.T) {
+	for query, want := range map[string]int64{
+		"INCR Table1.Counter1['key']['column']":                   1,
+		"INCR Table1.Counter1['key']['column'] BY 5":              5,
+		"INCR Table1.Counter1['key']['column'] by -3;":            -3,
+		"INCR Table1.Super1['key']['superColumn']['column'] BY 2": 2,
+	} {
+		stmt, err := parseQuery(query)
+		if err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		if stmt.children[0].id != parser.MqlParserRULE_incrStmt {
+			t.Fatalf("%v is parsed as rule %v", query, stmt.children[0].id)
+		}
+		delta, err := getDelta(stmt.children[0])
+		if err != nil || delta != want {
+			t.Errorf("%v adds %v, %v, want %v", query, delta, err, want)
+		}
+	}
+	for _, query := range []string{
+		"INCR Table1.Counter1['key']['column'] BY",
+		"INCR Table1.Counter1['key']['column'] BY 'a'",
+		"INCR Table1.Counter1['key']['column'] = '1'",
+	} {
+		if _, err := parseQuery(query); err == nil {
+			t.Errorf("%v is parsed", query)
+		}
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	stmt, err := parseQuery("GET Table1.Standard1 WHERE birthdate = '1975' and 'home state' = 'Joe''s';")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmt.children[0].id != parser.MqlParserRULE_getWhereStmt {
+		t.Fatalf("parsed as rule %v", stmt.children[0].id)
+	}
+	expressions := getIndexExpressions(stmt.children[0])
+	want := [][2]string{{"birthdate", "1975"}, {"home state", "Joe's"}}
+	if len(expressions) != len(want) {
+		t.Fatalf("got %v expressions, want %v", len(expressions), len(want))
+	}
+	for i, expr := range expressions {
+		if string(expr.ColumnName) != want[i][0] || string(expr.Value) != want[i][1] {
+			t.Errorf("expression %v is %s = %s, want %v = %v", i, expr.ColumnName, expr.Value, want[i][0], want[i][1])
+		}
+	}
+	stmt, err = parseQuery("GET Table1.Standard1['key']")
+	if err != nil || stmt.children[0].id != parser.MqlParserRULE_getStmt {
+		t.Errorf("a GET of a row is not parsed as getStmt: %v", err)
+	}
+	for _, query := range []string{
+		"SELECT Table1.Standard1 WHERE birthdate = 1975",
+		"GET Table1.Standard1 WHERE birthdate = '1975' AND",
+		"GET Table1.Standard1 WHERE birthdate = '1975' state = 'UT'",
+		"GET Table1.Standard1 WHERE",
+	} {
+		if _, err := parseQuery(query); err == nil {
+			t.Errorf("%v is parsed", query)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for query, want := range map[string][3]interface{}{
+		"GET Table1.Standard1 RANGE 'a' TO 'z'":                {"a", "z", defaultRangeLimit},
+		"SELECT Table1.Standard1 range '' to 'k''s' limit 10;": {"", "k's", 10},
+	} {
+		stmt, err := parseQuery(query)
+		if err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		ast := stmt.children[0]
+		if ast.id != parser.MqlParserRULE_getRangeStmt {
+			t.Fatalf("%v is parsed as rule %v", query, ast.id)
+		}
+		limit, err := getLimit(ast)
+		if err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		got := [3]interface{}{unquote(ast.children[2].text), unquote(ast.children[3].text), limit}
+		if got != want {
+			t.Errorf("%v reads %v, want %v", query, got, want)
+		}
+	}
+	for _, query := range []string{
+		"GET Table1.Standard1 RANGE 'a'",
+		"GET Table1.Standard1 RANGE a TO z",
+		"GET Table1.Standard1 RANGE 'a' TO 'z' LIMIT",
+		"GET Table1.Standard1 RANGE 'a' TO 'z' LIMIT -1",
+	} {
+		if _, err := parseQuery(query); err == nil {
+			t.Errorf("%v is parsed", query)
+		}
+	}
+	stmt, err := parseQuery("GET Table1.Standard1 RANGE 'a' TO 'z' LIMIT 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getLimit(stmt.children[0]); err == nil {
+		t.Errorf("a limit of 0 is accepted")
+	}
+}
+
+// failingServer fails the rpcs of Mongongo with err
+type failingServer struct {
+	err error
+}
+
+func (s *failingServer) Insert(args *service.InsertArgs, reply *service.InsertReply) error {
+	return s.err
+}
+
+func (s *failingServer) Get(args *service.GetArgs, reply *service.GetReply) error {
+	return s.err
+}
+
+func (s *failingServer) Add(args *service.AddArgs, reply *service.AddReply) error {
+	return s.err
+}
+
+func TestExecuteQueryReportsErrorCodes(t *testing.T) {
+	server := rpc.NewServer()
+	failing := &failingServer{}
+	if err := server.RegisterName("Mongongo", failing); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go server.ServeConn(serverConn)
+	client := rpc.NewClient(clientConn)
+	defer client.Close()
+	queries := []string{
+		"SET Table1.Standard1['key']['column'] = 'value'",
+		"GET Table1.Standard1['key']['column']",
+		"INCR Table1.Counter1['key']['column']",
+	}
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{&service.InvalidRequestError{Why: "unknown consistency level 7"}, service.ErrorCodeInvalidRequest},
+		{&service.UnavailableError{Key: "key", Required: 2, Alive: 1}, service.ErrorCodeUnavailable},
+		{&service.TimedOutError{Key: "key", Required: 2, Answered: 1}, service.ErrorCodeTimedOut},
+		{errors.New("column family Table1.Standard1 does not exist"), service.ErrorCodeOther},
+		{nil, service.ErrorCodeNone},
+	}
+	for _, test := range tests {
+		failing.err = test.err
+		for _, query := range queries {
+			res := ExecuteQuery(client, query)
+			if res.ErrorCode != test.code {
+				t.Errorf("%v failing with %v has error code %v, want %v", query, test.err, res.ErrorCode, test.code)
+			}
+		}
+	}
+	if res := ExecuteQuery(client, "SET Table1"); res.ErrorCode != service.ErrorCodeOther {
+		t.Errorf("a syntax error has error code %v", res.ErrorCode)
+	}
+}
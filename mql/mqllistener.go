@@ -6,6 +6,7 @@
 package mql
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/antlr/antlr4/runtime/Go/antlr"
@@ -58,3 +59,18 @@ func (l *mqlListener) ExitEveryRule(c antlr.ParserRuleContext) {
 	log.Printf("GetText %v\n", c.GetText())
 	l.curNode = l.curNode.parent
 }
+
+// syntaxErrorListener keeps the first syntax error of a query
+// instead of printing them all to the console
+type syntaxErrorListener struct {
+	*antlr.DefaultErrorListener
+	err error
+}
+
+// SyntaxError is called by the lexer and the parser on a syntax error
+func (l *syntaxErrorListener) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{},
+	line, column int, msg string, e antlr.RecognitionException) {
+	if l.err == nil {
+		l.err = fmt.Errorf("syntax error at column %v: %v", column, msg)
+	}
+}
@@ -4,6 +4,18 @@ grammar Mql;
 // Tokens 
 GET: 'GET';
 SET: 'SET';
+INCR: 'INCR';
+SELECT: 'SELECT';
+// statements start with upper case keywords, the CLI upper cases
+// the first word of a line, the keywords of clauses are case
+// insensitive
+TTL: [tT][tT][lL];
+BY: [bB][yY];
+WHERE: [wW][hH][eE][rR][eE];
+AND: [aA][nN][dD];
+RANGE: [rR][aA][nN][gG][eE];
+TO: [tT][oO];
+LIMIT: [lL][iI][mM][iI][tT];
 WHITESPACE: [ \r\n\t]+ -> skip;
 ASSOC: '=>';
 COMMA: ',';
@@ -41,8 +53,12 @@ stringVal
 
 // Rules
 stmt
-    : getStmt
-    | setStmt
+    : ( getStmt
+      | setStmt
+      | incrStmt
+      | getWhereStmt
+      | getRangeStmt
+      ) SEMICOLON? EOF
     ;
 
 getStmt
@@ -50,7 +66,7 @@ getStmt
     ;
 
 setStmt
-    : SET columnSpec '=' valueExpr
+    : SET columnSpec '=' valueExpr (TTL ttl)?
     ;
 
 columnSpec
@@ -97,3 +113,40 @@ columnOrSuperColumnKey: stringVal;
 columnKey: stringVal;
 superColumnKey: stringVal;
 
+// ttl is in seconds
+ttl: IntegerLiteral;
+
+// incrStmt adds delta, 1 if left out, to a counter column
+incrStmt
+    : INCR columnSpec (BY delta)?
+    ;
+
+delta: '-'? IntegerLiteral;
+
+
+// getWhereStmt reads the rows whose columns have the given values
+// through the index of one of the columns
+getWhereStmt
+    : (GET | SELECT) tableName '.' columnFamilyName
+        WHERE indexExpr (AND indexExpr)*
+    ;
+
+indexExpr: indexColumn '=' indexValue;
+
+indexColumn
+    : Identifier
+    | StringLiteral
+    ;
+
+indexValue: StringLiteral;
+
+// getRangeStmt reads the rows from startKey to endKey in the order
+// of the ring, an empty key leaves that end open
+getRangeStmt
+    : (GET | SELECT) tableName '.' columnFamilyName
+        RANGE startKey TO endKey (LIMIT limit)?
+    ;
+
+startKey: StringLiteral;
+endKey: StringLiteral;
+limit: IntegerLiteral;
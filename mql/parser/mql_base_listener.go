@@ -128,3 +128,69 @@ func (s *BaseMqlListener) EnterSuperColumnKey(ctx *SuperColumnKeyContext) {}
 
 // ExitSuperColumnKey is called when production superColumnKey is exited.
 func (s *BaseMqlListener) ExitSuperColumnKey(ctx *SuperColumnKeyContext) {}
+
+// EnterTtl is called when production ttl is entered.
+func (s *BaseMqlListener) EnterTtl(ctx *TtlContext) {}
+
+// ExitTtl is called when production ttl is exited.
+func (s *BaseMqlListener) ExitTtl(ctx *TtlContext) {}
+
+// EnterIncrStmt is called when production incrStmt is entered.
+func (s *BaseMqlListener) EnterIncrStmt(ctx *IncrStmtContext) {}
+
+// ExitIncrStmt is called when production incrStmt is exited.
+func (s *BaseMqlListener) ExitIncrStmt(ctx *IncrStmtContext) {}
+
+// EnterDelta is called when production delta is entered.
+func (s *BaseMqlListener) EnterDelta(ctx *DeltaContext) {}
+
+// ExitDelta is called when production delta is exited.
+func (s *BaseMqlListener) ExitDelta(ctx *DeltaContext) {}
+
+// EnterGetWhereStmt is called when production getWhereStmt is entered.
+func (s *BaseMqlListener) EnterGetWhereStmt(ctx *GetWhereStmtContext) {}
+
+// ExitGetWhereStmt is called when production getWhereStmt is exited.
+func (s *BaseMqlListener) ExitGetWhereStmt(ctx *GetWhereStmtContext) {}
+
+// EnterIndexExpr is called when production indexExpr is entered.
+func (s *BaseMqlListener) EnterIndexExpr(ctx *IndexExprContext) {}
+
+// ExitIndexExpr is called when production indexExpr is exited.
+func (s *BaseMqlListener) ExitIndexExpr(ctx *IndexExprContext) {}
+
+// EnterIndexColumn is called when production indexColumn is entered.
+func (s *BaseMqlListener) EnterIndexColumn(ctx *IndexColumnContext) {}
+
+// ExitIndexColumn is called when production indexColumn is exited.
+func (s *BaseMqlListener) ExitIndexColumn(ctx *IndexColumnContext) {}
+
+// EnterIndexValue is called when production indexValue is entered.
+func (s *BaseMqlListener) EnterIndexValue(ctx *IndexValueContext) {}
+
+// ExitIndexValue is called when production indexValue is exited.
+func (s *BaseMqlListener) ExitIndexValue(ctx *IndexValueContext) {}
+
+// EnterGetRangeStmt is called when production getRangeStmt is entered.
+func (s *BaseMqlListener) EnterGetRangeStmt(ctx *GetRangeStmtContext) {}
+
+// ExitGetRangeStmt is called when production getRangeStmt is exited.
+func (s *BaseMqlListener) ExitGetRangeStmt(ctx *GetRangeStmtContext) {}
+
+// EnterStartKey is called when production startKey is entered.
+func (s *BaseMqlListener) EnterStartKey(ctx *StartKeyContext) {}
+
+// ExitStartKey is called when production startKey is exited.
+func (s *BaseMqlListener) ExitStartKey(ctx *StartKeyContext) {}
+
+// EnterEndKey is called when production endKey is entered.
+func (s *BaseMqlListener) EnterEndKey(ctx *EndKeyContext) {}
+
+// ExitEndKey is called when production endKey is exited.
+func (s *BaseMqlListener) ExitEndKey(ctx *EndKeyContext) {}
+
+// EnterLimit is called when production limit is entered.
+func (s *BaseMqlListener) EnterLimit(ctx *LimitContext) {}
+
+// ExitLimit is called when production limit is exited.
+func (s *BaseMqlListener) ExitLimit(ctx *LimitContext) {}
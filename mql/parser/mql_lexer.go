@@ -14,56 +14,89 @@ var _ = fmt.Printf
 var _ = unicode.IsLetter
 
 var serializedLexerAtn = []uint16{
-	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 2, 18, 114,
+	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 2, 28, 180,
 	8, 1, 4, 2, 9, 2, 4, 3, 9, 3, 4, 4, 9, 4, 4, 5, 9, 5, 4, 6, 9, 6, 4, 7,
 	9, 7, 4, 8, 9, 8, 4, 9, 9, 9, 4, 10, 9, 10, 4, 11, 9, 11, 4, 12, 9, 12,
 	4, 13, 9, 13, 4, 14, 9, 14, 4, 15, 9, 15, 4, 16, 9, 16, 4, 17, 9, 17, 4,
-	18, 9, 18, 4, 19, 9, 19, 3, 2, 3, 2, 3, 3, 3, 3, 3, 4, 3, 4, 3, 5, 3, 5,
-	3, 6, 3, 6, 3, 7, 3, 7, 3, 7, 3, 7, 3, 8, 3, 8, 3, 8, 3, 8, 3, 9, 6, 9,
-	59, 10, 9, 13, 9, 14, 9, 60, 3, 9, 3, 9, 3, 10, 3, 10, 3, 10, 3, 11, 3,
-	11, 3, 12, 3, 12, 3, 13, 3, 13, 3, 14, 3, 14, 3, 15, 3, 15, 3, 16, 3, 16,
-	3, 17, 3, 17, 3, 17, 3, 17, 7, 17, 84, 10, 17, 12, 17, 14, 17, 87, 11,
-	17, 3, 18, 3, 18, 7, 18, 91, 10, 18, 12, 18, 14, 18, 94, 11, 18, 3, 18,
-	3, 18, 3, 18, 7, 18, 99, 10, 18, 12, 18, 14, 18, 102, 11, 18, 3, 18, 7,
-	18, 105, 10, 18, 12, 18, 14, 18, 108, 11, 18, 3, 19, 6, 19, 111, 10, 19,
-	13, 19, 14, 19, 112, 2, 2, 20, 3, 3, 5, 4, 7, 5, 9, 6, 11, 7, 13, 8, 15,
-	9, 17, 10, 19, 11, 21, 12, 23, 13, 25, 14, 27, 15, 29, 2, 31, 2, 33, 16,
-	35, 17, 37, 18, 3, 2, 5, 5, 2, 11, 12, 15, 15, 34, 34, 4, 2, 67, 92, 99,
-	124, 3, 2, 41, 41, 2, 119, 2, 3, 3, 2, 2, 2, 2, 5, 3, 2, 2, 2, 2, 7, 3,
-	2, 2, 2, 2, 9, 3, 2, 2, 2, 2, 11, 3, 2, 2, 2, 2, 13, 3, 2, 2, 2, 2, 15,
-	3, 2, 2, 2, 2, 17, 3, 2, 2, 2, 2, 19, 3, 2, 2, 2, 2, 21, 3, 2, 2, 2, 2,
-	23, 3, 2, 2, 2, 2, 25, 3, 2, 2, 2, 2, 27, 3, 2, 2, 2, 2, 33, 3, 2, 2, 2,
-	2, 35, 3, 2, 2, 2, 2, 37, 3, 2, 2, 2, 3, 39, 3, 2, 2, 2, 5, 41, 3, 2, 2,
-	2, 7, 43, 3, 2, 2, 2, 9, 45, 3, 2, 2, 2, 11, 47, 3, 2, 2, 2, 13, 49, 3,
-	2, 2, 2, 15, 53, 3, 2, 2, 2, 17, 58, 3, 2, 2, 2, 19, 64, 3, 2, 2, 2, 21,
-	67, 3, 2, 2, 2, 23, 69, 3, 2, 2, 2, 25, 71, 3, 2, 2, 2, 27, 73, 3, 2, 2,
-	2, 29, 75, 3, 2, 2, 2, 31, 77, 3, 2, 2, 2, 33, 79, 3, 2, 2, 2, 35, 88,
-	3, 2, 2, 2, 37, 110, 3, 2, 2, 2, 39, 40, 7, 65, 2, 2, 40, 4, 3, 2, 2, 2,
-	41, 42, 7, 63, 2, 2, 42, 6, 3, 2, 2, 2, 43, 44, 7, 48, 2, 2, 44, 8, 3,
-	2, 2, 2, 45, 46, 7, 93, 2, 2, 46, 10, 3, 2, 2, 2, 47, 48, 7, 95, 2, 2,
-	48, 12, 3, 2, 2, 2, 49, 50, 7, 73, 2, 2, 50, 51, 7, 71, 2, 2, 51, 52, 7,
-	86, 2, 2, 52, 14, 3, 2, 2, 2, 53, 54, 7, 85, 2, 2, 54, 55, 7, 71, 2, 2,
-	55, 56, 7, 86, 2, 2, 56, 16, 3, 2, 2, 2, 57, 59, 9, 2, 2, 2, 58, 57, 3,
-	2, 2, 2, 59, 60, 3, 2, 2, 2, 60, 58, 3, 2, 2, 2, 60, 61, 3, 2, 2, 2, 61,
-	62, 3, 2, 2, 2, 62, 63, 8, 9, 2, 2, 63, 18, 3, 2, 2, 2, 64, 65, 7, 63,
-	2, 2, 65, 66, 7, 64, 2, 2, 66, 20, 3, 2, 2, 2, 67, 68, 7, 46, 2, 2, 68,
-	22, 3, 2, 2, 2, 69, 70, 7, 125, 2, 2, 70, 24, 3, 2, 2, 2, 71, 72, 7, 127,
-	2, 2, 72, 26, 3, 2, 2, 2, 73, 74, 7, 61, 2, 2, 74, 28, 3, 2, 2, 2, 75,
-	76, 9, 3, 2, 2, 76, 30, 3, 2, 2, 2, 77, 78, 4, 50, 59, 2, 78, 32, 3, 2,
-	2, 2, 79, 85, 5, 29, 15, 2, 80, 84, 5, 29, 15, 2, 81, 84, 5, 31, 16, 2,
-	82, 84, 7, 97, 2, 2, 83, 80, 3, 2, 2, 2, 83, 81, 3, 2, 2, 2, 83, 82, 3,
-	2, 2, 2, 84, 87, 3, 2, 2, 2, 85, 83, 3, 2, 2, 2, 85, 86, 3, 2, 2, 2, 86,
-	34, 3, 2, 2, 2, 87, 85, 3, 2, 2, 2, 88, 92, 7, 41, 2, 2, 89, 91, 10, 4,
-	2, 2, 90, 89, 3, 2, 2, 2, 91, 94, 3, 2, 2, 2, 92, 90, 3, 2, 2, 2, 92, 93,
-	3, 2, 2, 2, 93, 95, 3, 2, 2, 2, 94, 92, 3, 2, 2, 2, 95, 106, 7, 41, 2,
-	2, 96, 100, 7, 41, 2, 2, 97, 99, 10, 4, 2, 2, 98, 97, 3, 2, 2, 2, 99, 102,
-	3, 2, 2, 2, 100, 98, 3, 2, 2, 2, 100, 101, 3, 2, 2, 2, 101, 103, 3, 2,
-	2, 2, 102, 100, 3, 2, 2, 2, 103, 105, 7, 41, 2, 2, 104, 96, 3, 2, 2, 2,
-	105, 108, 3, 2, 2, 2, 106, 104, 3, 2, 2, 2, 106, 107, 3, 2, 2, 2, 107,
-	36, 3, 2, 2, 2, 108, 106, 3, 2, 2, 2, 109, 111, 5, 31, 16, 2, 110, 109,
-	3, 2, 2, 2, 111, 112, 3, 2, 2, 2, 112, 110, 3, 2, 2, 2, 112, 113, 3, 2,
-	2, 2, 113, 38, 3, 2, 2, 2, 10, 2, 60, 83, 85, 92, 100, 106, 112, 3, 8,
-	2, 2,
+	18, 9, 18, 4, 19, 9, 19, 4, 20, 9, 20, 4, 21, 9, 21, 4, 22, 9, 22, 4, 23,
+	9, 23, 4, 24, 9, 24, 4, 25, 9, 25, 4, 26, 9, 26, 4, 27, 9, 27, 4, 28, 9,
+	28, 4, 29, 9, 29, 3, 2, 3, 2, 3, 3, 3, 3, 3, 4, 3, 4, 3, 5, 3, 5, 3, 6,
+	3, 6, 3, 7, 3, 7, 3, 8, 3, 8, 3, 8, 3, 8, 3, 9, 3, 9, 3, 9, 3, 9, 3, 10,
+	3, 10, 3, 10, 3, 10, 3, 10, 3, 11, 3, 11, 3, 11, 3, 11, 3, 11, 3, 11, 3,
+	11, 3, 12, 3, 12, 3, 12, 3, 12, 3, 13, 3, 13, 3, 13, 3, 14, 3, 14, 3, 14,
+	3, 14, 3, 14, 3, 14, 3, 15, 3, 15, 3, 15, 3, 15, 3, 16, 3, 16, 3, 16, 3,
+	16, 3, 16, 3, 16, 3, 17, 3, 17, 3, 17, 3, 18, 3, 18, 3, 18, 3, 18, 3, 18,
+	3, 18, 3, 19, 6, 19, 125, 10, 19, 13, 19, 14, 19, 126, 3, 19, 3, 19, 3,
+	20, 3, 20, 3, 20, 3, 21, 3, 21, 3, 22, 3, 22, 3, 23, 3, 23, 3, 24, 3, 24,
+	3, 25, 3, 25, 3, 26, 3, 26, 3, 27, 3, 27, 3, 27, 3, 27, 7, 27, 150, 10,
+	27, 12, 27, 14, 27, 153, 11, 27, 3, 28, 3, 28, 7, 28, 157, 10, 28, 12,
+	28, 14, 28, 160, 11, 28, 3, 28, 3, 28, 3, 28, 7, 28, 165, 10, 28, 12, 28,
+	14, 28, 168, 11, 28, 3, 28, 7, 28, 171, 10, 28, 12, 28, 14, 28, 174, 11,
+	28, 3, 29, 6, 29, 177, 10, 29, 13, 29, 14, 29, 178, 2, 2, 30, 3, 3, 5,
+	4, 7, 5, 9, 6, 11, 7, 13, 8, 15, 9, 17, 10, 19, 11, 21, 12, 23, 13, 25,
+	14, 27, 15, 29, 16, 31, 17, 33, 18, 35, 19, 37, 20, 39, 21, 41, 22, 43,
+	23, 45, 24, 47, 25, 49, 2, 51, 2, 53, 26, 55, 27, 57, 28, 3, 2, 20, 4,
+	2, 86, 86, 118, 118, 4, 2, 78, 78, 110, 110, 4, 2, 68, 68, 100, 100, 4,
+	2, 91, 91, 123, 123, 4, 2, 89, 89, 121, 121, 4, 2, 74, 74, 106, 106, 4,
+	2, 71, 71, 103, 103, 4, 2, 84, 84, 116, 116, 4, 2, 67, 67, 99, 99, 4, 2,
+	80, 80, 112, 112, 4, 2, 70, 70, 102, 102, 4, 2, 73, 73, 105, 105, 4, 2,
+	81, 81, 113, 113, 4, 2, 75, 75, 107, 107, 4, 2, 79, 79, 111, 111, 5, 2,
+	11, 12, 15, 15, 34, 34, 4, 2, 67, 92, 99, 124, 3, 2, 41, 41, 2, 185, 2,
+	3, 3, 2, 2, 2, 2, 5, 3, 2, 2, 2, 2, 7, 3, 2, 2, 2, 2, 9, 3, 2, 2, 2, 2,
+	11, 3, 2, 2, 2, 2, 13, 3, 2, 2, 2, 2, 15, 3, 2, 2, 2, 2, 17, 3, 2, 2, 2,
+	2, 19, 3, 2, 2, 2, 2, 21, 3, 2, 2, 2, 2, 23, 3, 2, 2, 2, 2, 25, 3, 2, 2,
+	2, 2, 27, 3, 2, 2, 2, 2, 29, 3, 2, 2, 2, 2, 31, 3, 2, 2, 2, 2, 33, 3, 2,
+	2, 2, 2, 35, 3, 2, 2, 2, 2, 37, 3, 2, 2, 2, 2, 39, 3, 2, 2, 2, 2, 41, 3,
+	2, 2, 2, 2, 43, 3, 2, 2, 2, 2, 45, 3, 2, 2, 2, 2, 47, 3, 2, 2, 2, 2, 53,
+	3, 2, 2, 2, 2, 55, 3, 2, 2, 2, 2, 57, 3, 2, 2, 2, 3, 59, 3, 2, 2, 2, 5,
+	61, 3, 2, 2, 2, 7, 63, 3, 2, 2, 2, 9, 65, 3, 2, 2, 2, 11, 67, 3, 2, 2,
+	2, 13, 69, 3, 2, 2, 2, 15, 71, 3, 2, 2, 2, 17, 75, 3, 2, 2, 2, 19, 79,
+	3, 2, 2, 2, 21, 84, 3, 2, 2, 2, 23, 91, 3, 2, 2, 2, 25, 95, 3, 2, 2, 2,
+	27, 98, 3, 2, 2, 2, 29, 104, 3, 2, 2, 2, 31, 108, 3, 2, 2, 2, 33, 114,
+	3, 2, 2, 2, 35, 117, 3, 2, 2, 2, 37, 124, 3, 2, 2, 2, 39, 130, 3, 2, 2,
+	2, 41, 133, 3, 2, 2, 2, 43, 135, 3, 2, 2, 2, 45, 137, 3, 2, 2, 2, 47, 139,
+	3, 2, 2, 2, 49, 141, 3, 2, 2, 2, 51, 143, 3, 2, 2, 2, 53, 145, 3, 2, 2,
+	2, 55, 154, 3, 2, 2, 2, 57, 176, 3, 2, 2, 2, 59, 60, 7, 65, 2, 2, 60, 4,
+	3, 2, 2, 2, 61, 62, 7, 63, 2, 2, 62, 6, 3, 2, 2, 2, 63, 64, 7, 48, 2, 2,
+	64, 8, 3, 2, 2, 2, 65, 66, 7, 93, 2, 2, 66, 10, 3, 2, 2, 2, 67, 68, 7,
+	95, 2, 2, 68, 12, 3, 2, 2, 2, 69, 70, 7, 47, 2, 2, 70, 14, 3, 2, 2, 2,
+	71, 72, 7, 73, 2, 2, 72, 73, 7, 71, 2, 2, 73, 74, 7, 86, 2, 2, 74, 16,
+	3, 2, 2, 2, 75, 76, 7, 85, 2, 2, 76, 77, 7, 71, 2, 2, 77, 78, 7, 86, 2,
+	2, 78, 18, 3, 2, 2, 2, 79, 80, 7, 75, 2, 2, 80, 81, 7, 80, 2, 2, 81, 82,
+	7, 69, 2, 2, 82, 83, 7, 84, 2, 2, 83, 20, 3, 2, 2, 2, 84, 85, 7, 85, 2,
+	2, 85, 86, 7, 71, 2, 2, 86, 87, 7, 78, 2, 2, 87, 88, 7, 71, 2, 2, 88, 89,
+	7, 69, 2, 2, 89, 90, 7, 86, 2, 2, 90, 22, 3, 2, 2, 2, 91, 92, 9, 2, 2,
+	2, 92, 93, 9, 2, 2, 2, 93, 94, 9, 3, 2, 2, 94, 24, 3, 2, 2, 2, 95, 96,
+	9, 4, 2, 2, 96, 97, 9, 5, 2, 2, 97, 26, 3, 2, 2, 2, 98, 99, 9, 6, 2, 2,
+	99, 100, 9, 7, 2, 2, 100, 101, 9, 8, 2, 2, 101, 102, 9, 9, 2, 2, 102, 103,
+	9, 8, 2, 2, 103, 28, 3, 2, 2, 2, 104, 105, 9, 10, 2, 2, 105, 106, 9, 11,
+	2, 2, 106, 107, 9, 12, 2, 2, 107, 30, 3, 2, 2, 2, 108, 109, 9, 9, 2, 2,
+	109, 110, 9, 10, 2, 2, 110, 111, 9, 11, 2, 2, 111, 112, 9, 13, 2, 2, 112,
+	113, 9, 8, 2, 2, 113, 32, 3, 2, 2, 2, 114, 115, 9, 2, 2, 2, 115, 116, 9,
+	14, 2, 2, 116, 34, 3, 2, 2, 2, 117, 118, 9, 3, 2, 2, 118, 119, 9, 15, 2,
+	2, 119, 120, 9, 16, 2, 2, 120, 121, 9, 15, 2, 2, 121, 122, 9, 2, 2, 2,
+	122, 36, 3, 2, 2, 2, 123, 125, 9, 17, 2, 2, 124, 123, 3, 2, 2, 2, 125,
+	126, 3, 2, 2, 2, 126, 124, 3, 2, 2, 2, 126, 127, 3, 2, 2, 2, 127, 128,
+	3, 2, 2, 2, 128, 129, 8, 19, 2, 2, 129, 38, 3, 2, 2, 2, 130, 131, 7, 63,
+	2, 2, 131, 132, 7, 64, 2, 2, 132, 40, 3, 2, 2, 2, 133, 134, 7, 46, 2, 2,
+	134, 42, 3, 2, 2, 2, 135, 136, 7, 125, 2, 2, 136, 44, 3, 2, 2, 2, 137,
+	138, 7, 127, 2, 2, 138, 46, 3, 2, 2, 2, 139, 140, 7, 61, 2, 2, 140, 48,
+	3, 2, 2, 2, 141, 142, 9, 18, 2, 2, 142, 50, 3, 2, 2, 2, 143, 144, 4, 50,
+	59, 2, 144, 52, 3, 2, 2, 2, 145, 151, 5, 49, 25, 2, 146, 150, 5, 49, 25,
+	2, 147, 150, 5, 51, 26, 2, 148, 150, 7, 97, 2, 2, 149, 146, 3, 2, 2, 2,
+	149, 147, 3, 2, 2, 2, 149, 148, 3, 2, 2, 2, 150, 153, 3, 2, 2, 2, 151,
+	149, 3, 2, 2, 2, 151, 152, 3, 2, 2, 2, 152, 54, 3, 2, 2, 2, 153, 151, 3,
+	2, 2, 2, 154, 158, 7, 41, 2, 2, 155, 157, 10, 19, 2, 2, 156, 155, 3, 2,
+	2, 2, 157, 160, 3, 2, 2, 2, 158, 156, 3, 2, 2, 2, 158, 159, 3, 2, 2, 2,
+	159, 161, 3, 2, 2, 2, 160, 158, 3, 2, 2, 2, 161, 172, 7, 41, 2, 2, 162,
+	166, 7, 41, 2, 2, 163, 165, 10, 19, 2, 2, 164, 163, 3, 2, 2, 2, 165, 168,
+	3, 2, 2, 2, 166, 164, 3, 2, 2, 2, 166, 167, 3, 2, 2, 2, 167, 169, 3, 2,
+	2, 2, 168, 166, 3, 2, 2, 2, 169, 171, 7, 41, 2, 2, 170, 162, 3, 2, 2, 2,
+	171, 174, 3, 2, 2, 2, 172, 170, 3, 2, 2, 2, 172, 173, 3, 2, 2, 2, 173,
+	56, 3, 2, 2, 2, 174, 172, 3, 2, 2, 2, 175, 177, 5, 51, 26, 2, 176, 175,
+	3, 2, 2, 2, 177, 178, 3, 2, 2, 2, 178, 176, 3, 2, 2, 2, 178, 179, 3, 2,
+	2, 2, 179, 58, 3, 2, 2, 2, 10, 2, 126, 149, 151, 158, 166, 172, 178, 3,
+	8, 2, 2,
 }
 
 var lexerDeserializer = antlr.NewATNDeserializer(nil)
@@ -78,17 +111,21 @@ var lexerModeNames = []string{
 }
 
 var lexerLiteralNames = []string{
-	"", "'?'", "'='", "'.'", "'['", "']'", "'GET'", "'SET'", "", "'=>'", "','",
-	"'{'", "'}'", "';'",
+	"", "'?'", "'='", "'.'", "'['", "']'", "'-'", "'GET'", "'SET'", "'INCR'",
+	"'SELECT'", "", "", "", "", "", "", "", "", "'=>'", "','", "'{'", "'}'",
+	"';'",
 }
 
 var lexerSymbolicNames = []string{
-	"", "", "", "", "", "", "GET", "SET", "WHITESPACE", "ASSOC", "COMMA", "LEFT_BRACE",
-	"RIGHT_BRACE", "SEMICOLON", "Identifier", "StringLiteral", "IntegerLiteral",
+	"", "", "", "", "", "", "", "GET", "SET", "INCR", "SELECT", "TTL", "BY",
+	"WHERE", "AND", "RANGE", "TO", "LIMIT", "WHITESPACE", "ASSOC", "COMMA",
+	"LEFT_BRACE", "RIGHT_BRACE", "SEMICOLON", "Identifier", "StringLiteral",
+	"IntegerLiteral",
 }
 
 var lexerRuleNames = []string{
-	"T__0", "T__1", "T__2", "T__3", "T__4", "GET", "SET", "WHITESPACE", "ASSOC",
+	"T__0", "T__1", "T__2", "T__3", "T__4", "T__5", "GET", "SET", "INCR", "SELECT",
+	"TTL", "BY", "WHERE", "AND", "RANGE", "TO", "LIMIT", "WHITESPACE", "ASSOC",
 	"COMMA", "LEFT_BRACE", "RIGHT_BRACE", "SEMICOLON", "Letter", "Digit", "Identifier",
 	"StringLiteral", "IntegerLiteral",
 }
@@ -133,15 +170,25 @@ const (
 	MqlLexerT__2           = 3
 	MqlLexerT__3           = 4
 	MqlLexerT__4           = 5
-	MqlLexerGET            = 6
-	MqlLexerSET            = 7
-	MqlLexerWHITESPACE     = 8
-	MqlLexerASSOC          = 9
-	MqlLexerCOMMA          = 10
-	MqlLexerLEFT_BRACE     = 11
-	MqlLexerRIGHT_BRACE    = 12
-	MqlLexerSEMICOLON      = 13
-	MqlLexerIdentifier     = 14
-	MqlLexerStringLiteral  = 15
-	MqlLexerIntegerLiteral = 16
+	MqlLexerT__5           = 6
+	MqlLexerGET            = 7
+	MqlLexerSET            = 8
+	MqlLexerINCR           = 9
+	MqlLexerSELECT         = 10
+	MqlLexerTTL            = 11
+	MqlLexerBY             = 12
+	MqlLexerWHERE          = 13
+	MqlLexerAND            = 14
+	MqlLexerRANGE          = 15
+	MqlLexerTO             = 16
+	MqlLexerLIMIT          = 17
+	MqlLexerWHITESPACE     = 18
+	MqlLexerASSOC          = 19
+	MqlLexerCOMMA          = 20
+	MqlLexerLEFT_BRACE     = 21
+	MqlLexerRIGHT_BRACE    = 22
+	MqlLexerSEMICOLON      = 23
+	MqlLexerIdentifier     = 24
+	MqlLexerStringLiteral  = 25
+	MqlLexerIntegerLiteral = 26
 )
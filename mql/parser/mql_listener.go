@@ -62,6 +62,39 @@ type MqlListener interface {
 	// EnterSuperColumnKey is called when entering the superColumnKey production.
 	EnterSuperColumnKey(c *SuperColumnKeyContext)
 
+	// EnterTtl is called when entering the ttl production.
+	EnterTtl(c *TtlContext)
+
+	// EnterIncrStmt is called when entering the incrStmt production.
+	EnterIncrStmt(c *IncrStmtContext)
+
+	// EnterDelta is called when entering the delta production.
+	EnterDelta(c *DeltaContext)
+
+	// EnterGetWhereStmt is called when entering the getWhereStmt production.
+	EnterGetWhereStmt(c *GetWhereStmtContext)
+
+	// EnterIndexExpr is called when entering the indexExpr production.
+	EnterIndexExpr(c *IndexExprContext)
+
+	// EnterIndexColumn is called when entering the indexColumn production.
+	EnterIndexColumn(c *IndexColumnContext)
+
+	// EnterIndexValue is called when entering the indexValue production.
+	EnterIndexValue(c *IndexValueContext)
+
+	// EnterGetRangeStmt is called when entering the getRangeStmt production.
+	EnterGetRangeStmt(c *GetRangeStmtContext)
+
+	// EnterStartKey is called when entering the startKey production.
+	EnterStartKey(c *StartKeyContext)
+
+	// EnterEndKey is called when entering the endKey production.
+	EnterEndKey(c *EndKeyContext)
+
+	// EnterLimit is called when entering the limit production.
+	EnterLimit(c *LimitContext)
+
 	// ExitStringVal is called when exiting the stringVal production.
 	ExitStringVal(c *StringValContext)
 
@@ -115,4 +148,37 @@ type MqlListener interface {
 
 	// ExitSuperColumnKey is called when exiting the superColumnKey production.
 	ExitSuperColumnKey(c *SuperColumnKeyContext)
+
+	// ExitTtl is called when exiting the ttl production.
+	ExitTtl(c *TtlContext)
+
+	// ExitIncrStmt is called when exiting the incrStmt production.
+	ExitIncrStmt(c *IncrStmtContext)
+
+	// ExitDelta is called when exiting the delta production.
+	ExitDelta(c *DeltaContext)
+
+	// ExitGetWhereStmt is called when exiting the getWhereStmt production.
+	ExitGetWhereStmt(c *GetWhereStmtContext)
+
+	// ExitIndexExpr is called when exiting the indexExpr production.
+	ExitIndexExpr(c *IndexExprContext)
+
+	// ExitIndexColumn is called when exiting the indexColumn production.
+	ExitIndexColumn(c *IndexColumnContext)
+
+	// ExitIndexValue is called when exiting the indexValue production.
+	ExitIndexValue(c *IndexValueContext)
+
+	// ExitGetRangeStmt is called when exiting the getRangeStmt production.
+	ExitGetRangeStmt(c *GetRangeStmtContext)
+
+	// ExitStartKey is called when exiting the startKey production.
+	ExitStartKey(c *StartKeyContext)
+
+	// ExitEndKey is called when exiting the endKey production.
+	ExitEndKey(c *EndKeyContext)
+
+	// ExitLimit is called when exiting the limit production.
+	ExitLimit(c *LimitContext)
 }
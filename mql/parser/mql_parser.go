@@ -16,69 +16,111 @@ var _ = reflect.Copy
 var _ = strconv.Itoa
 
 var parserATN = []uint16{
-	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 3, 18, 121,
+	3, 24715, 42794, 33075, 47597, 16764, 15335, 30598, 22884, 3, 28, 206,
 	4, 2, 9, 2, 4, 3, 9, 3, 4, 4, 9, 4, 4, 5, 9, 5, 4, 6, 9, 6, 4, 7, 9, 7,
 	4, 8, 9, 8, 4, 9, 9, 9, 4, 10, 9, 10, 4, 11, 9, 11, 4, 12, 9, 12, 4, 13,
 	9, 13, 4, 14, 9, 14, 4, 15, 9, 15, 4, 16, 9, 16, 4, 17, 9, 17, 4, 18, 9,
-	18, 4, 19, 9, 19, 3, 2, 3, 2, 3, 3, 3, 3, 5, 3, 43, 10, 3, 3, 4, 3, 4,
-	3, 4, 3, 5, 3, 5, 3, 5, 3, 5, 3, 5, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6,
-	3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 5, 6, 66, 10, 6, 5, 6, 68, 10,
-	6, 3, 7, 3, 7, 3, 8, 3, 8, 3, 9, 3, 9, 3, 9, 5, 9, 77, 10, 9, 3, 10, 3,
-	10, 3, 11, 3, 11, 3, 11, 3, 11, 7, 11, 85, 10, 11, 12, 11, 14, 11, 88,
-	11, 11, 3, 11, 3, 11, 3, 12, 3, 12, 3, 12, 3, 12, 7, 12, 96, 10, 12, 12,
-	12, 14, 12, 99, 11, 12, 3, 12, 3, 12, 3, 13, 3, 13, 3, 13, 3, 13, 3, 14,
-	3, 14, 3, 14, 3, 14, 3, 15, 3, 15, 3, 16, 3, 16, 3, 17, 3, 17, 3, 18, 3,
-	18, 3, 19, 3, 19, 3, 19, 2, 2, 20, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20,
-	22, 24, 26, 28, 30, 32, 34, 36, 2, 3, 4, 2, 3, 3, 17, 17, 2, 109, 2, 38,
-	3, 2, 2, 2, 4, 42, 3, 2, 2, 2, 6, 44, 3, 2, 2, 2, 8, 47, 3, 2, 2, 2, 10,
-	52, 3, 2, 2, 2, 12, 69, 3, 2, 2, 2, 14, 71, 3, 2, 2, 2, 16, 76, 3, 2, 2,
-	2, 18, 78, 3, 2, 2, 2, 20, 80, 3, 2, 2, 2, 22, 91, 3, 2, 2, 2, 24, 102,
-	3, 2, 2, 2, 26, 106, 3, 2, 2, 2, 28, 110, 3, 2, 2, 2, 30, 112, 3, 2, 2,
-	2, 32, 114, 3, 2, 2, 2, 34, 116, 3, 2, 2, 2, 36, 118, 3, 2, 2, 2, 38, 39,
-	9, 2, 2, 2, 39, 3, 3, 2, 2, 2, 40, 43, 5, 6, 4, 2, 41, 43, 5, 8, 5, 2,
-	42, 40, 3, 2, 2, 2, 42, 41, 3, 2, 2, 2, 43, 5, 3, 2, 2, 2, 44, 45, 7, 8,
-	2, 2, 45, 46, 5, 10, 6, 2, 46, 7, 3, 2, 2, 2, 47, 48, 7, 9, 2, 2, 48, 49,
-	5, 10, 6, 2, 49, 50, 7, 4, 2, 2, 50, 51, 5, 16, 9, 2, 51, 9, 3, 2, 2, 2,
-	52, 53, 5, 12, 7, 2, 53, 54, 7, 5, 2, 2, 54, 55, 5, 14, 8, 2, 55, 56, 7,
-	6, 2, 2, 56, 57, 5, 30, 16, 2, 57, 67, 7, 7, 2, 2, 58, 59, 7, 6, 2, 2,
-	59, 60, 5, 32, 17, 2, 60, 65, 7, 7, 2, 2, 61, 62, 7, 6, 2, 2, 62, 63, 5,
-	32, 17, 2, 63, 64, 7, 7, 2, 2, 64, 66, 3, 2, 2, 2, 65, 61, 3, 2, 2, 2,
-	65, 66, 3, 2, 2, 2, 66, 68, 3, 2, 2, 2, 67, 58, 3, 2, 2, 2, 67, 68, 3,
-	2, 2, 2, 68, 11, 3, 2, 2, 2, 69, 70, 7, 16, 2, 2, 70, 13, 3, 2, 2, 2, 71,
-	72, 7, 16, 2, 2, 72, 15, 3, 2, 2, 2, 73, 77, 5, 18, 10, 2, 74, 77, 5, 20,
-	11, 2, 75, 77, 5, 22, 12, 2, 76, 73, 3, 2, 2, 2, 76, 74, 3, 2, 2, 2, 76,
-	75, 3, 2, 2, 2, 77, 17, 3, 2, 2, 2, 78, 79, 5, 2, 2, 2, 79, 19, 3, 2, 2,
-	2, 80, 81, 7, 13, 2, 2, 81, 86, 5, 24, 13, 2, 82, 83, 7, 12, 2, 2, 83,
-	85, 5, 24, 13, 2, 84, 82, 3, 2, 2, 2, 85, 88, 3, 2, 2, 2, 86, 84, 3, 2,
-	2, 2, 86, 87, 3, 2, 2, 2, 87, 89, 3, 2, 2, 2, 88, 86, 3, 2, 2, 2, 89, 90,
-	7, 14, 2, 2, 90, 21, 3, 2, 2, 2, 91, 92, 7, 13, 2, 2, 92, 97, 5, 26, 14,
-	2, 93, 94, 7, 12, 2, 2, 94, 96, 5, 26, 14, 2, 95, 93, 3, 2, 2, 2, 96, 99,
-	3, 2, 2, 2, 97, 95, 3, 2, 2, 2, 97, 98, 3, 2, 2, 2, 98, 100, 3, 2, 2, 2,
-	99, 97, 3, 2, 2, 2, 100, 101, 7, 14, 2, 2, 101, 23, 3, 2, 2, 2, 102, 103,
-	5, 34, 18, 2, 103, 104, 7, 11, 2, 2, 104, 105, 5, 18, 10, 2, 105, 25, 3,
-	2, 2, 2, 106, 107, 5, 36, 19, 2, 107, 108, 7, 11, 2, 2, 108, 109, 5, 20,
-	11, 2, 109, 27, 3, 2, 2, 2, 110, 111, 7, 16, 2, 2, 111, 29, 3, 2, 2, 2,
-	112, 113, 5, 2, 2, 2, 113, 31, 3, 2, 2, 2, 114, 115, 5, 2, 2, 2, 115, 33,
-	3, 2, 2, 2, 116, 117, 5, 2, 2, 2, 117, 35, 3, 2, 2, 2, 118, 119, 5, 2,
-	2, 2, 119, 37, 3, 2, 2, 2, 8, 42, 65, 67, 76, 86, 97,
+	18, 4, 19, 9, 19, 4, 20, 9, 20, 4, 21, 9, 21, 4, 22, 9, 22, 4, 23, 9, 23,
+	4, 24, 9, 24, 4, 25, 9, 25, 4, 26, 9, 26, 4, 27, 9, 27, 4, 28, 9, 28, 4,
+	29, 9, 29, 4, 30, 9, 30, 3, 2, 3, 2, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 5, 3,
+	68, 10, 3, 3, 3, 5, 3, 71, 10, 3, 3, 3, 3, 3, 3, 4, 3, 4, 3, 4, 3, 5, 3,
+	5, 3, 5, 3, 5, 3, 5, 3, 5, 5, 5, 84, 10, 5, 3, 6, 3, 6, 3, 6, 3, 6, 3,
+	6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 3, 6, 5, 6, 99, 10, 6, 5,
+	6, 101, 10, 6, 3, 7, 3, 7, 3, 8, 3, 8, 3, 9, 3, 9, 3, 9, 5, 9, 110, 10,
+	9, 3, 10, 3, 10, 3, 11, 3, 11, 3, 11, 3, 11, 7, 11, 118, 10, 11, 12, 11,
+	14, 11, 121, 11, 11, 3, 11, 3, 11, 3, 12, 3, 12, 3, 12, 3, 12, 7, 12, 129,
+	10, 12, 12, 12, 14, 12, 132, 11, 12, 3, 12, 3, 12, 3, 13, 3, 13, 3, 13,
+	3, 13, 3, 14, 3, 14, 3, 14, 3, 14, 3, 15, 3, 15, 3, 16, 3, 16, 3, 17, 3,
+	17, 3, 18, 3, 18, 3, 19, 3, 19, 3, 20, 3, 20, 3, 21, 3, 21, 3, 21, 3, 21,
+	5, 21, 160, 10, 21, 3, 22, 5, 22, 163, 10, 22, 3, 22, 3, 22, 3, 23, 3,
+	23, 3, 23, 3, 23, 3, 23, 3, 23, 3, 23, 3, 23, 7, 23, 175, 10, 23, 12, 23,
+	14, 23, 178, 11, 23, 3, 24, 3, 24, 3, 24, 3, 24, 3, 25, 3, 25, 3, 26, 3,
+	26, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27, 3, 27,
+	5, 27, 198, 10, 27, 3, 28, 3, 28, 3, 29, 3, 29, 3, 30, 3, 30, 3, 30, 2,
+	2, 31, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34,
+	36, 38, 40, 42, 44, 46, 48, 50, 52, 54, 56, 58, 2, 5, 4, 2, 3, 3, 27, 27,
+	4, 2, 9, 9, 12, 12, 3, 2, 26, 27, 2, 192, 2, 60, 3, 2, 2, 2, 4, 67, 3,
+	2, 2, 2, 6, 74, 3, 2, 2, 2, 8, 77, 3, 2, 2, 2, 10, 85, 3, 2, 2, 2, 12,
+	102, 3, 2, 2, 2, 14, 104, 3, 2, 2, 2, 16, 109, 3, 2, 2, 2, 18, 111, 3,
+	2, 2, 2, 20, 113, 3, 2, 2, 2, 22, 124, 3, 2, 2, 2, 24, 135, 3, 2, 2, 2,
+	26, 139, 3, 2, 2, 2, 28, 143, 3, 2, 2, 2, 30, 145, 3, 2, 2, 2, 32, 147,
+	3, 2, 2, 2, 34, 149, 3, 2, 2, 2, 36, 151, 3, 2, 2, 2, 38, 153, 3, 2, 2,
+	2, 40, 155, 3, 2, 2, 2, 42, 162, 3, 2, 2, 2, 44, 166, 3, 2, 2, 2, 46, 179,
+	3, 2, 2, 2, 48, 183, 3, 2, 2, 2, 50, 185, 3, 2, 2, 2, 52, 187, 3, 2, 2,
+	2, 54, 199, 3, 2, 2, 2, 56, 201, 3, 2, 2, 2, 58, 203, 3, 2, 2, 2, 60, 61,
+	9, 2, 2, 2, 61, 3, 3, 2, 2, 2, 62, 68, 5, 6, 4, 2, 63, 68, 5, 8, 5, 2,
+	64, 68, 5, 40, 21, 2, 65, 68, 5, 44, 23, 2, 66, 68, 5, 52, 27, 2, 67, 62,
+	3, 2, 2, 2, 67, 63, 3, 2, 2, 2, 67, 64, 3, 2, 2, 2, 67, 65, 3, 2, 2, 2,
+	67, 66, 3, 2, 2, 2, 68, 70, 3, 2, 2, 2, 69, 71, 7, 25, 2, 2, 70, 69, 3,
+	2, 2, 2, 70, 71, 3, 2, 2, 2, 71, 72, 3, 2, 2, 2, 72, 73, 7, 2, 2, 3, 73,
+	5, 3, 2, 2, 2, 74, 75, 7, 9, 2, 2, 75, 76, 5, 10, 6, 2, 76, 7, 3, 2, 2,
+	2, 77, 78, 7, 10, 2, 2, 78, 79, 5, 10, 6, 2, 79, 80, 7, 4, 2, 2, 80, 83,
+	5, 16, 9, 2, 81, 82, 7, 13, 2, 2, 82, 84, 5, 38, 20, 2, 83, 81, 3, 2, 2,
+	2, 83, 84, 3, 2, 2, 2, 84, 9, 3, 2, 2, 2, 85, 86, 5, 12, 7, 2, 86, 87,
+	7, 5, 2, 2, 87, 88, 5, 14, 8, 2, 88, 89, 7, 6, 2, 2, 89, 90, 5, 30, 16,
+	2, 90, 100, 7, 7, 2, 2, 91, 92, 7, 6, 2, 2, 92, 93, 5, 32, 17, 2, 93, 98,
+	7, 7, 2, 2, 94, 95, 7, 6, 2, 2, 95, 96, 5, 32, 17, 2, 96, 97, 7, 7, 2,
+	2, 97, 99, 3, 2, 2, 2, 98, 94, 3, 2, 2, 2, 98, 99, 3, 2, 2, 2, 99, 101,
+	3, 2, 2, 2, 100, 91, 3, 2, 2, 2, 100, 101, 3, 2, 2, 2, 101, 11, 3, 2, 2,
+	2, 102, 103, 7, 26, 2, 2, 103, 13, 3, 2, 2, 2, 104, 105, 7, 26, 2, 2, 105,
+	15, 3, 2, 2, 2, 106, 110, 5, 18, 10, 2, 107, 110, 5, 20, 11, 2, 108, 110,
+	5, 22, 12, 2, 109, 106, 3, 2, 2, 2, 109, 107, 3, 2, 2, 2, 109, 108, 3,
+	2, 2, 2, 110, 17, 3, 2, 2, 2, 111, 112, 5, 2, 2, 2, 112, 19, 3, 2, 2, 2,
+	113, 114, 7, 23, 2, 2, 114, 119, 5, 24, 13, 2, 115, 116, 7, 22, 2, 2, 116,
+	118, 5, 24, 13, 2, 117, 115, 3, 2, 2, 2, 118, 121, 3, 2, 2, 2, 119, 117,
+	3, 2, 2, 2, 119, 120, 3, 2, 2, 2, 120, 122, 3, 2, 2, 2, 121, 119, 3, 2,
+	2, 2, 122, 123, 7, 24, 2, 2, 123, 21, 3, 2, 2, 2, 124, 125, 7, 23, 2, 2,
+	125, 130, 5, 26, 14, 2, 126, 127, 7, 22, 2, 2, 127, 129, 5, 26, 14, 2,
+	128, 126, 3, 2, 2, 2, 129, 132, 3, 2, 2, 2, 130, 128, 3, 2, 2, 2, 130,
+	131, 3, 2, 2, 2, 131, 133, 3, 2, 2, 2, 132, 130, 3, 2, 2, 2, 133, 134,
+	7, 24, 2, 2, 134, 23, 3, 2, 2, 2, 135, 136, 5, 34, 18, 2, 136, 137, 7,
+	21, 2, 2, 137, 138, 5, 18, 10, 2, 138, 25, 3, 2, 2, 2, 139, 140, 5, 36,
+	19, 2, 140, 141, 7, 21, 2, 2, 141, 142, 5, 20, 11, 2, 142, 27, 3, 2, 2,
+	2, 143, 144, 7, 26, 2, 2, 144, 29, 3, 2, 2, 2, 145, 146, 5, 2, 2, 2, 146,
+	31, 3, 2, 2, 2, 147, 148, 5, 2, 2, 2, 148, 33, 3, 2, 2, 2, 149, 150, 5,
+	2, 2, 2, 150, 35, 3, 2, 2, 2, 151, 152, 5, 2, 2, 2, 152, 37, 3, 2, 2, 2,
+	153, 154, 7, 28, 2, 2, 154, 39, 3, 2, 2, 2, 155, 156, 7, 11, 2, 2, 156,
+	159, 5, 10, 6, 2, 157, 158, 7, 14, 2, 2, 158, 160, 5, 42, 22, 2, 159, 157,
+	3, 2, 2, 2, 159, 160, 3, 2, 2, 2, 160, 41, 3, 2, 2, 2, 161, 163, 7, 8,
+	2, 2, 162, 161, 3, 2, 2, 2, 162, 163, 3, 2, 2, 2, 163, 164, 3, 2, 2, 2,
+	164, 165, 7, 28, 2, 2, 165, 43, 3, 2, 2, 2, 166, 167, 9, 3, 2, 2, 167,
+	168, 5, 12, 7, 2, 168, 169, 7, 5, 2, 2, 169, 170, 5, 14, 8, 2, 170, 171,
+	7, 15, 2, 2, 171, 176, 5, 46, 24, 2, 172, 173, 7, 16, 2, 2, 173, 175, 5,
+	46, 24, 2, 174, 172, 3, 2, 2, 2, 175, 178, 3, 2, 2, 2, 176, 174, 3, 2,
+	2, 2, 176, 177, 3, 2, 2, 2, 177, 45, 3, 2, 2, 2, 178, 176, 3, 2, 2, 2,
+	179, 180, 5, 48, 25, 2, 180, 181, 7, 4, 2, 2, 181, 182, 5, 50, 26, 2, 182,
+	47, 3, 2, 2, 2, 183, 184, 9, 4, 2, 2, 184, 49, 3, 2, 2, 2, 185, 186, 7,
+	27, 2, 2, 186, 51, 3, 2, 2, 2, 187, 188, 9, 3, 2, 2, 188, 189, 5, 12, 7,
+	2, 189, 190, 7, 5, 2, 2, 190, 191, 5, 14, 8, 2, 191, 192, 7, 17, 2, 2,
+	192, 193, 5, 54, 28, 2, 193, 194, 7, 18, 2, 2, 194, 197, 5, 56, 29, 2,
+	195, 196, 7, 19, 2, 2, 196, 198, 5, 58, 30, 2, 197, 195, 3, 2, 2, 2, 197,
+	198, 3, 2, 2, 2, 198, 53, 3, 2, 2, 2, 199, 200, 7, 27, 2, 2, 200, 55, 3,
+	2, 2, 2, 201, 202, 7, 27, 2, 2, 202, 57, 3, 2, 2, 2, 203, 204, 7, 28, 2,
+	2, 204, 59, 3, 2, 2, 2, 14, 67, 70, 83, 98, 100, 109, 119, 130, 159, 162,
+	176, 197,
 }
 var deserializer = antlr.NewATNDeserializer(nil)
 var deserializedATN = deserializer.DeserializeFromUInt16(parserATN)
 
 var literalNames = []string{
-	"", "'?'", "'='", "'.'", "'['", "']'", "'GET'", "'SET'", "", "'=>'", "','",
-	"'{'", "'}'", "';'",
+	"", "'?'", "'='", "'.'", "'['", "']'", "'-'", "'GET'", "'SET'", "'INCR'",
+	"'SELECT'", "", "", "", "", "", "", "", "", "'=>'", "','", "'{'", "'}'",
+	"';'",
 }
 var symbolicNames = []string{
-	"", "", "", "", "", "", "GET", "SET", "WHITESPACE", "ASSOC", "COMMA", "LEFT_BRACE",
-	"RIGHT_BRACE", "SEMICOLON", "Identifier", "StringLiteral", "IntegerLiteral",
+	"", "", "", "", "", "", "", "GET", "SET", "INCR", "SELECT", "TTL", "BY",
+	"WHERE", "AND", "RANGE", "TO", "LIMIT", "WHITESPACE", "ASSOC", "COMMA",
+	"LEFT_BRACE", "RIGHT_BRACE", "SEMICOLON", "Identifier", "StringLiteral",
+	"IntegerLiteral",
 }
 
 var ruleNames = []string{
 	"stringVal", "stmt", "getStmt", "setStmt", "columnSpec", "tableName", "columnFamilyName",
 	"valueExpr", "cellValue", "columnMapValue", "superColumnMapValue", "columnMapEntry",
 	"superColumnMapEntry", "columnOrSuperColumnName", "rowKey", "columnOrSuperColumnKey",
-	"columnKey", "superColumnKey",
+	"columnKey", "superColumnKey", "ttl", "incrStmt", "delta", "getWhereStmt",
+	"indexExpr", "indexColumn", "indexValue", "getRangeStmt", "startKey", "endKey",
+	"limit",
 }
 var decisionToDFA = make([]*antlr.DFA, len(deserializedATN.DecisionToState))
 
@@ -114,17 +156,27 @@ const (
 	MqlParserT__2           = 3
 	MqlParserT__3           = 4
 	MqlParserT__4           = 5
-	MqlParserGET            = 6
-	MqlParserSET            = 7
-	MqlParserWHITESPACE     = 8
-	MqlParserASSOC          = 9
-	MqlParserCOMMA          = 10
-	MqlParserLEFT_BRACE     = 11
-	MqlParserRIGHT_BRACE    = 12
-	MqlParserSEMICOLON      = 13
-	MqlParserIdentifier     = 14
-	MqlParserStringLiteral  = 15
-	MqlParserIntegerLiteral = 16
+	MqlParserT__5           = 6
+	MqlParserGET            = 7
+	MqlParserSET            = 8
+	MqlParserINCR           = 9
+	MqlParserSELECT         = 10
+	MqlParserTTL            = 11
+	MqlParserBY             = 12
+	MqlParserWHERE          = 13
+	MqlParserAND            = 14
+	MqlParserRANGE          = 15
+	MqlParserTO             = 16
+	MqlParserLIMIT          = 17
+	MqlParserWHITESPACE     = 18
+	MqlParserASSOC          = 19
+	MqlParserCOMMA          = 20
+	MqlParserLEFT_BRACE     = 21
+	MqlParserRIGHT_BRACE    = 22
+	MqlParserSEMICOLON      = 23
+	MqlParserIdentifier     = 24
+	MqlParserStringLiteral  = 25
+	MqlParserIntegerLiteral = 26
 )
 
 // MqlParser rules.
@@ -147,6 +199,17 @@ const (
 	MqlParserRULE_columnOrSuperColumnKey  = 15
 	MqlParserRULE_columnKey               = 16
 	MqlParserRULE_superColumnKey          = 17
+	MqlParserRULE_ttl                     = 18
+	MqlParserRULE_incrStmt                = 19
+	MqlParserRULE_delta                   = 20
+	MqlParserRULE_getWhereStmt            = 21
+	MqlParserRULE_indexExpr               = 22
+	MqlParserRULE_indexColumn             = 23
+	MqlParserRULE_indexValue              = 24
+	MqlParserRULE_getRangeStmt            = 25
+	MqlParserRULE_startKey                = 26
+	MqlParserRULE_endKey                  = 27
+	MqlParserRULE_limit                   = 28
 )
 
 // IStringValContext is an interface to support dynamic dispatch.
@@ -234,7 +297,7 @@ func (p *MqlParser) StringVal() (localctx IStringValContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(36)
+		p.SetState(58)
 		_la = p.GetTokenStream().LA(1)
 
 		if !(_la == MqlParserT__0 || _la == MqlParserStringLiteral) {
@@ -286,6 +349,10 @@ func NewStmtContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokin
 
 func (s *StmtContext) GetParser() antlr.Parser { return s.parser }
 
+func (s *StmtContext) EOF() antlr.TerminalNode {
+	return s.GetToken(MqlParserEOF, 0)
+}
+
 func (s *StmtContext) GetStmt() IGetStmtContext {
 	var t = s.GetTypedRuleContext(reflect.TypeOf((*IGetStmtContext)(nil)).Elem(), 0)
 
@@ -306,6 +373,40 @@ func (s *StmtContext) SetStmt() ISetStmtContext {
 	return t.(ISetStmtContext)
 }
 
+func (s *StmtContext) IncrStmt() IIncrStmtContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IIncrStmtContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IIncrStmtContext)
+}
+
+func (s *StmtContext) GetWhereStmt() IGetWhereStmtContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IGetWhereStmtContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IGetWhereStmtContext)
+}
+
+func (s *StmtContext) GetRangeStmt() IGetRangeStmtContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IGetRangeStmtContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IGetRangeStmtContext)
+}
+
+func (s *StmtContext) SEMICOLON() antlr.TerminalNode {
+	return s.GetToken(MqlParserSEMICOLON, 0)
+}
+
 func (s *StmtContext) GetRuleContext() antlr.RuleContext {
 	return s
 }
@@ -329,6 +430,7 @@ func (s *StmtContext) ExitRule(listener antlr.ParseTreeListener) {
 func (p *MqlParser) Stmt() (localctx IStmtContext) {
 	localctx = NewStmtContext(p, p.GetParserRuleContext(), p.GetState())
 	p.EnterRule(localctx, 2, MqlParserRULE_stmt)
+	var _la int
 
 	defer func() {
 		p.ExitRule()
@@ -346,26 +448,56 @@ func (p *MqlParser) Stmt() (localctx IStmtContext) {
 		}
 	}()
 
-	p.SetState(40)
+	p.EnterOuterAlt(localctx, 1)
+	p.SetState(65)
 	p.GetErrorHandler().Sync(p)
-
-	switch p.GetTokenStream().LA(1) {
-	case MqlParserGET:
-		p.EnterOuterAlt(localctx, 1)
+	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 0, p.GetParserRuleContext()) {
+	case 1:
 		{
-			p.SetState(38)
+			p.SetState(60)
 			p.GetStmt()
 		}
 
-	case MqlParserSET:
-		p.EnterOuterAlt(localctx, 2)
+	case 2:
 		{
-			p.SetState(39)
+			p.SetState(61)
 			p.SetStmt()
 		}
 
-	default:
-		panic(antlr.NewNoViableAltException(p, nil, nil, nil, nil, nil))
+	case 3:
+		{
+			p.SetState(62)
+			p.IncrStmt()
+		}
+
+	case 4:
+		{
+			p.SetState(63)
+			p.GetWhereStmt()
+		}
+
+	case 5:
+		{
+			p.SetState(64)
+			p.GetRangeStmt()
+		}
+
+	}
+	p.SetState(68)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	if _la == MqlParserSEMICOLON {
+		{
+			p.SetState(67)
+			p.Match(MqlParserSEMICOLON)
+		}
+
+	}
+
+	{
+		p.SetState(70)
+		p.Match(MqlParserEOF)
 	}
 
 	return localctx
@@ -465,11 +597,11 @@ func (p *MqlParser) GetStmt() (localctx IGetStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(42)
+		p.SetState(72)
 		p.Match(MqlParserGET)
 	}
 	{
-		p.SetState(43)
+		p.SetState(73)
 		p.ColumnSpec()
 	}
 
@@ -538,6 +670,20 @@ func (s *SetStmtContext) ValueExpr() IValueExprContext {
 	return t.(IValueExprContext)
 }
 
+func (s *SetStmtContext) TTL() antlr.TerminalNode {
+	return s.GetToken(MqlParserTTL, 0)
+}
+
+func (s *SetStmtContext) Ttl() ITtlContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*ITtlContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(ITtlContext)
+}
+
 func (s *SetStmtContext) GetRuleContext() antlr.RuleContext {
 	return s
 }
@@ -561,6 +707,7 @@ func (s *SetStmtContext) ExitRule(listener antlr.ParseTreeListener) {
 func (p *MqlParser) SetStmt() (localctx ISetStmtContext) {
 	localctx = NewSetStmtContext(p, p.GetParserRuleContext(), p.GetState())
 	p.EnterRule(localctx, 6, MqlParserRULE_setStmt)
+	var _la int
 
 	defer func() {
 		p.ExitRule()
@@ -580,21 +727,36 @@ func (p *MqlParser) SetStmt() (localctx ISetStmtContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(45)
+		p.SetState(75)
 		p.Match(MqlParserSET)
 	}
 	{
-		p.SetState(46)
+		p.SetState(76)
 		p.ColumnSpec()
 	}
 	{
-		p.SetState(47)
+		p.SetState(77)
 		p.Match(MqlParserT__1)
 	}
 	{
-		p.SetState(48)
+		p.SetState(78)
 		p.ValueExpr()
 	}
+	p.SetState(81)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	if _la == MqlParserTTL {
+		{
+			p.SetState(79)
+			p.Match(MqlParserTTL)
+		}
+		{
+			p.SetState(80)
+			p.Ttl()
+		}
+
+	}
 
 	return localctx
 }
@@ -759,40 +921,40 @@ func (p *MqlParser) ColumnSpec() (localctx IColumnSpecContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(50)
+		p.SetState(83)
 		p.TableName()
 	}
 	{
-		p.SetState(51)
+		p.SetState(84)
 		p.Match(MqlParserT__2)
 	}
 	{
-		p.SetState(52)
+		p.SetState(85)
 		p.ColumnFamilyName()
 	}
 	{
-		p.SetState(53)
+		p.SetState(86)
 		p.Match(MqlParserT__3)
 	}
 	{
-		p.SetState(54)
+		p.SetState(87)
 		p.RowKey()
 	}
 	{
-		p.SetState(55)
+		p.SetState(88)
 		p.Match(MqlParserT__4)
 	}
-	p.SetState(65)
+	p.SetState(98)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	if _la == MqlParserT__3 {
 		{
-			p.SetState(56)
+			p.SetState(89)
 			p.Match(MqlParserT__3)
 		}
 		{
-			p.SetState(57)
+			p.SetState(90)
 
 			var _x = p.ColumnOrSuperColumnKey()
 
@@ -800,20 +962,20 @@ func (p *MqlParser) ColumnSpec() (localctx IColumnSpecContext) {
 		}
 		localctx.(*ColumnSpecContext).a = append(localctx.(*ColumnSpecContext).a, localctx.(*ColumnSpecContext)._columnOrSuperColumnKey)
 		{
-			p.SetState(58)
+			p.SetState(91)
 			p.Match(MqlParserT__4)
 		}
-		p.SetState(63)
+		p.SetState(96)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 
 		if _la == MqlParserT__3 {
 			{
-				p.SetState(59)
+				p.SetState(92)
 				p.Match(MqlParserT__3)
 			}
 			{
-				p.SetState(60)
+				p.SetState(93)
 
 				var _x = p.ColumnOrSuperColumnKey()
 
@@ -821,7 +983,7 @@ func (p *MqlParser) ColumnSpec() (localctx IColumnSpecContext) {
 			}
 			localctx.(*ColumnSpecContext).a = append(localctx.(*ColumnSpecContext).a, localctx.(*ColumnSpecContext)._columnOrSuperColumnKey)
 			{
-				p.SetState(61)
+				p.SetState(94)
 				p.Match(MqlParserT__4)
 			}
 
@@ -916,7 +1078,7 @@ func (p *MqlParser) TableName() (localctx ITableNameContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(67)
+		p.SetState(100)
 		p.Match(MqlParserIdentifier)
 	}
 
@@ -1007,7 +1169,7 @@ func (p *MqlParser) ColumnFamilyName() (localctx IColumnFamilyNameContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(69)
+		p.SetState(102)
 		p.Match(MqlParserIdentifier)
 	}
 
@@ -1122,27 +1284,27 @@ func (p *MqlParser) ValueExpr() (localctx IValueExprContext) {
 		}
 	}()
 
-	p.SetState(74)
+	p.SetState(107)
 	p.GetErrorHandler().Sync(p)
-	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 3, p.GetParserRuleContext()) {
+	switch p.GetInterpreter().AdaptivePredict(p.GetTokenStream(), 5, p.GetParserRuleContext()) {
 	case 1:
 		p.EnterOuterAlt(localctx, 1)
 		{
-			p.SetState(71)
+			p.SetState(104)
 			p.CellValue()
 		}
 
 	case 2:
 		p.EnterOuterAlt(localctx, 2)
 		{
-			p.SetState(72)
+			p.SetState(105)
 			p.ColumnMapValue()
 		}
 
 	case 3:
 		p.EnterOuterAlt(localctx, 3)
 		{
-			p.SetState(73)
+			p.SetState(106)
 			p.SuperColumnMapValue()
 		}
 
@@ -1241,7 +1403,7 @@ func (p *MqlParser) CellValue() (localctx ICellValueContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(76)
+		p.SetState(109)
 		p.StringVal()
 	}
 
@@ -1368,33 +1530,33 @@ func (p *MqlParser) ColumnMapValue() (localctx IColumnMapValueContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(78)
+		p.SetState(111)
 		p.Match(MqlParserLEFT_BRACE)
 	}
 	{
-		p.SetState(79)
+		p.SetState(112)
 		p.ColumnMapEntry()
 	}
-	p.SetState(84)
+	p.SetState(117)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == MqlParserCOMMA {
 		{
-			p.SetState(80)
+			p.SetState(113)
 			p.Match(MqlParserCOMMA)
 		}
 		{
-			p.SetState(81)
+			p.SetState(114)
 			p.ColumnMapEntry()
 		}
 
-		p.SetState(86)
+		p.SetState(119)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
 	{
-		p.SetState(87)
+		p.SetState(120)
 		p.Match(MqlParserRIGHT_BRACE)
 	}
 
@@ -1521,33 +1683,33 @@ func (p *MqlParser) SuperColumnMapValue() (localctx ISuperColumnMapValueContext)
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(89)
+		p.SetState(122)
 		p.Match(MqlParserLEFT_BRACE)
 	}
 	{
-		p.SetState(90)
+		p.SetState(123)
 		p.SuperColumnMapEntry()
 	}
-	p.SetState(95)
+	p.SetState(128)
 	p.GetErrorHandler().Sync(p)
 	_la = p.GetTokenStream().LA(1)
 
 	for _la == MqlParserCOMMA {
 		{
-			p.SetState(91)
+			p.SetState(124)
 			p.Match(MqlParserCOMMA)
 		}
 		{
-			p.SetState(92)
+			p.SetState(125)
 			p.SuperColumnMapEntry()
 		}
 
-		p.SetState(97)
+		p.SetState(130)
 		p.GetErrorHandler().Sync(p)
 		_la = p.GetTokenStream().LA(1)
 	}
 	{
-		p.SetState(98)
+		p.SetState(131)
 		p.Match(MqlParserRIGHT_BRACE)
 	}
 
@@ -1658,15 +1820,15 @@ func (p *MqlParser) ColumnMapEntry() (localctx IColumnMapEntryContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(100)
+		p.SetState(133)
 		p.ColumnKey()
 	}
 	{
-		p.SetState(101)
+		p.SetState(134)
 		p.Match(MqlParserASSOC)
 	}
 	{
-		p.SetState(102)
+		p.SetState(135)
 		p.CellValue()
 	}
 
@@ -1777,15 +1939,15 @@ func (p *MqlParser) SuperColumnMapEntry() (localctx ISuperColumnMapEntryContext)
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(104)
+		p.SetState(137)
 		p.SuperColumnKey()
 	}
 	{
-		p.SetState(105)
+		p.SetState(138)
 		p.Match(MqlParserASSOC)
 	}
 	{
-		p.SetState(106)
+		p.SetState(139)
 		p.ColumnMapValue()
 	}
 
@@ -1876,7 +2038,7 @@ func (p *MqlParser) ColumnOrSuperColumnName() (localctx IColumnOrSuperColumnName
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(108)
+		p.SetState(141)
 		p.Match(MqlParserIdentifier)
 	}
 
@@ -1973,7 +2135,7 @@ func (p *MqlParser) RowKey() (localctx IRowKeyContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(110)
+		p.SetState(143)
 		p.StringVal()
 	}
 
@@ -2070,7 +2232,7 @@ func (p *MqlParser) ColumnOrSuperColumnKey() (localctx IColumnOrSuperColumnKeyCo
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(112)
+		p.SetState(145)
 		p.StringVal()
 	}
 
@@ -2167,7 +2329,7 @@ func (p *MqlParser) ColumnKey() (localctx IColumnKeyContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(114)
+		p.SetState(147)
 		p.StringVal()
 	}
 
@@ -2264,9 +2426,1325 @@ func (p *MqlParser) SuperColumnKey() (localctx ISuperColumnKeyContext) {
 
 	p.EnterOuterAlt(localctx, 1)
 	{
-		p.SetState(116)
+		p.SetState(149)
 		p.StringVal()
 	}
 
 	return localctx
 }
+
+// ITtlContext is an interface to support dynamic dispatch.
+type ITtlContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsTtlContext differentiates from other interfaces.
+	IsTtlContext()
+}
+
+type TtlContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyTtlContext() *TtlContext {
+	var p = new(TtlContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_ttl
+	return p
+}
+
+func (*TtlContext) IsTtlContext() {}
+
+func NewTtlContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *TtlContext {
+	var p = new(TtlContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_ttl
+
+	return p
+}
+
+func (s *TtlContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *TtlContext) IntegerLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserIntegerLiteral, 0)
+}
+
+func (s *TtlContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *TtlContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *TtlContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterTtl(s)
+	}
+}
+
+func (s *TtlContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitTtl(s)
+	}
+}
+
+func (p *MqlParser) Ttl() (localctx ITtlContext) {
+	localctx = NewTtlContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 36, MqlParserRULE_ttl)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(151)
+		p.Match(MqlParserIntegerLiteral)
+	}
+
+	return localctx
+}
+
+// IIncrStmtContext is an interface to support dynamic dispatch.
+type IIncrStmtContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsIncrStmtContext differentiates from other interfaces.
+	IsIncrStmtContext()
+}
+
+type IncrStmtContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyIncrStmtContext() *IncrStmtContext {
+	var p = new(IncrStmtContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_incrStmt
+	return p
+}
+
+func (*IncrStmtContext) IsIncrStmtContext() {}
+
+func NewIncrStmtContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *IncrStmtContext {
+	var p = new(IncrStmtContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_incrStmt
+
+	return p
+}
+
+func (s *IncrStmtContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *IncrStmtContext) INCR() antlr.TerminalNode {
+	return s.GetToken(MqlParserINCR, 0)
+}
+
+func (s *IncrStmtContext) ColumnSpec() IColumnSpecContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IColumnSpecContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IColumnSpecContext)
+}
+
+func (s *IncrStmtContext) BY() antlr.TerminalNode {
+	return s.GetToken(MqlParserBY, 0)
+}
+
+func (s *IncrStmtContext) Delta() IDeltaContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IDeltaContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IDeltaContext)
+}
+
+func (s *IncrStmtContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *IncrStmtContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *IncrStmtContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterIncrStmt(s)
+	}
+}
+
+func (s *IncrStmtContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitIncrStmt(s)
+	}
+}
+
+func (p *MqlParser) IncrStmt() (localctx IIncrStmtContext) {
+	localctx = NewIncrStmtContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 38, MqlParserRULE_incrStmt)
+	var _la int
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(153)
+		p.Match(MqlParserINCR)
+	}
+	{
+		p.SetState(154)
+		p.ColumnSpec()
+	}
+	p.SetState(157)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	if _la == MqlParserBY {
+		{
+			p.SetState(155)
+			p.Match(MqlParserBY)
+		}
+		{
+			p.SetState(156)
+			p.Delta()
+		}
+
+	}
+
+	return localctx
+}
+
+// IDeltaContext is an interface to support dynamic dispatch.
+type IDeltaContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsDeltaContext differentiates from other interfaces.
+	IsDeltaContext()
+}
+
+type DeltaContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyDeltaContext() *DeltaContext {
+	var p = new(DeltaContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_delta
+	return p
+}
+
+func (*DeltaContext) IsDeltaContext() {}
+
+func NewDeltaContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *DeltaContext {
+	var p = new(DeltaContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_delta
+
+	return p
+}
+
+func (s *DeltaContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *DeltaContext) IntegerLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserIntegerLiteral, 0)
+}
+
+func (s *DeltaContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *DeltaContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *DeltaContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterDelta(s)
+	}
+}
+
+func (s *DeltaContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitDelta(s)
+	}
+}
+
+func (p *MqlParser) Delta() (localctx IDeltaContext) {
+	localctx = NewDeltaContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 40, MqlParserRULE_delta)
+	var _la int
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	p.SetState(160)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	if _la == MqlParserT__5 {
+		{
+			p.SetState(159)
+			p.Match(MqlParserT__5)
+		}
+
+	}
+
+	{
+		p.SetState(162)
+		p.Match(MqlParserIntegerLiteral)
+	}
+
+	return localctx
+}
+
+// IGetWhereStmtContext is an interface to support dynamic dispatch.
+type IGetWhereStmtContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsGetWhereStmtContext differentiates from other interfaces.
+	IsGetWhereStmtContext()
+}
+
+type GetWhereStmtContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyGetWhereStmtContext() *GetWhereStmtContext {
+	var p = new(GetWhereStmtContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_getWhereStmt
+	return p
+}
+
+func (*GetWhereStmtContext) IsGetWhereStmtContext() {}
+
+func NewGetWhereStmtContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *GetWhereStmtContext {
+	var p = new(GetWhereStmtContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_getWhereStmt
+
+	return p
+}
+
+func (s *GetWhereStmtContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *GetWhereStmtContext) TableName() ITableNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*ITableNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(ITableNameContext)
+}
+
+func (s *GetWhereStmtContext) ColumnFamilyName() IColumnFamilyNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IColumnFamilyNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IColumnFamilyNameContext)
+}
+
+func (s *GetWhereStmtContext) WHERE() antlr.TerminalNode {
+	return s.GetToken(MqlParserWHERE, 0)
+}
+
+func (s *GetWhereStmtContext) AllIndexExpr() []IIndexExprContext {
+	var ts = s.GetTypedRuleContexts(reflect.TypeOf((*IIndexExprContext)(nil)).Elem())
+	var tst = make([]IIndexExprContext, len(ts))
+
+	for i, t := range ts {
+		if t != nil {
+			tst[i] = t.(IIndexExprContext)
+		}
+	}
+
+	return tst
+}
+
+func (s *GetWhereStmtContext) IndexExpr(i int) IIndexExprContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IIndexExprContext)(nil)).Elem(), i)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IIndexExprContext)
+}
+
+func (s *GetWhereStmtContext) GET() antlr.TerminalNode {
+	return s.GetToken(MqlParserGET, 0)
+}
+
+func (s *GetWhereStmtContext) SELECT() antlr.TerminalNode {
+	return s.GetToken(MqlParserSELECT, 0)
+}
+
+func (s *GetWhereStmtContext) AllAND() []antlr.TerminalNode {
+	return s.GetTokens(MqlParserAND)
+}
+
+func (s *GetWhereStmtContext) AND(i int) antlr.TerminalNode {
+	return s.GetToken(MqlParserAND, i)
+}
+
+func (s *GetWhereStmtContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *GetWhereStmtContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *GetWhereStmtContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterGetWhereStmt(s)
+	}
+}
+
+func (s *GetWhereStmtContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitGetWhereStmt(s)
+	}
+}
+
+func (p *MqlParser) GetWhereStmt() (localctx IGetWhereStmtContext) {
+	localctx = NewGetWhereStmtContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 42, MqlParserRULE_getWhereStmt)
+	var _la int
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(164)
+		_la = p.GetTokenStream().LA(1)
+
+		if !(_la == MqlParserGET || _la == MqlParserSELECT) {
+			p.GetErrorHandler().RecoverInline(p)
+		} else {
+			p.GetErrorHandler().ReportMatch(p)
+			p.Consume()
+		}
+	}
+	{
+		p.SetState(165)
+		p.TableName()
+	}
+	{
+		p.SetState(166)
+		p.Match(MqlParserT__2)
+	}
+	{
+		p.SetState(167)
+		p.ColumnFamilyName()
+	}
+	{
+		p.SetState(168)
+		p.Match(MqlParserWHERE)
+	}
+	{
+		p.SetState(169)
+		p.IndexExpr()
+	}
+	p.SetState(174)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	for _la == MqlParserAND {
+		{
+			p.SetState(170)
+			p.Match(MqlParserAND)
+		}
+		{
+			p.SetState(171)
+			p.IndexExpr()
+		}
+
+		p.SetState(176)
+		p.GetErrorHandler().Sync(p)
+		_la = p.GetTokenStream().LA(1)
+	}
+
+	return localctx
+}
+
+// IIndexExprContext is an interface to support dynamic dispatch.
+type IIndexExprContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsIndexExprContext differentiates from other interfaces.
+	IsIndexExprContext()
+}
+
+type IndexExprContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyIndexExprContext() *IndexExprContext {
+	var p = new(IndexExprContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_indexExpr
+	return p
+}
+
+func (*IndexExprContext) IsIndexExprContext() {}
+
+func NewIndexExprContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *IndexExprContext {
+	var p = new(IndexExprContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_indexExpr
+
+	return p
+}
+
+func (s *IndexExprContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *IndexExprContext) IndexColumn() IIndexColumnContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IIndexColumnContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IIndexColumnContext)
+}
+
+func (s *IndexExprContext) IndexValue() IIndexValueContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IIndexValueContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IIndexValueContext)
+}
+
+func (s *IndexExprContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *IndexExprContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *IndexExprContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterIndexExpr(s)
+	}
+}
+
+func (s *IndexExprContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitIndexExpr(s)
+	}
+}
+
+func (p *MqlParser) IndexExpr() (localctx IIndexExprContext) {
+	localctx = NewIndexExprContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 44, MqlParserRULE_indexExpr)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(177)
+		p.IndexColumn()
+	}
+	{
+		p.SetState(178)
+		p.Match(MqlParserT__1)
+	}
+	{
+		p.SetState(179)
+		p.IndexValue()
+	}
+
+	return localctx
+}
+
+// IIndexColumnContext is an interface to support dynamic dispatch.
+type IIndexColumnContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsIndexColumnContext differentiates from other interfaces.
+	IsIndexColumnContext()
+}
+
+type IndexColumnContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyIndexColumnContext() *IndexColumnContext {
+	var p = new(IndexColumnContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_indexColumn
+	return p
+}
+
+func (*IndexColumnContext) IsIndexColumnContext() {}
+
+func NewIndexColumnContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *IndexColumnContext {
+	var p = new(IndexColumnContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_indexColumn
+
+	return p
+}
+
+func (s *IndexColumnContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *IndexColumnContext) Identifier() antlr.TerminalNode {
+	return s.GetToken(MqlParserIdentifier, 0)
+}
+
+func (s *IndexColumnContext) StringLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserStringLiteral, 0)
+}
+
+func (s *IndexColumnContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *IndexColumnContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *IndexColumnContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterIndexColumn(s)
+	}
+}
+
+func (s *IndexColumnContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitIndexColumn(s)
+	}
+}
+
+func (p *MqlParser) IndexColumn() (localctx IIndexColumnContext) {
+	localctx = NewIndexColumnContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 46, MqlParserRULE_indexColumn)
+	var _la int
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(181)
+		_la = p.GetTokenStream().LA(1)
+
+		if !(_la == MqlParserIdentifier || _la == MqlParserStringLiteral) {
+			p.GetErrorHandler().RecoverInline(p)
+		} else {
+			p.GetErrorHandler().ReportMatch(p)
+			p.Consume()
+		}
+	}
+
+	return localctx
+}
+
+// IIndexValueContext is an interface to support dynamic dispatch.
+type IIndexValueContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsIndexValueContext differentiates from other interfaces.
+	IsIndexValueContext()
+}
+
+type IndexValueContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyIndexValueContext() *IndexValueContext {
+	var p = new(IndexValueContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_indexValue
+	return p
+}
+
+func (*IndexValueContext) IsIndexValueContext() {}
+
+func NewIndexValueContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *IndexValueContext {
+	var p = new(IndexValueContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_indexValue
+
+	return p
+}
+
+func (s *IndexValueContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *IndexValueContext) StringLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserStringLiteral, 0)
+}
+
+func (s *IndexValueContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *IndexValueContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *IndexValueContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterIndexValue(s)
+	}
+}
+
+func (s *IndexValueContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitIndexValue(s)
+	}
+}
+
+func (p *MqlParser) IndexValue() (localctx IIndexValueContext) {
+	localctx = NewIndexValueContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 48, MqlParserRULE_indexValue)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(183)
+		p.Match(MqlParserStringLiteral)
+	}
+
+	return localctx
+}
+
+// IGetRangeStmtContext is an interface to support dynamic dispatch.
+type IGetRangeStmtContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsGetRangeStmtContext differentiates from other interfaces.
+	IsGetRangeStmtContext()
+}
+
+type GetRangeStmtContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyGetRangeStmtContext() *GetRangeStmtContext {
+	var p = new(GetRangeStmtContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_getRangeStmt
+	return p
+}
+
+func (*GetRangeStmtContext) IsGetRangeStmtContext() {}
+
+func NewGetRangeStmtContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *GetRangeStmtContext {
+	var p = new(GetRangeStmtContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_getRangeStmt
+
+	return p
+}
+
+func (s *GetRangeStmtContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *GetRangeStmtContext) TableName() ITableNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*ITableNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(ITableNameContext)
+}
+
+func (s *GetRangeStmtContext) ColumnFamilyName() IColumnFamilyNameContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IColumnFamilyNameContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IColumnFamilyNameContext)
+}
+
+func (s *GetRangeStmtContext) RANGE() antlr.TerminalNode {
+	return s.GetToken(MqlParserRANGE, 0)
+}
+
+func (s *GetRangeStmtContext) StartKey() IStartKeyContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IStartKeyContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IStartKeyContext)
+}
+
+func (s *GetRangeStmtContext) TO() antlr.TerminalNode {
+	return s.GetToken(MqlParserTO, 0)
+}
+
+func (s *GetRangeStmtContext) EndKey() IEndKeyContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*IEndKeyContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(IEndKeyContext)
+}
+
+func (s *GetRangeStmtContext) GET() antlr.TerminalNode {
+	return s.GetToken(MqlParserGET, 0)
+}
+
+func (s *GetRangeStmtContext) SELECT() antlr.TerminalNode {
+	return s.GetToken(MqlParserSELECT, 0)
+}
+
+func (s *GetRangeStmtContext) LIMIT() antlr.TerminalNode {
+	return s.GetToken(MqlParserLIMIT, 0)
+}
+
+func (s *GetRangeStmtContext) Limit() ILimitContext {
+	var t = s.GetTypedRuleContext(reflect.TypeOf((*ILimitContext)(nil)).Elem(), 0)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.(ILimitContext)
+}
+
+func (s *GetRangeStmtContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *GetRangeStmtContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *GetRangeStmtContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterGetRangeStmt(s)
+	}
+}
+
+func (s *GetRangeStmtContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitGetRangeStmt(s)
+	}
+}
+
+func (p *MqlParser) GetRangeStmt() (localctx IGetRangeStmtContext) {
+	localctx = NewGetRangeStmtContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 50, MqlParserRULE_getRangeStmt)
+	var _la int
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(185)
+		_la = p.GetTokenStream().LA(1)
+
+		if !(_la == MqlParserGET || _la == MqlParserSELECT) {
+			p.GetErrorHandler().RecoverInline(p)
+		} else {
+			p.GetErrorHandler().ReportMatch(p)
+			p.Consume()
+		}
+	}
+	{
+		p.SetState(186)
+		p.TableName()
+	}
+	{
+		p.SetState(187)
+		p.Match(MqlParserT__2)
+	}
+	{
+		p.SetState(188)
+		p.ColumnFamilyName()
+	}
+	{
+		p.SetState(189)
+		p.Match(MqlParserRANGE)
+	}
+	{
+		p.SetState(190)
+		p.StartKey()
+	}
+	{
+		p.SetState(191)
+		p.Match(MqlParserTO)
+	}
+	{
+		p.SetState(192)
+		p.EndKey()
+	}
+	p.SetState(195)
+	p.GetErrorHandler().Sync(p)
+	_la = p.GetTokenStream().LA(1)
+
+	if _la == MqlParserLIMIT {
+		{
+			p.SetState(193)
+			p.Match(MqlParserLIMIT)
+		}
+		{
+			p.SetState(194)
+			p.Limit()
+		}
+
+	}
+
+	return localctx
+}
+
+// IStartKeyContext is an interface to support dynamic dispatch.
+type IStartKeyContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsStartKeyContext differentiates from other interfaces.
+	IsStartKeyContext()
+}
+
+type StartKeyContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyStartKeyContext() *StartKeyContext {
+	var p = new(StartKeyContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_startKey
+	return p
+}
+
+func (*StartKeyContext) IsStartKeyContext() {}
+
+func NewStartKeyContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *StartKeyContext {
+	var p = new(StartKeyContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_startKey
+
+	return p
+}
+
+func (s *StartKeyContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *StartKeyContext) StringLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserStringLiteral, 0)
+}
+
+func (s *StartKeyContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *StartKeyContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *StartKeyContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterStartKey(s)
+	}
+}
+
+func (s *StartKeyContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitStartKey(s)
+	}
+}
+
+func (p *MqlParser) StartKey() (localctx IStartKeyContext) {
+	localctx = NewStartKeyContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 52, MqlParserRULE_startKey)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(197)
+		p.Match(MqlParserStringLiteral)
+	}
+
+	return localctx
+}
+
+// IEndKeyContext is an interface to support dynamic dispatch.
+type IEndKeyContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsEndKeyContext differentiates from other interfaces.
+	IsEndKeyContext()
+}
+
+type EndKeyContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyEndKeyContext() *EndKeyContext {
+	var p = new(EndKeyContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_endKey
+	return p
+}
+
+func (*EndKeyContext) IsEndKeyContext() {}
+
+func NewEndKeyContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *EndKeyContext {
+	var p = new(EndKeyContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_endKey
+
+	return p
+}
+
+func (s *EndKeyContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *EndKeyContext) StringLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserStringLiteral, 0)
+}
+
+func (s *EndKeyContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *EndKeyContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *EndKeyContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterEndKey(s)
+	}
+}
+
+func (s *EndKeyContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitEndKey(s)
+	}
+}
+
+func (p *MqlParser) EndKey() (localctx IEndKeyContext) {
+	localctx = NewEndKeyContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 54, MqlParserRULE_endKey)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(199)
+		p.Match(MqlParserStringLiteral)
+	}
+
+	return localctx
+}
+
+// ILimitContext is an interface to support dynamic dispatch.
+type ILimitContext interface {
+	antlr.ParserRuleContext
+
+	// GetParser returns the parser.
+	GetParser() antlr.Parser
+
+	// IsLimitContext differentiates from other interfaces.
+	IsLimitContext()
+}
+
+type LimitContext struct {
+	*antlr.BaseParserRuleContext
+	parser antlr.Parser
+}
+
+func NewEmptyLimitContext() *LimitContext {
+	var p = new(LimitContext)
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(nil, -1)
+	p.RuleIndex = MqlParserRULE_limit
+	return p
+}
+
+func (*LimitContext) IsLimitContext() {}
+
+func NewLimitContext(parser antlr.Parser, parent antlr.ParserRuleContext, invokingState int) *LimitContext {
+	var p = new(LimitContext)
+
+	p.BaseParserRuleContext = antlr.NewBaseParserRuleContext(parent, invokingState)
+
+	p.parser = parser
+	p.RuleIndex = MqlParserRULE_limit
+
+	return p
+}
+
+func (s *LimitContext) GetParser() antlr.Parser { return s.parser }
+
+func (s *LimitContext) IntegerLiteral() antlr.TerminalNode {
+	return s.GetToken(MqlParserIntegerLiteral, 0)
+}
+
+func (s *LimitContext) GetRuleContext() antlr.RuleContext {
+	return s
+}
+
+func (s *LimitContext) ToStringTree(ruleNames []string, recog antlr.Recognizer) string {
+	return antlr.TreesStringTree(s, ruleNames, recog)
+}
+
+func (s *LimitContext) EnterRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.EnterLimit(s)
+	}
+}
+
+func (s *LimitContext) ExitRule(listener antlr.ParseTreeListener) {
+	if listenerT, ok := listener.(MqlListener); ok {
+		listenerT.ExitLimit(s)
+	}
+}
+
+func (p *MqlParser) Limit() (localctx ILimitContext) {
+	localctx = NewLimitContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 56, MqlParserRULE_limit)
+
+	defer func() {
+		p.ExitRule()
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			if v, ok := err.(antlr.RecognitionException); ok {
+				localctx.SetException(v)
+				p.GetErrorHandler().ReportError(p, v)
+				p.GetErrorHandler().Recover(p, v)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+
+	p.EnterOuterAlt(localctx, 1)
+	{
+		p.SetState(201)
+		p.Match(MqlParserIntegerLiteral)
+	}
+
+	return localctx
+}
@@ -7,10 +7,10 @@ package mql
 
 import (
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/DistAlchemist/Mongongo/config"
-	"github.com/DistAlchemist/Mongongo/db"
 	"github.com/DistAlchemist/Mongongo/service"
 )
 
@@ -25,17 +25,22 @@ type setUniqueKey struct {
 func (p setUniqueKey) execute() {
 	fmt.Println(p.explainPlan())
 	columnKey := p.columnKey
-	var columnFamilyColumn string
+	var superColumn []byte
 	if p.superColumnKey != "" {
-		superColumnKey := p.superColumnKey
-		columnFamilyColumn = p.cfMetaData.CFName + ":" + superColumnKey + ":" +
-			columnKey
-	} else {
-		columnFamilyColumn = p.cfMetaData.CFName + ":" + columnKey
+		superColumn = []byte(p.superColumnKey)
+	}
+	args := service.InsertArgs{}
+	reply := service.InsertReply{}
+	args.Table = p.cfMetaData.TableName
+	args.Key = p.rowKey
+	args.CPath = service.NewColumnPath(p.cfMetaData.CFName, superColumn, []byte(columnKey))
+	args.Value = []byte(p.value)
+	args.Timestamp = makeTimestamp()
+	args.ConsistencyLevel = service.ConsistencyZero
+	err := cc.Call("Mongongo.Insert", &args, &reply)
+	if err != nil {
+		log.Print(err)
 	}
-	rm := db.NewRowMutation(p.cfMetaData.TableName, p.rowKey)
-	rm.Add(columnFamilyColumn, p.value, makeTimestamp())
-	service.Insert(rm)
 	return
 }
 
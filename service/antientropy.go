@@ -0,0 +1,418 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/rpc"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/dht"
+	"github.com/DistAlchemist/Mongongo/network"
+	"github.com/DistAlchemist/Mongongo/utils"
+)
+
+const (
+	// merkleTreeDepth halves every repaired range 10 times,
+	// so the rows are compared in 1024 parts of it
+	merkleTreeDepth = 10
+	// streamBatchSize is the most rows streamed at once
+	streamBatchSize = 100
+	// repairSessionTTL is how long the status of a
+	// finished repair can be read
+	repairSessionTTL = time.Hour
+)
+
+// RepairArgs ...
+type RepairArgs struct {
+	Keyspace string
+	// ColumnFamily is empty to repair the whole keyspace
+	ColumnFamily string
+	// StartToken and EndToken bound the repaired range like
+	// the ends of a dht.Range. They are both empty to repair
+	// every range this node replicates, otherwise the range
+	// must lie within the range of a single node.
+	StartToken string
+	EndToken   string
+}
+
+// RepairReply ...
+type RepairReply struct {
+	ID string
+}
+
+// RepairStatusArgs ...
+type RepairStatusArgs struct {
+	ID string
+}
+
+// RepairStatusReply ...
+type RepairStatusReply struct {
+	Session RepairSession
+}
+
+// RepairSession is the progress of a repair started on this node
+type RepairSession struct {
+	ID             string
+	Keyspace       string
+	ColumnFamilies []string
+	// RangesTotal counts a range once for every column family
+	RangesTotal int
+	RangesDone  int
+	// Progress are the messages of the repair in order
+	Progress []string
+	Done     bool
+	// doneAt is when the repair finished
+	doneAt time.Time
+}
+
+var (
+	repairSessionsMu sync.Mutex
+	repairSessions   = make(map[string]*RepairSession)
+	repairSessionCnt int64
+)
+
+// repairJob is a range repaired with the replicas of it
+type repairJob struct {
+	r     dht.Range
+	peers []network.EndPoint
+}
+
+// Repair makes the replicas of a keyspace or column family agree on
+// their rows. For every range this node replicates, the replicas build
+// merkle trees of their rows in it and send them to this node, which
+// compares them with its own. Only the parts of the range where a
+// replica differs are streamed, first from the replica to this node,
+// then back from this node, which by then has the newest version of
+// every row, to each replica that differed. The repair runs in the
+// background, GetRepairStatus reports its progress.
+func (mg *Mongongo) Repair(args *RepairArgs, reply *RepairReply) error {
+	log.Printf("enter mg.Repair %v.%v\n", args.Keyspace, args.ColumnFamily)
+	cfNames, err := getRepairedColumnFamilies(args.Keyspace, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	jobs, err := getRepairJobs(args.StartToken, args.EndToken)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("this node has no other live replica to repair with")
+	}
+	session := &RepairSession{}
+	session.ID = fmt.Sprintf("repair-%v", atomic.AddInt64(&repairSessionCnt, 1))
+	session.Keyspace = args.Keyspace
+	session.ColumnFamilies = cfNames
+	session.RangesTotal = len(jobs) * len(cfNames)
+	session.Progress = make([]string, 0)
+	repairSessionsMu.Lock()
+	pruneRepairSessions()
+	repairSessions[session.ID] = session
+	repairSessionsMu.Unlock()
+	go session.run(jobs)
+	reply.ID = session.ID
+	return nil
+}
+
+// GetRepairStatus reports the progress of a repair
+func (mg *Mongongo) GetRepairStatus(args *RepairStatusArgs, reply *RepairStatusReply) error {
+	repairSessionsMu.Lock()
+	defer repairSessionsMu.Unlock()
+	session, ok := repairSessions[args.ID]
+	if !ok {
+		return fmt.Errorf("no repair %q", args.ID)
+	}
+	reply.Session = *session
+	reply.Session.Progress = append([]string{}, session.Progress...)
+	return nil
+}
+
+// pruneRepairSessions forgets the repairs that finished more than
+// repairSessionTTL ago. The caller holds repairSessionsMu.
+func pruneRepairSessions() {
+	for id, session := range repairSessions {
+		if session.Done && time.Since(session.doneAt) > repairSessionTTL {
+			delete(repairSessions, id)
+		}
+	}
+}
+
+func getRepairedColumnFamilies(keyspace, cfName string) ([]string, error) {
+	cfMetaDatas := config.GetTableMetaData(keyspace)
+	if cfMetaDatas == nil {
+		return nil, fmt.Errorf("keyspace %q does not exist", keyspace)
+	}
+	if cfName != "" {
+		if _, ok := cfMetaDatas[cfName]; !ok || config.IsIndexCF(keyspace, cfName) {
+			return nil, fmt.Errorf("column family %v.%v does not exist", keyspace, cfName)
+		}
+		return []string{cfName}, nil
+	}
+	// indexes are rebuilt from the rows they index
+	res := make([]string, 0, len(cfMetaDatas))
+	for name := range cfMetaDatas {
+		if !config.IsIndexCF(keyspace, name) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// getRepairJobs returns the ranges of this node to repair with their
+// other live replicas, or the range from startToken to endToken. A
+// requested range running past the end of the ring is repaired as
+// its parts on either side of the end.
+func getRepairJobs(startToken, endToken string) ([]repairJob, error) {
+	ss := GetInstance()
+	tokens := getSortedTokens()
+	jobs := make([]repairJob, 0)
+	requested := startToken != "" || endToken != ""
+	parts := splitAtRingEnd(dht.NewRange(startToken, endToken))
+	found := 0
+	for i := 0; i <= len(tokens) && len(tokens) > 0; i++ {
+		segment, owner := getRingSegment(tokens, i)
+		ranges := []dht.Range{segment}
+		if requested {
+			ranges = make([]dht.Range, 0, len(parts))
+			for _, part := range parts {
+				if isSubRange(part, segment) {
+					ranges = append(ranges, part)
+				}
+			}
+			if len(ranges) == 0 {
+				continue
+			}
+			found += len(ranges)
+		}
+		replicas := ss.nodePicker.GetReadStorageEndPoints(owner)
+		if !replicas[*ss.tcpAddr] {
+			if requested {
+				return nil, fmt.Errorf("this node does not replicate the range %v to %v",
+					formatToken(startToken), formatToken(endToken))
+			}
+			continue
+		}
+		delete(replicas, *ss.tcpAddr)
+		peers := getLiveEndPoints(replicas)
+		if len(peers) < len(replicas) {
+			log.Printf("repairing the range %v to %v without %v dead replicas\n",
+				formatToken(segment.Left), formatToken(segment.Right), len(replicas)-len(peers))
+		}
+		if len(peers) > 0 {
+			for _, r := range ranges {
+				jobs = append(jobs, repairJob{r, peers})
+			}
+		}
+	}
+	if requested && found < len(parts) {
+		return nil, fmt.Errorf("the range %v to %v does not lie within the range of a single node",
+			formatToken(startToken), formatToken(endToken))
+	}
+	return jobs, nil
+}
+
+// formatToken returns token the way REPAIR takes it: the random
+// partitioner's tokens are raw md5 sums, so they are written in
+// hex after 0x, the keys other partitioners use as tokens quoted
+func formatToken(token string) string {
+	if config.HashingStrategy == config.Random && token != "" {
+		return "0x" + hex.EncodeToString([]byte(token))
+	}
+	return fmt.Sprintf("%q", token)
+}
+
+// isSubRange tells whether r is a part of segment. Ranges running
+// past the end of the ring are split there, as dht.Range.Contains
+// does, and every part of r has to lie within a part of segment.
+func isSubRange(r, segment dht.Range) bool {
+	segmentParts := splitAtRingEnd(segment)
+	for _, part := range splitAtRingEnd(r) {
+		inside := false
+		for _, segmentPart := range segmentParts {
+			if segmentPart.Left <= part.Left && (segmentPart.Right == "" ||
+				part.Right != "" && part.Right <= segmentPart.Right) {
+				inside = true
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
+// splitAtRingEnd returns the parts of r on either side of the end
+// of the ring. None of them wraps: each runs from its Left up to its
+// Right, or to the end of the ring if its Right is empty.
+func splitAtRingEnd(r dht.Range) []dht.Range {
+	if r.Right == "" || !r.IsWrapAround() {
+		return []dht.Range{r}
+	}
+	return []dht.Range{dht.NewRange(r.Left, ""), dht.NewRange("", r.Right)}
+}
+
+func (session *RepairSession) report(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("%v: %v\n", session.ID, message)
+	repairSessionsMu.Lock()
+	session.Progress = append(session.Progress, message)
+	repairSessionsMu.Unlock()
+}
+
+func (session *RepairSession) run(jobs []repairJob) {
+	for _, cfName := range session.ColumnFamilies {
+		for _, job := range jobs {
+			session.repairRange(cfName, job)
+			repairSessionsMu.Lock()
+			session.RangesDone++
+			repairSessionsMu.Unlock()
+		}
+	}
+	session.report("repair of %v done", session.Keyspace)
+	repairSessionsMu.Lock()
+	session.Done = true
+	session.doneAt = time.Now()
+	repairSessionsMu.Unlock()
+}
+
+// repairRange compares the trees of the replicas of a range and
+// streams the parts where they differ
+func (session *RepairSession) repairRange(cfName string, job repairJob) {
+	ss := GetInstance()
+	table := session.Keyspace
+	session.report("repairing %v.%v in %v to %v with %v replicas", table, cfName,
+		formatToken(job.r.Left), formatToken(job.r.Right), len(job.peers))
+	message := db.ValidationArgs{}
+	message.Table = table
+	message.ColumnFamily = cfName
+	message.Range = job.r
+	message.Depth = merkleTreeDepth
+	localTree, err := validationOn(*ss.tcpAddr, &message)
+	if err != nil {
+		session.report("cannot validate %v.%v on this node: %v", table, cfName, err)
+		return
+	}
+	trees := make(map[network.EndPoint]*utils.MerkleTree)
+	for _, peer := range job.peers {
+		tree, err := validationOn(peer, &message)
+		if err != nil {
+			session.report("cannot validate %v.%v on %v: %v", table, cfName, peer, err)
+			continue
+		}
+		trees[peer] = tree
+	}
+	// bring this node up to date with every replica
+	outOfDate := make([]network.EndPoint, 0)
+	for _, peer := range job.peers {
+		tree, ok := trees[peer]
+		if !ok {
+			continue
+		}
+		differences := localTree.Difference(tree)
+		if len(differences) == 0 {
+			session.report("%v is in sync with this node", peer)
+			continue
+		}
+		outOfDate = append(outOfDate, peer)
+		rows, err := streamRows(peer, *ss.tcpAddr, table, cfName, differences)
+		if err != nil {
+			session.report("cannot stream from %v: %v", peer, err)
+			continue
+		}
+		session.report("%v differs in %v ranges, streamed %v rows from it", peer, len(differences), rows)
+	}
+	if len(outOfDate) == 0 {
+		return
+	}
+	// then bring the replicas up to date with this node
+	localTree, err = validationOn(*ss.tcpAddr, &message)
+	if err != nil {
+		session.report("cannot validate %v.%v on this node: %v", table, cfName, err)
+		return
+	}
+	for _, peer := range outOfDate {
+		differences := localTree.Difference(trees[peer])
+		if len(differences) == 0 {
+			continue
+		}
+		rows, err := streamRows(*ss.tcpAddr, peer, table, cfName, differences)
+		if err != nil {
+			session.report("cannot stream to %v: %v", peer, err)
+			continue
+		}
+		session.report("streamed %v rows in %v ranges to %v", rows, len(differences), peer)
+	}
+}
+
+// streamRows writes the rows of from in the ranges to to, and
+// returns the number of rows written. The rows are read from from
+// in batches of streamBatchSize, a batch is written before the next
+// one is read.
+func streamRows(from, to network.EndPoint, table, cfName string, ranges []dht.Range) (int, error) {
+	message := db.StreamRowsArgs{}
+	message.Table = table
+	message.ColumnFamily = cfName
+	message.Ranges = ranges
+	message.MaxRows = streamBatchSize
+	written := 0
+	for {
+		reply := db.StreamRowsReply{}
+		var err error
+		if from == *GetInstance().tcpAddr {
+			err = db.DoStreamRows(&message, &reply)
+		} else {
+			err = callOn(from, "StorageService.DoStreamRows", &message, &reply)
+		}
+		if err != nil {
+			return written, err
+		}
+		for _, row := range reply.Rows {
+			mutation := db.RowMutationArgs{}
+			mutation.From = *GetInstance().tcpAddr
+			mutation.RM = *db.NewRowMutationR(table, row)
+			err = rowMutationOn(to, &mutation)
+			if err != nil {
+				return written, err
+			}
+			written++
+		}
+		if reply.Next == "" {
+			return written, nil
+		}
+		message.After = reply.Next
+	}
+}
+
+func validationOn(endpoint network.EndPoint, message *db.ValidationArgs) (*utils.MerkleTree, error) {
+	reply := db.ValidationReply{}
+	var err error
+	if endpoint == *GetInstance().tcpAddr {
+		err = db.DoValidation(message, &reply)
+	} else {
+		err = callOn(endpoint, "StorageService.DoValidation", message, &reply)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reply.Tree, nil
+}
+
+func callOn(endpoint network.EndPoint, method string, message, reply interface{}) error {
+	client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call(method, message, reply)
+}
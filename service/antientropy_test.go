@@ -0,0 +1,73 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/dht"
+)
+
+func TestIsSubRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		r, segment  dht.Range
+		wantInRange bool
+	}{
+		{"inside", dht.NewRange("c", "d"), dht.NewRange("b", "e"), true},
+		{"same range", dht.NewRange("b", "e"), dht.NewRange("b", "e"), true},
+		{"past the right end", dht.NewRange("c", "f"), dht.NewRange("b", "e"), false},
+		{"before the left end", dht.NewRange("a", "d"), dht.NewRange("b", "e"), false},
+		{"to the end of the ring", dht.NewRange("c", ""), dht.NewRange("b", ""), true},
+		{"past a segment to the end of the ring", dht.NewRange("c", ""), dht.NewRange("b", "e"), false},
+		{"from the start of the ring", dht.NewRange("", "c"), dht.NewRange("", "e"), true},
+		{"whole ring", dht.NewRange("c", "c"), dht.NewRange("b", "e"), false},
+		{"anything in the whole ring", dht.NewRange("c", "d"), dht.NewRange("", ""), true},
+		{"wrapping range in the whole ring", dht.NewRange("x", "b"), dht.NewRange("", ""), true},
+		{"wrapping range in a wrapping segment", dht.NewRange("x", "b"), dht.NewRange("w", "c"), true},
+		{"wrapping range past a wrapping segment", dht.NewRange("x", "d"), dht.NewRange("w", "c"), false},
+		{"wrapping range before a wrapping segment", dht.NewRange("v", "b"), dht.NewRange("w", "c"), false},
+		{"before the ring end in a wrapping segment", dht.NewRange("x", "y"), dht.NewRange("w", "c"), true},
+		{"after the ring start in a wrapping segment", dht.NewRange("a", "b"), dht.NewRange("w", "c"), true},
+		{"between the ends of a wrapping segment", dht.NewRange("d", "e"), dht.NewRange("w", "c"), false},
+		{"wrapping range in a segment that does not wrap", dht.NewRange("x", "b"), dht.NewRange("a", "z"), false},
+	}
+	for _, test := range tests {
+		if got := isSubRange(test.r, test.segment); got != test.wantInRange {
+			t.Errorf("%v: isSubRange(%v, %v) = %v, want %v", test.name, test.r, test.segment, got, test.wantInRange)
+		}
+	}
+}
+
+func TestSplitAtRingEnd(t *testing.T) {
+	tests := []struct {
+		r    dht.Range
+		want []dht.Range
+	}{
+		{dht.NewRange("b", "e"), []dht.Range{dht.NewRange("b", "e")}},
+		{dht.NewRange("b", ""), []dht.Range{dht.NewRange("b", "")}},
+		{dht.NewRange("", ""), []dht.Range{dht.NewRange("", "")}},
+		{dht.NewRange("x", "b"), []dht.Range{dht.NewRange("x", ""), dht.NewRange("", "b")}},
+		{dht.NewRange("c", "c"), []dht.Range{dht.NewRange("c", ""), dht.NewRange("", "c")}},
+	}
+	for _, test := range tests {
+		got := splitAtRingEnd(test.r)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitAtRingEnd(%v) = %v, want %v", test.r, got, test.want)
+		}
+		// the parts hold the same tokens as the range
+		for _, token := range []string{"", "a", "b", "c", "d", "x", "z"} {
+			inParts := false
+			for _, part := range got {
+				inParts = inParts || part.Contains(token)
+			}
+			if inParts != test.r.Contains(token) {
+				t.Errorf("splitAtRingEnd(%v): token %q is in the parts = %v", test.r, token, inParts)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+// Consistency levels of reads and writes, they tell how many
+// replicas of a key must answer before a request returns
+const (
+	// ConsistencyZero writes without waiting for any replica,
+	// it may not be used for reads
+	ConsistencyZero = 0
+	// ConsistencyOne waits for a single replica
+	ConsistencyOne = 1
+	// ConsistencyQuorum waits for a majority of the replicas
+	ConsistencyQuorum = 2
+	// ConsistencyAll waits for every replica
+	ConsistencyAll = 3
+)
+
+func checkReadConsistency(consistencyLevel int) error {
+	switch consistencyLevel {
+	case ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+		return nil
+	case ConsistencyZero:
+		return &InvalidRequestError{"consistency level zero may not be applied to read operation"}
+	}
+	return newUnknownConsistencyError(consistencyLevel)
+}
+
+func checkWriteConsistency(consistencyLevel int) error {
+	switch consistencyLevel {
+	case ConsistencyZero, ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+		return nil
+	}
+	return newUnknownConsistencyError(consistencyLevel)
+}
+
+// getBlockFor returns the number of the n replicas of a
+// key that must answer at the consistency level
+func getBlockFor(consistencyLevel, n int) int {
+	switch consistencyLevel {
+	case ConsistencyZero:
+		return 0
+	case ConsistencyOne:
+		return 1
+	case ConsistencyQuorum:
+		return n/2 + 1
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	tests := []struct {
+		level     int
+		readOK    bool
+		writeOK   bool
+		blockFor3 int
+	}{
+		{ConsistencyZero, false, true, 0},
+		{ConsistencyOne, true, true, 1},
+		{ConsistencyQuorum, true, true, 2},
+		{ConsistencyAll, true, true, 3},
+		{-1, false, false, 3},
+		{4, false, false, 3},
+	}
+	for _, test := range tests {
+		var invalid *InvalidRequestError
+		err := checkReadConsistency(test.level)
+		if (err == nil) != test.readOK || err != nil && !errors.As(err, &invalid) {
+			t.Errorf("level %v: checkReadConsistency() = %v", test.level, err)
+		}
+		err = checkWriteConsistency(test.level)
+		if (err == nil) != test.writeOK || err != nil && !errors.As(err, &invalid) {
+			t.Errorf("level %v: checkWriteConsistency() = %v", test.level, err)
+		}
+		if test.readOK || test.writeOK {
+			if got := getBlockFor(test.level, 3); got != test.blockFor3 {
+				t.Errorf("level %v: getBlockFor(3 replicas) = %v, want %v", test.level, got, test.blockFor3)
+			}
+		}
+	}
+}
+
+func TestGetBlockForQuorum(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 3} {
+		if got := getBlockFor(ConsistencyQuorum, n); got != want {
+			t.Errorf("a quorum of %v replicas is %v, want %v", n, got, want)
+		}
+		if got := getBlockFor(ConsistencyAll, n); got != n {
+			t.Errorf("all of %v replicas is %v", n, got)
+		}
+	}
+}
+
+func TestReadProtocolRejectsInvalidConsistency(t *testing.T) {
+	for _, level := range []int{ConsistencyZero, -1, 4} {
+		_, err := readProtocol(nil, level)
+		var invalid *InvalidRequestError
+		if !errors.As(err, &invalid) {
+			t.Errorf("level %v: readProtocol() = %v, want an InvalidRequestError", level, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"strings"
+)
+
+// net/rpc hands the errors of the rpcs to clients as rpc.ServerError,
+// which only holds the message. The message of each typed error below
+// starts with its own prefix, from which ParseError builds the typed
+// error again on the client.
+const (
+	invalidRequestPrefix = "invalid request: "
+	unavailablePrefix    = "unavailable: "
+	timedOutPrefix       = "timed out: "
+)
+
+// Error codes tell clients what kind of error an rpc failed
+// with, see GetErrorCode
+const (
+	ErrorCodeNone = iota
+	ErrorCodeOther
+	ErrorCodeInvalidRequest
+	ErrorCodeUnavailable
+	ErrorCodeTimedOut
+)
+
+// InvalidRequestError is returned for a request
+// that cannot be served as it is
+type InvalidRequestError struct {
+	Why string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return invalidRequestPrefix + e.Why
+}
+
+func newUnknownConsistencyError(consistencyLevel int) *InvalidRequestError {
+	return &InvalidRequestError{fmt.Sprintf("unknown consistency level %v", consistencyLevel)}
+}
+
+// UnavailableError is returned when fewer replicas of a key
+// are alive than the consistency level asks for, nothing
+// has been sent to any of them
+type UnavailableError struct {
+	Key      string
+	Required int
+	Alive    int
+}
+
+const unavailableFormat = "key %q needs %d live replicas, %d are alive"
+
+func (e *UnavailableError) Error() string {
+	return unavailablePrefix + fmt.Sprintf(unavailableFormat, e.Key, e.Required, e.Alive)
+}
+
+// TimedOutError is returned when fewer replicas than the
+// consistency level asks for answered in time. A write may
+// still have been applied by some of them.
+type TimedOutError struct {
+	Key      string
+	Required int
+	Answered int
+}
+
+const timedOutFormat = "key %q needs %d replicas to answer, %d did"
+
+func (e *TimedOutError) Error() string {
+	return timedOutPrefix + fmt.Sprintf(timedOutFormat, e.Key, e.Required, e.Answered)
+}
+
+// ParseError returns the typed error an rpc failed with, or
+// err itself if the rpc failed with an untyped error
+func ParseError(err error) error {
+	serverError, ok := err.(rpc.ServerError)
+	if !ok {
+		return err
+	}
+	message := string(serverError)
+	switch {
+	case strings.HasPrefix(message, invalidRequestPrefix):
+		return &InvalidRequestError{strings.TrimPrefix(message, invalidRequestPrefix)}
+	case strings.HasPrefix(message, unavailablePrefix):
+		e := &UnavailableError{}
+		_, scanErr := fmt.Sscanf(strings.TrimPrefix(message, unavailablePrefix), unavailableFormat,
+			&e.Key, &e.Required, &e.Alive)
+		if scanErr == nil {
+			return e
+		}
+	case strings.HasPrefix(message, timedOutPrefix):
+		e := &TimedOutError{}
+		_, scanErr := fmt.Sscanf(strings.TrimPrefix(message, timedOutPrefix), timedOutFormat,
+			&e.Key, &e.Required, &e.Answered)
+		if scanErr == nil {
+			return e
+		}
+	}
+	return err
+}
+
+// GetErrorCode returns the code of the error an rpc failed
+// with, ErrorCodeNone if err is nil
+func GetErrorCode(err error) int {
+	var invalid *InvalidRequestError
+	var unavailable *UnavailableError
+	var timedOut *TimedOutError
+	err = ParseError(err)
+	switch {
+	case err == nil:
+		return ErrorCodeNone
+	case errors.As(err, &invalid):
+		return ErrorCodeInvalidRequest
+	case errors.As(err, &unavailable):
+		return ErrorCodeUnavailable
+	case errors.As(err, &timedOut):
+		return ErrorCodeTimedOut
+	}
+	return ErrorCodeOther
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"reflect"
+	"testing"
+)
+
+// failingService fails every call with the error it holds
+type failingService struct {
+	err error
+}
+
+func (s *failingService) Fail(args *int, reply *int) error {
+	return s.err
+}
+
+// newTestRPCClient serves rcvr over an in-memory connection
+func newTestRPCClient(t *testing.T, name string, rcvr interface{}) *rpc.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName(name, rcvr); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go server.ServeConn(serverConn)
+	client := rpc.NewClient(clientConn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestTypedErrorsSurviveRPC(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"invalid request", &InvalidRequestError{"unknown consistency level 7"}, ErrorCodeInvalidRequest},
+		{"unavailable", &UnavailableError{"key 1", 2, 1}, ErrorCodeUnavailable},
+		{"unavailable with a quote in the key", &UnavailableError{`k"ey`, 3, 0}, ErrorCodeUnavailable},
+		{"timed out", &TimedOutError{"key", 2, 1}, ErrorCodeTimedOut},
+		{"untyped", errors.New("column family t.cf does not exist"), ErrorCodeOther},
+		{"untyped with a prefix", errors.New("timed out: waiting for the flush"), ErrorCodeOther},
+	}
+	service := &failingService{}
+	client := newTestRPCClient(t, "Failing", service)
+	for _, test := range tests {
+		service.err = test.err
+		err := client.Call("Failing.Fail", new(int), new(int))
+		if _, ok := err.(rpc.ServerError); !ok {
+			t.Fatalf("%v: the call failed with %#v, want an rpc.ServerError", test.name, err)
+		}
+		if got := GetErrorCode(err); got != test.code {
+			t.Errorf("%v: GetErrorCode(%v) = %v, want %v", test.name, err, got, test.code)
+		}
+		if test.code == ErrorCodeOther {
+			if got := ParseError(err); got != err {
+				t.Errorf("%v: ParseError(%v) = %#v, want the error unchanged", test.name, err, got)
+			}
+			continue
+		}
+		if got := ParseError(err); !reflect.DeepEqual(got, test.err) {
+			t.Errorf("%v: ParseError(%v) = %#v, want %#v", test.name, err, got, test.err)
+		}
+	}
+	if GetErrorCode(nil) != ErrorCodeNone || ParseError(nil) != nil {
+		t.Errorf("a call that succeeded has an error")
+	}
+}
+
+func TestInvalidConsistencyOverRPC(t *testing.T) {
+	client := newTestRPCClient(t, "Mongongo", &Mongongo{})
+	args := &InsertArgs{Table: "Table1", Key: "key", CPath: NewColumnPath("Standard1", nil, []byte("c")),
+		Value: []byte("v"), ConsistencyLevel: -1}
+	err := ParseError(client.Call("Mongongo.Insert", args, &InsertReply{}))
+	var invalid *InvalidRequestError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Insert at an unknown consistency level failed with %#v, want an InvalidRequestError", err)
+	}
+	if *invalid != *newUnknownConsistencyError(-1) {
+		t.Errorf("got %v, want %v", invalid, newUnknownConsistencyError(-1))
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+// IndexOperator compares a column with the value of an IndexExpression
+type IndexOperator int
+
+const (
+	// EQ matches columns equal to the value
+	EQ IndexOperator = iota
+)
+
+// IndexExpression ...
+type IndexExpression struct {
+	ColumnName []byte
+	Op         IndexOperator
+	Value      []byte
+}
+
+// NewIndexExpression ...
+func NewIndexExpression(columnName []byte, op IndexOperator, value []byte) IndexExpression {
+	res := IndexExpression{}
+	res.ColumnName = columnName
+	res.Op = op
+	res.Value = value
+	return res
+}
+
+// IndexClause selects the rows matching all of its expressions,
+// at least one of them has to be on an indexed column. Rows are
+// returned in the order of their keys, starting at StartKey, and
+// at most Count of them.
+type IndexClause struct {
+	Expressions []IndexExpression
+	StartKey    string
+	Count       int
+}
+
+// NewIndexClause ...
+func NewIndexClause(expressions []IndexExpression, startKey string, count int) IndexClause {
+	res := IndexClause{}
+	res.Expressions = expressions
+	res.StartKey = startKey
+	res.Count = count
+	return res
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+// KeyRange selects rows either by keys or by tokens. The keys are
+// both included, an empty EndKey runs to the end of the ring. The
+// tokens are the ends of a dht.Range: the rows after StartToken up
+// to and including EndToken, wrapping around the ring if StartToken
+// is not before EndToken.
+type KeyRange struct {
+	StartKey   string
+	EndKey     string
+	StartToken string
+	EndToken   string
+	// Count is the most rows returned
+	Count int
+}
+
+// NewKeyRange ...
+func NewKeyRange(startKey, endKey string, count int) KeyRange {
+	res := KeyRange{}
+	res.StartKey = startKey
+	res.EndKey = endKey
+	res.Count = count
+	return res
+}
+
+// NewTokenRange ...
+func NewTokenRange(startToken, endToken string, count int) KeyRange {
+	res := KeyRange{}
+	res.StartToken = startToken
+	res.EndToken = endToken
+	res.Count = count
+	return res
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+// KeySlice is a row key with the columns read from its row
+type KeySlice struct {
+	Key     string
+	Columns []ColumnOrSuperColumn
+}
+
+// NewKeySlice ...
+func NewKeySlice(key string, columns []ColumnOrSuperColumn) KeySlice {
+	res := KeySlice{}
+	res.Key = key
+	res.Columns = columns
+	return res
+}
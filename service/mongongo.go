@@ -6,10 +6,10 @@
 package service
 
 import (
+	"fmt"
 	"log"
 
-	"github.com/davecgh/go-spew/spew"
-
+	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/db"
 )
 
@@ -53,6 +53,9 @@ type InsertArgs struct {
 	Value            []byte
 	Timestamp        int64
 	ConsistencyLevel int
+	// TTL is the number of seconds after which the column
+	// expires, 0 for the DefaultTTL of its column family
+	TTL int
 }
 
 // InsertReply ...
@@ -70,21 +73,25 @@ func (mg *Mongongo) Insert(args *InsertArgs, reply *InsertReply) error {
 	timestamp := args.Timestamp
 	consistencyLevel := args.ConsistencyLevel
 	rm := db.NewRowMutation(table, key)
-	rm.AddQ(db.NewQueryPath(columnPath.ColumnFamily, columnPath.SuperColumn, columnPath.Column),
-		value, timestamp)
-	mg.doInsert(consistencyLevel, rm)
+	if args.TTL < 0 {
+		return fmt.Errorf("ttl must not be negative, got %v", args.TTL)
+	}
+	if config.IsIndexCF(table, columnPath.ColumnFamily) {
+		return fmt.Errorf("column family %v.%v is an index, it cannot be written", table, columnPath.ColumnFamily)
+	}
+	if cfMetaData, _ := config.GetCFMetaData(table, columnPath.ColumnFamily); cfMetaData.Counter {
+		return fmt.Errorf("column family %v.%v holds counters, use Add", table, columnPath.ColumnFamily)
+	}
+	rm.AddQWithTTL(db.NewQueryPath(columnPath.ColumnFamily, columnPath.SuperColumn, columnPath.Column),
+		value, timestamp, args.TTL)
+	err := writeProtocol(consistencyLevel, rm)
+	if err != nil {
+		return err
+	}
 	reply.Result = "Success"
 	return nil
 }
 
-func (mg *Mongongo) doInsert(consistencyLevel int, rm db.RowMutation) {
-	if consistencyLevel != 0 {
-		insertBlocking(rm, consistencyLevel)
-	} else {
-		insert(rm)
-	}
-}
-
 // GetSliceArgs ...
 type GetSliceArgs struct {
 	Keyspace         string
@@ -107,13 +114,17 @@ func (mg *Mongongo) GetSlice(args *GetSliceArgs, reply *GetSliceReply) error {
 	columnParent := args.ColumnParent
 	predicate := args.Predicate
 	consistencyLevel := args.ConsistencyLevel
-	reply.Columns = mg.multigetSliceInternal(keyspace, []string{key}, columnParent,
-		predicate, consistencyLevel)[key]
+	columns, err := mg.multigetSliceInternal(keyspace, []string{key}, columnParent,
+		predicate, consistencyLevel)
+	if err != nil {
+		return err
+	}
+	reply.Columns = columns[key]
 	return nil
 }
 
 func (mg *Mongongo) multigetSliceInternal(keyspace string, keys []string, columnParent ColumnParent,
-	predicate SlicePredicate, consistencyLevel int) map[string][]ColumnOrSuperColumn {
+	predicate SlicePredicate, consistencyLevel int) (map[string][]ColumnOrSuperColumn, error) {
 	commands := make([]db.ReadCommand, 0)
 	sRange := predicate.SRange
 	if predicate.ColumnNames != nil {
@@ -131,12 +142,19 @@ func (mg *Mongongo) multigetSliceInternal(keyspace string, keys []string, column
 	return mg.getSlice(commands, consistencyLevel)
 }
 
-func (mg *Mongongo) getSlice(commands []db.ReadCommand, consistencyLevel int) map[string][]ColumnOrSuperColumn {
-	cfs := mg.readColumnFamily(commands, consistencyLevel)
+func (mg *Mongongo) getSlice(commands []db.ReadCommand, consistencyLevel int) (map[string][]ColumnOrSuperColumn, error) {
+	cfs, err := mg.readColumnFamily(commands, consistencyLevel)
+	if err != nil {
+		return nil, err
+	}
 	cfMap := make(map[string][]ColumnOrSuperColumn)
 	for _, command := range commands {
-		cf := cfs[command.GetKey()]
-		_, ok := command.(*db.SliceFromReadCommand)
+		cf, ok := cfs[command.GetKey()]
+		if !ok {
+			// the row does not exist
+			continue
+		}
+		_, ok = command.(*db.SliceFromReadCommand)
 		reverseOrder := false
 		if ok && command.(*db.SliceFromReadCommand).Reversed {
 			reverseOrder = true
@@ -168,11 +186,11 @@ func (mg *Mongongo) getSlice(commands []db.ReadCommand, consistencyLevel int) ma
 			cfMap[command.GetKey()] = mg.procColumns(cf.GetSortedColumns(), reverseOrder)
 		}
 	}
-	return cfMap
+	return cfMap, nil
 }
 
 func (mg *Mongongo) procColumns(columns []db.IColumn, reverseOrder bool) []ColumnOrSuperColumn {
-	res := make([]ColumnOrSuperColumn, len(columns))
+	res := make([]ColumnOrSuperColumn, 0, len(columns))
 	for _, column := range columns {
 		if column.IsMarkedForDelete() {
 			continue
@@ -189,7 +207,7 @@ func (mg *Mongongo) procColumns(columns []db.IColumn, reverseOrder bool) []Colum
 }
 
 func (mg *Mongongo) procSuperColumns(columns []db.IColumn, reverseOrder bool) []ColumnOrSuperColumn {
-	res := make([]ColumnOrSuperColumn, len(columns))
+	res := make([]ColumnOrSuperColumn, 0, len(columns))
 	for _, column := range columns {
 		subcolumns := mg.procSubColumns(column.GetSubColumns())
 		if len(subcolumns) == 0 {
@@ -221,20 +239,22 @@ func (mg *Mongongo) procSubColumns(columns map[string]db.IColumn) map[string]db.
 	return res
 }
 
-func (mg *Mongongo) readColumnFamily(commands []db.ReadCommand, consistencyLevel int) map[string]*db.ColumnFamily {
+// readColumnFamily reads the column family of the commands from
+// the rows of their keys. The keys without a row are left out, the
+// column family of the others is nil if no column was selected.
+func (mg *Mongongo) readColumnFamily(commands []db.ReadCommand, consistencyLevel int) (map[string]*db.ColumnFamily, error) {
 	cfName := commands[0].GetCFName()
 	res := make(map[string]*db.ColumnFamily)
-	if consistencyLevel == 0 {
-		log.Fatal("consistency level 0 may not be applied to read operation")
+	rows, err := readProtocol(commands, consistencyLevel)
+	if err != nil {
+		return nil, err
 	}
-	if consistencyLevel == 3 {
-		log.Fatal("consistency level all is not yet supported on read operation")
-	}
-	rows := readProtocol(commands, consistencyLevel)
-	for _, row := range rows {
-		res[row.Key] = row.ColumnFamilies[cfName]
+	for idx, row := range rows {
+		if row != nil {
+			res[commands[idx].GetKey()] = row.ColumnFamilies[cfName]
+		}
 	}
-	return res
+	return res, nil
 }
 
 // GetArgs ...
@@ -257,13 +277,17 @@ func (mg *Mongongo) Get(args *GetArgs, reply *GetReply) error {
 	key := args.Key
 	columnPath := args.ColumnPath
 	consistencyLevel := args.ConsistencyLevel
-	reply.Cosc = mg.multigeteInternal(keyspace, []string{key}, columnPath,
-		consistencyLevel)[key]
+	columns, err := mg.multigeteInternal(keyspace, []string{key}, columnPath,
+		consistencyLevel)
+	if err != nil {
+		return err
+	}
+	reply.Cosc = columns[key]
 	return nil
 }
 
 func (mg *Mongongo) multigeteInternal(table string, keys []string, columnPath ColumnPath,
-	consistencyLevel int) map[string]ColumnOrSuperColumn {
+	consistencyLevel int) (map[string]ColumnOrSuperColumn, error) {
 	path := db.NewQueryPath(columnPath.ColumnFamily, []byte(columnPath.SuperColumn),
 		[]byte(columnPath.Column))
 	// assume without super column, just
@@ -274,11 +298,18 @@ func (mg *Mongongo) multigeteInternal(table string, keys []string, columnPath Co
 		commands = append(commands, db.NewSliceByNamesReadCommand(table, key, *path, [][]byte{name}))
 	}
 	cfMap := make(map[string]ColumnOrSuperColumn)
-	columnsMap := mg.multigetColumns(commands, consistencyLevel)
+	columnsMap, err := mg.multigetColumns(commands, consistencyLevel)
+	if err != nil {
+		return nil, err
+	}
 	for _, command := range commands {
-		columns := columnsMap[command.GetKey()]
+		columns, ok := columnsMap[command.GetKey()]
+		if !ok {
+			// the row does not exist
+			continue
+		}
 		var c ColumnOrSuperColumn
-		if columns == nil {
+		if len(columns) == 0 {
 			c = ColumnOrSuperColumn{}
 		} else {
 			var column db.IColumn
@@ -295,18 +326,22 @@ func (mg *Mongongo) multigeteInternal(table string, keys []string, columnPath Co
 		}
 		cfMap[command.GetKey()] = c
 	}
-	return cfMap
+	return cfMap, nil
 }
 
-func (mg *Mongongo) multigetColumns(commands []db.ReadCommand, consistencyLevel int) map[string][]db.IColumn {
-	cfs := mg.readColumnFamily(commands, consistencyLevel)
-	spew.Printf("\tcfs: %#+v\n\n", cfs)
+func (mg *Mongongo) multigetColumns(commands []db.ReadCommand, consistencyLevel int) (map[string][]db.IColumn, error) {
+	cfs, err := mg.readColumnFamily(commands, consistencyLevel)
+	if err != nil {
+		return nil, err
+	}
 	cfMap := make(map[string][]db.IColumn)
 	for _, command := range commands {
-		cf := cfs[command.GetKey()]
-		spew.Printf("\tcf: %#+v\n\n", cf)
-		spew.Printf("\tcommand: %#+v\n\n", command)
+		cf, ok := cfs[command.GetKey()]
+		if !ok {
+			continue
+		}
 		if cf == nil {
+			cfMap[command.GetKey()] = nil
 			continue
 		}
 		columns := make([]db.IColumn, 0)
@@ -321,9 +356,7 @@ func (mg *Mongongo) multigetColumns(commands []db.ReadCommand, consistencyLevel
 		} else {
 			columns = cf.GetSortedColumns()
 		}
-		if columns != nil && len(columns) != 0 {
-			cfMap[command.GetKey()] = columns
-		}
+		cfMap[command.GetKey()] = columns
 	}
-	return cfMap
+	return cfMap, nil
 }
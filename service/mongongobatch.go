@@ -0,0 +1,163 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// BatchMutateArgs ...
+type BatchMutateArgs struct {
+	Keyspace string
+	// MutationMap maps row keys to column families
+	// to the mutations of the row in them
+	MutationMap      map[string]map[string][]Mutation
+	ConsistencyLevel int
+}
+
+// BatchMutateReply ...
+type BatchMutateReply struct {
+	Result string
+}
+
+// BatchMutate applies inserts and deletions to any number of rows and
+// column families. The mutations of a row become a single row mutation,
+// which is written to the commit log as one entry, and it is sent to the
+// replicas of its key at the consistency level like by Insert. The whole
+// batch is validated before any row is written. The rows are written in
+// the order of their keys, if one of them fails the rows before it have
+// been written and the ones after it have not.
+func (mg *Mongongo) BatchMutate(args *BatchMutateArgs, reply *BatchMutateReply) error {
+	log.Printf("enter mg.BatchMutate\n")
+	keys := make([]string, 0, len(args.MutationMap))
+	for key := range args.MutationMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	rms := make([]db.RowMutation, 0, len(keys))
+	for _, key := range keys {
+		if key == "" {
+			return fmt.Errorf("key may not be empty")
+		}
+		rm := db.NewRowMutation(args.Keyspace, key)
+		for cfName, mutations := range args.MutationMap[key] {
+			err := addMutations(&rm, cfName, mutations)
+			if err != nil {
+				return fmt.Errorf("row %q: %v", key, err)
+			}
+		}
+		if len(rm.Modification) > 0 {
+			rms = append(rms, rm)
+		}
+	}
+	for _, rm := range rms {
+		// the errors of the write name the key themselves and
+		// are passed on as they are, so clients see their kind
+		err := writeProtocol(args.ConsistencyLevel, rm)
+		if err != nil {
+			return err
+		}
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// addMutations adds the mutations of column family cfName to rm
+func addMutations(rm *db.RowMutation, cfName string, mutations []Mutation) error {
+	cfMetaData, ok := config.GetCFMetaData(rm.TableName, cfName)
+	if !ok || config.IsIndexCF(rm.TableName, cfName) {
+		return fmt.Errorf("column family %v.%v does not exist", rm.TableName, cfName)
+	}
+	if cfMetaData.Counter {
+		return fmt.Errorf("column family %v.%v holds counters, use Add", rm.TableName, cfName)
+	}
+	isSuper := cfMetaData.ColumnType == "Super"
+	for _, mutation := range mutations {
+		if (mutation.ColumnOrSuperColumn == nil) == (mutation.Deletion == nil) {
+			return fmt.Errorf("a mutation is either an insert or a deletion")
+		}
+		var err error
+		if mutation.Deletion != nil {
+			err = addDeletion(rm, cfName, isSuper, mutation.Deletion)
+		} else {
+			err = addInsert(rm, cfName, isSuper, mutation.ColumnOrSuperColumn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addInsert(rm *db.RowMutation, cfName string, isSuper bool, cosc *ColumnOrSuperColumn) error {
+	if (cosc.Column == nil) == (cosc.SColumn == nil) {
+		return fmt.Errorf("an insert has either a column or a super column")
+	}
+	if !isSuper {
+		if cosc.Column == nil {
+			return fmt.Errorf("standard column family %v has no super columns", cfName)
+		}
+		return addColumn(rm, db.NewQueryPath(cfName, nil, []byte(cosc.Column.Name)), *cosc.Column)
+	}
+	if cosc.SColumn == nil {
+		return fmt.Errorf("super column family %v only holds super columns", cfName)
+	}
+	if cosc.SColumn.Name == "" {
+		return fmt.Errorf("super column name may not be empty")
+	}
+	for _, subColumn := range cosc.SColumn.Columns {
+		column, ok := subColumn.(db.Column)
+		if !ok {
+			return fmt.Errorf("super column %q holds a %T", cosc.SColumn.Name, subColumn)
+		}
+		err := addColumn(rm, db.NewQueryPath(cfName, []byte(cosc.SColumn.Name), []byte(column.Name)), column)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addColumn(rm *db.RowMutation, path *db.QueryPath, column db.Column) error {
+	if column.Name == "" {
+		return fmt.Errorf("column name may not be empty")
+	}
+	if column.TTL < 0 {
+		return fmt.Errorf("ttl must not be negative, got %v", column.TTL)
+	}
+	rm.AddQWithTTL(path, []byte(column.Value), column.Timestamp, column.TTL)
+	return nil
+}
+
+func addDeletion(rm *db.RowMutation, cfName string, isSuper bool, deletion *Deletion) error {
+	if deletion.SuperColumn != nil && !isSuper {
+		return fmt.Errorf("standard column family %v has no super columns", cfName)
+	}
+	if deletion.Predicate == nil {
+		rm.Delete(db.NewQueryPath(cfName, deletion.SuperColumn, nil), deletion.Timestamp)
+		return nil
+	}
+	if deletion.Predicate.ColumnNames == nil {
+		return fmt.Errorf("deletions name their columns, slice ranges cannot be deleted")
+	}
+	for _, name := range deletion.Predicate.ColumnNames {
+		if len(name) == 0 {
+			return fmt.Errorf("column name may not be empty")
+		}
+		if isSuper && deletion.SuperColumn == nil {
+			// the names are the names of super columns
+			rm.Delete(db.NewQueryPath(cfName, name, nil), deletion.Timestamp)
+		} else {
+			rm.Delete(db.NewQueryPath(cfName, deletion.SuperColumn, name), deletion.Timestamp)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/rpc"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/network"
+)
+
+// BulkLoadArgs ...
+type BulkLoadArgs struct {
+	Keyspace     string
+	ColumnFamily string
+	// Rows are serialized with db.SerializeBinaryRow
+	Rows []db.BinaryRow
+	// Flush makes all rows loaded so far readable, on every node
+	Flush bool
+}
+
+// BulkLoadReply ...
+type BulkLoadReply struct {
+	Rows int
+}
+
+// BulkLoad sends pre-serialized rows to the replicas that own them,
+// bypassing the commit log and the memtables. Loaded rows can be read
+// once they are flushed, which happens when enough of them piled up
+// on a node or when Flush is set.
+func (mg *Mongongo) BulkLoad(args *BulkLoadArgs, reply *BulkLoadReply) error {
+	log.Printf("enter mg.BulkLoad %v.%v, %v rows\n", args.Keyspace, args.ColumnFamily, len(args.Rows))
+	ss := GetInstance()
+	endpointToRows := make(map[network.EndPoint][]db.BinaryRow)
+	for _, row := range args.Rows {
+		for endpoint, owner := range ss.getNStorageEndPointMap(row.Key) {
+			if endpoint != owner {
+				// bulk loads are not hinted, the load
+				// has to be repeated once owner is back
+				return fmt.Errorf("replica %v:%v of %q is down", owner.HostName, owner.Port, row.Key)
+			}
+			endpointToRows[endpoint] = append(endpointToRows[endpoint], row)
+		}
+	}
+	if args.Flush {
+		// rows loaded by earlier calls may be on any node
+		for _, endpoint := range ss.tokenMetadata.CloneTokenEndPointMap() {
+			if _, ok := endpointToRows[endpoint]; !ok {
+				endpointToRows[endpoint] = []db.BinaryRow{}
+			}
+		}
+	}
+	for endpoint, rows := range endpointToRows {
+		client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+		if err != nil {
+			return err
+		}
+		message := db.BinaryLoadArgs{}
+		message.Table = args.Keyspace
+		message.ColumnFamily = args.ColumnFamily
+		message.Rows = rows
+		message.Flush = args.Flush
+		err = client.Call("StorageService.DoBinaryLoad", &message, &db.BinaryLoadReply{})
+		client.Close()
+		if err != nil {
+			return err
+		}
+	}
+	reply.Rows = len(args.Rows)
+	return nil
+}
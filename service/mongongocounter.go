@@ -0,0 +1,102 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/rpc"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/network"
+)
+
+// AddArgs ...
+type AddArgs struct {
+	Table string
+	Key   string
+	CPath ColumnPath
+	// Delta is added to the counter, negative to decrement it
+	Delta            int64
+	ConsistencyLevel int
+}
+
+// AddReply ...
+type AddReply struct {
+	Result string
+}
+
+// Add is an rpc that adds to a counter of a counter column family.
+// The increment is applied by one replica of the key, the leader,
+// which changes its own shard of the counter and then writes the
+// new shard to the replicas at the consistency level.
+func (mg *Mongongo) Add(args *AddArgs, reply *AddReply) error {
+	log.Printf("enter mg.Add\n")
+	err := checkWriteConsistency(args.ConsistencyLevel)
+	if err != nil {
+		return err
+	}
+	cfMetaData, ok := config.GetCFMetaData(args.Table, args.CPath.ColumnFamily)
+	if !ok {
+		return fmt.Errorf("column family %v.%v does not exist", args.Table, args.CPath.ColumnFamily)
+	}
+	if !cfMetaData.Counter {
+		return fmt.Errorf("column family %v.%v is not a counter column family", args.Table, args.CPath.ColumnFamily)
+	}
+	leader, err := getCounterLeader(args.Key)
+	if err != nil {
+		return err
+	}
+	message := db.CounterAddArgs{}
+	message.Table = args.Table
+	message.Key = args.Key
+	message.Path = *db.NewQueryPath(args.CPath.ColumnFamily, args.CPath.SuperColumn, args.CPath.Column)
+	message.Delta = args.Delta
+	message.ConsistencyLevel = args.ConsistencyLevel
+	ss := GetInstance()
+	if leader == *ss.tcpAddr {
+		err = ss.DoCounterAdd(&message, &db.CounterAddReply{})
+	} else {
+		var client *rpc.Client
+		client, err = rpc.DialHTTP("tcp", leader.HostName+":"+config.StoragePort)
+		if err != nil {
+			return err
+		}
+		err = client.Call("StorageService.DoCounterAdd", &message, &db.CounterAddReply{})
+		client.Close()
+	}
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// getCounterLeader picks the replica of key that applies an
+// increment, this node if it is a live replica of key
+func getCounterLeader(key string) (network.EndPoint, error) {
+	ss := GetInstance()
+	live := make([]network.EndPoint, 0)
+	for endpoint, owner := range ss.getNStorageEndPointMap(key) {
+		if endpoint != owner {
+			// hinted, the owner is down
+			continue
+		}
+		if endpoint == *ss.tcpAddr {
+			return endpoint, nil
+		}
+		live = append(live, endpoint)
+	}
+	if len(live) == 0 {
+		return network.EndPoint{}, fmt.Errorf("no replica of %q is up", key)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].HostName+":"+live[i].Port < live[j].HostName+":"+live[j].Port
+	})
+	return live[0], nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/rpc"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/network"
+)
+
+// GetIndexedSlicesArgs ...
+type GetIndexedSlicesArgs struct {
+	Keyspace         string
+	ColumnParent     ColumnParent
+	IndexClause      IndexClause
+	Predicate        SlicePredicate
+	ConsistencyLevel int
+}
+
+// GetIndexedSlicesReply ...
+type GetIndexedSlicesReply struct {
+	KeySlices []KeySlice
+}
+
+// GetIndexedSlices reads the columns selected by the predicate from
+// the rows matching the index clause. Every node indexes only the rows
+// it stores, so all nodes are asked for their matching keys, then the
+// rows are read at the consistency level like by GetSlice.
+func (mg *Mongongo) GetIndexedSlices(args *GetIndexedSlicesArgs, reply *GetIndexedSlicesReply) error {
+	log.Printf("enter mg.GetIndexedSlices\n")
+	keyspace := args.Keyspace
+	columnParent := args.ColumnParent
+	clause := args.IndexClause
+	if _, ok := config.GetCFMetaData(keyspace, columnParent.ColumnFamily); !ok ||
+		config.IsIndexCF(keyspace, columnParent.ColumnFamily) {
+		return fmt.Errorf("column family %v.%v does not exist", keyspace, columnParent.ColumnFamily)
+	}
+	if columnParent.SuperColumn != nil {
+		return fmt.Errorf("indexed column families have no super columns")
+	}
+	if clause.Count < 0 {
+		return fmt.Errorf("count must not be negative, got %v", clause.Count)
+	}
+	if err := checkReadConsistency(args.ConsistencyLevel); err != nil {
+		return err
+	}
+	message := db.IndexScanArgs{}
+	message.Table = keyspace
+	message.ColumnFamily = columnParent.ColumnFamily
+	message.StartKey = clause.StartKey
+	message.Count = clause.Count
+	for _, expression := range clause.Expressions {
+		if expression.Op != EQ {
+			return fmt.Errorf("unsupported index operator %v", expression.Op)
+		}
+		message.Expressions = append(message.Expressions,
+			db.IndexExpression{ColumnName: string(expression.ColumnName), Value: string(expression.Value)})
+	}
+	keys, err := scanIndexes(&message)
+	if err != nil {
+		return err
+	}
+	reply.KeySlices = make([]KeySlice, 0, len(keys))
+	if len(keys) == 0 {
+		return nil
+	}
+	columns, err := mg.multigetSliceInternal(keyspace, keys, columnParent, args.Predicate, args.ConsistencyLevel)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		reply.KeySlices = append(reply.KeySlices, NewKeySlice(key, columns[key]))
+	}
+	return nil
+}
+
+// scanIndexes asks every node for its keys matching message
+// and returns the first message.Count of all of them
+func scanIndexes(message *db.IndexScanArgs) ([]string, error) {
+	ss := GetInstance()
+	endpoints := make(map[network.EndPoint]bool)
+	for _, endpoint := range ss.tokenMetadata.CloneTokenEndPointMap() {
+		endpoints[endpoint] = true
+	}
+	endpoints[*ss.tcpAddr] = true
+	seen := make(map[string]bool)
+	for endpoint := range endpoints {
+		reply := db.IndexScanReply{}
+		var err error
+		if endpoint == *ss.tcpAddr {
+			err = ss.DoIndexScan(message, &reply)
+		} else {
+			var client *rpc.Client
+			client, err = rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+			if err != nil {
+				return nil, err
+			}
+			err = client.Call("StorageService.DoIndexScan", message, &reply)
+			client.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		// replicas of a row all return its key
+		for _, key := range reply.Keys {
+			seen[key] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if message.Count > 0 && len(keys) > message.Count {
+		keys = keys[:message.Count]
+	}
+	return keys, nil
+}
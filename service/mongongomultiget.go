@@ -0,0 +1,139 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/config"
+)
+
+// MultigetSliceArgs ...
+type MultigetSliceArgs struct {
+	Keyspace         string
+	Keys             []string
+	ColumnParent     ColumnParent
+	Predicate        SlicePredicate
+	ConsistencyLevel int
+}
+
+// MultigetSliceReply ...
+type MultigetSliceReply struct {
+	// Columns maps every key that has a row to the columns
+	// the predicate selects from it, which may be none
+	Columns map[string][]ColumnOrSuperColumn
+	// Missing are the keys without a row, in the order
+	// they were asked for
+	Missing []string
+}
+
+// MultigetSlice reads the columns selected by the predicate from the
+// rows of all the keys. The keys are grouped by the replica they are
+// read from and the replicas are read in parallel.
+func (mg *Mongongo) MultigetSlice(args *MultigetSliceArgs, reply *MultigetSliceReply) error {
+	log.Printf("enter mg.MultigetSlice\n")
+	keyspace := args.Keyspace
+	columnParent := args.ColumnParent
+	consistencyLevel := args.ConsistencyLevel
+	keys, err := checkMultiget(keyspace, columnParent.ColumnFamily, args.Keys, consistencyLevel)
+	if err != nil {
+		return err
+	}
+	reply.Columns = make(map[string][]ColumnOrSuperColumn)
+	reply.Missing = make([]string, 0)
+	if len(keys) == 0 {
+		return nil
+	}
+	columns, err := mg.multigetSliceInternal(keyspace, keys, columnParent, args.Predicate, consistencyLevel)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		keyColumns, ok := columns[key]
+		if !ok {
+			reply.Missing = append(reply.Missing, key)
+			continue
+		}
+		reply.Columns[key] = keyColumns
+	}
+	return nil
+}
+
+// MultiGetArgs ...
+type MultiGetArgs struct {
+	Keyspace         string
+	Keys             []string
+	ColumnPath       ColumnPath
+	ConsistencyLevel int
+}
+
+// MultiGetReply ...
+type MultiGetReply struct {
+	// Columns maps every key that has a row to the column,
+	// which is empty if the row does not have it
+	Columns map[string]ColumnOrSuperColumn
+	// Missing are the keys without a row, in the order
+	// they were asked for
+	Missing []string
+}
+
+// MultiGet reads the column of the path from the rows of all the keys
+// like MultigetSlice
+func (mg *Mongongo) MultiGet(args *MultiGetArgs, reply *MultiGetReply) error {
+	log.Printf("enter mg.MultiGet\n")
+	keyspace := args.Keyspace
+	columnPath := args.ColumnPath
+	consistencyLevel := args.ConsistencyLevel
+	keys, err := checkMultiget(keyspace, columnPath.ColumnFamily, args.Keys, consistencyLevel)
+	if err != nil {
+		return err
+	}
+	if columnPath.Column == nil {
+		return fmt.Errorf("the column path has no column")
+	}
+	reply.Columns = make(map[string]ColumnOrSuperColumn)
+	reply.Missing = make([]string, 0)
+	if len(keys) == 0 {
+		return nil
+	}
+	columns, err := mg.multigeteInternal(keyspace, keys, columnPath, consistencyLevel)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		column, ok := columns[key]
+		if !ok {
+			reply.Missing = append(reply.Missing, key)
+			continue
+		}
+		reply.Columns[key] = column
+	}
+	return nil
+}
+
+// checkMultiget validates a read of several keys and
+// returns the keys without repetitions
+func checkMultiget(keyspace, cfName string, keys []string, consistencyLevel int) ([]string, error) {
+	if _, ok := config.GetCFMetaData(keyspace, cfName); !ok || config.IsIndexCF(keyspace, cfName) {
+		return nil, fmt.Errorf("column family %v.%v does not exist", keyspace, cfName)
+	}
+	if err := checkReadConsistency(consistencyLevel); err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("key may not be empty")
+		}
+		if !seen[key] {
+			seen[key] = true
+			res = append(res, key)
+		}
+	}
+	return res, nil
+}
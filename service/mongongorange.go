@@ -0,0 +1,233 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/rpc"
+	"sort"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/dht"
+	"github.com/DistAlchemist/Mongongo/gms"
+	"github.com/DistAlchemist/Mongongo/network"
+)
+
+// GetRangeSlicesArgs ...
+type GetRangeSlicesArgs struct {
+	Keyspace         string
+	ColumnParent     ColumnParent
+	Predicate        SlicePredicate
+	Range            KeyRange
+	ConsistencyLevel int
+}
+
+// GetRangeSlicesReply ...
+type GetRangeSlicesReply struct {
+	// KeySlices are in the order of the ring
+	KeySlices []KeySlice
+}
+
+// GetRangeSlices reads the columns selected by the predicate from the
+// rows of a key range or token range, at most Range.Count of them. The
+// ring is walked from the start of the range, asking the live replicas
+// of every part of it for their keys until there are enough of them,
+// then the rows are read at the consistency level like by GetSlice.
+// The rows come in the order of the ring, which is the order of the
+// keys under the order preserving partitioner. Deleted rows are
+// returned without columns.
+func (mg *Mongongo) GetRangeSlices(args *GetRangeSlicesArgs, reply *GetRangeSlicesReply) error {
+	log.Printf("enter mg.GetRangeSlices\n")
+	keyspace := args.Keyspace
+	columnParent := args.ColumnParent
+	keyRange := args.Range
+	if _, ok := config.GetCFMetaData(keyspace, columnParent.ColumnFamily); !ok ||
+		config.IsIndexCF(keyspace, columnParent.ColumnFamily) {
+		return fmt.Errorf("column family %v.%v does not exist", keyspace, columnParent.ColumnFamily)
+	}
+	byKeys := keyRange.StartKey != "" || keyRange.EndKey != ""
+	if byKeys && (keyRange.StartToken != "" || keyRange.EndToken != "") {
+		return fmt.Errorf("a range is given either by keys or by tokens")
+	}
+	if keyRange.Count <= 0 {
+		return fmt.Errorf("count must be positive, got %v", keyRange.Count)
+	}
+	if err := checkReadConsistency(args.ConsistencyLevel); err != nil {
+		return err
+	}
+	ss := GetInstance()
+	message := db.RangeSliceArgs{}
+	message.Table = keyspace
+	message.ColumnFamily = columnParent.ColumnFamily
+	var keys []string
+	var err error
+	if byKeys {
+		startToken := ""
+		if keyRange.StartKey != "" {
+			startToken = ss.partitioner.GetToken(keyRange.StartKey)
+		}
+		if keyRange.StartKey != "" && keyRange.EndKey != "" && ss.partitioner.DecorateKey(keyRange.StartKey) >
+			ss.partitioner.DecorateKey(keyRange.EndKey) {
+			return fmt.Errorf("start key %q comes after end key %q on the ring", keyRange.StartKey, keyRange.EndKey)
+		}
+		message.StartKey = keyRange.StartKey
+		message.EndKey = keyRange.EndKey
+		tokens := getSortedTokens()
+		first := sort.SearchStrings(tokens, startToken)
+		last := len(tokens)
+		if keyRange.EndKey != "" {
+			last = sort.SearchStrings(tokens, ss.partitioner.GetToken(keyRange.EndKey))
+		}
+		keys, err = scanRange(&message, tokens, first, last, keyRange.Count)
+	} else {
+		// a wrapping range is scanned as the part up to the end
+		// of the ring followed by the part from its start
+		keys, err = scanTokenRange(&message, keyRange.StartToken, keyRange.EndToken, keyRange.Count)
+		if err == nil && keyRange.EndToken != "" && keyRange.StartToken >= keyRange.EndToken &&
+			len(keys) < keyRange.Count {
+			var wrapped []string
+			wrapped, err = scanTokenRange(&message, "", keyRange.EndToken, keyRange.Count-len(keys))
+			keys = append(keys, wrapped...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	reply.KeySlices = make([]KeySlice, 0, len(keys))
+	if len(keys) == 0 {
+		return nil
+	}
+	columns, err := mg.multigetSliceInternal(keyspace, keys, columnParent, args.Predicate, args.ConsistencyLevel)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		reply.KeySlices = append(reply.KeySlices, NewKeySlice(key, columns[key]))
+	}
+	return nil
+}
+
+// scanTokenRange scans the tokens after startToken up to endToken,
+// or up to the end of the ring if endToken is not after startToken
+func scanTokenRange(message *db.RangeSliceArgs, startToken, endToken string, count int) ([]string, error) {
+	if endToken != "" && startToken >= endToken {
+		endToken = ""
+	}
+	message.StartToken = startToken
+	message.EndToken = endToken
+	tokens := getSortedTokens()
+	first := sort.Search(len(tokens), func(i int) bool { return tokens[i] > startToken })
+	last := len(tokens)
+	if endToken != "" {
+		last = sort.SearchStrings(tokens, endToken)
+	}
+	return scanRange(message, tokens, first, last, count)
+}
+
+// getSortedTokens returns the tokens of the ring in order
+func getSortedTokens() []string {
+	tokenToEndPoint := GetInstance().tokenMetadata.CloneTokenEndPointMap()
+	tokens := make([]string, 0, len(tokenToEndPoint))
+	for token := range tokenToEndPoint {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// getRingSegment returns the part i of the ring owned by a single
+// node. Part i < len(tokens) runs up to tokens[i], part len(tokens)
+// after the last token to the end of the ring. It belongs to the
+// node of the first token like part 0, but comes last in the order
+// of the ring.
+func getRingSegment(tokens []string, i int) (segment dht.Range, owner string) {
+	if len(tokens) == 0 {
+		return dht.NewRange("", ""), ""
+	}
+	if i == len(tokens) {
+		return dht.NewRange(tokens[i-1], ""), tokens[0]
+	}
+	if i == 0 {
+		return dht.NewRange("", tokens[0]), tokens[0]
+	}
+	return dht.NewRange(tokens[i-1], tokens[i]), tokens[i]
+}
+
+// scanRange asks the live replicas of the parts first to last of
+// the ring for the keys matching message, in ring order, and returns
+// the first count of them
+func scanRange(message *db.RangeSliceArgs, tokens []string, first, last, count int) ([]string, error) {
+	ss := GetInstance()
+	keys := make([]string, 0)
+	for i := first; i <= last && len(keys) < count; i++ {
+		segment, owner := getRingSegment(tokens, i)
+		message.Range = segment
+		message.MaxKeys = count - len(keys)
+		endpoints := []network.EndPoint{*ss.tcpAddr}
+		if len(tokens) > 0 {
+			endpoints = getLiveEndPoints(ss.nodePicker.GetReadStorageEndPoints(owner))
+		}
+		if len(endpoints) == 0 {
+			return nil, fmt.Errorf("no replica of the range %q to %q is alive", segment.Left, segment.Right)
+		}
+		seen := make(map[string]bool)
+		answered := 0
+		for _, endpoint := range endpoints {
+			reply := db.RangeSliceReply{}
+			err := rangeSliceOn(endpoint, message, &reply)
+			if err != nil {
+				log.Printf("cannot scan the range %q to %q on %v: %v\n", segment.Left, segment.Right, endpoint, err)
+				continue
+			}
+			answered++
+			for _, key := range reply.Keys {
+				seen[key] = true
+			}
+		}
+		if answered == 0 {
+			return nil, fmt.Errorf("no replica of the range %q to %q answered", segment.Left, segment.Right)
+		}
+		// each replica returned its first keys, so the first
+		// keys of all of them are among those
+		segmentKeys := make([]string, 0, len(seen))
+		for key := range seen {
+			segmentKeys = append(segmentKeys, key)
+		}
+		sort.Slice(segmentKeys, func(a, b int) bool {
+			return ss.partitioner.DecorateKey(segmentKeys[a]) < ss.partitioner.DecorateKey(segmentKeys[b])
+		})
+		if len(segmentKeys) > message.MaxKeys {
+			segmentKeys = segmentKeys[:message.MaxKeys]
+		}
+		keys = append(keys, segmentKeys...)
+	}
+	return keys, nil
+}
+
+func getLiveEndPoints(endpoints map[network.EndPoint]bool) []network.EndPoint {
+	res := make([]network.EndPoint, 0, len(endpoints))
+	for endpoint := range endpoints {
+		if gms.GetFailureDetector().IsAlive(endpoint) {
+			res = append(res, endpoint)
+		}
+	}
+	return res
+}
+
+func rangeSliceOn(endpoint network.EndPoint, message *db.RangeSliceArgs, reply *db.RangeSliceReply) error {
+	ss := GetInstance()
+	if endpoint == *ss.tcpAddr {
+		return ss.DoRangeSlice(message, reply)
+	}
+	client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call("StorageService.DoRangeSlice", message, reply)
+}
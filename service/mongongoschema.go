@@ -0,0 +1,109 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// KeyspaceArgs describes a keyspace and all its column families
+type KeyspaceArgs struct {
+	Keyspace       string
+	ColumnFamilies []config.CFMetaData
+}
+
+// DropKeyspaceArgs ...
+type DropKeyspaceArgs struct {
+	Keyspace string
+}
+
+// ColumnFamilyArgs describes a single column family,
+// CFMetaData.TableName names its keyspace
+type ColumnFamilyArgs struct {
+	CFMetaData config.CFMetaData
+}
+
+// DropColumnFamilyArgs ...
+type DropColumnFamilyArgs struct {
+	Keyspace     string
+	ColumnFamily string
+}
+
+// SchemaReply ...
+type SchemaReply struct {
+	Result string
+}
+
+// AddKeyspace creates a keyspace with its column families
+func (mg *Mongongo) AddKeyspace(args *KeyspaceArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.AddKeyspace %v\n", args.Keyspace)
+	err := db.AddTable(args.Keyspace, args.ColumnFamilies)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// UpdateKeyspace makes the column families of a keyspace match
+// args: new ones are created, existing ones are updated and the
+// ones left out are dropped together with their data
+func (mg *Mongongo) UpdateKeyspace(args *KeyspaceArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.UpdateKeyspace %v\n", args.Keyspace)
+	err := db.UpdateTable(args.Keyspace, args.ColumnFamilies)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// DropKeyspace drops a keyspace and all its data
+func (mg *Mongongo) DropKeyspace(args *DropKeyspaceArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.DropKeyspace %v\n", args.Keyspace)
+	err := db.DropTable(args.Keyspace)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// AddColumnFamily creates a column family in an existing keyspace
+func (mg *Mongongo) AddColumnFamily(args *ColumnFamilyArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.AddColumnFamily %v.%v\n", args.CFMetaData.TableName, args.CFMetaData.CFName)
+	err := db.AddColumnFamily(args.CFMetaData)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// UpdateColumnFamily changes the definition of a column family
+func (mg *Mongongo) UpdateColumnFamily(args *ColumnFamilyArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.UpdateColumnFamily %v.%v\n", args.CFMetaData.TableName, args.CFMetaData.CFName)
+	err := db.UpdateColumnFamily(args.CFMetaData)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
+
+// DropColumnFamily drops a column family and all its data
+func (mg *Mongongo) DropColumnFamily(args *DropColumnFamilyArgs, reply *SchemaReply) error {
+	log.Printf("enter mg.DropColumnFamily %v.%v\n", args.Keyspace, args.ColumnFamily)
+	err := db.DropColumnFamily(args.Keyspace, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	reply.Result = "Success"
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// SnapshotArgs ...
+type SnapshotArgs struct {
+	Keyspace string
+	// ColumnFamily is empty to snapshot the whole keyspace
+	ColumnFamily string
+	// Name is empty to name the snapshot after the current time
+	Name string
+}
+
+// SnapshotReply ...
+type SnapshotReply struct {
+	Name string
+}
+
+// ListSnapshotsArgs ...
+type ListSnapshotsArgs struct{}
+
+// ListSnapshotsReply ...
+type ListSnapshotsReply struct {
+	Snapshots []*db.SnapshotInfo
+}
+
+// ClearSnapshotArgs ...
+type ClearSnapshotArgs struct {
+	// Name is empty to clear all snapshots
+	Name string
+}
+
+// ClearSnapshotReply ...
+type ClearSnapshotReply struct {
+	Cleared []string
+}
+
+// Snapshot flushes a keyspace or column family on this node and
+// links its sstables into a snapshot directory
+func (mg *Mongongo) Snapshot(args *SnapshotArgs, reply *SnapshotReply) error {
+	log.Printf("enter mg.Snapshot %v.%v %v\n", args.Keyspace, args.ColumnFamily, args.Name)
+	name, err := db.TakeSnapshot(args.Keyspace, args.ColumnFamily, args.Name)
+	if err != nil {
+		return err
+	}
+	reply.Name = name
+	return nil
+}
+
+// ListSnapshots describes the snapshots taken on this node
+func (mg *Mongongo) ListSnapshots(args *ListSnapshotsArgs, reply *ListSnapshotsReply) error {
+	snapshots, err := db.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	reply.Snapshots = snapshots
+	return nil
+}
+
+// ClearSnapshot deletes a snapshot, or all of them, on this node
+func (mg *Mongongo) ClearSnapshot(args *ClearSnapshotArgs, reply *ClearSnapshotReply) error {
+	log.Printf("enter mg.ClearSnapshot %q\n", args.Name)
+	cleared, err := db.ClearSnapshot(args.Name)
+	if err != nil {
+		return err
+	}
+	reply.Cleared = cleared
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// ColumnFamilyStatsArgs ...
+type ColumnFamilyStatsArgs struct {
+	Keyspace     string
+	ColumnFamily string
+}
+
+// ColumnFamilyStatsReply ...
+type ColumnFamilyStatsReply struct {
+	Stats db.ColumnFamilyStats
+}
+
+// GetColumnFamilyStats reports the statistics of a column family
+// on this node, e.g. sstable count, disk usage and compression ratio
+func (mg *Mongongo) GetColumnFamilyStats(args *ColumnFamilyStatsArgs, reply *ColumnFamilyStatsReply) error {
+	log.Printf("enter mg.GetColumnFamilyStats %v.%v\n", args.Keyspace, args.ColumnFamily)
+	stats, err := db.GetColumnFamilyStats(args.Keyspace, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	reply.Stats = *stats
+	return nil
+}
+
+// ReadRepairStatsArgs ...
+type ReadRepairStatsArgs struct {
+}
+
+// ReadRepairStatsReply ...
+type ReadRepairStatsReply struct {
+	Stats ReadRepairStats
+}
+
+// GetReadRepairStats reports how often the replicas read by this
+// node disagreed and how many rows it wrote back to repair them
+func (mg *Mongongo) GetReadRepairStats(args *ReadRepairStatsArgs, reply *ReadRepairStatsReply) error {
+	reply.Stats = GetReadRepairStats()
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"log"
+
+	"github.com/DistAlchemist/Mongongo/db"
+)
+
+// VerifyArgs ...
+type VerifyArgs struct {
+	Keyspace     string
+	ColumnFamily string
+}
+
+// VerifyReply ...
+type VerifyReply struct {
+	Results []*db.SSTableVerifyResult
+}
+
+// ScrubArgs ...
+type ScrubArgs struct {
+	Keyspace     string
+	ColumnFamily string
+}
+
+// ScrubReply ...
+type ScrubReply struct {
+	Results []*db.SSTableScrubResult
+}
+
+// Verify checks the sstables of a column family on this node
+// against their checksums and reports the corrupt regions
+func (mg *Mongongo) Verify(args *VerifyArgs, reply *VerifyReply) error {
+	log.Printf("enter mg.Verify %v.%v\n", args.Keyspace, args.ColumnFamily)
+	results, err := db.VerifyColumnFamily(args.Keyspace, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	reply.Results = results
+	return nil
+}
+
+// Scrub rewrites the sstables of a column family on this node,
+// dropping the rows that cannot be read
+func (mg *Mongongo) Scrub(args *ScrubArgs, reply *ScrubReply) error {
+	log.Printf("enter mg.Scrub %v.%v\n", args.Keyspace, args.ColumnFamily)
+	results, err := db.ScrubColumnFamily(args.Keyspace, args.ColumnFamily)
+	if err != nil {
+		return err
+	}
+	reply.Results = results
+	return nil
+}
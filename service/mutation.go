@@ -0,0 +1,47 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+// Mutation is either the insert of a column or super
+// column or a deletion, exactly one of them is set
+type Mutation struct {
+	ColumnOrSuperColumn *ColumnOrSuperColumn
+	Deletion            *Deletion
+}
+
+// NewInsertMutation ...
+func NewInsertMutation(cosc ColumnOrSuperColumn) Mutation {
+	res := Mutation{}
+	res.ColumnOrSuperColumn = &cosc
+	return res
+}
+
+// NewDeletionMutation ...
+func NewDeletionMutation(deletion Deletion) Mutation {
+	res := Mutation{}
+	res.Deletion = &deletion
+	return res
+}
+
+// Deletion removes the columns named by Predicate, from the super
+// column SuperColumn if it is set. Without a predicate it removes the
+// super column, or without a super column the row of the column family.
+type Deletion struct {
+	Timestamp   int64
+	SuperColumn []byte
+	// Predicate may only name columns, slice ranges
+	// cannot be deleted
+	Predicate *SlicePredicate
+}
+
+// NewDeletion ...
+func NewDeletion(timestamp int64, superColumn []byte, predicate *SlicePredicate) Deletion {
+	res := Deletion{}
+	res.Timestamp = timestamp
+	res.SuperColumn = superColumn
+	res.Predicate = predicate
+	return res
+}
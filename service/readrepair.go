@@ -0,0 +1,89 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"bytes"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/network"
+)
+
+// ReadRepairStats counts the read repair work of this node
+type ReadRepairStats struct {
+	// ConsistencyChecks are the background checks
+	// started after reads at consistency level one
+	ConsistencyChecks int64
+	// DigestMismatches are the reads, in the background
+	// or at quorum and above, whose replicas disagreed
+	DigestMismatches int64
+	// Repairs are the rows written to replicas
+	// which returned an out of date version
+	Repairs int64
+}
+
+// readRepairStats is only accessed atomically
+var readRepairStats ReadRepairStats
+
+// GetReadRepairStats returns the read repair counts since start
+func GetReadRepairStats() ReadRepairStats {
+	return ReadRepairStats{
+		ConsistencyChecks: atomic.LoadInt64(&readRepairStats.ConsistencyChecks),
+		DigestMismatches:  atomic.LoadInt64(&readRepairStats.DigestMismatches),
+		Repairs:           atomic.LoadInt64(&readRepairStats.Repairs),
+	}
+}
+
+// runConsistencyCheck asks the endpoints for the digest of the row
+// of command and compares them with the digest of row. If any of
+// them differs the row is read from all live replicas, and the
+// resolved version is written to the ones that are out of date.
+func runConsistencyCheck(row *db.Row, endpoints []network.EndPoint, command db.ReadCommand) {
+	atomic.AddInt64(&readRepairStats.ConsistencyChecks, 1)
+	ss := GetInstance()
+	key := command.GetKey()
+	responses := make(chan *rowReadResponse, len(endpoints))
+	for _, endpoint := range endpoints {
+		message := db.RowReadArgs{}
+		message.From = *ss.tcpAddr
+		message.RCommand = command
+		message.DigestQuery = true
+		go sendRowRead(endpoint, message, responses)
+	}
+	digest := row.Digest()
+	mismatch := false
+	timeout := time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond)
+wait:
+	for i := 0; i < len(endpoints) && !mismatch; i++ {
+		select {
+		case response := <-responses:
+			if response.err != nil {
+				log.Printf("cannot check key %v on %v: %v\n", key, response.endpoint, response.err)
+				continue
+			}
+			mismatch = !bytes.Equal(response.reply.Digest, digest)
+		case <-timeout:
+			break wait
+		}
+	}
+	if !mismatch {
+		return
+	}
+	atomic.AddInt64(&readRepairStats.DigestMismatches, 1)
+	log.Printf("digest mismatch for key %v, repairing it in the background\n", key)
+	replicas := ss.getLiveReadStorageEndPoints(key)
+	if ss.isBootstrapMode {
+		replicas = removeEndPoint(replicas, *ss.tcpAddr)
+	}
+	_, err := repairRead(command, replicas, 1)
+	if err != nil {
+		log.Printf("cannot repair key %v: %v\n", key, err)
+	}
+}
@@ -6,58 +6,21 @@
 package service
 
 import (
-	"encoding/gob"
+	"bytes"
 	"fmt"
 	"log"
 	"net/rpc"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/db"
+	"github.com/DistAlchemist/Mongongo/gms"
 	"github.com/DistAlchemist/Mongongo/network"
 	"github.com/DistAlchemist/Mongongo/utils"
 )
 
-// RowMutationArgs for rm arguments
-type RowMutationArgs struct {
-	RM db.RowMutation
-}
-
-// RowMutationReply for rm reply structure
-type RowMutationReply struct {
-	Result string
-}
-
-// Insert will apply this row mutation to
-// all replicas. (TODO) It will take care of the
-// possibility of a replica being down and
-// hint the data across to some other replica.
-func Insert(rm db.RowMutation) {
-	endpointMap := GetInstance().getNStorageEndPointMap(rm.RowKey)
-	gob.Register(db.SuperColumnFactory{})
-	gob.Register(db.SuperColumn{})
-	for endpoint := range endpointMap {
-		go func(end network.EndPoint) {
-			c, err := rpc.DialHTTP("tcp", end.HostName+":"+end.Port)
-			defer c.Close()
-			if err != nil {
-				log.Fatal("dialing:", err)
-			}
-			args := RowMutationArgs{rm}
-			reply := RowMutationReply{}
-			err = c.Call("StorageService.DoRowMutation", &args, &reply)
-			if err != nil {
-				log.Fatal("calling:", err)
-			}
-			fmt.Printf("DoRowMutation.Result for %v:%v: %+v\n",
-				end.HostName, end.Port, reply.Result)
-		}(endpoint)
-	}
-	return
-}
-
 func getUnhintedNodes(endpointMap map[network.EndPoint]network.EndPoint) []network.EndPoint {
 	liveEndPoints := make([]network.EndPoint, 0)
 	for k, v := range endpointMap {
@@ -68,26 +31,68 @@ func getUnhintedNodes(endpointMap map[network.EndPoint]network.EndPoint) []netwo
 	return liveEndPoints
 }
 
-func insertBlocking(rm db.RowMutation, consistencyLevel int) {
-	// TODO
-	// endpointMap := GetInstance().getHintedStorageEndpointMap(rm.RowKey)
-	// messageMap := createWriteMessage(rm, endpointMap)
-	// blockFor := consistencyLevel
-	// primaryNodes := getUnhintedNodes(endpointMap)
-	// if len(primaryNodes) < blockFor {
-	// 	log.Fatal("should gurantee blockFor = W live nodes")
-	// }
-	// reply := db.RowMutationReply{}
-	// for endpoint, message := range messageMap {
-	// 	log.Printf("insert writing key %v to %v\n", rm.RowKey, endpoint)
-	// 	to := endpoint
-	// 	client, err := rpc.DialHTTP("tcp", to.HostName+":"+config.StoragePort)
-	// 	if err != nil {
-	// 		log.Fatal("dialing: ", err)
-	// 	}
-	// 	client.Call("StorageService.DoRowMutation", &message, &reply)
-	// 	log.Printf("row mutation status for %v: %v\n", to, reply)
-	// }
+// insertBlocking sends rm to the replicas of its key and the nodes
+// holding hints for the dead ones, and waits until as many replicas
+// as the consistency level asks for have applied it. Hinted writes
+// are not counted, they only reach the replica once it is back.
+// It fails with an UnavailableError or a TimedOutError, which reach
+// clients with their own error codes, see GetErrorCode.
+func insertBlocking(rm db.RowMutation, consistencyLevel int) error {
+	ss := GetInstance()
+	endpointMap := ss.getHintedStorageEndpointMap(rm.RowKey)
+	blockFor := getBlockFor(consistencyLevel, len(ss.getReadStorageEndPoints(rm.RowKey)))
+	primaryNodes := getUnhintedNodes(endpointMap)
+	if len(primaryNodes) < blockFor {
+		return &UnavailableError{rm.RowKey, blockFor, len(primaryNodes)}
+	}
+	type writeResponse struct {
+		endpoint network.EndPoint
+		primary  bool
+		err      error
+	}
+	messageMap := createWriteMessage(rm, endpointMap)
+	responses := make(chan *writeResponse, len(messageMap))
+	for endpoint, message := range messageMap {
+		log.Printf("insert writing key %v to %v\n", rm.RowKey, endpoint)
+		go func(endpoint network.EndPoint, message db.RowMutationArgs) {
+			response := &writeResponse{endpoint: endpoint, primary: message.HeaderKey != db.HINT}
+			response.err = rowMutationOn(endpoint, &message)
+			responses <- response
+		}(endpoint, message)
+	}
+	acks := 0
+	timeout := time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond)
+	for i := 0; i < len(messageMap) && acks < blockFor; i++ {
+		select {
+		case response := <-responses:
+			if response.err != nil {
+				log.Printf("cannot write key %v to %v: %v\n", rm.RowKey, response.endpoint, response.err)
+				continue
+			}
+			if response.primary {
+				acks++
+			}
+		case <-timeout:
+			return &TimedOutError{rm.RowKey, blockFor, acks}
+		}
+	}
+	if acks < blockFor {
+		return &TimedOutError{rm.RowKey, blockFor, acks}
+	}
+	return nil
+}
+
+func rowMutationOn(endpoint network.EndPoint, message *db.RowMutationArgs) error {
+	reply := db.RowMutationReply{}
+	if endpoint == *GetInstance().tcpAddr {
+		return db.DoRowMutation(message, &reply)
+	}
+	client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call("StorageService.DoRowMutation", message, &reply)
 }
 
 func insert(rm db.RowMutation) {
@@ -106,20 +111,15 @@ func insert(rm db.RowMutation) {
 	// care if we don't really have N destinations available.
 	endpointMap := GetInstance().getHintedStorageEndpointMap(rm.RowKey)
 	messageMap := createWriteMessage(rm, endpointMap)
-	reply := db.RowMutationReply{}
 	for endpoint, message := range messageMap {
 		utils.LoggerInstance().Printf("enter storageproxy.insert\n")
 		log.Printf("insert writing key %v to %v\n", rm.RowKey, endpoint)
-		to := endpoint
-		client, err := rpc.DialHTTP("tcp", to.HostName+":"+config.StoragePort)
-		if err != nil {
-			log.Fatal("dialing: ", err)
-		}
-		err = client.Call("StorageService.DoRowMutation", &message, &reply)
-		if err != nil {
-			log.Print(err)
-		}
-		log.Printf("row mutation status for %v: %v\n", to, reply)
+		go func(endpoint network.EndPoint, message db.RowMutationArgs) {
+			err := rowMutationOn(endpoint, &message)
+			if err != nil {
+				log.Printf("cannot write key %v to %v: %v\n", rm.RowKey, endpoint, err)
+			}
+		}(endpoint, message)
 	}
 }
 
@@ -138,6 +138,11 @@ func createWriteMessage(rm db.RowMutation, endpointMap map[network.EndPoint]netw
 	message.From = *GetInstance().tcpAddr
 	for target, hint := range endpointMap {
 		if target != hint {
+			if !shouldHint(hint) {
+				log.Printf("not hinting %v, it is down for longer than %vms\n",
+					hint.HostName, config.MaxHintWindowInMillis)
+				continue
+			}
 			hintedMessage := db.RowMutationArgs{}
 			hintedMessage.HeaderKey = db.HINT
 			hintedMessage.HeaderValue = hint
@@ -152,119 +157,163 @@ func createWriteMessage(rm db.RowMutation, endpointMap map[network.EndPoint]netw
 	return messageMap
 }
 
-func readProtocol(commands []db.ReadCommand, consistencyLevel int) []*db.Row {
+// shouldHint tells whether the writes of a dead endpoint are still
+// kept for it, which stops MaxHintWindowInMillis after it went down
+func shouldHint(endpoint network.EndPoint) bool {
+	ep := network.EndPoint{HostName: endpoint.HostName, Port: config.ControlPort}
+	epState := gms.GetGossiper().GetEndPointStateForEndPoint(ep)
+	return isInHintWindow(epState, utils.CurrentTimeMillis())
+}
+
+// isInHintWindow tells whether the endpoint of epState, which is
+// down, was last heard of at most MaxHintWindowInMillis before now.
+// An endpoint the gossiper knows nothing of is hinted.
+func isInHintWindow(epState *gms.EndPointState, now int64) bool {
+	if epState == nil {
+		return true
+	}
+	downtime := now - epState.GetUpdateTimestamp()
+	return downtime <= int64(config.MaxHintWindowInMillis)
+}
+
+// writeProtocol writes rm at the consistency level. Level 0 returns
+// as soon as the writes are sent, the others wait for the
+// replicas to acknowledge them.
+func writeProtocol(consistencyLevel int, rm db.RowMutation) error {
+	err := checkWriteConsistency(consistencyLevel)
+	if err != nil {
+		return err
+	}
+	if consistencyLevel == ConsistencyZero {
+		insert(rm)
+		return nil
+	}
+	return insertBlocking(rm, consistencyLevel)
+}
+
+func readProtocol(commands []db.ReadCommand, consistencyLevel int) ([]*db.Row, error) {
 	// performs the actual reading of a row out of the StorageService,
 	// fetching a specific set of column names from a given column family
-	rows := make([]*db.Row, 0)
-	if consistencyLevel == 1 {
-		localCommands := make([]db.ReadCommand, 0)
-		remoteCommands := make([]db.ReadCommand, 0)
-		for _, command := range commands {
-			endpoints := GetInstance().getReadStorageEndPoints(command.GetKey())
-			_, foundlocal := endpoints[*GetInstance().tcpAddr]
-			if foundlocal && GetInstance().isBootstrapMode == false {
-				localCommands = append(localCommands, command)
-			} else {
-				remoteCommands = append(remoteCommands, command)
-			}
-		}
-		if len(localCommands) > 0 {
-			rows = append(rows, weakReadLocal(localCommands)...)
-		} else {
-			rows = append(rows, weakReadRemote(remoteCommands)...)
-		}
-	} else {
-		if consistencyLevel != 2 { // Quorum
-			rows = strongRead(commands)
-		}
+	err := checkReadConsistency(consistencyLevel)
+	if err != nil {
+		return nil, err
+	}
+	if consistencyLevel == ConsistencyOne {
+		return weakRead(commands)
 	}
-	return rows
+	return strongRead(commands, consistencyLevel)
 }
 
-func remove(list []network.EndPoint, elem network.EndPoint) {
-	idx := 0
-	var e interface{}
-	for idx, e = range list {
-		if e == elem {
-			break
+// removeEndPoint returns list without elem
+func removeEndPoint(list []network.EndPoint, elem network.EndPoint) []network.EndPoint {
+	res := make([]network.EndPoint, 0, len(list))
+	for _, e := range list {
+		if e != elem {
+			res = append(res, e)
 		}
 	}
-	list = append(list[:idx], list[idx+1:]...)
+	return res
 }
 
-func weakReadLocal(commands []db.ReadCommand) []*db.Row {
-	// this function executes the read protocol locally
-	// and should be used only if consistency is not a
-	// concern. read the data from the local disk and
-	// return if the row is NOT NULL. if the data is NULL
-	// do the read from one of the other replicas (in the
-	// same data center if possible) till we get the data.
-	// in the event we get the data we perform consistency
-	// checks and figure out if any repairs need to be done
-	// to the replicas
-	rows := make([]*db.Row, 0)
-	for _, command := range commands {
-		endpoints := GetInstance().getLiveReadStorageEndPoints(command.GetKey())
-		// remove the local storage endpoint from the list
-		remove(endpoints, *GetInstance().tcpAddr)
-		spew.Printf("\tweakreadlocal reading %#+v\n\n", command)
-		table := db.OpenTable(command.GetTable())
-		spew.Printf("\ttable: %#+v\n\n", table)
-		row := command.GetRow(table)
-		spew.Printf("\trow: %#+v\n\n", row)
-		if row != nil {
-			rows = append(rows, row)
-		}
-		// do the consistency checks in the background and return
-		// the not NILL row
-		if len(endpoints) > 0 && config.DoConsistencyCheck {
-			GetInstance().doConsistencyCheck(row, endpoints, command)
+func weakRead(commands []db.ReadCommand) ([]*db.Row, error) {
+	// this function reads every row from one replica, the
+	// local node if it is one and preferably one in the same
+	// data center otherwise. consistency is not a concern, so
+	// the commands are grouped by the replica chosen for them,
+	// every replica is asked for all of its rows at once and
+	// all of them in parallel. in the event we get the data we
+	// perform consistency checks and figure out if any repairs
+	// need to be done to the replicas
+	ss := GetInstance()
+	groups := make(map[network.EndPoint][]int)
+	for idx, command := range commands {
+		endpoint := ss.findSuitableEndPoint(command.GetKey())
+		if endpoint == (network.EndPoint{}) {
+			return nil, &UnavailableError{command.GetKey(), 1, 0}
 		}
+		groups[endpoint] = append(groups[endpoint], idx)
 	}
-	return rows
-}
-
-func weakReadRemote(commands []db.ReadCommand) []*db.Row {
-	// read the data from one replica. if there is no reply,
-	// read the data from another. in the event we get the
-	// data we perform consistency checks and figure out if
-	// any repairs need to be done to the replicas.
-	log.Printf("weakrealremote reading %v\n", commands)
-	rows := make([]*db.Row, 0)
-	divCalls := make([]*rpc.Call, 0)
-	replys := make([]*db.RowReadReply, 0)
-	endpoints := make([]network.EndPoint, 0)
-	for _, command := range commands {
-		endpoint := GetInstance().findSuitableEndPoint(command.GetKey())
-		endpoints = append(endpoints, endpoint)
-		message := db.RowReadArgs{}
-		message.From = *GetInstance().tcpAddr
-		message.RCommand = command
-		message.HeaderKey = db.DoREPAIR
-		reply := db.RowReadReply{}
-		to := endpoint
-		client, err := rpc.DialHTTP("tcp", to.HostName+":"+config.StoragePort)
-		if err != nil {
-			log.Fatal("dialing: ", err)
+	type readResult struct {
+		endpoint network.EndPoint
+		indexes  []int
+		reply    db.MultiRowReadReply
+		err      error
+	}
+	results := make(chan *readResult, len(groups))
+	for endpoint, indexes := range groups {
+		group := make([]db.ReadCommand, 0, len(indexes))
+		for _, idx := range indexes {
+			group = append(group, commands[idx])
 		}
-		divCall := client.Go("StorageService.DoRowRead", &message, &reply, nil)
-		replys = append(replys, &reply)
-		divCalls = append(divCalls, divCall)
+		go func(endpoint network.EndPoint, indexes []int, group []db.ReadCommand) {
+			result := &readResult{endpoint: endpoint, indexes: indexes}
+			result.err = multiRowReadOn(endpoint, group, &result.reply)
+			if result.err == nil && (len(result.reply.Rows) != len(group) || len(result.reply.Exists) != len(group)) {
+				result.err = fmt.Errorf("%v rows in the reply to %v reads", len(result.reply.Rows), len(group))
+			}
+			results <- result
+		}(endpoint, indexes, group)
 	}
-	for idx, divCall := range divCalls {
+	// the rows of the keys without one stay nil
+	rows := make([]*db.Row, len(commands))
+	answered := make(map[network.EndPoint]bool)
+	timeout := time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond)
+	for i := 0; i < len(groups); i++ {
 		select {
-		case _ = <-divCall.Done:
-			if replys[idx].R != nil {
-				rows = append(rows, replys[idx].R)
+		case result := <-results:
+			if result.err != nil {
+				log.Printf("cannot read %v keys from %v: %v\n", len(result.indexes), result.endpoint, result.err)
+				return nil, &TimedOutError{commands[result.indexes[0]].GetKey(), 1, 0}
+			}
+			answered[result.endpoint] = true
+			for pos, idx := range result.indexes {
+				row := result.reply.Rows[pos]
+				if row == nil {
+					continue
+				}
+				if result.reply.Exists[pos] {
+					rows[idx] = row
+				}
+				if config.DoConsistencyCheck {
+					endpoints := make([]network.EndPoint, 0)
+					for _, endpoint := range ss.getLiveReadStorageEndPoints(row.Key) {
+						if endpoint != result.endpoint {
+							endpoints = append(endpoints, endpoint)
+						}
+					}
+					if len(endpoints) > 0 {
+						ss.doConsistencyCheck(row, endpoints, commands[idx])
+					}
+				}
+			}
+		case <-timeout:
+			for endpoint, indexes := range groups {
+				if !answered[endpoint] {
+					log.Printf("timeout reading %v keys from %v\n", len(indexes), endpoint)
+					return nil, &TimedOutError{commands[indexes[0]].GetKey(), 1, 0}
+				}
 			}
-		case <-time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond):
-			log.Printf("timeout calling %v for command %v\n", endpoints[idx], commands[idx])
 		}
 	}
-	return rows
+	return rows, nil
 }
 
-func strongRead(commands []db.ReadCommand) []*db.Row {
+func multiRowReadOn(endpoint network.EndPoint, commands []db.ReadCommand, reply *db.MultiRowReadReply) error {
+	message := db.MultiRowReadArgs{}
+	message.From = *GetInstance().tcpAddr
+	message.Commands = commands
+	if endpoint == *GetInstance().tcpAddr {
+		return db.DoMultiRowRead(&message, reply)
+	}
+	client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call("StorageService.DoMultiRowRead", &message, reply)
+}
+
+func strongRead(commands []db.ReadCommand, consistencyLevel int) ([]*db.Row, error) {
 	// this function executes the read protocol
 	// 1. get the N nodes from storage service where
 	//    the data needs to be replicated
@@ -278,6 +327,209 @@ func strongRead(commands []db.ReadCommand) []*db.Row {
 	// 7. else carry out read repair by getting data from
 	//    all the nodes
 	// 8. return success
-	// TODO
-	return make([]*db.Row, 0)
+	// every row is read on its own, all of them in parallel
+	rows := make([]*db.Row, len(commands))
+	errs := make([]error, len(commands))
+	var wg sync.WaitGroup
+	for idx, command := range commands {
+		wg.Add(1)
+		go func(idx int, command db.ReadCommand) {
+			defer wg.Done()
+			rows[idx], errs[idx] = strongReadRow(command, consistencyLevel)
+		}(idx, command)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// rowReadResponse is the answer of a replica to a row read
+type rowReadResponse struct {
+	endpoint network.EndPoint
+	reply    db.RowReadReply
+	err      error
+}
+
+// strongReadRow reads the row of command from as many of its
+// replicas as the consistency level asks for. The data is read
+// from one of them and digests from the others, if they disagree
+// the row is read from all of them. The row is nil if it has no
+// live column in the column family read. Like insertBlocking it
+// fails with an UnavailableError or a TimedOutError.
+func strongReadRow(command db.ReadCommand, consistencyLevel int) (*db.Row, error) {
+	ss := GetInstance()
+	key := command.GetKey()
+	blockFor := getBlockFor(consistencyLevel, len(ss.getReadStorageEndPoints(key)))
+	endpoints := ss.getLiveReadStorageEndPoints(key)
+	if ss.isBootstrapMode {
+		// this node is still receiving its data
+		endpoints = removeEndPoint(endpoints, *ss.tcpAddr)
+	}
+	if len(endpoints) < blockFor {
+		return nil, &UnavailableError{key, blockFor, len(endpoints)}
+	}
+	dataEndPoint := ss.findSuitableEndPoint(key)
+	responses := make(chan *rowReadResponse, len(endpoints))
+	for _, endpoint := range endpoints {
+		message := db.RowReadArgs{}
+		message.From = *ss.tcpAddr
+		message.RCommand = command
+		message.DigestQuery = endpoint != dataEndPoint
+		go sendRowRead(endpoint, message, responses)
+	}
+	var data *db.Row
+	exists := false
+	digests := make(map[network.EndPoint][]byte)
+	timeout := time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond)
+wait:
+	for i := 0; i < len(endpoints) && (data == nil || len(digests) < blockFor); i++ {
+		select {
+		case response := <-responses:
+			if response.err != nil {
+				log.Printf("cannot read key %v from %v: %v\n", key, response.endpoint, response.err)
+				continue
+			}
+			if response.endpoint == dataEndPoint {
+				data = response.reply.R
+				exists = response.reply.Exists
+				digests[response.endpoint] = data.Digest()
+			} else {
+				digests[response.endpoint] = response.reply.Digest
+			}
+		case <-timeout:
+			break wait
+		}
+	}
+	if len(digests) < blockFor {
+		return nil, &TimedOutError{key, blockFor, len(digests)}
+	}
+	if data != nil && digestsMatch(digests) {
+		if !exists {
+			return nil, nil
+		}
+		return data, nil
+	}
+	if data != nil {
+		atomic.AddInt64(&readRepairStats.DigestMismatches, 1)
+	}
+	log.Printf("digest mismatch for key %v, reading it from all replicas\n", key)
+	return repairRead(command, endpoints, blockFor)
+}
+
+// repairRead reads the full row of command from all the endpoints,
+// resolves the versions and sends the result to every replica whose
+// version is out of date
+func repairRead(command db.ReadCommand, endpoints []network.EndPoint, blockFor int) (*db.Row, error) {
+	ss := GetInstance()
+	key := command.GetKey()
+	responses := make(chan *rowReadResponse, len(endpoints))
+	for _, endpoint := range endpoints {
+		message := db.RowReadArgs{}
+		message.From = *ss.tcpAddr
+		message.RCommand = command
+		go sendRowRead(endpoint, message, responses)
+	}
+	versions := make(map[network.EndPoint]*db.Row)
+	exists := false
+	timeout := time.After(time.Duration(config.RPCTimeoutInMillis) * time.Millisecond)
+wait:
+	for i := 0; i < len(endpoints); i++ {
+		select {
+		case response := <-responses:
+			if response.err != nil {
+				log.Printf("cannot read key %v from %v: %v\n", key, response.endpoint, response.err)
+				continue
+			}
+			versions[response.endpoint] = response.reply.R
+			exists = exists || response.reply.Exists
+		case <-timeout:
+			break wait
+		}
+	}
+	if len(versions) < blockFor {
+		return nil, &TimedOutError{key, blockFor, len(versions)}
+	}
+	// the digests are taken before the versions are merged
+	digests := make(map[network.EndPoint][]byte)
+	rows := make([]*db.Row, 0, len(versions))
+	for endpoint, version := range versions {
+		if version == nil {
+			version = db.NewRowT(command.GetTable(), key)
+		}
+		digests[endpoint] = version.Digest()
+		rows = append(rows, version)
+	}
+	resolved := db.ResolveRows(command.GetTable(), key, rows)
+	digest := resolved.Digest()
+	for endpoint, versionDigest := range digests {
+		if !bytes.Equal(versionDigest, digest) {
+			go sendReadRepair(endpoint, resolved)
+		}
+	}
+	if !exists {
+		return nil, nil
+	}
+	return resolved, nil
+}
+
+func digestsMatch(digests map[network.EndPoint][]byte) bool {
+	var first []byte
+	for _, digest := range digests {
+		if first == nil {
+			first = digest
+		} else if !bytes.Equal(first, digest) {
+			return false
+		}
+	}
+	return true
+}
+
+func sendRowRead(endpoint network.EndPoint, message db.RowReadArgs, responses chan<- *rowReadResponse) {
+	response := &rowReadResponse{endpoint: endpoint}
+	response.err = rowReadOn(endpoint, &message, &response.reply)
+	if response.err == nil && !message.DigestQuery && response.reply.R == nil {
+		response.err = fmt.Errorf("no row in the reply")
+	}
+	responses <- response
+}
+
+func rowReadOn(endpoint network.EndPoint, message *db.RowReadArgs, reply *db.RowReadReply) error {
+	if endpoint == *GetInstance().tcpAddr {
+		return db.DoRowRead(message, reply)
+	}
+	client, err := rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Call("StorageService.DoRowRead", message, reply)
+}
+
+// sendReadRepair writes the resolved row to a replica
+// which returned an older version of it
+func sendReadRepair(endpoint network.EndPoint, row *db.Row) {
+	log.Printf("repairing key %v on %v\n", row.Key, endpoint)
+	atomic.AddInt64(&readRepairStats.Repairs, 1)
+	message := db.RowMutationArgs{}
+	message.From = *GetInstance().tcpAddr
+	message.RM = *db.NewRowMutationR(row.Table, row)
+	reply := db.RowMutationReply{}
+	var err error
+	if endpoint == *GetInstance().tcpAddr {
+		err = db.DoRowMutation(&message, &reply)
+	} else {
+		var client *rpc.Client
+		client, err = rpc.DialHTTP("tcp", endpoint.HostName+":"+config.StoragePort)
+		if err == nil {
+			err = client.Call("StorageService.DoRowMutation", &message, &reply)
+			client.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("cannot repair key %v on %v: %v\n", row.Key, endpoint, err)
+	}
 }
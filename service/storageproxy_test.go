@@ -0,0 +1,56 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package service
+
+import (
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/config"
+	"github.com/DistAlchemist/Mongongo/gms"
+)
+
+func TestIsInHintWindow(t *testing.T) {
+	window := int64(config.MaxHintWindowInMillis)
+	epState := gms.NewEndPointState(gms.NewHeartBeatState(1, 0))
+	lastHeard := epState.GetUpdateTimestamp()
+	tests := []struct {
+		name     string
+		downtime int64
+		want     bool
+	}{
+		{"just went down", 0, true},
+		{"down for a while", window / 2, true},
+		{"at the end of the window", window, true},
+		{"past the window", window + 1, false},
+		{"long gone", 10 * window, false},
+	}
+	for _, test := range tests {
+		if got := isInHintWindow(epState, lastHeard+test.downtime); got != test.want {
+			t.Errorf("%v: isInHintWindow() = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if !isInHintWindow(nil, lastHeard+10*window) {
+		t.Errorf("an endpoint without state is not hinted")
+	}
+}
+
+func TestIsInHintWindowWhileGossiping(t *testing.T) {
+	epState := gms.NewEndPointState(gms.NewHeartBeatState(1, 0))
+	done := make(chan bool)
+	go func() {
+		// the gossiper marks the heartbeats it receives
+		for i := 0; i < 1000; i++ {
+			epState.SetHeartBeatState(gms.NewHeartBeatState(1, i))
+		}
+		close(done)
+	}()
+	for i := 0; i < 1000; i++ {
+		if !isInHintWindow(epState, epState.GetUpdateTimestamp()) {
+			t.Fatalf("an endpoint heard of now is past the hint window")
+		}
+	}
+	<-done
+}
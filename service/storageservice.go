@@ -7,7 +7,6 @@ package service
 
 import (
 	"encoding/gob"
-	"fmt"
 	"log"
 	"net"
 	"net/http"
@@ -16,8 +15,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/DistAlchemist/Mongongo/config"
 	"github.com/DistAlchemist/Mongongo/db"
 	"github.com/DistAlchemist/Mongongo/dht"
@@ -66,11 +63,13 @@ func (ss *StorageService) init() {
 	gob.Register(db.SuperColumnFactory{})
 	gob.Register(db.SuperColumn{})
 	gob.Register(db.Column{})
+	gob.Register(db.CounterColumn{})
 	gob.Register(network.EndPoint{})
 	gob.Register(db.RowMutation{})
 	gob.Register(db.ColumnFamily{})
-	gob.Register(db.SliceByNamesReadCommand{})
-	gob.Register(db.SliceFromReadCommand{})
+	// read commands implement db.ReadCommand by pointer
+	gob.Register(&db.SliceByNamesReadCommand{})
+	gob.Register(&db.SliceFromReadCommand{})
 	gob.Register(gms.GossipDigest{})
 	gob.Register(gms.EndPointState{})
 	gob.Register(gms.HeartBeatState{})
@@ -202,7 +201,6 @@ func (ss *StorageService) runBootStrap(targets []*network.EndPoint, tokens ...st
 func (ss *StorageService) DoRowMutation(args *db.RowMutationArgs, reply *db.RowMutationReply) error {
 	log.Println("enter ss.DoRowMutation")
 	utils.LoggerInstance().Printf("enter ss.DoRowMutation\n")
-	spew.Printf("args: %+v\n", args)
 	db.DoRowMutation(args, reply)
 	// apply row mutation
 	// args.RM.Apply(db.NewRow(args.RM.RowKey))
@@ -210,11 +208,60 @@ func (ss *StorageService) DoRowMutation(args *db.RowMutationArgs, reply *db.RowM
 	return nil
 }
 
+// DoBinaryLoad is an rpc served by storage service
+func (ss *StorageService) DoBinaryLoad(args *db.BinaryLoadArgs, reply *db.BinaryLoadReply) error {
+	return db.DoBinaryLoad(args, reply)
+}
+
+// DoCounterAdd is an rpc served by storage service, the node
+// receiving it changes its shard of the counter and writes the
+// new shard to all replicas of the key at the consistency level
+func (ss *StorageService) DoCounterAdd(args *db.CounterAddArgs, reply *db.CounterAddReply) error {
+	err := db.DoCounterAdd(args, reply)
+	if err != nil {
+		return err
+	}
+	return writeProtocol(args.ConsistencyLevel, reply.RM)
+}
+
+// DoIndexScan is an rpc served by storage service, it returns
+// the keys of the rows of this node matching the expressions
+func (ss *StorageService) DoIndexScan(args *db.IndexScanArgs, reply *db.IndexScanReply) error {
+	return db.DoIndexScan(args, reply)
+}
+
+// DoRangeSlice is an rpc served by storage service, it returns
+// the keys of the rows of this node in a part of the ring
+func (ss *StorageService) DoRangeSlice(args *db.RangeSliceArgs, reply *db.RangeSliceReply) error {
+	return db.DoRangeSlice(args, reply)
+}
+
+// DoMultiRowRead is an rpc served by storage service, it reads
+// all the rows a coordinator needs from this node at once
+func (ss *StorageService) DoMultiRowRead(args *db.MultiRowReadArgs, reply *db.MultiRowReadReply) error {
+	return db.DoMultiRowRead(args, reply)
+}
+
+// DoValidation is an rpc served by storage service, it returns
+// the merkle tree of the rows of this node in a range
+func (ss *StorageService) DoValidation(args *db.ValidationArgs, reply *db.ValidationReply) error {
+	return db.DoValidation(args, reply)
+}
+
+// DoStreamRows is an rpc served by storage service, it returns
+// the rows of this node in some ranges for a repair
+func (ss *StorageService) DoStreamRows(args *db.StreamRowsArgs, reply *db.StreamRowsReply) error {
+	return db.DoStreamRows(args, reply)
+}
+
 // DoRowRead is an rpc served by storage service
 func (ss *StorageService) DoRowRead(args *db.RowReadArgs, reply *db.RowReadReply) error {
-	fmt.Println("enter DoRowRead")
-	db.DoRowRead(args, reply)
-	if args.HeaderKey == db.DoREPAIR {
+	log.Println("enter ss.DoRowRead")
+	err := db.DoRowRead(args, reply)
+	if err != nil {
+		return err
+	}
+	if args.HeaderKey == db.DoREPAIR && reply.R != nil {
 		ss.doReadRepair(reply.R, args.RCommand)
 	}
 	return nil
@@ -223,7 +270,7 @@ func (ss *StorageService) DoRowRead(args *db.RowReadArgs, reply *db.RowReadReply
 func (ss *StorageService) doReadRepair(row *db.Row, readCommand db.ReadCommand) {
 	endpoints := ss.getLiveReadStorageEndPoints(readCommand.GetKey())
 	// remove the local storage endpoint from the list
-	remove(endpoints, *ss.tcpAddr)
+	endpoints = removeEndPoint(endpoints, *ss.tcpAddr)
 	if len(endpoints) > 0 && config.DoConsistencyCheck {
 		ss.doConsistencyCheck(row, endpoints, readCommand)
 	}
@@ -247,7 +294,7 @@ func (ss *StorageService) getLiveReadStorageEndPoints(key string) []network.EndP
 }
 
 func (ss *StorageService) deliverHints(endpoint *network.EndPoint) {
-	db.GetHintedHandOffManagerInstance()
+	db.GetHintedHandOffManagerInstance().DeliverHints(endpoint)
 }
 
 // OnChange implements interface for endpoint
@@ -305,15 +352,20 @@ func (ss *StorageService) getHintedStorageEndpointMap(key string) map[network.En
 	return ss.nodePicker.GetHintedStorageEndPoints(ss.partitioner.GetToken(key))
 }
 
+// doConsistencyCheck compares row with the versions of the
+// endpoints in the background and repairs them if they differ
 func (ss *StorageService) doConsistencyCheck(row *db.Row, endpoints []network.EndPoint, command db.ReadCommand) {
-	// TODO
-	// go runConsistency
+	go runConsistencyCheck(row, endpoints, command)
 }
 
 func (ss *StorageService) findSuitableEndPoint(key string) network.EndPoint {
 	// this function finds the most suitable endpoint given a key
 	// it checks for locality and alive test
 	endpoints := ss.getReadStorageEndPoints(key)
+	if ss.isBootstrapMode {
+		// this node is still receiving its data
+		delete(endpoints, *ss.tcpAddr)
+	}
 	for endpoint := range endpoints {
 		if endpoint == *ss.tcpAddr {
 			return endpoint
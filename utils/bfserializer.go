@@ -6,6 +6,9 @@
 package utils
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
 
@@ -37,28 +40,35 @@ func (b *BloomFilterSerializer) Serialize(bf *BloomFilter, dos *os.File) {
 }
 
 // SerializeB serialize bloom filter to byte slice
-func (b *BloomFilterSerializer) SerializeB(bf *BloomFilter, dos []byte) {
+func (b *BloomFilterSerializer) SerializeB(bf *BloomFilter, dos *[]byte) {
 	writeInt32B(dos, int32(bf.hashes))
 	bs, err := bf.filter.MarshalBinary()
 	if err != nil {
 		log.Fatal(err)
 	}
-	dos = append(dos, bs...)
+	*dos = append(*dos, bs...)
 }
 
 // DeserializeB deserialize bloom filter from bytes
-func (b *BloomFilterSerializer) DeserializeB(dis []byte) *BloomFilter {
+func (b *BloomFilterSerializer) DeserializeB(dis []byte) (*BloomFilter, error) {
+	if len(dis) < 4 {
+		return nil, fmt.Errorf("bloom filter of %v bytes is too short", len(dis))
+	}
 	hashes := readInt32B(dis)
 	dis = dis[4:] // skip 4 bytes
-	var bs *bitset.BitSet
-	bs.UnmarshalBinary(dis)
-	return NewBloomFilterDS(hashes, bs)
+	bs := &bitset.BitSet{}
+	err := bs.UnmarshalBinary(dis)
+	if err != nil {
+		return nil, err
+	}
+	return NewBloomFilterDS(hashes, bs), nil
 }
 
 // Deserialize ...
-func (b *BloomFilterSerializer) Deserialize(dis *os.File) *BloomFilter {
-	hashes := readInt32(dis)
-	var bs *bitset.BitSet
-	bs.UnmarshalBinary(restBytes(dis))
-	return NewBloomFilterDS(hashes, bs)
+func (b *BloomFilterSerializer) Deserialize(dis io.Reader) (*BloomFilter, error) {
+	buf, err := ioutil.ReadAll(dis)
+	if err != nil {
+		return nil, err
+	}
+	return b.DeserializeB(buf)
 }
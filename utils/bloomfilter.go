@@ -52,6 +52,8 @@ func NewBloomFilterS(count, hashes, bitsize int32, bs *bitset.BitSet) *BloomFilt
 func NewBloomFilterDS(hashes int32, filter *bitset.BitSet) *BloomFilter {
 	bf := &BloomFilter{}
 	bf.hashes = int(hashes)
+	// the bitset keeps its length, which is the size of the filter
+	bf.size = int(filter.Len())
 	bf.filter = filter
 	return bf
 }
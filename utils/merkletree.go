@@ -0,0 +1,102 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+
+	"github.com/DistAlchemist/Mongongo/dht"
+)
+
+// MerkleTree hashes the rows of a token range, so that two
+// replicas can find the parts of the range where they differ
+// by exchanging trees instead of rows. The range is halved
+// Depth times, each leaf hashes the rows of one part of it.
+type MerkleTree struct {
+	Range dht.Range
+	Depth int
+	// Hashes are the hashes of the nodes, breadth first from
+	// the root, the children of node i are 2i+1 and 2i+2
+	Hashes [][]byte
+}
+
+// NewMerkleTree creates an empty tree over r
+func NewMerkleTree(r dht.Range, depth int) *MerkleTree {
+	t := &MerkleTree{}
+	t.Range = r
+	t.Depth = depth
+	t.Hashes = make([][]byte, 1<<uint(depth+1)-1)
+	return t
+}
+
+// Add adds the hash of a row with the given token. The rows of
+// a leaf can be added in any order, their hashes are xored.
+func (t *MerkleTree) Add(token string, hash []byte) {
+	node := 0
+	r := t.Range
+	for level := 0; level < t.Depth; level++ {
+		mid := r.Midpoint()
+		if dht.NewRange(r.Left, mid).Contains(token) {
+			node = 2*node + 1
+			r = dht.NewRange(r.Left, mid)
+		} else {
+			node = 2*node + 2
+			r = dht.NewRange(mid, r.Right)
+		}
+	}
+	leaf := t.Hashes[node]
+	if leaf == nil {
+		leaf = make([]byte, md5.Size)
+		t.Hashes[node] = leaf
+	}
+	for i := 0; i < len(leaf) && i < len(hash); i++ {
+		leaf[i] ^= hash[i]
+	}
+}
+
+// Finish computes the hashes of the inner nodes
+// once all the rows have been added
+func (t *MerkleTree) Finish() {
+	for node := len(t.Hashes)/2 - 1; node >= 0; node-- {
+		left, right := t.Hashes[2*node+1], t.Hashes[2*node+2]
+		if left == nil && right == nil {
+			continue
+		}
+		hash := md5.Sum(append(append([]byte{}, left...), right...))
+		t.Hashes[node] = hash[:]
+	}
+}
+
+// Difference returns the parts of the range where the rows of
+// the two trees differ, adjacent parts are joined. Trees of
+// different ranges or depths differ on the whole range.
+func (t *MerkleTree) Difference(other *MerkleTree) []dht.Range {
+	if t.Range != other.Range || t.Depth != other.Depth || len(t.Hashes) != len(other.Hashes) {
+		return []dht.Range{t.Range}
+	}
+	res := make([]dht.Range, 0)
+	t.difference(other, 0, t.Range, &res)
+	return res
+}
+
+func (t *MerkleTree) difference(other *MerkleTree, node int, r dht.Range, res *[]dht.Range) {
+	if bytes.Equal(t.Hashes[node], other.Hashes[node]) {
+		return
+	}
+	if 2*node+1 >= len(t.Hashes) {
+		last := len(*res) - 1
+		if last >= 0 && (*res)[last].Right == r.Left {
+			(*res)[last].Right = r.Right
+		} else {
+			*res = append(*res, r)
+		}
+		return
+	}
+	mid := r.Midpoint()
+	t.difference(other, 2*node+1, dht.NewRange(r.Left, mid), res)
+	t.difference(other, 2*node+2, dht.NewRange(mid, r.Right), res)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 DistAlchemist
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package utils
+
+import (
+	"crypto/md5"
+	"reflect"
+	"testing"
+
+	"github.com/DistAlchemist/Mongongo/dht"
+)
+
+// row is a row added to a merkle tree
+type row struct {
+	token string
+	value string
+}
+
+func buildTree(r dht.Range, depth int, rows []row) *MerkleTree {
+	tree := NewMerkleTree(r, depth)
+	for _, row := range rows {
+		hash := md5.Sum([]byte(row.value))
+		tree.Add(row.token, hash[:])
+	}
+	tree.Finish()
+	return tree
+}
+
+func TestMerkleTreeDifference(t *testing.T) {
+	ring := dht.NewRange("", "")
+	rows := []row{{"\x10", "a"}, {"\x50", "b"}, {"\x90", "c"}, {"\xd0", "d"}}
+	tests := []struct {
+		name  string
+		r     dht.Range
+		depth int
+		left  []row
+		right []row
+		want  []dht.Range
+	}{
+		{"same rows", ring, 3, rows, rows, []dht.Range{}},
+		{"same rows in another order", ring, 3, rows,
+			[]row{rows[3], rows[1], rows[0], rows[2]}, []dht.Range{}},
+		{"no rows", ring, 3, nil, nil, []dht.Range{}},
+		{"changed row", ring, 3, rows,
+			[]row{{"\x10", "x"}, rows[1], rows[2], rows[3]},
+			[]dht.Range{dht.NewRange("", "\x20\x00\x00")}},
+		{"missing row", ring, 3, rows, rows[:3],
+			[]dht.Range{dht.NewRange("\xc0\x00", "\xe0\x00\x00")}},
+		{"adjacent leaves are joined", ring, 3, rows,
+			[]row{rows[0], {"\x50", "x"}, {"\x70", "e"}, rows[2], rows[3]},
+			[]dht.Range{dht.NewRange("\x40\x00", "\x80")}},
+		{"separate leaves", ring, 3, rows,
+			[]row{{"\x10", "x"}, rows[1], rows[2], {"\xd0", "x"}},
+			[]dht.Range{dht.NewRange("", "\x20\x00\x00"), dht.NewRange("\xc0\x00", "\xe0\x00\x00")}},
+		{"wrapping range", dht.NewRange("\xc0", "\x40"), 1,
+			[]row{{"\xd0", "a"}, {"\x10", "b"}}, []row{{"\xd0", "a"}, {"\x10", "x"}},
+			[]dht.Range{dht.NewRange("\x00\x00", "\x40")}},
+	}
+	for _, test := range tests {
+		left := buildTree(test.r, test.depth, test.left)
+		right := buildTree(test.r, test.depth, test.right)
+		if got := left.Difference(right); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: Difference() = %q, want %q", test.name, got, test.want)
+		}
+		if got := right.Difference(left); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: reverse Difference() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMerkleTreeDifferenceOfOtherTrees(t *testing.T) {
+	ring := dht.NewRange("", "")
+	tree := buildTree(ring, 3, nil)
+	tests := []struct {
+		name  string
+		other *MerkleTree
+	}{
+		{"other depth", buildTree(ring, 2, nil)},
+		{"other range", buildTree(dht.NewRange("", "\x80"), 3, nil)},
+	}
+	for _, test := range tests {
+		want := []dht.Range{ring}
+		if got := tree.Difference(test.other); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: Difference() = %q, want %q", test.name, got, want)
+		}
+	}
+}